@@ -1,13 +1,12 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/aggregate"
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
 )
@@ -73,7 +72,10 @@ var aggQueryCmd = &cobra.Command{
 		having, _ := cmd.Flags().GetString("having")
 		orderBy, _ := cmd.Flags().GetString("order-by")
 		limit, _ := cmd.Flags().GetInt("limit")
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		aggClient := client.Aggregate(tableName)
 		q := aggClient.NewQuery()
@@ -169,7 +171,7 @@ var aggQueryCmd = &cobra.Command{
 			return fmt.Errorf("failed to execute aggregate query: %w", err)
 		}
 
-		return outputAggregateResult(result, format)
+		return outputAggregateResult(result, printer)
 	},
 }
 
@@ -187,7 +189,10 @@ var aggStatsCmd = &cobra.Command{
 		tableName := args[0]
 		field := args[1]
 		filter, _ := cmd.Flags().GetString("filter")
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		aggClient := client.Aggregate(tableName)
 		q := aggClient.NewQuery().
@@ -207,7 +212,7 @@ var aggStatsCmd = &cobra.Command{
 			return fmt.Errorf("failed to get statistics: %w", err)
 		}
 
-		return outputStatistics(result, field, format)
+		return outputStatistics(result, field, printer)
 	},
 }
 
@@ -226,7 +231,10 @@ var aggGroupCmd = &cobra.Command{
 		field := args[1]
 		filter, _ := cmd.Flags().GetString("filter")
 		limit, _ := cmd.Flags().GetInt("limit")
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		aggClient := client.Aggregate(tableName)
 		q := aggClient.NewQuery().
@@ -249,7 +257,7 @@ var aggGroupCmd = &cobra.Command{
 			return fmt.Errorf("failed to group records: %w", err)
 		}
 
-		return outputGroupedResult(result, field, format)
+		return outputGroupedResult(result, field, printer)
 	},
 }
 
@@ -313,51 +321,29 @@ func addFilterToAggregateQuery(q *aggregate.AggregateQuery, filter string) *aggr
 	return q
 }
 
-func outputAggregateResult(result interface{}, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(result)
-	default:
-		// Handle the result based on its actual type
-		// This would need to be implemented based on the actual aggregate result structure
-		fmt.Printf("Aggregate Result:\n")
-		if data, err := json.MarshalIndent(result, "", "  "); err == nil {
-			fmt.Println(string(data))
-		} else {
-			fmt.Printf("%+v\n", result)
-		}
-		return nil
+// outputAggregateResult prints grouped results as rows and ungrouped statistics as fields
+func outputAggregateResult(result *aggregate.AggregateResult, printer *output.Printer) error {
+	if !printer.Human() {
+		return printer.Print(result)
+	}
+	if len(result.Result) > 0 {
+		return printer.Print(result.Result)
 	}
+	return printer.Print(result.Stats)
 }
 
-func outputStatistics(result interface{}, field, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(result)
-	default:
+func outputStatistics(result *aggregate.AggregateResult, field string, printer *output.Printer) error {
+	if printer.Human() {
 		fmt.Printf("Statistics for field '%s':\n", field)
-		if data, err := json.MarshalIndent(result, "", "  "); err == nil {
-			fmt.Println(string(data))
-		} else {
-			fmt.Printf("%+v\n", result)
-		}
-		return nil
 	}
+	return outputAggregateResult(result, printer)
 }
 
-func outputGroupedResult(result interface{}, field, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(result)
-	default:
+func outputGroupedResult(result *aggregate.AggregateResult, field string, printer *output.Printer) error {
+	if printer.Human() {
 		fmt.Printf("Grouped by '%s':\n", field)
-		if data, err := json.MarshalIndent(result, "", "  "); err == nil {
-			fmt.Println(string(data))
-		} else {
-			fmt.Printf("%+v\n", result)
-		}
-		return nil
 	}
+	return outputAggregateResult(result, printer)
 }
 
 func init() {
@@ -375,16 +361,16 @@ func init() {
 	aggQueryCmd.Flags().StringP("having", "H", "", "Having clause")
 	aggQueryCmd.Flags().StringP("order-by", "o", "", "Order by field (append ' DESC' for descending)")
 	aggQueryCmd.Flags().IntP("limit", "l", 0, "Limit number of results")
-	aggQueryCmd.Flags().StringP("format", "", "table", "Output format (table, json)")
+	addOutputFlags(aggQueryCmd, "")
 
 	// Stats command flags
 	aggStatsCmd.Flags().StringP("filter", "f", "", "Filter criteria")
-	aggStatsCmd.Flags().StringP("format", "", "table", "Output format (table, json)")
+	addOutputFlags(aggStatsCmd, "")
 
 	// Group command flags
 	aggGroupCmd.Flags().StringP("filter", "f", "", "Filter criteria")
 	aggGroupCmd.Flags().IntP("limit", "l", 10, "Limit number of groups")
-	aggGroupCmd.Flags().StringP("format", "", "table", "Output format (table, json)")
+	addOutputFlags(aggGroupCmd, "")
 
 	// Add subcommands
 	aggregateCmd.AddCommand(aggCountCmd, aggQueryCmd, aggStatsCmd, aggGroupCmd)
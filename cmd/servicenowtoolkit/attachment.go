@@ -1,11 +1,10 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/attachment"
 )
 
@@ -28,7 +27,10 @@ var attachmentListCmd = &cobra.Command{
 
 		tableName := args[0]
 		sysID := args[1]
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		attachClient := attachment.NewAttachmentClient(client.Core())
 		attachments, err := attachClient.List(tableName, sysID)
@@ -36,7 +38,7 @@ var attachmentListCmd = &cobra.Command{
 			return fmt.Errorf("failed to list attachments: %w", err)
 		}
 
-		return outputAttachments(attachments, format)
+		return outputAttachments(attachments, printer)
 	},
 }
 
@@ -138,22 +140,20 @@ var attachmentDeleteCmd = &cobra.Command{
 }
 
 // Output functions
-func outputAttachments(attachments interface{}, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(attachments)
-	default:
-		fmt.Println("Attachments:")
-		if data, err := json.MarshalIndent(attachments, "", "  "); err == nil {
-			fmt.Println(string(data))
-		}
-		return nil
-	}
+func outputAttachments(attachments []map[string]interface{}, printer *output.Printer) error {
+	return printer.Print(attachments,
+		output.Column{Path: "sys_id"},
+		output.Column{Path: "file_name"},
+		output.Column{Path: "content_type"},
+		output.Column{Path: "size_bytes", Header: "SIZE"},
+		output.Column{Path: "sys_created_on", Header: "CREATED"},
+		output.Column{Path: "sys_created_by", Header: "CREATED_BY", Wide: true},
+	)
 }
 
 func init() {
 	// List command flags
-	attachmentListCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
+	addOutputFlags(attachmentListCmd, "f")
 
 	// Get command flags
 	attachmentGetCmd.Flags().StringP("output", "o", "", "Output file path (defaults to original filename)")
@@ -1,16 +1,21 @@
 package main
 
 import (
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"strconv"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow"
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/batch"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/undo"
 )
 
 var batchCmd = &cobra.Command{
@@ -34,6 +39,10 @@ var batchCreateCmd = &cobra.Command{
 		dataFile, _ := cmd.Flags().GetString("file")
 		data, _ := cmd.Flags().GetString("data")
 		format, _ := cmd.Flags().GetString("input-format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		var records []map[string]interface{}
 
@@ -55,7 +64,7 @@ var batchCreateCmd = &cobra.Command{
 			return fmt.Errorf("no records to create")
 		}
 
-		fmt.Printf("Creating %d records in batch...\n", len(records))
+		fmt.Fprintf(os.Stderr, "Creating %d records in batch...\n", len(records))
 
 		batchClient := client.Batch()
 		result, err := batchClient.CreateMultiple(tableName, records)
@@ -63,7 +72,7 @@ var batchCreateCmd = &cobra.Command{
 			return fmt.Errorf("batch create failed: %w", err)
 		}
 
-		return outputBatchResult(result, "create")
+		return outputBatchResult(result, "create", printer)
 	},
 }
 
@@ -82,6 +91,10 @@ var batchUpdateCmd = &cobra.Command{
 		dataFile, _ := cmd.Flags().GetString("file")
 		data, _ := cmd.Flags().GetString("data")
 		format, _ := cmd.Flags().GetString("input-format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		var updates map[string]map[string]interface{}
 
@@ -103,7 +116,20 @@ var batchUpdateCmd = &cobra.Command{
 			return fmt.Errorf("no records to update")
 		}
 
-		fmt.Printf("Updating %d records in batch...\n", len(updates))
+		fmt.Fprintf(os.Stderr, "Updating %d records in batch...\n", len(updates))
+
+		beforeImage, _ := cmd.Flags().GetBool("before-image")
+		if beforeImage {
+			recorder := newUndoRecorder(client, true, fmt.Sprintf("batch update %s (%d records)", tableName, len(updates)))
+			result, err := updateMultipleWithBeforeImages(client, recorder, tableName, updates)
+			if err != nil {
+				return fmt.Errorf("batch update failed: %w", err)
+			}
+			if err := outputBatchResult(result, "update", printer); err != nil {
+				return err
+			}
+			return saveUndoRecorder(recorder)
+		}
 
 		batchClient := client.Batch()
 		result, err := batchClient.UpdateMultiple(tableName, updates)
@@ -111,7 +137,7 @@ var batchUpdateCmd = &cobra.Command{
 			return fmt.Errorf("batch update failed: %w", err)
 		}
 
-		return outputBatchResult(result, "update")
+		return outputBatchResult(result, "update", printer)
 	},
 }
 
@@ -129,6 +155,10 @@ var batchDeleteCmd = &cobra.Command{
 		tableName := args[0]
 		sysIDs, _ := cmd.Flags().GetStringSlice("ids")
 		idsFile, _ := cmd.Flags().GetString("ids-file")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		var recordIDs []string
 
@@ -147,22 +177,35 @@ var batchDeleteCmd = &cobra.Command{
 			return fmt.Errorf("no record IDs provided")
 		}
 
-		fmt.Printf("Deleting %d records in batch...\n", len(recordIDs))
+		fmt.Fprintf(os.Stderr, "Deleting %d records in batch...\n", len(recordIDs))
 
 		// Confirm deletion
 		confirm, _ := cmd.Flags().GetBool("confirm")
 		if !confirm {
-			fmt.Printf("⚠️  This will permanently delete %d records. Use --confirm to proceed.\n", len(recordIDs))
+			fmt.Fprintf(os.Stderr, "⚠️  This will permanently delete %d records. Use --confirm to proceed.\n", len(recordIDs))
 			return nil
 		}
 
+		beforeImage, _ := cmd.Flags().GetBool("before-image")
+		if beforeImage {
+			recorder := newUndoRecorder(client, true, fmt.Sprintf("batch delete %s (%d records)", tableName, len(recordIDs)))
+			result, err := deleteMultipleWithBeforeImages(client, recorder, tableName, recordIDs)
+			if err != nil {
+				return fmt.Errorf("batch delete failed: %w", err)
+			}
+			if err := outputBatchResult(result, "delete", printer); err != nil {
+				return err
+			}
+			return saveUndoRecorder(recorder)
+		}
+
 		batchClient := client.Batch()
 		result, err := batchClient.DeleteMultiple(tableName, recordIDs)
 		if err != nil {
 			return fmt.Errorf("batch delete failed: %w", err)
 		}
 
-		return outputBatchResult(result, "delete")
+		return outputBatchResult(result, "delete", printer)
 	},
 }
 
@@ -177,6 +220,10 @@ var batchMixedCmd = &cobra.Command{
 		}
 
 		configFile, _ := cmd.Flags().GetString("config")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 		if configFile == "" {
 			return fmt.Errorf("--config file is required for mixed operations")
 		}
@@ -186,7 +233,7 @@ var batchMixedCmd = &cobra.Command{
 			return fmt.Errorf("failed to load operations from config: %w", err)
 		}
 
-		fmt.Printf("Executing mixed batch with %d operations...\n", 
+		fmt.Fprintf(os.Stderr, "Executing mixed batch with %d operations...\n", 
 			len(operations.Creates)+len(operations.Updates)+len(operations.Gets)+len(operations.Deletes))
 
 		batchClient := client.Batch()
@@ -195,7 +242,7 @@ var batchMixedCmd = &cobra.Command{
 			return fmt.Errorf("mixed batch execution failed: %w", err)
 		}
 
-		return outputMixedBatchResult(result)
+		return outputMixedBatchResult(result, printer)
 	},
 }
 
@@ -334,50 +381,121 @@ func loadMixedOperationsFromFile(filename string) (batch.MixedOperations, error)
 	return operations, err
 }
 
-// Output functions
-func outputBatchResult(result interface{}, operation string) error {
-	// This would need to be implemented based on the actual batch result structure
-	fmt.Printf("✅ Batch %s completed\n", operation)
-	
-	if data, err := json.MarshalIndent(result, "", "  "); err == nil {
-		fmt.Println(string(data))
-	} else {
-		fmt.Printf("Result: %+v\n", result)
+// updateMultipleWithBeforeImages captures each record before updating it.
+// Batch request IDs are the sys_ids so results can be matched to before-images.
+func updateMultipleWithBeforeImages(client *servicenow.Client, recorder *undo.Recorder, tableName string, updates map[string]map[string]interface{}) (*batch.BatchResult, error) {
+	ctx := context.Background()
+	builder := client.Batch().NewBatch()
+	for sysID, data := range updates {
+		fields := make([]string, 0, len(data))
+		for field := range data {
+			fields = append(fields, field)
+		}
+		if _, err := recorder.Capture(ctx, tableName, sysID, undo.ActionUpdate, fields); err != nil {
+			return nil, err
+		}
+		builder.Update(sysID, tableName, sysID, data)
 	}
 
-	return nil
+	result, err := builder.ExecuteWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for sysID, reqResult := range result.Results {
+		if reqResult.StatusCode >= 300 {
+			continue
+		}
+		after, _ := batch.ExtractRecordData(reqResult)
+		recorder.MarkApplied(tableName, sysID, after)
+	}
+	return result, nil
 }
 
-func outputMixedBatchResult(result interface{}) error {
-	fmt.Println("✅ Mixed batch operation completed")
-	
-	if data, err := json.MarshalIndent(result, "", "  "); err == nil {
-		fmt.Println(string(data))
-	} else {
-		fmt.Printf("Result: %+v\n", result)
+// deleteMultipleWithBeforeImages captures each record before deleting it
+func deleteMultipleWithBeforeImages(client *servicenow.Client, recorder *undo.Recorder, tableName string, sysIDs []string) (*batch.BatchResult, error) {
+	ctx := context.Background()
+	builder := client.Batch().NewBatch()
+	for _, sysID := range sysIDs {
+		if _, err := recorder.Capture(ctx, tableName, sysID, undo.ActionDelete, nil); err != nil {
+			return nil, err
+		}
+		builder.Delete(sysID, tableName, sysID)
 	}
 
+	result, err := builder.ExecuteWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for sysID, reqResult := range result.Results {
+		if reqResult.StatusCode >= 300 {
+			continue
+		}
+		recorder.MarkApplied(tableName, sysID, nil)
+	}
+	return result, nil
+}
+
+// Output functions
+func outputBatchResult(result *batch.BatchResult, operation string, printer *output.Printer) error {
+	if !printer.Human() {
+		return printer.Print(result)
+	}
+
+	var rows []map[string]interface{}
+	for id, r := range result.Results {
+		row := map[string]interface{}{"id": id, "status": r.StatusCode, "message": r.StatusText}
+		if record, err := batch.ExtractRecordData(r); err == nil {
+			row["sys_id"] = record["sys_id"]
+		}
+		rows = append(rows, row)
+	}
+	for id, e := range result.Errors {
+		rows = append(rows, map[string]interface{}{"id": id, "status": e.StatusCode, "message": e.ErrorDetail})
+	}
+	// Request IDs end in a sequence number: create_2 sorts before create_10
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i]["id"].(string), rows[j]["id"].(string)
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+	if err := printer.Print(rows, output.Columns("id", "status", "sys_id", "message")...); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✅ Batch %s completed: %d succeeded, %d failed\n", operation, len(result.Results), len(result.Errors))
 	return nil
 }
 
+func outputMixedBatchResult(result *batch.BatchResult, printer *output.Printer) error {
+	return outputBatchResult(result, "mixed operation", printer)
+}
+
 func init() {
 	// Create command flags
 	batchCreateCmd.Flags().StringP("file", "f", "", "JSON or CSV file containing records to create")
 	batchCreateCmd.Flags().StringP("data", "d", "", "JSON string containing records to create")
 	batchCreateCmd.Flags().StringP("input-format", "", "json", "Input file format (json, csv)")
+	addOutputFlags(batchCreateCmd, "")
 
 	// Update command flags
 	batchUpdateCmd.Flags().StringP("file", "f", "", "JSON file containing updates (format: {\"sys_id\": {\"field\": \"value\"}})")
 	batchUpdateCmd.Flags().StringP("data", "d", "", "JSON string containing updates")
 	batchUpdateCmd.Flags().StringP("input-format", "", "json", "Input file format (json)")
+	batchUpdateCmd.Flags().BoolP("before-image", "", false, "Save records before updating so they can be restored with 'undo'")
+	addOutputFlags(batchUpdateCmd, "")
 
 	// Delete command flags
 	batchDeleteCmd.Flags().StringSliceP("ids", "i", nil, "Comma-separated list of sys_ids to delete")
 	batchDeleteCmd.Flags().StringP("ids-file", "f", "", "File containing sys_ids (one per line or JSON array)")
 	batchDeleteCmd.Flags().BoolP("confirm", "", false, "Confirm deletion (required for safety)")
+	batchDeleteCmd.Flags().BoolP("before-image", "", false, "Save records before deleting so they can be restored with 'undo'")
+	addOutputFlags(batchDeleteCmd, "")
 
 	// Mixed command flags
 	batchMixedCmd.Flags().StringP("config", "c", "", "JSON configuration file for mixed operations")
+	addOutputFlags(batchMixedCmd, "")
 
 	// Add subcommands
 	batchCmd.AddCommand(batchCreateCmd, batchUpdateCmd, batchDeleteCmd, batchMixedCmd, batchStatusCmd)
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/catalog"
 	"github.com/spf13/cobra"
 )
 
@@ -24,7 +26,10 @@ var catalogListCmd = &cobra.Command{
 			return err
 		}
 
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		catalogClient := client.Catalog()
 		catalogs, err := catalogClient.ListCatalogs()
@@ -32,7 +37,7 @@ var catalogListCmd = &cobra.Command{
 			return fmt.Errorf("failed to list catalogs: %w", err)
 		}
 
-		return outputCatalogs(catalogs, format)
+		return outputCatalogs(catalogs, printer)
 	},
 }
 
@@ -52,18 +57,21 @@ var catalogItemsListCmd = &cobra.Command{
 			return err
 		}
 
-		catalog, _ := cmd.Flags().GetString("catalog")
+		catalogID, _ := cmd.Flags().GetString("catalog")
 		category, _ := cmd.Flags().GetString("category")
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		catalogClient := client.Catalog()
 
-		var items interface{}
+		var items []catalog.CatalogItem
 		var itemsErr error
 
-		if catalog != "" {
+		if catalogID != "" {
 			// List items in specific catalog
-			items, itemsErr = catalogClient.ListItems(catalog)
+			items, itemsErr = catalogClient.ListItems(catalogID)
 		} else if category != "" {
 			// List items in specific category
 			items, itemsErr = catalogClient.ListItemsByCategory(category)
@@ -76,7 +84,7 @@ var catalogItemsListCmd = &cobra.Command{
 			return fmt.Errorf("failed to list catalog items: %w", itemsErr)
 		}
 
-		return outputCatalogItems(items, format)
+		return outputCatalogItems(items, printer)
 	},
 }
 
@@ -93,7 +101,10 @@ var catalogItemsSearchCmd = &cobra.Command{
 
 		query := args[0]
 		limit, _ := cmd.Flags().GetInt("limit")
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		catalogClient := client.Catalog()
 		items, err := catalogClient.SearchItems(query)
@@ -106,7 +117,7 @@ var catalogItemsSearchCmd = &cobra.Command{
 			items = items[:limit]
 		}
 
-		return outputCatalogItems(items, format)
+		return outputCatalogItems(items, printer)
 	},
 }
 
@@ -122,12 +133,15 @@ var catalogItemsGetCmd = &cobra.Command{
 		}
 
 		itemID := args[0]
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 		showVariables, _ := cmd.Flags().GetBool("variables")
 
 		catalogClient := client.Catalog()
 
-		var item interface{}
+		var item *catalog.CatalogItem
 		if showVariables {
 			item, err = catalogClient.GetItemWithVariables(itemID)
 		} else {
@@ -138,7 +152,7 @@ var catalogItemsGetCmd = &cobra.Command{
 			return fmt.Errorf("failed to get catalog item: %w", err)
 		}
 
-		return outputCatalogItem(item, format)
+		return outputCatalogItem(item, printer)
 	},
 }
 
@@ -247,7 +261,10 @@ var catalogCartListCmd = &cobra.Command{
 			return err
 		}
 
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		catalogClient := client.Catalog()
 		cart, err := catalogClient.GetCart()
@@ -255,7 +272,7 @@ var catalogCartListCmd = &cobra.Command{
 			return fmt.Errorf("failed to get cart: %w", err)
 		}
 
-		return outputCart(cart, format)
+		return outputCart(cart, printer)
 	},
 }
 
@@ -350,7 +367,10 @@ var catalogRequestsListCmd = &cobra.Command{
 		}
 
 		limit, _ := cmd.Flags().GetInt("limit")
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		catalogClient := client.Catalog()
 		tracker := catalogClient.NewRequestTracker()
@@ -360,7 +380,7 @@ var catalogRequestsListCmd = &cobra.Command{
 			return fmt.Errorf("failed to get requests: %w", err)
 		}
 
-		return outputRequests(requests, format)
+		return outputRequests(requests, printer)
 	},
 }
 
@@ -376,7 +396,10 @@ var catalogRequestsGetCmd = &cobra.Command{
 		}
 
 		requestNumber := args[0]
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		catalogClient := client.Catalog()
 		tracker := catalogClient.NewRequestTracker()
@@ -386,126 +409,119 @@ var catalogRequestsGetCmd = &cobra.Command{
 			return fmt.Errorf("failed to get request: %w", err)
 		}
 
-		return outputRequest(request, format)
+		return outputRequest(request, printer)
 	},
 }
 
-// Output functions (simplified implementations)
-func outputCatalogs(catalogs interface{}, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(catalogs)
-	default:
-		fmt.Println("Available Catalogs:")
-		if data, err := json.MarshalIndent(catalogs, "", "  "); err == nil {
-			fmt.Println(string(data))
-		}
-		return nil
-	}
+// Output functions
+var catalogItemColumns = []output.Column{
+	{Path: "sys_id"},
+	{Path: "name"},
+	{Path: "short_description"},
+	{Path: "price"},
+	{Path: "recurring_price", Wide: true},
+	{Path: "category", Wide: true},
+	{Path: "active"},
 }
 
-func outputCatalogItems(items interface{}, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(items)
-	default:
-		fmt.Println("Catalog Items:")
-		if data, err := json.MarshalIndent(items, "", "  "); err == nil {
-			fmt.Println(string(data))
-		}
-		return nil
-	}
+var requestColumns = []output.Column{
+	{Path: "number"},
+	{Path: "state"},
+	{Path: "stage"},
+	{Path: "short_description"},
+	{Path: "requested_for", Wide: true},
+	{Path: "opened_at"},
+	{Path: "price", Wide: true},
 }
 
-func outputCatalogItem(item interface{}, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(item)
-	default:
-		fmt.Println("Catalog Item Details:")
-		if data, err := json.MarshalIndent(item, "", "  "); err == nil {
-			fmt.Println(string(data))
-		}
-		return nil
-	}
+func outputCatalogs(catalogs []catalog.Catalog, printer *output.Printer) error {
+	return printer.Print(catalogs, output.Columns("sys_id", "title", "description", "active")...)
 }
 
-func outputCart(cart interface{}, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(cart)
-	default:
-		fmt.Println("Shopping Cart:")
-		if data, err := json.MarshalIndent(cart, "", "  "); err == nil {
-			fmt.Println(string(data))
-		}
-		return nil
+func outputCatalogItems(items []catalog.CatalogItem, printer *output.Printer) error {
+	return printer.Print(items, catalogItemColumns...)
+}
+
+func outputCatalogItem(item *catalog.CatalogItem, printer *output.Printer) error {
+	if !printer.Human() {
+		return printer.Print(item)
 	}
+	if err := printer.Print(item, append(catalogItemColumns, output.Columns("description", "sc_catalog", "type")...)...); err != nil {
+		return err
+	}
+	if len(item.Variables) > 0 {
+		fmt.Println("\nVariables:")
+		return printer.Print(item.Variables, output.Columns("name", "question", "type", "mandatory", "default_value")...)
+	}
+	return nil
 }
 
-func outputRequests(requests interface{}, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(requests)
-	default:
-		fmt.Println("Catalog Requests:")
-		if data, err := json.MarshalIndent(requests, "", "  "); err == nil {
-			fmt.Println(string(data))
-		}
-		return nil
+func outputCart(cart *catalog.Cart, printer *output.Printer) error {
+	if !printer.Human() {
+		return printer.Print(cart)
+	}
+	if err := printer.Print(cart.Items, output.Columns("sys_id", "cat_item", "quantity", "price", "recurring_price")...); err != nil {
+		return err
 	}
+	fmt.Printf("\nSubtotal: %s  Tax: %s  Total: %s\n", cart.Subtotal, cart.Tax, cart.TotalPrice)
+	return nil
 }
 
-func outputRequest(request interface{}, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(request)
-	default:
-		fmt.Println("Request Details:")
-		if data, err := json.MarshalIndent(request, "", "  "); err == nil {
-			fmt.Println(string(data))
-		}
-		return nil
+func outputRequests(requests []catalog.Request, printer *output.Printer) error {
+	return printer.Print(requests, requestColumns...)
+}
+
+func outputRequest(request *catalog.Request, printer *output.Printer) error {
+	if !printer.Human() {
+		return printer.Print(request)
+	}
+	if err := printer.Print(request, append(requestColumns, output.Columns("sys_id", "requested_by", "due_date", "approval")...)...); err != nil {
+		return err
+	}
+	if len(request.RequestItems) > 0 {
+		fmt.Println("\nRequested Items:")
+		return printer.Print(request.RequestItems, output.Columns("number", "cat_item", "quantity", "state", "stage", "assigned_to")...)
 	}
+	return nil
 }
 
 func init() {
 	// Catalog list flags
-	catalogListCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
+	addOutputFlags(catalogListCmd, "f")
 
 	// Items list flags
 	catalogItemsListCmd.Flags().StringP("catalog", "c", "", "Filter by catalog ID")
 	catalogItemsListCmd.Flags().StringP("category", "", "", "Filter by category ID")
 	catalogItemsListCmd.Flags().IntP("limit", "l", 10, "Limit number of results")
-	catalogItemsListCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
+	addOutputFlags(catalogItemsListCmd, "f")
 
 	// Items search flags
 	catalogItemsSearchCmd.Flags().IntP("limit", "l", 10, "Limit number of results")
-	catalogItemsSearchCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
+	addOutputFlags(catalogItemsSearchCmd, "f")
 
 	// Items get flags
-	catalogItemsGetCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
-	catalogItemsGetCmd.Flags().BoolP("variables", "v", false, "Include item variables")
+	addOutputFlags(catalogItemsGetCmd, "f")
+	catalogItemsGetCmd.Flags().BoolP("variables", "", false, "Include item variables")
 
 	// Order flags
 	catalogOrderCmd.Flags().IntP("quantity", "q", 1, "Quantity to order")
-	catalogOrderCmd.Flags().StringP("variables", "v", "", "JSON string of item variables")
+	catalogOrderCmd.Flags().StringP("variables", "", "", "JSON string of item variables")
 	catalogOrderCmd.Flags().StringP("variables-file", "f", "", "JSON file containing item variables")
 	catalogOrderCmd.Flags().BoolP("dry-run", "", true, "Show price estimate only, don't place order")
 
 	// Cart list flags
-	catalogCartListCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
+	addOutputFlags(catalogCartListCmd, "f")
 
 	// Cart add flags
 	catalogCartAddCmd.Flags().IntP("quantity", "q", 1, "Quantity to add")
-	catalogCartAddCmd.Flags().StringP("variables", "v", "", "JSON string of item variables")
+	catalogCartAddCmd.Flags().StringP("variables", "", "", "JSON string of item variables")
 
 	// Requests list flags
 	catalogRequestsListCmd.Flags().IntP("limit", "l", 10, "Limit number of results")
-	catalogRequestsListCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
+	addOutputFlags(catalogRequestsListCmd, "f")
 
 	// Requests get flags
-	catalogRequestsGetCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
+	addOutputFlags(catalogRequestsGetCmd, "f")
 
 	// Add subcommands
 	catalogItemsCmd.AddCommand(catalogItemsListCmd, catalogItemsSearchCmd, catalogItemsGetCmd)
@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
-	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/cmdb"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/metadata"
 )
 
 var cmdbCmd = &cobra.Command{
@@ -38,7 +40,10 @@ var ciListCmd = &cobra.Command{
 		name, _ := cmd.Flags().GetString("name")
 		active, _ := cmd.Flags().GetBool("active")
 		limit, _ := cmd.Flags().GetInt("limit")
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 		fields, _ := cmd.Flags().GetString("fields")
 
 		cmdbClient := client.CMDB()
@@ -71,7 +76,7 @@ var ciListCmd = &cobra.Command{
 			return fmt.Errorf("failed to list CIs: %w", err)
 		}
 
-		return outputCIs(cis, format)
+		return outputCIs(cis, printer)
 	},
 }
 
@@ -87,7 +92,10 @@ var ciGetCmd = &cobra.Command{
 		}
 
 		ciID := args[0]
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 		includeRelated, _ := cmd.Flags().GetBool("relationships")
 
 		cmdbClient := client.CMDB()
@@ -97,16 +105,16 @@ var ciGetCmd = &cobra.Command{
 		}
 
 		// Get relationships if requested
-		var relationships interface{}
+		var relationships []*cmdb.CIRelationship
 		if includeRelated {
 			relClient := cmdbClient.NewRelationshipClient()
 			relationships, err = relClient.GetRelationships(ciID)
 			if err != nil {
-				fmt.Printf("Warning: failed to get relationships: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Warning: failed to get relationships: %v\n", err)
 			}
 		}
 
-		return outputCIDetails(ci, relationships, format)
+		return outputCIDetails(ci, relationships, printer)
 	},
 }
 
@@ -237,13 +245,16 @@ var relationshipListCmd = &cobra.Command{
 
 		ciID := args[0]
 		relType, _ := cmd.Flags().GetString("type")
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		cmdbClient := client.CMDB()
 
 		relClient := cmdbClient.NewRelationshipClient()
 		
-		var relationships interface{}
+		var relationships []*cmdb.CIRelationship
 		if relType != "" {
 			relationships, err = relClient.GetRelationshipsByType(relType)
 		} else {
@@ -254,7 +265,7 @@ var relationshipListCmd = &cobra.Command{
 			return fmt.Errorf("failed to get relationships: %w", err)
 		}
 
-		return outputRelationships(relationships, format)
+		return outputRelationships(relationships, printer)
 	},
 }
 
@@ -298,7 +309,10 @@ var relationshipParentsCmd = &cobra.Command{
 		}
 
 		ciID := args[0]
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		cmdbClient := client.CMDB()
 		relClient := cmdbClient.NewRelationshipClient()
@@ -307,7 +321,7 @@ var relationshipParentsCmd = &cobra.Command{
 			return fmt.Errorf("failed to get parent CIs: %w", err)
 		}
 
-		return outputRelationships(parents, format)
+		return outputRelationships(parents, printer)
 	},
 }
 
@@ -323,7 +337,10 @@ var relationshipChildrenCmd = &cobra.Command{
 		}
 
 		ciID := args[0]
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		cmdbClient := client.CMDB()
 		relClient := cmdbClient.NewRelationshipClient()
@@ -332,7 +349,7 @@ var relationshipChildrenCmd = &cobra.Command{
 			return fmt.Errorf("failed to get child CIs: %w", err)
 		}
 
-		return outputRelationships(children, format)
+		return outputRelationships(children, printer)
 	},
 }
 
@@ -353,7 +370,10 @@ var classListCmd = &cobra.Command{
 			return err
 		}
 
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		cmdbClient := client.CMDB()
 		classClient := cmdbClient.NewClassClient()
@@ -362,7 +382,7 @@ var classListCmd = &cobra.Command{
 			return fmt.Errorf("failed to list CI classes: %w", err)
 		}
 
-		return outputClasses(classes, format)
+		return outputClasses(classes, printer)
 	},
 }
 
@@ -378,7 +398,10 @@ var classGetCmd = &cobra.Command{
 		}
 
 		className := args[0]
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		cmdbClient := client.CMDB()
 		classClient := cmdbClient.NewClassClient()
@@ -387,7 +410,7 @@ var classGetCmd = &cobra.Command{
 			return fmt.Errorf("failed to get CI class: %w", err)
 		}
 
-		return outputClass(class, format)
+		return outputClass(class, printer)
 	},
 }
 
@@ -403,7 +426,10 @@ var classHierarchyCmd = &cobra.Command{
 		}
 
 		className := args[0]
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		cmdbClient := client.CMDB()
 		classClient := cmdbClient.NewClassClient()
@@ -412,112 +438,79 @@ var classHierarchyCmd = &cobra.Command{
 			return fmt.Errorf("failed to get class hierarchy: %w", err)
 		}
 
-		return outputClassHierarchy(hierarchy, format)
+		return outputClassHierarchy(hierarchy, printer)
 	},
 }
 
 // Output functions
-func outputCIs(cis []*cmdb.ConfigurationItem, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(cis)
-	default:
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "SYS_ID\tNAME\tCLASS\tSTATE\tIP_ADDRESS\tSERIAL_NUMBER")
-		for _, ci := range cis {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
-				ci.SysID,
-				ci.Name,
-				ci.SysClassName,
-				ci.State,
-				ci.IPAddress,
-				ci.SerialNumber,
-			)
-		}
-		return w.Flush()
-	}
+var ciColumns = []output.Column{
+	{Path: "sys_id"},
+	{Path: "name"},
+	{Path: "sys_class_name", Header: "CLASS"},
+	{Path: "install_status", Header: "STATE"},
+	{Path: "ip_address"},
+	{Path: "serial_number"},
+	{Path: "location", Wide: true},
+	{Path: "owned_by", Header: "OWNER", Wide: true},
+}
+
+var relationshipColumns = output.Columns("sys_id", "parent", "type", "child", "connection_type")
+
+var classColumns = output.Columns("sys_id", "label", "super_class", "active", "abstract", "extensible")
+
+func outputCIs(cis []*cmdb.ConfigurationItem, printer *output.Printer) error {
+	return printer.Print(cis, ciColumns...)
 }
 
-func outputCIDetails(ci *cmdb.ConfigurationItem, relationships interface{}, format string) error {
-	switch format {
-	case "json":
+func outputCIDetails(ci *cmdb.ConfigurationItem, relationships []*cmdb.CIRelationship, printer *output.Printer) error {
+	if !printer.Human() {
 		result := map[string]interface{}{
 			"ci":            ci,
 			"relationships": relationships,
 		}
-		return json.NewEncoder(os.Stdout).Encode(result)
-	default:
-		fmt.Printf("Configuration Item Details:\n")
-		fmt.Printf("  SysID:         %s\n", ci.SysID)
-		fmt.Printf("  Name:          %s\n", ci.Name)
-		fmt.Printf("  Class:         %s\n", ci.SysClassName)
-		fmt.Printf("  State:         %s\n", ci.State)
-		fmt.Printf("  IP Address:    %s\n", ci.IPAddress)
-		fmt.Printf("  Serial Number: %s\n", ci.SerialNumber)
-		fmt.Printf("  Location:      %s\n", ci.Location)
-		fmt.Printf("  Owner:         %s\n", ci.Owner)
-		fmt.Printf("  Created:       %s\n", ci.CreatedOn.Format("2006-01-02 15:04:05"))
-
-		if relationships != nil {
-			fmt.Printf("\nRelationships:\n")
-			if data, err := json.MarshalIndent(relationships, "  ", "  "); err == nil {
-				fmt.Printf("  %s\n", string(data))
-			}
-		}
-		return nil
+		return printer.Print(result)
 	}
-}
 
-func outputRelationships(relationships interface{}, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(relationships)
-	default:
-		fmt.Println("CI Relationships:")
-		if data, err := json.MarshalIndent(relationships, "", "  "); err == nil {
-			fmt.Println(string(data))
-		}
-		return nil
+	columns := append(ciColumns, output.Columns("operational_status", "environment", "sys_created_on")...)
+	if err := printer.Print(ci, columns...); err != nil {
+		return err
+	}
+	if relationships != nil {
+		fmt.Printf("\nRelationships:\n")
+		return printer.Print(relationships, relationshipColumns...)
 	}
+	return nil
 }
 
-func outputClasses(classes interface{}, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(classes)
-	default:
-		fmt.Println("CMDB Classes:")
-		if data, err := json.MarshalIndent(classes, "", "  "); err == nil {
-			fmt.Println(string(data))
-		}
-		return nil
-	}
+func outputRelationships(relationships []*cmdb.CIRelationship, printer *output.Printer) error {
+	return printer.Print(relationships, relationshipColumns...)
 }
 
-func outputClass(class interface{}, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(class)
-	default:
-		fmt.Println("Class Details:")
-		if data, err := json.MarshalIndent(class, "", "  "); err == nil {
-			fmt.Println(string(data))
-		}
-		return nil
-	}
+func outputClasses(classes []*cmdb.CIClass, printer *output.Printer) error {
+	return printer.Print(classes, classColumns...)
 }
 
-func outputClassHierarchy(hierarchy interface{}, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(hierarchy)
-	default:
-		fmt.Println("Class Hierarchy:")
-		if data, err := json.MarshalIndent(hierarchy, "", "  "); err == nil {
-			fmt.Println(string(data))
+func outputClass(class *cmdb.CIClass, printer *output.Printer) error {
+	return printer.Print(class, append(classColumns, output.Columns("number_ref", "sys_package", "sys_documentation")...)...)
+}
+
+// outputClassHierarchy prints one row per subclass with its parent class
+func outputClassHierarchy(hierarchy map[string][]*cmdb.CIClass, printer *output.Printer) error {
+	if !printer.Human() {
+		return printer.Print(hierarchy)
+	}
+	parents := make([]string, 0, len(hierarchy))
+	for parent := range hierarchy {
+		parents = append(parents, parent)
+	}
+	sort.Strings(parents)
+	var rows []map[string]interface{}
+	for _, parent := range parents {
+		for _, class := range hierarchy[parent] {
+			rows = append(rows, map[string]interface{}{"parent": parent, "label": class.Label, "sys_id": class.SysID, "abstract": class.Abstract})
 		}
-		return nil
 	}
+	return printer.Print(rows, output.Columns("parent", "label", "sys_id", "abstract")...)
 }
 
 func init() {
@@ -526,12 +519,13 @@ func init() {
 	ciListCmd.Flags().StringP("name", "n", "", "Filter by name")
 	ciListCmd.Flags().BoolP("active", "a", false, "Filter by active status")
 	ciListCmd.Flags().IntP("limit", "l", 10, "Limit number of results")
-	ciListCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
 	ciListCmd.Flags().StringP("fields", "", "", "Comma-separated list of fields to include")
+	setCompletionTable(ciListCmd, metadata.CIBaseClass)
+	addOutputFlags(ciListCmd, "f")
 
 	// CI get flags
-	ciGetCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
 	ciGetCmd.Flags().BoolP("relationships", "r", false, "Include relationships")
+	addOutputFlags(ciGetCmd, "f")
 
 	// CI create flags
 	ciCreateCmd.Flags().StringP("name", "n", "", "CI name")
@@ -544,16 +538,16 @@ func init() {
 
 	// Relationship list flags
 	relationshipListCmd.Flags().StringP("type", "t", "", "Filter by relationship type")
-	relationshipListCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
+	addOutputFlags(relationshipListCmd, "f")
 
 	// Relationship parents/children flags
-	relationshipParentsCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
-	relationshipChildrenCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
+	addOutputFlags(relationshipParentsCmd, "f")
+	addOutputFlags(relationshipChildrenCmd, "f")
 
 	// Class flags
-	classListCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
-	classGetCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
-	classHierarchyCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
+	addOutputFlags(classListCmd, "f")
+	addOutputFlags(classGetCmd, "f")
+	addOutputFlags(classHierarchyCmd, "f")
 
 	// Add subcommands
 	ciCmd.AddCommand(ciListCmd, ciGetCmd, ciCreateCmd, ciUpdateCmd)
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/app/explorer"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/metadata"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
+	"github.com/spf13/cobra"
+)
+
+// completionTimeout bounds the requests of one completion, so an unreachable
+// instance falls back to cached metadata quickly
+const completionTimeout = 3 * time.Second
+
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// argCompletions complete positional arguments by their placeholder in Use
+var argCompletions = map[string]completionFunc{
+	"table":         completeTables,
+	"table_name":    completeTables,
+	"field":         completeFieldList,
+	"class":         completeCIClasses,
+	"class_name":    completeCIClasses,
+	"item_id":       completeCatalogItems,
+	"encoded_query": completeEncodedQuery,
+}
+
+// flagCompletions complete flag values by flag name
+var flagCompletions = map[string]completionFunc{
+	"table":    completeTables,
+	"class":    completeCIClasses,
+	"fields":   completeFieldList,
+	"field":    completeFieldList,
+	"columns":  completeFieldList,
+	"order-by": completeFieldList,
+	"group-by": completeFieldList,
+	"key":      completeFieldList,
+	"filter":   completeEncodedQuery,
+	"query":    completeEncodedQuery,
+}
+
+// registerCompletions adds metadata-backed completion to a command and its
+// subcommands. It runs once every command has registered its flags.
+func registerCompletions(cmd *cobra.Command) {
+	if cmd.ValidArgsFunction == nil && len(cmd.ValidArgs) == 0 {
+		for _, placeholder := range argPlaceholders(cmd) {
+			if _, ok := argCompletions[placeholder]; ok {
+				cmd.ValidArgsFunction = completeArgs
+				break
+			}
+		}
+	}
+	for name, complete := range flagCompletions {
+		if cmd.Flags().Lookup(name) == nil {
+			continue
+		}
+		_ = cmd.RegisterFlagCompletionFunc(name, complete)
+	}
+	for _, child := range cmd.Commands() {
+		registerCompletions(child)
+	}
+}
+
+// setCompletionTable names the table whose fields complete the flags of a command
+// without a table argument
+func setCompletionTable(cmd *cobra.Command, tableName string) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations["completionTable"] = tableName
+}
+
+// argPlaceholders returns the argument names in Use, e.g. table_name for [table_name...]
+func argPlaceholders(cmd *cobra.Command) []string {
+	var placeholders []string
+	for _, word := range strings.Fields(cmd.Use)[1:] {
+		placeholders = append(placeholders, strings.Trim(word, "[].<>"))
+	}
+	return placeholders
+}
+
+func completeArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	placeholders := argPlaceholders(cmd)
+	i := len(args)
+	if i >= len(placeholders) {
+		// Only a variadic last argument takes more values
+		words := strings.Fields(cmd.Use)
+		if len(placeholders) == 0 || !strings.Contains(words[len(words)-1], "...") {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		i = len(placeholders) - 1
+	}
+	if complete, ok := argCompletions[placeholders[i]]; ok {
+		return complete(cmd, args, toComplete)
+	}
+	if placeholders[i] == "file_path" {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completionTable returns the table a command works on: its --table or --class
+// flag, its table argument, or the table it was registered with
+func completionTable(cmd *cobra.Command, args []string) string {
+	for _, name := range []string{"table", "class"} {
+		if value, _ := cmd.Flags().GetString(name); value != "" && cmd.Flags().Changed(name) {
+			return value
+		}
+	}
+	for i, placeholder := range argPlaceholders(cmd) {
+		if (placeholder == "table" || placeholder == "table_name") && i < len(args) {
+			return args[i]
+		}
+	}
+	return cmd.Annotations["completionTable"]
+}
+
+// withMetadata runs a completion against the metadata cache. Completion stays
+// silent when no instance is configured.
+func withMetadata(directive cobra.ShellCompDirective, complete func(ctx context.Context, cache *metadata.Cache) []string) ([]string, cobra.ShellCompDirective) {
+	cache, err := newMetadataCache()
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+		return nil, directive
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	return complete(ctx, cache), directive
+}
+
+// newMetadataCache opens the metadata cache of the configured instance. The TTL
+// can be changed with SERVICENOW_METADATA_TTL (e.g. 1h).
+func newMetadataCache() (*metadata.Cache, error) {
+	client, err := createClient()
+	if err != nil {
+		return nil, err
+	}
+	configDir := filepath.Dir(explorer.NewConfigManager().GetConfigPath())
+	cache := client.Metadata(filepath.Join(configDir, "metadata"))
+	if ttl, err := time.ParseDuration(os.Getenv("SERVICENOW_METADATA_TTL")); err == nil {
+		cache.SetTTL(ttl)
+	}
+	return cache, nil
+}
+
+func completeTables(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return withMetadata(cobra.ShellCompDirectiveNoFileComp, func(ctx context.Context, cache *metadata.Cache) []string {
+		tables, err := cache.Tables(ctx)
+		if err != nil {
+			cobra.CompDebugln(err.Error(), false)
+			return nil
+		}
+		return tableCompletions(tables, toComplete)
+	})
+}
+
+func completeCIClasses(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return withMetadata(cobra.ShellCompDirectiveNoFileComp, func(ctx context.Context, cache *metadata.Cache) []string {
+		classes, err := cache.CIClasses(ctx)
+		if err != nil {
+			cobra.CompDebugln(err.Error(), false)
+			return nil
+		}
+		return tableCompletions(classes, toComplete)
+	})
+}
+
+func tableCompletions(tables []metadata.TableInfo, toComplete string) []string {
+	var completions []string
+	for _, t := range tables {
+		if strings.HasPrefix(t.Name, toComplete) {
+			completions = append(completions, t.Name+"\t"+t.Label)
+		}
+	}
+	return completions
+}
+
+func completeCatalogItems(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return withMetadata(cobra.ShellCompDirectiveNoFileComp, func(ctx context.Context, cache *metadata.Cache) []string {
+		items, err := cache.CatalogItems(ctx)
+		if err != nil {
+			cobra.CompDebugln(err.Error(), false)
+			return nil
+		}
+		var completions []string
+		for _, item := range items {
+			if strings.HasPrefix(item.SysID, toComplete) {
+				completions = append(completions, item.SysID+"\t"+item.Name)
+			}
+		}
+		return completions
+	})
+}
+
+// completeFieldList completes the last name of a comma-separated field list,
+// walking reference fields for dot-walked names
+func completeFieldList(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	tableName := completionTable(cmd, args)
+	if tableName == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	prefix := ""
+	if i := strings.LastIndexByte(toComplete, ','); i >= 0 {
+		prefix, toComplete = toComplete[:i+1], toComplete[i+1:]
+	}
+	return withMetadata(cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveNoSpace, func(ctx context.Context, cache *metadata.Cache) []string {
+		return fieldCompletions(ctx, cache, tableName, prefix, toComplete)
+	})
+}
+
+// completeEncodedQuery completes the last condition of an encoded query: field
+// names, or choice values after field= and field!=
+func completeEncodedQuery(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	tableName := completionTable(cmd, args)
+	if tableName == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	prefix, condition := splitLastCondition(toComplete)
+	return withMetadata(cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveNoSpace, func(ctx context.Context, cache *metadata.Cache) []string {
+		field, operator, value, ok := splitChoiceCondition(condition)
+		if !ok {
+			return fieldCompletions(ctx, cache, tableName, prefix, condition)
+		}
+		resolved, err := cache.ResolveField(ctx, tableName, field)
+		if err != nil {
+			cobra.CompDebugln(err.Error(), false)
+			return nil
+		}
+		prefix += field + operator
+		var completions []string
+		seen := make(map[string]bool)
+		for _, choice := range fieldValues(resolved) {
+			if seen[choice.Value] || !strings.HasPrefix(choice.Value, value) {
+				continue
+			}
+			seen[choice.Value] = true
+			completions = append(completions, prefix+choice.Value+"\t"+choice.Label)
+		}
+		return completions
+	})
+}
+
+// fieldCompletions lists the fields of a table starting with toComplete. Text
+// before the last dot is resolved as a chain of reference fields; reference
+// fields are also offered with a trailing dot to continue the walk.
+func fieldCompletions(ctx context.Context, cache *metadata.Cache, tableName, prefix, toComplete string) []string {
+	walked, name := "", toComplete
+	if i := strings.LastIndexByte(toComplete, '.'); i >= 0 {
+		walked, name = toComplete[:i+1], toComplete[i+1:]
+		reference, err := cache.ResolveField(ctx, tableName, toComplete[:i])
+		if err != nil || !reference.IsReference() {
+			return nil
+		}
+		tableName = reference.Reference
+	}
+	table, err := cache.Table(ctx, tableName)
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+		return nil
+	}
+
+	var completions []string
+	for _, field := range table.Fields {
+		if !strings.HasPrefix(field.Name, name) {
+			continue
+		}
+		completions = append(completions, prefix+walked+field.Name+"\t"+field.Label)
+		if field.IsReference() {
+			completions = append(completions, prefix+walked+field.Name+".\t"+field.Label+" → "+field.Reference)
+		}
+	}
+	return completions
+}
+
+// fieldValues returns the values offered for a field: its choices, or true and
+// false for booleans
+func fieldValues(field *schema.Field) []schema.Choice {
+	if len(field.Choices) > 0 {
+		return field.Choices
+	}
+	if field.Type == "boolean" {
+		return []schema.Choice{{Value: "true", Label: "True"}, {Value: "false", Label: "False"}}
+	}
+	return nil
+}
+
+// splitLastCondition splits an encoded query before its last condition, keeping
+// the ^, ^OR, ^NQ or ^ORDERBY that starts it in the prefix
+func splitLastCondition(query string) (prefix, condition string) {
+	i := strings.LastIndexByte(query, '^')
+	if i < 0 {
+		return "", query
+	}
+	prefix, condition = query[:i+1], query[i+1:]
+	for _, keyword := range []string{"ORDERBYDESC", "ORDERBY", "NQ", "OR"} {
+		if strings.HasPrefix(condition, keyword) {
+			return prefix + keyword, condition[len(keyword):]
+		}
+	}
+	return prefix, condition
+}
+
+// splitChoiceCondition splits a field=value or field!=value condition
+func splitChoiceCondition(condition string) (field, operator, value string, ok bool) {
+	i := strings.IndexByte(condition, '=')
+	if i <= 0 {
+		return "", "", "", false
+	}
+	field, operator, value = condition[:i], "=", condition[i+1:]
+	if strings.HasSuffix(field, "!") {
+		field, operator = field[:len(field)-1], "!="
+	}
+	return field, operator, value, field != ""
+}
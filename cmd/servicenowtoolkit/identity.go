@@ -1,13 +1,11 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
-	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/identity"
 )
 
@@ -39,7 +37,10 @@ var userListCmd = &cobra.Command{
 		active, _ := cmd.Flags().GetBool("active")
 		department, _ := cmd.Flags().GetString("department")
 		title, _ := cmd.Flags().GetString("title")
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 		fields, _ := cmd.Flags().GetString("fields")
 
 		// Create filter
@@ -67,7 +68,7 @@ var userListCmd = &cobra.Command{
 		}
 
 		// Output results
-		return outputUsers(users, format)
+		return outputUsers(users, printer)
 	},
 }
 
@@ -83,7 +84,10 @@ var userGetCmd = &cobra.Command{
 		}
 
 		userID := args[0]
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		var user *identity.User
 
@@ -98,7 +102,7 @@ var userGetCmd = &cobra.Command{
 			return fmt.Errorf("failed to get user: %w", err)
 		}
 
-		return outputUser(user, format)
+		return outputUser(user, printer)
 	},
 }
 
@@ -162,7 +166,10 @@ var roleListCmd = &cobra.Command{
 		limit, _ := cmd.Flags().GetInt("limit")
 		active, _ := cmd.Flags().GetBool("active")
 		name, _ := cmd.Flags().GetString("name")
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		filter := &identity.RoleFilter{
 			Limit: limit,
@@ -181,7 +188,7 @@ var roleListCmd = &cobra.Command{
 			return fmt.Errorf("failed to list roles: %w", err)
 		}
 
-		return outputRoles(roles, format)
+		return outputRoles(roles, printer)
 	},
 }
 
@@ -245,7 +252,10 @@ var groupListCmd = &cobra.Command{
 		limit, _ := cmd.Flags().GetInt("limit")
 		active, _ := cmd.Flags().GetBool("active")
 		name, _ := cmd.Flags().GetString("name")
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		filter := &identity.GroupFilter{
 			Limit: limit,
@@ -264,7 +274,7 @@ var groupListCmd = &cobra.Command{
 			return fmt.Errorf("failed to list groups: %w", err)
 		}
 
-		return outputGroups(groups, format)
+		return outputGroups(groups, printer)
 	},
 }
 
@@ -347,86 +357,52 @@ var accessCheckCmd = &cobra.Command{
 }
 
 // Output functions
-func outputUsers(users []*identity.User, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(users)
-	case "table":
-		fallthrough
-	default:
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "SYS_ID\tUSERNAME\tNAME\tEMAIL\tACTIVE\tDEPARTMENT")
-		for _, user := range users {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\n",
-				user.SysID,
-				user.UserName,
-				user.Name,
-				user.Email,
-				user.Active,
-				user.Department,
-			)
-		}
-		return w.Flush()
-	}
+func outputUsers(users []*identity.User, printer *output.Printer) error {
+	return printer.Print(users,
+		output.Column{Path: "sys_id"},
+		output.Column{Path: "user_name", Header: "USERNAME"},
+		output.Column{Path: "name"},
+		output.Column{Path: "email"},
+		output.Column{Path: "active"},
+		output.Column{Path: "department"},
+		output.Column{Path: "title", Wide: true},
+		output.Column{Path: "manager", Wide: true},
+	)
 }
 
-func outputUser(user *identity.User, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(user)
-	default:
-		fmt.Printf("User Information:\n")
-		fmt.Printf("  SysID:      %s\n", user.SysID)
-		fmt.Printf("  Username:   %s\n", user.UserName)
-		fmt.Printf("  Name:       %s\n", user.Name)
-		fmt.Printf("  Email:      %s\n", user.Email)
-		fmt.Printf("  Active:     %t\n", user.Active)
-		fmt.Printf("  Department: %s\n", user.Department)
-		fmt.Printf("  Title:      %s\n", user.Title)
-		fmt.Printf("  Manager:    %s\n", user.Manager)
-		fmt.Printf("  Created:    %s\n", user.CreatedOn.Format("2006-01-02 15:04:05"))
-		return nil
-	}
+func outputUser(user *identity.User, printer *output.Printer) error {
+	return printer.Print(user,
+		output.Column{Path: "sys_id", Header: "SysID"},
+		output.Column{Path: "user_name", Header: "Username"},
+		output.Column{Path: "name", Header: "Name"},
+		output.Column{Path: "email", Header: "Email"},
+		output.Column{Path: "active", Header: "Active"},
+		output.Column{Path: "department", Header: "Department"},
+		output.Column{Path: "title", Header: "Title"},
+		output.Column{Path: "manager", Header: "Manager"},
+		output.Column{Path: "sys_created_on", Header: "Created"},
+	)
 }
 
-func outputRoles(roles []*identity.Role, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(roles)
-	default:
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "SYS_ID\tNAME\tDESCRIPTION\tACTIVE\tELEVATED")
-		for _, role := range roles {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%t\n",
-				role.SysID,
-				role.Name,
-				role.Description,
-				role.Active,
-				role.ElevatedPrivilege,
-			)
-		}
-		return w.Flush()
-	}
+func outputRoles(roles []*identity.Role, printer *output.Printer) error {
+	return printer.Print(roles,
+		output.Column{Path: "sys_id"},
+		output.Column{Path: "name"},
+		output.Column{Path: "description"},
+		output.Column{Path: "active"},
+		output.Column{Path: "elevated_privilege", Header: "ELEVATED"},
+	)
 }
 
-func outputGroups(groups []*identity.Group, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(groups)
-	default:
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "SYS_ID\tNAME\tDESCRIPTION\tACTIVE\tTYPE")
-		for _, group := range groups {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n",
-				group.SysID,
-				group.Name,
-				group.Description,
-				group.Active,
-				group.Type,
-			)
-		}
-		return w.Flush()
-	}
+func outputGroups(groups []*identity.Group, printer *output.Printer) error {
+	return printer.Print(groups,
+		output.Column{Path: "sys_id"},
+		output.Column{Path: "name"},
+		output.Column{Path: "description"},
+		output.Column{Path: "active"},
+		output.Column{Path: "type"},
+		output.Column{Path: "manager", Wide: true},
+	)
 }
 
 func init() {
@@ -435,10 +411,11 @@ func init() {
 	userListCmd.Flags().BoolP("active", "a", false, "Filter by active status")
 	userListCmd.Flags().StringP("department", "d", "", "Filter by department")
 	userListCmd.Flags().StringP("title", "t", "", "Filter by title")
-	userListCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
 	userListCmd.Flags().StringP("fields", "", "", "Comma-separated list of fields to include")
+	setCompletionTable(userListCmd, "sys_user")
+	addOutputFlags(userListCmd, "f")
 
-	userGetCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
+	addOutputFlags(userGetCmd, "f")
 
 	userCreateCmd.Flags().StringP("username", "u", "", "Username")
 	userCreateCmd.Flags().StringP("first-name", "", "", "First name")
@@ -450,13 +427,13 @@ func init() {
 	roleListCmd.Flags().IntP("limit", "l", 10, "Limit number of results")
 	roleListCmd.Flags().BoolP("active", "a", false, "Filter by active status")
 	roleListCmd.Flags().StringP("name", "n", "", "Filter by role name")
-	roleListCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
+	addOutputFlags(roleListCmd, "f")
 
 	// Group command flags
 	groupListCmd.Flags().IntP("limit", "l", 10, "Limit number of results")
 	groupListCmd.Flags().BoolP("active", "a", false, "Filter by active status")
 	groupListCmd.Flags().StringP("name", "n", "", "Filter by group name")
-	groupListCmd.Flags().StringP("format", "f", "table", "Output format (table, json)")
+	addOutputFlags(groupListCmd, "f")
 
 	// Add subcommands
 	userCmd.AddCommand(userListCmd, userGetCmd, userCreateCmd)
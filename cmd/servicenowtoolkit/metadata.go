@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var metadataCmd = &cobra.Command{
+	Use:   "metadata",
+	Short: "Manage the metadata cache used by shell completion",
+	Long: `Shell completion suggests table names, field names, choice values, catalog items
+and CI classes from a local cache of instance metadata, kept next to the config
+file with one directory per instance. Entries are loaded again after
+SERVICENOW_METADATA_TTL (default 24h); when the instance cannot be reached the
+cached entries are used as they are.
+
+Enable completion with, for example:
+  source <(servicenowtoolkit completion bash)`,
+}
+
+var metadataRefreshCmd = &cobra.Command{
+	Use:   "refresh [table_name...]",
+	Short: "Reload cached metadata",
+	Long: `Clear the metadata cache of the instance and load the table list, catalog items
+and the schemas of the given tables again.`,
+	Example: `  servicenowtoolkit metadata refresh
+  servicenowtoolkit metadata refresh incident sys_user`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := newMetadataCache()
+		if err != nil {
+			return err
+		}
+		if err := cache.Clear(); err != nil {
+			return err
+		}
+		ctx := context.Background()
+
+		tables, err := cache.Tables(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ %d tables\n", len(tables))
+		if items, err := cache.CatalogItems(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Catalog items not cached: %v\n", err)
+		} else {
+			fmt.Printf("✅ %d catalog items\n", len(items))
+		}
+		for _, tableName := range args {
+			table, err := cache.Table(ctx, tableName)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✅ %s: %d fields\n", tableName, len(table.Fields))
+		}
+		fmt.Printf("\nCache: %s\n", cache.Dir())
+		return nil
+	},
+}
+
+var metadataClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove cached metadata of the instance",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := newMetadataCache()
+		if err != nil {
+			return err
+		}
+		if err := cache.Clear(); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Cleared %s\n", cache.Dir())
+		return nil
+	},
+}
+
+func init() {
+	metadataCmd.AddCommand(metadataRefreshCmd, metadataClearCmd)
+	rootCmd.AddCommand(metadataCmd)
+}
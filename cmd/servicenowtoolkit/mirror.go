@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/app/explorer"
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/mirror"
+	"github.com/spf13/cobra"
+)
+
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Keep local copies of tables for offline queries",
+	Long: `Mirror tables into a local store and query them without contacting the instance.
+
+The first sync of a table copies every record matching --query; later syncs only
+fetch records updated since the previous one and, unless --no-deletes is given,
+drop records that were deleted or no longer match the query. Records are stored
+with raw values (sys_ids for references, UTC date/times).`,
+}
+
+var mirrorSyncCmd = &cobra.Command{
+	Use:   "sync [table_name...]",
+	Short: "Sync mirrored tables with the instance",
+	Example: `  servicenowtoolkit mirror sync incident --query "active=true" --fields number,short_description,state,priority,assigned_to
+  servicenowtoolkit mirror sync incident
+  servicenowtoolkit mirror sync --all`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query, _ := cmd.Flags().GetString("query")
+		fields, _ := cmd.Flags().GetStringSlice("fields")
+		full, _ := cmd.Flags().GetBool("full")
+		noDeletes, _ := cmd.Flags().GetBool("no-deletes")
+		all, _ := cmd.Flags().GetBool("all")
+		workers, _ := cmd.Flags().GetInt("workers")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+
+		client, err := createClient()
+		if err != nil {
+			return err
+		}
+		m := mirror.NewMirror(client.Core(), mirrorDir(cmd))
+
+		tables := args
+		if all {
+			if tables, err = m.Tables(); err != nil {
+				return err
+			}
+		}
+		if len(tables) == 0 {
+			return fmt.Errorf("name the tables to sync, or use --all")
+		}
+		selection := cmd.Flags().Changed("query") || cmd.Flags().Changed("fields")
+		if selection && len(tables) > 1 {
+			return fmt.Errorf("--query and --fields apply to a single table")
+		}
+
+		ctx := context.Background()
+		var results []*mirror.SyncResult
+		for _, tableName := range tables {
+			config := mirror.TableConfig{Table: tableName, Query: query, Fields: fields}
+			manifest, err := m.Status(tableName)
+			if err != nil {
+				return err
+			}
+			if manifest != nil && !selection {
+				config = manifest.TableConfig
+			}
+			if manifest != nil && selection && !full {
+				return fmt.Errorf("%s is already mirrored; add --full to change its query or fields", tableName)
+			}
+
+			if printer.Human() {
+				fmt.Fprintf(os.Stderr, "🔄 Syncing %s...\n", tableName)
+			}
+			result, err := m.SyncWithContext(ctx, config, mirror.SyncOptions{Full: full, DetectDeletes: !noDeletes, Workers: workers})
+			if err != nil {
+				return err
+			}
+			results = append(results, result)
+		}
+
+		if !printer.Human() {
+			return printer.Print(results)
+		}
+		for _, result := range results {
+			kind := "delta"
+			if result.Full {
+				kind = "full"
+			}
+			fmt.Printf("✅ %s: %s sync, %d created, %d updated, %d deleted, %d records (%s)\n",
+				result.Table, kind, result.Created, result.Updated, result.Deleted, result.Records, result.Duration.Round(time.Millisecond))
+		}
+		return nil
+	},
+}
+
+var mirrorStatusCmd = &cobra.Command{
+	Use:   "status [table_name]",
+	Short: "Show mirrored tables and when they were synced",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+		m := mirror.NewMirror(nil, mirrorDir(cmd))
+
+		tables := args
+		if len(tables) == 0 {
+			if tables, err = m.Tables(); err != nil {
+				return err
+			}
+		}
+		var manifests []*mirror.Manifest
+		for _, tableName := range tables {
+			manifest, err := m.Status(tableName)
+			if err != nil {
+				return err
+			}
+			if manifest == nil {
+				return fmt.Errorf("%s is not mirrored", tableName)
+			}
+			manifests = append(manifests, manifest)
+		}
+
+		if !printer.Human() {
+			return printer.Print(manifests)
+		}
+		if len(manifests) == 0 {
+			fmt.Printf("No mirrored tables in %s\n", mirrorDir(cmd))
+			return nil
+		}
+		rows := make([]map[string]interface{}, len(manifests))
+		for i, manifest := range manifests {
+			rows[i] = map[string]interface{}{
+				"table":          manifest.Table,
+				"records":        manifest.Records,
+				"high_water":     manifest.HighWater,
+				"last_sync":      manifest.LastSync.Local().Format("2006-01-02 15:04:05"),
+				"last_full_sync": manifest.LastFullSync.Local().Format("2006-01-02 15:04:05"),
+				"segments":       len(manifest.Segments),
+				"query":          manifest.Query,
+			}
+		}
+		return printer.Print(rows,
+			output.Column{Path: "table"},
+			output.Column{Path: "records"},
+			output.Column{Path: "high_water", Header: "HIGH WATER"},
+			output.Column{Path: "last_sync", Header: "LAST SYNC"},
+			output.Column{Path: "last_full_sync", Header: "LAST FULL SYNC"},
+			output.Column{Path: "segments"},
+			output.Column{Path: "query"},
+		)
+	},
+}
+
+var mirrorQueryCmd = &cobra.Command{
+	Use:   "query [table_name] [encoded_query]",
+	Short: "Query a mirrored table locally",
+	Long: `Evaluate an encoded query against a mirrored table without contacting the instance.
+
+Supported: =, !=, <, <=, >, >=, IN, NOT IN, STARTSWITH, ENDSWITH, LIKE, CONTAINS,
+NOT LIKE, DOESNOTCONTAIN, ISEMPTY, ISNOTEMPTY, ANYTHING, SAMEAS, NSAMEAS, BETWEEN
+and ON with fixed dates, ^OR, ^NQ and ORDERBY/ORDERBYDESC. javascript: values and
+relative dates are rejected. Date/times compare as stored, in UTC.`,
+	Example: `  servicenowtoolkit mirror query incident "priority=1^stateIN1,2^ORDERBYDESCsys_updated_on" --limit 20
+  servicenowtoolkit mirror query incident "" --sys-id 46d44a5dc0a8010e0144b2c4b6b4c7e0 --format json`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fields, _ := cmd.Flags().GetStringSlice("fields")
+		limit, _ := cmd.Flags().GetInt("limit")
+		sysID, _ := cmd.Flags().GetString("sys-id")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+
+		reader, err := mirror.NewMirror(nil, mirrorDir(cmd)).Open(args[0])
+		if err != nil {
+			return err
+		}
+		if sysID != "" {
+			record, err := reader.Get(sysID)
+			if err != nil {
+				return err
+			}
+			if record == nil {
+				return fmt.Errorf("%s %s is not in the mirror", args[0], sysID)
+			}
+			return outputRecord(selectFields(record, fields), printer, fields...)
+		}
+
+		encoded := ""
+		if len(args) == 2 {
+			encoded = args[1]
+		}
+		records, err := reader.Query(encoded, limit)
+		if err != nil {
+			return err
+		}
+		for i := range records {
+			records[i] = selectFields(records[i], fields)
+		}
+		return outputRecords(records, printer, fields...)
+	},
+}
+
+// selectFields returns the given fields of a record, or the record when none are given
+func selectFields(record map[string]interface{}, fields []string) map[string]interface{} {
+	if len(fields) == 0 {
+		return record
+	}
+	selected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := record[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected
+}
+
+func mirrorDir(cmd *cobra.Command) string {
+	if dir, _ := cmd.Flags().GetString("dir"); dir != "" {
+		return dir
+	}
+	return filepath.Join(filepath.Dir(explorer.NewConfigManager().GetConfigPath()), "mirror")
+}
+
+func init() {
+	mirrorCmd.PersistentFlags().StringP("dir", "", "", "Mirror directory (default: mirror/ next to the config file)")
+
+	mirrorSyncCmd.Flags().StringP("query", "q", "", "Encoded query selecting the mirrored records")
+	mirrorSyncCmd.Flags().StringSliceP("fields", "", nil, "Fields to mirror (default: all)")
+	mirrorSyncCmd.Flags().BoolP("full", "", false, "Copy every record again instead of syncing changes")
+	mirrorSyncCmd.Flags().BoolP("no-deletes", "", false, "Skip the sys_id scan that detects deleted records")
+	mirrorSyncCmd.Flags().BoolP("all", "", false, "Sync every mirrored table")
+	mirrorSyncCmd.Flags().IntP("workers", "", 0, "Parallel page requests")
+	addOutputFlags(mirrorSyncCmd, "")
+
+	addOutputFlags(mirrorStatusCmd, "")
+
+	mirrorQueryCmd.Flags().StringSliceP("fields", "", nil, "Fields to show")
+	mirrorQueryCmd.Flags().IntP("limit", "l", 0, "Maximum number of records (default: all)")
+	mirrorQueryCmd.Flags().StringP("sys-id", "", "", "Show one record by sys_id")
+	addOutputFlags(mirrorQueryCmd, "")
+
+	mirrorCmd.AddCommand(mirrorSyncCmd, mirrorStatusCmd, mirrorQueryCmd)
+	rootCmd.AddCommand(mirrorCmd)
+}
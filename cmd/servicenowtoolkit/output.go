@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// addOutputFlags registers the shared output flags on a command: --format, --columns,
+// --template and --jq, a jq-style path expression. Extra formats are rendered by
+// the command itself.
+func addOutputFlags(cmd *cobra.Command, shorthand string, extra ...string) {
+	formats := append(output.Formats(), extra...)
+	cmd.Flags().StringP("format", shorthand, string(output.FormatTable), fmt.Sprintf("Output format (%s)", strings.Join(formats, ", ")))
+	_ = cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(formats, cobra.ShellCompDirectiveNoFileComp))
+	cmd.Flags().StringSlice("columns", nil, "Fields to show, in order (dot-separated for nested values)")
+	cmd.Flags().String("template", "", "Go template for --format template, executed per result")
+	cmd.Flags().String("jq", "", "jq-style path applied to the result before formatting (e.g. '.[].number')")
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations["outputFormats"] = strings.Join(extra, ",")
+}
+
+// newPrinter returns a printer for stdout configured by the output flags
+func newPrinter(cmd *cobra.Command) (*output.Printer, error) {
+	format, _ := cmd.Flags().GetString("format")
+	columns, _ := cmd.Flags().GetStringSlice("columns")
+	tmpl, _ := cmd.Flags().GetString("template")
+	query, _ := cmd.Flags().GetString("jq")
+	if tmpl != "" && !cmd.Flags().Changed("format") {
+		format = string(output.FormatTemplate)
+	}
+
+	color, width := output.Terminal(os.Stdout)
+	options := output.Options{
+		Format:   output.Format(format),
+		Columns:  columns,
+		Query:    query,
+		Template: tmpl,
+		Color:    color,
+		Width:    width,
+	}
+	if extra := cmd.Annotations["outputFormats"]; extra != "" {
+		options.Extra = strings.Split(extra, ",")
+	}
+	return output.NewPrinter(os.Stdout, options)
+}
+
+// newFormatPrinter returns a printer writing a fixed format to w, for output of
+// commands whose --format selects something else, such as an export file format
+func newFormatPrinter(w io.Writer, format output.Format) *output.Printer {
+	printer, _ := output.NewPrinter(w, output.Options{Format: format})
+	return printer
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/lint"
+	"github.com/spf13/cobra"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Work with encoded queries",
+	Long:  "Check encoded queries against the schema of the table they run on",
+}
+
+var queryLintCmd = &cobra.Command{
+	Use:   "lint [table_name] [encoded_query]",
+	Short: "Check an encoded query against the table schema",
+	Long: `Check an encoded query against the schema of a table before running it.
+
+The instance accepts almost any encoded query: an unknown field or a choice label
+used as a value silently matches nothing. lint reports unknown fields (including
+dot-walks), operators that don't suit the field type, values that aren't numbers,
+booleans, dates or choices of the field, display values compared with reference
+sys_ids, and text searches that can't use an index on tables with at least
+--large-table records.
+
+The command fails when the query has errors, so it can guard scripts.`,
+	Example: `  servicenowtoolkit query lint incident "active=true^state=New"
+  servicenowtoolkit query lint incident "caller_id.manager.nam=Beth^short_descriptionLIKEvpn"
+  servicenowtoolkit query lint incident "priority=1" --format json`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tableName, encoded := args[0], args[1]
+		largeTable, _ := cmd.Flags().GetInt("large-table")
+		indexed, _ := cmd.Flags().GetStringSlice("indexed")
+
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+		client, err := createClient()
+		if err != nil {
+			return err
+		}
+
+		options := lint.Options{LargeTable: largeTable, Indexed: indexed}
+		if largeTable == 0 {
+			options.LargeTable = -1
+		}
+		result, err := lint.NewLinter(client.Core(), client.Schema(), options).LintWithContext(context.Background(), tableName, encoded)
+		if err != nil {
+			return err
+		}
+		if err := outputLintResult(result, printer); err != nil {
+			return err
+		}
+		if errs := result.Errors(); len(errs) > 0 {
+			return fmt.Errorf("query has %d error(s)", len(errs))
+		}
+		return nil
+	},
+}
+
+var lintColumns = []output.Column{
+	{Path: "severity"},
+	{Path: "code"},
+	{Path: "condition"},
+	{Path: "message"},
+	{Path: "suggestion", Wide: true},
+}
+
+func outputLintResult(result *lint.Result, printer *output.Printer) error {
+	if !printer.Human() {
+		return printer.Print(result)
+	}
+	if len(result.Diagnostics) == 0 {
+		fmt.Fprintf(os.Stderr, "✅ No problems found in the query on %s\n", result.Table)
+		return nil
+	}
+	if err := printer.Print(result.Diagnostics, lintColumns...); err != nil {
+		return err
+	}
+	errorCount, warningCount := 0, 0
+	for _, d := range result.Diagnostics {
+		switch d.Severity {
+		case lint.SeverityError:
+			errorCount++
+		case lint.SeverityWarning:
+			warningCount++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "\n%d error(s), %d warning(s)\n", errorCount, warningCount)
+	return nil
+}
+
+func init() {
+	queryLintCmd.Flags().IntP("large-table", "", lint.DefaultLargeTable, "Row count from which unindexed text searches are flagged (0 disables the check)")
+	queryLintCmd.Flags().StringSliceP("indexed", "", nil, "Additional fields known to be indexed")
+	addOutputFlags(queryLintCmd, "")
+
+	queryCmd.AddCommand(queryLintCmd)
+	rootCmd.AddCommand(queryCmd)
+}
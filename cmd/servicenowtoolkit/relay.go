@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/app/explorer"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/relay"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/watch"
+	"github.com/spf13/cobra"
+)
+
+const relayExampleConfig = `{
+  "interval": "30s",
+  "max_attempts": 5,
+  "sources": [
+    {"table": "incident", "query": "priority=1", "fields": ["number", "short_description", "state", "assigned_to"], "deletes": true},
+    {"name": "changes", "table": "change_request", "query": "type=emergency", "targets": ["chatops"]}
+  ],
+  "targets": [
+    {"name": "pager", "url": "http://localhost:8080/hooks/servicenow", "secret_env": "PAGER_HOOK_SECRET"},
+    {
+      "name": "chatops",
+      "url": "https://chatops.internal/api/notify",
+      "fields": ["number", "short_description"],
+      "template": "{\"text\": \"{{.Table}} {{index .Record \\\"number\\\"}} was {{.Type}} by {{.User}}\"}",
+      "headers": {"Authorization": "Bearer ${CHATOPS_TOKEN}"}
+    }
+  ]
+}
+`
+
+var relayCmd = &cobra.Command{
+	Use:   "relay",
+	Short: "POST watched table events to webhooks",
+	Long: `Watch one or more table queries and POST each created, updated or deleted record
+to HTTP targets as JSON.
+
+Sources and targets are read from a JSON config file (see --example), or given as
+flags for a single table and target. Each delivery carries X-Relay-Event and a stable
+X-Relay-Delivery ID; targets with a secret also get X-Relay-Signature, the
+"sha256=" hex HMAC-SHA256 of the body. Network errors, 429 and 5xx responses are
+retried with exponential backoff; events a target still doesn't accept are appended
+to the dead-letter file. Checkpoints are kept per source, so a restarted relay
+resumes where it stopped.
+
+Targets can limit the record fields they receive and render the body with a Go
+text/template over the event ({{.Type}}, {{.Table}}, {{.SysID}}, {{.User}},
+{{.Record}}, plus a json function). Header values expand ${ENV} variables.`,
+	Example: `  servicenowtoolkit relay --example > relay.json
+  servicenowtoolkit relay --config relay.json
+  servicenowtoolkit relay --table incident --query "priority=1" --url http://localhost:8080/hook --secret-env HOOK_SECRET`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if example, _ := cmd.Flags().GetBool("example"); example {
+			fmt.Print(relayExampleConfig)
+			return nil
+		}
+
+		configFile, _ := cmd.Flags().GetString("config")
+		tableName, _ := cmd.Flags().GetString("table")
+		once, _ := cmd.Flags().GetBool("once")
+
+		config := &relay.Config{}
+		if configFile != "" {
+			var err error
+			if config, err = relay.LoadConfig(configFile); err != nil {
+				return err
+			}
+		}
+		if tableName != "" {
+			query, _ := cmd.Flags().GetString("query")
+			fields, _ := cmd.Flags().GetStringSlice("fields")
+			deletes, _ := cmd.Flags().GetBool("deletes")
+			targetURL, _ := cmd.Flags().GetString("url")
+			secretEnv, _ := cmd.Flags().GetString("secret-env")
+			config.Sources = append(config.Sources, relay.Source{Table: tableName, Query: query, Fields: fields, IncludeDeletes: deletes})
+			if targetURL != "" {
+				config.Targets = append(config.Targets, relay.Target{Name: "default", URL: targetURL, SecretEnv: secretEnv})
+			}
+		}
+		if cmd.Flags().Changed("interval") {
+			interval, _ := cmd.Flags().GetDuration("interval")
+			config.Interval = relay.Duration(interval)
+		}
+
+		relayDir := filepath.Join(filepath.Dir(explorer.NewConfigManager().GetConfigPath()), "relay")
+		if deadLetter, _ := cmd.Flags().GetString("dead-letter"); deadLetter != "" {
+			config.DeadLetterFile = deadLetter
+		} else if config.DeadLetterFile == "" {
+			config.DeadLetterFile = filepath.Join(relayDir, "dead-letter.ndjson")
+		}
+		if config.CheckpointDir == "" {
+			config.CheckpointDir = relayDir
+		}
+
+		client, err := createClient()
+		if err != nil {
+			return err
+		}
+		r, err := relay.NewRelay(client.Core(), *config)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if once {
+			return r.RunOnce(ctx)
+		}
+		interval := time.Duration(config.Interval)
+		if interval <= 0 {
+			interval = watch.DefaultInterval
+		}
+		fmt.Fprintf(os.Stderr, "📡 Relaying %d source(s) to %d target(s) every %s (dead letters: %s)\n",
+			len(config.Sources), len(config.Targets), interval, config.DeadLetterFile)
+		err = r.Run(ctx)
+		if ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr, "✅ Relay stopped")
+			return nil
+		}
+		return err
+	},
+}
+
+func init() {
+	relayCmd.Flags().StringP("config", "c", "", "Relay config file (JSON)")
+	relayCmd.Flags().BoolP("example", "", false, "Print an example config file")
+	relayCmd.Flags().StringP("table", "t", "", "Watch this table (in addition to the config's sources)")
+	relayCmd.Flags().StringP("query", "q", "", "Encoded query for --table")
+	relayCmd.Flags().StringSliceP("fields", "", nil, "Fields for --table events")
+	relayCmd.Flags().BoolP("deletes", "", false, "Also relay deletions of --table")
+	relayCmd.Flags().StringP("url", "", "", "Target URL (in addition to the config's targets)")
+	relayCmd.Flags().StringP("secret-env", "", "", "Environment variable holding the HMAC secret for --url")
+	relayCmd.Flags().DurationP("interval", "", watch.DefaultInterval, "Time between polls")
+	relayCmd.Flags().StringP("dead-letter", "", "", "Dead-letter file (default: relay/dead-letter.ndjson next to the config file)")
+	relayCmd.Flags().BoolP("once", "", false, "Poll every source once and exit")
+
+	rootCmd.AddCommand(relayCmd)
+}
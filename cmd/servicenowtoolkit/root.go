@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow"
 	"github.com/joho/godotenv"
@@ -40,6 +41,9 @@ var (
 
 	// Explorer flags
 	demoMode bool
+
+	// Named connection profile
+	profile string
 )
 
 func init() {
@@ -49,6 +53,7 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&password, "password", "", "Password for basic auth (or set SERVICENOW_PASSWORD)")
 	rootCmd.PersistentFlags().StringVar(&apiKey, "api-key", "", "API key for authentication (or set SERVICENOW_API_KEY)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Named connection profile (reads SERVICENOW_<PROFILE>_INSTANCE_URL, _USERNAME, _API_KEY, ...)")
 
 	// OAuth flags
 	rootCmd.PersistentFlags().StringVar(&clientID, "client-id", "", "OAuth client ID (or set SERVICENOW_CLIENT_ID)")
@@ -62,6 +67,11 @@ func init() {
 
 // createClient creates a ServiceNow client based on provided credentials
 func createClient() (*servicenow.Client, error) {
+	return createClientForProfile(profile)
+}
+
+// createDefaultClient creates a client from the global flags and default environment variables
+func createDefaultClient() (*servicenow.Client, error) {
 	// Get credentials from flags or environment variables
 	url := getCredential(instanceURL, "SERVICENOW_INSTANCE_URL")
 	user := getCredential(username, "SERVICENOW_USERNAME")
@@ -77,6 +87,42 @@ func createClient() (*servicenow.Client, error) {
 		return nil, fmt.Errorf("ServiceNow instance URL is required (use --instance or set SERVICENOW_INSTANCE_URL)")
 	}
 
+	return createClientWithCredentials(url, user, pass, key, oauthClientID, oauthClientSecret, oauthRefreshToken)
+}
+
+// createClientForProfile creates a client from a named profile. Profile credentials are read from
+// SERVICENOW_<PROFILE>_INSTANCE_URL, SERVICENOW_<PROFILE>_USERNAME, SERVICENOW_<PROFILE>_API_KEY, etc.
+// An empty profile name uses the default flags and environment variables.
+func createClientForProfile(name string) (*servicenow.Client, error) {
+	if name == "" {
+		return createDefaultClient()
+	}
+
+	url := os.Getenv(profileEnvVar(name, "INSTANCE_URL"))
+	if url == "" {
+		return nil, fmt.Errorf("profile %q is not configured (set %s)", name, profileEnvVar(name, "INSTANCE_URL"))
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Using profile %s\n", name)
+	}
+
+	return createClientWithCredentials(url,
+		os.Getenv(profileEnvVar(name, "USERNAME")),
+		os.Getenv(profileEnvVar(name, "PASSWORD")),
+		os.Getenv(profileEnvVar(name, "API_KEY")),
+		os.Getenv(profileEnvVar(name, "CLIENT_ID")),
+		os.Getenv(profileEnvVar(name, "CLIENT_SECRET")),
+		os.Getenv(profileEnvVar(name, "REFRESH_TOKEN")))
+}
+
+// profileEnvVar returns the environment variable holding a profile setting
+func profileEnvVar(name, setting string) string {
+	name = strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(name))
+	return fmt.Sprintf("SERVICENOW_%s_%s", name, setting)
+}
+
+// createClientWithCredentials creates a client using the selected --auth-method
+func createClientWithCredentials(url, user, pass, key, oauthClientID, oauthClientSecret, oauthRefreshToken string) (*servicenow.Client, error) {
 	// Determine authentication method
 	switch authMethod {
 	case "basic":
@@ -195,5 +241,6 @@ func getCredential(flagValue, envVar string) string {
 
 // Execute runs the root command
 func Execute() error {
+	registerCompletions(rootCmd)
 	return rootCmd.Execute()
 }
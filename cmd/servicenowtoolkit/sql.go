@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/lint"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/sql"
+	"github.com/spf13/cobra"
+)
+
+var sqlCmd = &cobra.Command{
+	Use:   "sql [statement]",
+	Short: "Query a table with a SQL-like statement",
+	Long: `Run a SQL-like SELECT statement, compiled to an encoded query for the Table API
+or, with aggregate functions, to an aggregate query for the Stats API.
+
+WHERE supports =, !=, <>, <, <=, >, >=, [NOT] IN (...), [NOT] LIKE, BETWEEN ... AND ...,
+IS [NOT] EMPTY, AND, OR and parentheses. Quote text with ' or " and names with
+backticks, as in ORDER BY ` + "`order`" + `. A quoted or dot-walked name on the right
+of = or != is compared as a field, and with --validate so is an unquoted field of
+the table. LIKE '%text%' searches text, 'text%' and '%text' match the start and
+end. COUNT(*), COUNT, SUM, AVG, MIN and MAX with GROUP BY run aggregates.
+
+Statements without LIMIT return up to --limit records. --explain prints the compiled
+parameters without running the statement.`,
+	Example: `  servicenowtoolkit sql "SELECT number, short_description FROM incident WHERE priority <= 2 AND (state IN (1,2) OR assigned_to IS EMPTY) ORDER BY sys_created_on DESC LIMIT 50"
+  servicenowtoolkit sql "SELECT category, COUNT(*) FROM incident WHERE active = true GROUP BY category"
+  servicenowtoolkit sql "SELECT * FROM incident WHERE short_description LIKE '%vpn%'" --explain`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		explain, _ := cmd.Flags().GetBool("explain")
+		validate, _ := cmd.Flags().GetBool("validate")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		// --validate reads the schema to tell fields from unquoted text on the
+		// right of = and !=; without it such names must be quoted
+		ctx := context.Background()
+		var client *servicenow.Client
+		var isField sql.FieldChecker
+		if validate {
+			var err error
+			if client, err = createClient(); err != nil {
+				return err
+			}
+			isField = schemaFieldChecker(ctx, client)
+		}
+		statement, err := sql.ParseWithFields(args[0], isField)
+		if err != nil {
+			return err
+		}
+		if !statement.IsAggregate() && statement.Limit == 0 {
+			statement.Limit = limit
+		}
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+		if explain {
+			return outputSQLExplain(statement, printer)
+		}
+
+		if client == nil {
+			if client, err = createClient(); err != nil {
+				return err
+			}
+		}
+		if validate {
+			linter := lint.NewLinter(client.Core(), client.Schema(), lint.Options{LargeTable: -1})
+			result, err := linter.LintWithContext(ctx, statement.Table, statement.Query())
+			if err != nil {
+				return err
+			}
+			if err := result.Err(); err != nil {
+				return err
+			}
+		}
+
+		if statement.IsAggregate() {
+			result, err := statement.Aggregate(client.Core()).ExecuteWithContext(ctx)
+			if err != nil {
+				return err
+			}
+			return outputAggregateResult(result, printer)
+		}
+		records, err := client.Table(statement.Table).ListOptWithContext(ctx, statement.ListOptions())
+		if err != nil {
+			return fmt.Errorf("failed to list records: %w", err)
+		}
+		return outputRecords(records, printer, statement.Fields...)
+	},
+}
+
+// schemaFieldChecker checks names against the fields of the table's schema
+func schemaFieldChecker(ctx context.Context, client *servicenow.Client) sql.FieldChecker {
+	return func(tableName, name string) (bool, error) {
+		schemaTable, err := client.Schema().GetTableWithContext(ctx, tableName)
+		if err != nil {
+			return false, fmt.Errorf("failed to load the schema of %s: %w", tableName, err)
+		}
+		return schemaTable.Field(name) != nil, nil
+	}
+}
+
+// sqlExplain is the compiled form of a statement printed by --explain
+type sqlExplain struct {
+	Table     string            `json:"table"`
+	API       string            `json:"api"`
+	Params    map[string]string `json:"params"`
+	Aggregate bool              `json:"aggregate,omitempty"`
+}
+
+func outputSQLExplain(statement *sql.Statement, printer *output.Printer) error {
+	explain := sqlExplain{Table: statement.Table, API: "/table/" + statement.Table, Params: statement.Params()}
+	if statement.IsAggregate() {
+		explain.API = "/stats/" + statement.Table
+		explain.Params = statement.Aggregate(nil).BuildParams()
+		explain.Aggregate = true
+	}
+	if !printer.Human() {
+		return printer.Print(explain)
+	}
+	w := printer.Writer()
+	fmt.Fprintf(w, "GET %s\n", explain.API)
+	names := make([]string, 0, len(explain.Params))
+	for name := range explain.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s=%s\n", name, explain.Params[name])
+	}
+	return nil
+}
+
+func init() {
+	sqlCmd.Flags().BoolP("explain", "", false, "Print the compiled request parameters without running the statement")
+	sqlCmd.Flags().BoolP("validate", "", false, "Check the compiled query against the table schema before running it")
+	sqlCmd.Flags().IntP("limit", "l", 100, "Records to return when the statement has no LIMIT")
+	addOutputFlags(sqlCmd, "")
+
+	rootCmd.AddCommand(sqlCmd)
+}
@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
-	"text/tabwriter"
 
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/export"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/lint"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/undo"
 	"github.com/spf13/cobra"
 )
 
@@ -34,6 +42,28 @@ var tableListCmd = &cobra.Command{
 		fields, _ := cmd.Flags().GetString("fields")
 		orderBy, _ := cmd.Flags().GetString("order-by")
 		format, _ := cmd.Flags().GetString("format")
+		expand, _ := cmd.Flags().GetStringSlice("expand")
+		nested, _ := cmd.Flags().GetBool("nested")
+		outputFile, _ := cmd.Flags().GetString("output")
+		references, _ := cmd.Flags().GetString("references")
+		sheetBy, _ := cmd.Flags().GetString("sheet-by")
+
+		refMode, err := export.ParseReferenceMode(references)
+		if err != nil {
+			return err
+		}
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+		if format == "xlsx" && outputFile == "" {
+			return fmt.Errorf("--format xlsx needs an --output file")
+		}
+
+		// Dot-walked fields that don't exist are silently dropped by ServiceNow
+		if err := validateDotWalks(client, tableName, fields, expand); err != nil {
+			return err
+		}
 
 		// Build query parameters
 		params := make(map[string]string)
@@ -64,15 +94,46 @@ var tableListCmd = &cobra.Command{
 		if limit > 0 {
 			params["sysparm_limit"] = strconv.Itoa(limit)
 		}
+
+		// Spreadsheets show display values unless asked for sys_ids
+		if format == "xlsx" || cmd.Flags().Changed("references") {
+			params["sysparm_display_value"] = string(refMode.DisplayValue())
+		}
 		
 		// Execute query
 		tableClient := client.Table(tableName)
+		if validate, _ := cmd.Flags().GetBool("validate"); validate {
+			tableClient.WithQueryValidation(lint.NewLinter(client.Core(), client.Schema(), lint.Options{LargeTable: -1}))
+		}
 		records, err := tableClient.List(params)
 		if err != nil {
 			return fmt.Errorf("failed to list records: %w", err)
 		}
+		if err := tableClient.Expand(records, table.ListOptions{Expand: expand}); err != nil {
+			return err
+		}
+		if nested {
+			records = table.NestRecords(records)
+		}
 
-		return outputRecords(records, format)
+		if format == "xlsx" {
+			options := export.XLSXOptions{
+				Sheet:        tableName,
+				References:   refMode,
+				DisplayValue: refMode.DisplayValue(),
+				GroupBy:      sheetBy,
+				Location:     client.Core().GetTimeZone(),
+			}
+			if fields != "" {
+				options.Fields = strings.Split(fields, ",")
+			}
+			if err := writeRecordsXLSX(outputFile, records, options); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "✅ Wrote %d records to %s\n", len(records), outputFile)
+			return nil
+		}
+		return outputRecords(records, printer, splitFields(fields)...)
 	},
 }
 
@@ -89,14 +150,17 @@ var tableGetCmd = &cobra.Command{
 
 		tableName := args[0]
 		sysID := args[1]
-		format, _ := cmd.Flags().GetString("format")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
 		record, err := client.Table(tableName).Get(sysID)
 		if err != nil {
 			return fmt.Errorf("failed to get record: %w", err)
 		}
 
-		return outputRecord(record, format)
+		return outputRecord(record, printer)
 	},
 }
 
@@ -188,7 +252,20 @@ var tableUpdateCmd = &cobra.Command{
 			return fmt.Errorf("either --data or --file must be provided")
 		}
 
-		record, err := client.Table(tableName).Update(sysID, updates)
+		var record map[string]interface{}
+		expectModCount, _ := cmd.Flags().GetString("expect-mod-count")
+		if expectModCount != "" {
+			record, err = client.Table(tableName).UpdateIfVersion(sysID, table.ExpectedVersion{ModCount: expectModCount}, updates)
+			if conflict, ok := table.IsConflictError(err); ok {
+				fmt.Printf("⚠️  Record %s changed since version %s:\n", sysID, expectModCount)
+				for _, diff := range conflict.Diffs {
+					fmt.Printf("  %s: expected %q, found %q\n", diff.Field, diff.Expected, diff.Actual)
+				}
+				return conflict
+			}
+		} else {
+			record, err = client.Table(tableName).Update(sysID, updates)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to update record: %w", err)
 		}
@@ -218,129 +295,174 @@ var tableDeleteCmd = &cobra.Command{
 			return nil
 		}
 
+		beforeImage, _ := cmd.Flags().GetBool("before-image")
+		recorder := newUndoRecorder(client, beforeImage, fmt.Sprintf("table delete %s %s", tableName, sysID))
+		if recorder != nil {
+			if _, err := recorder.Capture(context.Background(), tableName, sysID, undo.ActionDelete, nil); err != nil {
+				return err
+			}
+		}
+
 		err = client.Table(tableName).Delete(sysID)
 		if err != nil {
 			return fmt.Errorf("failed to delete record: %w", err)
 		}
 
 		fmt.Printf("✅ Deleted record: %s\n", sysID)
-		return nil
+		if recorder != nil {
+			recorder.MarkApplied(tableName, sysID, nil)
+		}
+		return saveUndoRecorder(recorder)
 	},
 }
 
-// Output functions
-func outputRecords(records []map[string]interface{}, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(records)
-	case "csv":
-		return outputRecordsCSV(records)
-	case "table":
-		fallthrough
-	default:
-		return outputRecordsTable(records)
-	}
-}
+var tableUpsertCmd = &cobra.Command{
+	Use:   "upsert [table_name]",
+	Short: "Create or update records matched by natural key",
+	Long: `Create or update records from a JSON or CSV file, matching existing records
+by one or more natural key fields (e.g. number or correlation_id).
 
-func outputRecord(record map[string]interface{}, format string) error {
-	switch format {
-	case "json":
-		return json.NewEncoder(os.Stdout).Encode(record)
-	default:
-		fmt.Println("Record Details:")
-		for key, value := range record {
-			fmt.Printf("  %s: %v\n", key, value)
+Records with no match are created, records with exactly one match are updated
+(or left alone if nothing changed), and keys matching several records are
+reported as conflicts.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := createClient()
+		if err != nil {
+			return err
 		}
-		return nil
-	}
-}
 
-func outputRecordsTable(records []map[string]interface{}) error {
-	if len(records) == 0 {
-		fmt.Println("No records found.")
-		return nil
-	}
+		tableName := args[0]
+		keys, _ := cmd.Flags().GetStringSlice("key")
+		dataFile, _ := cmd.Flags().GetString("file")
+		dataStr, _ := cmd.Flags().GetString("data")
+		inputFormat, _ := cmd.Flags().GetString("input-format")
+		chunkSize, _ := cmd.Flags().GetInt("chunk-size")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
-	// Get all unique keys for headers
-	keys := make(map[string]bool)
-	for _, record := range records {
-		for key := range record {
-			keys[key] = true
+		if len(keys) == 0 {
+			return fmt.Errorf("--key is required")
 		}
-	}
 
-	// Convert to sorted slice
-	var headers []string
-	for key := range keys {
-		headers = append(headers, key)
+		var records []map[string]interface{}
+		if dataFile != "" {
+			records, err = loadDataFromFile(dataFile, inputFormat)
+			if err != nil {
+				return fmt.Errorf("failed to load data from file: %w", err)
+			}
+		} else if dataStr != "" {
+			if err := json.Unmarshal([]byte(dataStr), &records); err != nil {
+				return fmt.Errorf("failed to parse data: %w", err)
+			}
+		} else {
+			return fmt.Errorf("either --file or --data must be provided")
+		}
+
+		if len(records) == 0 {
+			return fmt.Errorf("no records to upsert")
+		}
+
+		results, err := client.Table(tableName).UpsertMany(records, table.UpsertOptions{
+			MatchFields: keys,
+			ChunkSize:   chunkSize,
+		})
+		if err != nil {
+			return fmt.Errorf("upsert failed: %w", err)
+		}
+
+		return outputUpsertResults(results, printer)
+	},
+}
+
+// Output functions
+func outputUpsertResults(results []*table.UpsertResult, printer *output.Printer) error {
+	if !printer.Human() {
+		return printer.Print(results)
 	}
 
-	// Create table writer
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-
-	// Print headers
-	fmt.Fprintln(w, strings.Join(headers, "\t"))
-
-	// Print records
-	for _, record := range records {
-		var values []string
-		for _, header := range headers {
-			value := ""
-			if v, exists := record[header]; exists && v != nil {
-				value = fmt.Sprintf("%v", v)
-				// Truncate long values
-				if len(value) > 50 {
-					value = value[:47] + "..."
-				}
-			}
-			values = append(values, value)
+	counts := make(map[table.UpsertStatus]int)
+	var rows []map[string]interface{}
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		counts[result.Status]++
+		message := result.Error
+		if result.Status == table.UpsertConflict && len(result.Matches) > 0 {
+			message = fmt.Sprintf("%s (%s)", message, strings.Join(result.Matches, ", "))
 		}
-		fmt.Fprintln(w, strings.Join(values, "\t"))
+		rows = append(rows, map[string]interface{}{
+			"index": result.Index, "key": result.Key, "status": result.Status, "sys_id": result.SysID, "message": message,
+		})
+	}
+	if err := printer.Print(rows, output.Columns("index", "key", "status", "sys_id", "message")...); err != nil {
+		return err
 	}
 
-	return w.Flush()
+	fmt.Printf("\n✅ Upsert completed: %d created, %d updated, %d unchanged, %d conflicts, %d failed\n",
+		counts[table.UpsertCreated], counts[table.UpsertUpdated], counts[table.UpsertUnchanged],
+		counts[table.UpsertConflict], counts[table.UpsertFailed])
+	return nil
 }
 
-func outputRecordsCSV(records []map[string]interface{}) error {
-	if len(records) == 0 {
+// validateDotWalks checks dot-walked field and expand paths against the schema. When the
+// dictionary can't be read the check is skipped with a warning.
+func validateDotWalks(client *servicenow.Client, tableName, fields string, expand []string) error {
+	var paths []string
+	for _, field := range strings.Split(fields, ",") {
+		if field = strings.TrimSpace(field); schema.IsDotWalk(field) {
+			paths = append(paths, field)
+		}
+	}
+	paths = append(paths, expand...)
+	if len(paths) == 0 {
 		return nil
 	}
 
-	// Get all unique keys for headers
-	keys := make(map[string]bool)
-	for _, record := range records {
-		for key := range record {
-			keys[key] = true
-		}
+	err := client.Schema().ValidateFields(context.Background(), tableName, paths)
+	var pathErr *schema.PathError
+	if err != nil && !errors.As(err, &pathErr) {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not validate dot-walked fields: %v\n", err)
+		return nil
 	}
+	return err
+}
 
-	// Convert to sorted slice
-	var headers []string
-	for key := range keys {
-		headers = append(headers, key)
-	}
+// outputRecords prints records with the given fields as columns, or every field when
+// none are given
+func outputRecords(records []map[string]interface{}, printer *output.Printer, fields ...string) error {
+	return printer.Print(records, output.Columns(fields...)...)
+}
 
-	// Print CSV headers
-	fmt.Println(strings.Join(headers, ","))
-
-	// Print CSV records
-	for _, record := range records {
-		var values []string
-		for _, header := range headers {
-			value := ""
-			if v, exists := record[header]; exists && v != nil {
-				value = fmt.Sprintf("%v", v)
-				// Escape commas and quotes
-				if strings.Contains(value, ",") || strings.Contains(value, "\"") {
-					value = "\"" + strings.ReplaceAll(value, "\"", "\"\"") + "\""
-				}
-			}
-			values = append(values, value)
+// splitFields splits a comma-separated field list
+func splitFields(fields string) []string {
+	var names []string
+	for _, field := range strings.Split(fields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			names = append(names, field)
 		}
-		fmt.Println(strings.Join(values, ","))
 	}
+	return names
+}
 
-	return nil
+// writeRecordsXLSX writes records to a workbook file
+func writeRecordsXLSX(path string, records []map[string]interface{}, options export.XLSXOptions) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := export.WriteXLSX(file, records, options); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func outputRecord(record map[string]interface{}, printer *output.Printer, fields ...string) error {
+	return printer.Print(record, output.Columns(fields...)...)
 }
 
 func init() {
@@ -349,10 +471,16 @@ func init() {
 	tableListCmd.Flags().StringP("filter", "f", "", "Filter criteria (field=value^field2=value2)")
 	tableListCmd.Flags().StringP("fields", "", "", "Comma-separated list of fields to include")
 	tableListCmd.Flags().StringP("order-by", "o", "", "Order by field (append ' DESC' for descending)")
-	tableListCmd.Flags().StringP("format", "", "table", "Output format (table, json, csv)")
+	tableListCmd.Flags().StringP("output", "O", "", "File to write xlsx output to")
+	tableListCmd.Flags().StringP("references", "", "display", "Reference values: display, sys_id or both (both adds sys_id columns to xlsx)")
+	tableListCmd.Flags().StringP("sheet-by", "", "", "Write one xlsx sheet per value of this field")
+	tableListCmd.Flags().StringSliceP("expand", "", nil, "Reference fields to fetch and embed (e.g. caller_id, caller_id.manager)")
+	tableListCmd.Flags().BoolP("nested", "", false, "Nest dot-walked fields (caller_id.name) into objects")
+	tableListCmd.Flags().BoolP("validate", "", false, "Check the filter against the table schema before running it (see 'query lint')")
+	addOutputFlags(tableListCmd, "", "xlsx")
 
 	// Get command flags
-	tableGetCmd.Flags().StringP("format", "", "table", "Output format (table, json)")
+	addOutputFlags(tableGetCmd, "")
 
 	// Create command flags
 	tableCreateCmd.Flags().StringP("data", "d", "", "JSON string of record data")
@@ -362,11 +490,21 @@ func init() {
 	// Update command flags
 	tableUpdateCmd.Flags().StringP("data", "d", "", "JSON string of updates")
 	tableUpdateCmd.Flags().StringP("file", "", "", "JSON file containing updates")
+	tableUpdateCmd.Flags().StringP("expect-mod-count", "", "", "Only update if the record's sys_mod_count still matches this value")
 
 	// Delete command flags
 	tableDeleteCmd.Flags().BoolP("confirm", "", false, "Confirm deletion (required for safety)")
+	tableDeleteCmd.Flags().BoolP("before-image", "", false, "Save the record before deleting so it can be restored with 'undo'")
+
+	// Upsert command flags
+	tableUpsertCmd.Flags().StringSliceP("key", "k", nil, "Natural key field(s) used to match existing records")
+	tableUpsertCmd.Flags().StringP("file", "", "", "JSON or CSV file containing records")
+	tableUpsertCmd.Flags().StringP("data", "d", "", "JSON string containing records")
+	tableUpsertCmd.Flags().StringP("input-format", "", "json", "Input file format (json, csv)")
+	tableUpsertCmd.Flags().IntP("chunk-size", "", table.DefaultUpsertChunkSize, "Records resolved and written per batch")
+	addOutputFlags(tableUpsertCmd, "")
 
 	// Add subcommands
-	tableCmd.AddCommand(tableListCmd, tableGetCmd, tableCreateCmd, tableUpdateCmd, tableDeleteCmd)
+	tableCmd.AddCommand(tableListCmd, tableGetCmd, tableCreateCmd, tableUpdateCmd, tableDeleteCmd, tableUpsertCmd)
 	rootCmd.AddCommand(tableCmd)
 }
\ No newline at end of file
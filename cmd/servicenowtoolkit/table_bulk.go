@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/bulk"
+	"github.com/spf13/cobra"
+)
+
+var tableUpdateWhereCmd = &cobra.Command{
+	Use:   "update-where [table_name]",
+	Short: "Update every record matching a query",
+	Long: `Set field values on every record matching --query.
+
+The matching records are counted and a sample is shown first. Nothing is written
+unless --confirm is given or --expect-count equals the number of matches, and
+runs matching more than --max-records records are refused. Records are written
+in batches of --chunk-size; failed records are reported and the run continues.
+
+Before-images are saved to the undo journal, so a run can be reverted with
+'servicenowtoolkit undo <id>'.`,
+	Example: `  servicenowtoolkit table update-where incident --query "active=true^assignment_group=NULL" --set assignment_group=d625dccec0a8016700a222a0f7900d06
+  servicenowtoolkit table update-where incident -q "state=6^sys_updated_on<javascript:gs.daysAgo(30)" --set state=7 --set close_code="Closed/Resolved by Caller" --expect-count 42`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		assignments, _ := cmd.Flags().GetStringArray("set")
+		set, err := parseAssignments(assignments)
+		if err != nil {
+			return err
+		}
+		return runWhere(cmd, args[0], bulk.ActionUpdate, set)
+	},
+}
+
+var tableDeleteWhereCmd = &cobra.Command{
+	Use:   "delete-where [table_name]",
+	Short: "Delete every record matching a query",
+	Long: `Delete every record matching --query.
+
+The matching records are counted and a sample is shown first. Nothing is deleted
+unless --confirm is given or --expect-count equals the number of matches, and
+runs matching more than --max-records records are refused. Records are deleted
+in batches of --chunk-size; failed records are reported and the run continues.
+
+Deleted records are saved to the undo journal, so a run can be reverted with
+'servicenowtoolkit undo <id>'.`,
+	Example: `  servicenowtoolkit table delete-where u_import_staging --query "sys_created_on<javascript:gs.daysAgo(90)"
+  servicenowtoolkit table delete-where u_import_staging -q "u_batch=2024-07" --expect-count 1250 --max-records 5000`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWhere(cmd, args[0], bulk.ActionDelete, nil)
+	},
+}
+
+// runWhere previews a query-driven update or delete and runs it once confirmed
+func runWhere(cmd *cobra.Command, tableName string, action bulk.Action, set map[string]interface{}) error {
+	query, _ := cmd.Flags().GetString("query")
+	expectCount, _ := cmd.Flags().GetInt("expect-count")
+	maxRecords, _ := cmd.Flags().GetInt("max-records")
+	chunkSize, _ := cmd.Flags().GetInt("chunk-size")
+	sampleSize, _ := cmd.Flags().GetInt("sample")
+	confirm, _ := cmd.Flags().GetBool("confirm")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	beforeImage, _ := cmd.Flags().GetBool("before-image")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+
+	printer, err := newPrinter(cmd)
+	if err != nil {
+		return err
+	}
+	if maxRecords == 0 {
+		maxRecords = -1 // 0 means no limit on the command line
+	}
+	options := bulk.Options{
+		Query:       query,
+		Set:         set,
+		ExpectCount: expectCount,
+		MaxRecords:  maxRecords,
+		ChunkSize:   chunkSize,
+		SampleSize:  sampleSize,
+	}
+
+	client, err := createClient()
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	plan, err := bulk.PlanWithContext(ctx, client.Core(), tableName, action, options)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "🔎 %d records in %s match %s\n", plan.Count, plan.Table, plan.Query)
+	proceed := plan.Count > 0 && !dryRun && (confirm || expectCount > 0)
+	// Structured output holds one document: the plan, or the result of the run
+	if printer.Human() || !proceed {
+		if err := outputBulkPlan(plan, printer); err != nil {
+			return err
+		}
+	}
+	if plan.Count == 0 {
+		return nil
+	}
+
+	verb := "update"
+	if action == bulk.ActionDelete {
+		verb = "permanently delete"
+	}
+	switch {
+	case dryRun:
+		fmt.Fprintf(os.Stderr, "🔍 Dry run: would %s %d records\n", verb, plan.Count)
+		return nil
+	case expectCount > 0 && plan.Count != expectCount:
+		return fmt.Errorf("%d records match, expected %d; nothing was changed", plan.Count, expectCount)
+	case maxRecords > 0 && plan.Count > maxRecords:
+		return fmt.Errorf("%d records match, more than --max-records %d; raise it to proceed", plan.Count, maxRecords)
+	case !proceed:
+		fmt.Fprintf(os.Stderr, "⚠️  This will %s %d records. Use --confirm or --expect-count %d to proceed.\n", verb, plan.Count, plan.Count)
+		return nil
+	}
+
+	options.Recorder = newUndoRecorder(client, beforeImage, fmt.Sprintf("%s-where %s (%s)", action, tableName, query))
+	if options.Recorder != nil && !quiet {
+		// The journal is saved after each chunk, so this ID works even if the run dies
+		fmt.Fprintf(os.Stderr, "↩️  Journaling before-images as %s\n", options.Recorder.Operation().ID)
+	}
+	if !quiet {
+		options.Progress = printBulkProgress
+	}
+	var result *bulk.Result
+	if action == bulk.ActionDelete {
+		result, err = bulk.DeleteWhereWithContext(ctx, client.Core(), tableName, options)
+	} else {
+		result, err = bulk.UpdateWhereWithContext(ctx, client.Core(), tableName, options)
+	}
+	if !quiet && result != nil {
+		fmt.Fprintln(os.Stderr)
+	}
+	// Records changed before an interruption or error stay undoable
+	if saveErr := saveUndoRecorder(options.Recorder); saveErr != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", saveErr)
+	}
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) && result != nil {
+			_ = outputBulkResult(result, printer)
+			return fmt.Errorf("interrupted after %d of %d records", result.Succeeded+result.Failed, result.Matched)
+		}
+		return err
+	}
+	if err := outputBulkResult(result, printer); err != nil {
+		return err
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("%d of %d records failed", result.Failed, result.Matched)
+	}
+	return nil
+}
+
+// parseAssignments parses --set field=value flags
+func parseAssignments(assignments []string) (map[string]interface{}, error) {
+	if len(assignments) == 0 {
+		return nil, fmt.Errorf("at least one --set field=value is required")
+	}
+	set := make(map[string]interface{}, len(assignments))
+	for _, assignment := range assignments {
+		field, value, ok := strings.Cut(assignment, "=")
+		field = strings.TrimSpace(field)
+		if !ok || field == "" {
+			return nil, fmt.Errorf("invalid --set %q: expected field=value", assignment)
+		}
+		set[field] = value
+	}
+	return set, nil
+}
+
+func outputBulkPlan(plan *bulk.Plan, printer *output.Printer) error {
+	if !printer.Human() {
+		return printer.Print(plan)
+	}
+	if len(plan.Sample) == 0 {
+		return nil
+	}
+	if plan.Count > len(plan.Sample) {
+		fmt.Fprintf(os.Stderr, "Sample of %d:\n", len(plan.Sample))
+	}
+	return printer.Print(plan.Sample, output.Columns(plan.Fields...)...)
+}
+
+func outputBulkResult(result *bulk.Result, printer *output.Printer) error {
+	if !printer.Human() {
+		return printer.Print(result)
+	}
+	if len(result.Failures) > 0 {
+		if err := printer.Print(result.Failures, output.Columns("sys_id", "status", "message")...); err != nil {
+			return err
+		}
+	}
+	verb := "Updated"
+	if result.Action == bulk.ActionDelete {
+		verb = "Deleted"
+	}
+	fmt.Fprintf(os.Stderr, "✅ %s %d of %d records in %s", verb, result.Succeeded, result.Matched, result.Duration.Round(time.Second))
+	if result.Failed > 0 {
+		fmt.Fprintf(os.Stderr, " (%d failed)", result.Failed)
+	}
+	fmt.Fprintln(os.Stderr)
+	return nil
+}
+
+// printBulkProgress rewrites a progress line on stderr
+func printBulkProgress(p bulk.Progress) {
+	line := fmt.Sprintf("⚙️  %d/%d records (%.1f%%)", p.Done, p.Total, 100*float64(p.Done)/float64(p.Total))
+	if p.Failed > 0 {
+		line += fmt.Sprintf(", %d failed", p.Failed)
+	}
+	fmt.Fprintf(os.Stderr, "\r%-100s", line)
+}
+
+func addWhereFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("query", "q", "", "Encoded query selecting the records (required)")
+	cmd.Flags().IntP("expect-count", "", 0, "Proceed without --confirm only if exactly this many records match")
+	cmd.Flags().IntP("max-records", "", bulk.DefaultMaxRecords, "Refuse to run when more records match (0 for no limit)")
+	cmd.Flags().IntP("chunk-size", "", bulk.DefaultChunkSize, "Records written per batch request")
+	cmd.Flags().IntP("sample", "", bulk.DefaultSampleSize, "Matching records shown in the preview")
+	cmd.Flags().BoolP("confirm", "", false, "Confirm the change (required for safety unless --expect-count is given)")
+	cmd.Flags().BoolP("dry-run", "", false, "Only show the matching records")
+	cmd.Flags().BoolP("before-image", "", true, "Save records to the undo journal before changing them")
+	cmd.Flags().BoolP("quiet", "", false, "Don't show progress")
+	_ = cmd.MarkFlagRequired("query")
+}
+
+func init() {
+	tableUpdateWhereCmd.Flags().StringArrayP("set", "", nil, "Field value to write, as field=value (repeatable)")
+	addWhereFlags(tableUpdateWhereCmd)
+	addOutputFlags(tableUpdateWhereCmd, "")
+
+	addWhereFlags(tableDeleteWhereCmd)
+	addOutputFlags(tableDeleteWhereCmd, "")
+
+	tableCmd.AddCommand(tableUpdateWhereCmd, tableDeleteWhereCmd)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/transfer"
+	"github.com/spf13/cobra"
+)
+
+var tableCopyCmd = &cobra.Command{
+	Use:   "copy [table_name]",
+	Short: "Copy records between two instances",
+	Long: `Copy records matching a query from one profile to another, remapping reference
+fields to the matching records on the target.
+
+Copied records are matched on the target by --key (default sys_id, which keeps
+sys_ids identical) and are created or updated accordingly. Each reference field
+is resolved on the target by sys_id first, then by the referenced table's natural
+key: user_name for users, name for groups, locations and companies, number for
+task tables. Override or add keys with --ref-key table=field[,field].
+
+References that cannot be resolved are left empty unless --follow is set, in
+which case the referenced records are copied too (up to --max-depth levels).`,
+	Example: `  servicenowtoolkit table copy sys_user_group --from dev --to test --query "nameSTARTSWITHCAB" --key name
+  servicenowtoolkit table copy sc_cat_item --from dev --to test --query "sys_id=abc123" --follow --dry-run
+  servicenowtoolkit table copy cmdb_ci_server --from prod --to dev --key name --ref-key cmn_location=name,city --report mapping.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tableName := args[0]
+		fromProfile, _ := cmd.Flags().GetString("from")
+		toProfile, _ := cmd.Flags().GetString("to")
+		filter, _ := cmd.Flags().GetString("query")
+		keys, _ := cmd.Flags().GetStringSlice("key")
+		fields, _ := cmd.Flags().GetStringSlice("fields")
+		skip, _ := cmd.Flags().GetStringSlice("skip")
+		refKeys, _ := cmd.Flags().GetStringArray("ref-key")
+		follow, _ := cmd.Flags().GetBool("follow")
+		maxDepth, _ := cmd.Flags().GetInt("max-depth")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		reportFile, _ := cmd.Flags().GetString("report")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+
+		if filter == "" {
+			return fmt.Errorf("--query is required")
+		}
+		if fromProfile == toProfile {
+			return fmt.Errorf("--from and --to must name different profiles")
+		}
+		referenceKeys, err := parseReferenceKeys(refKeys)
+		if err != nil {
+			return err
+		}
+
+		fromClient, err := createClientForProfile(fromProfile)
+		if err != nil {
+			return fmt.Errorf("from profile: %w", err)
+		}
+		toClient, err := createClientForProfile(toProfile)
+		if err != nil {
+			return fmt.Errorf("to profile: %w", err)
+		}
+
+		copier := transfer.NewCopier(fromClient.Core(), toClient.Core(), transfer.Options{
+			KeyFields:        keys,
+			Fields:           fields,
+			SkipFields:       skip,
+			ReferenceKeys:    referenceKeys,
+			FollowReferences: follow,
+			MaxDepth:         maxDepth,
+			DryRun:           dryRun,
+		})
+		report, copyErr := copier.Copy(context.Background(), tableName, filter)
+
+		if reportFile != "" {
+			if err := writeCopyReport(reportFile, report); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+		}
+		if err := outputCopyReport(report, printer, dryRun); err != nil {
+			return err
+		}
+		if copyErr != nil {
+			return copyErr
+		}
+		if reportFile != "" {
+			fmt.Fprintf(os.Stderr, "📄 Mapping report written to %s\n", reportFile)
+		}
+		return nil
+	},
+}
+
+// parseReferenceKeys parses table=field[,field] pairs
+func parseReferenceKeys(values []string) (map[string][]string, error) {
+	keys := make(map[string][]string, len(values))
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid --ref-key %q, expected table=field[,field]", value)
+		}
+		keys[parts[0]] = strings.Split(parts[1], ",")
+	}
+	return keys, nil
+}
+
+// writeCopyReport writes the mapping report to a file as JSON
+func writeCopyReport(path string, report *transfer.Report) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := newFormatPrinter(file, output.FormatJSON).Print(report); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func outputCopyReport(report *transfer.Report, printer *output.Printer, dryRun bool) error {
+	if !printer.Human() {
+		return printer.Print(report)
+	}
+
+	if err := printer.Print(report.Records,
+		output.Column{Path: "table"},
+		output.Column{Path: "source_sys_id", Header: "SOURCE SYS_ID"},
+		output.Column{Path: "target_sys_id", Header: "TARGET SYS_ID"},
+		output.Column{Path: "status"},
+		output.Column{Path: "key"},
+		output.Column{Path: "message"},
+	); err != nil {
+		return err
+	}
+
+	counts := report.Counts()
+	if dryRun {
+		fmt.Fprintf(printer.Writer(), "\n🔍 Dry run: %d record(s) would be copied\n", counts[transfer.StatusPlanned])
+	} else {
+		fmt.Fprintf(printer.Writer(), "\n✅ %d created, %d updated, %d unchanged, %d failed\n",
+			counts[transfer.StatusCreated], counts[transfer.StatusUpdated], counts[transfer.StatusUnchanged],
+			counts[transfer.StatusFailed]+counts[transfer.StatusConflict])
+	}
+
+	unresolved := report.Unresolved()
+	fmt.Fprintf(printer.Writer(), "🔗 %d reference(s) resolved, %d unresolved\n", len(report.References)-len(unresolved), len(unresolved))
+	for _, mapping := range unresolved {
+		fmt.Fprintf(printer.Writer(), "⚠️  %s %s (%s): %s\n", mapping.Table, mapping.SourceSysID, mapping.Key, mapping.Message)
+	}
+	return nil
+}
+
+func init() {
+	tableCopyCmd.Flags().StringP("from", "", "", "Source profile (empty for the default connection)")
+	tableCopyCmd.Flags().StringP("to", "", "", "Target profile (empty for the default connection)")
+	tableCopyCmd.Flags().StringP("query", "q", "", "Encoded query selecting source records")
+	tableCopyCmd.Flags().StringSliceP("key", "k", nil, "Field(s) matching copied records on the target (default sys_id)")
+	tableCopyCmd.Flags().StringSliceP("fields", "", nil, "Fields to copy (default: all)")
+	tableCopyCmd.Flags().StringSliceP("skip", "", nil, "Fields never copied")
+	tableCopyCmd.Flags().StringArrayP("ref-key", "", nil, "Natural key for a referenced table as table=field[,field] (repeatable)")
+	tableCopyCmd.Flags().BoolP("follow", "", false, "Copy referenced records that do not exist on the target")
+	tableCopyCmd.Flags().IntP("max-depth", "", 1, "Reference levels to follow with --follow")
+	tableCopyCmd.Flags().BoolP("dry-run", "", false, "Resolve references without writing to the target")
+	tableCopyCmd.Flags().StringP("report", "", "", "Write the source→target mapping report to a JSON file")
+	addOutputFlags(tableCopyCmd, "")
+
+	tableCmd.AddCommand(tableCopyCmd)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/diff"
+	"github.com/spf13/cobra"
+)
+
+var tableDiffCmd = &cobra.Command{
+	Use:   "diff [table_name]",
+	Short: "Compare a table between two instances",
+	Long: `Fetch matching records from two profiles, match them by key fields and report
+added, removed and changed records with field-level differences.
+
+"added" records exist only on the --to side and "removed" records only on the
+--from side. Display values are compared so reference fields line up across
+instances, and sys_* bookkeeping fields are ignored unless --include-sys is set.
+
+Profiles are configured with SERVICENOW_<PROFILE>_INSTANCE_URL and the matching
+credential variables; an empty profile uses the default connection.`,
+	Example: `  servicenowtoolkit table diff sys_user_group --from dev --to prod --key name
+  servicenowtoolkit table diff sys_choice --from dev --to prod --key name,element,value --query "name=incident" --format unified`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tableName := args[0]
+		fromProfile, _ := cmd.Flags().GetString("from")
+		toProfile, _ := cmd.Flags().GetString("to")
+		keys, _ := cmd.Flags().GetStringSlice("key")
+		filter, _ := cmd.Flags().GetString("query")
+		fields, _ := cmd.Flags().GetStringSlice("fields")
+		ignore, _ := cmd.Flags().GetStringSlice("ignore")
+		includeSys, _ := cmd.Flags().GetBool("include-sys")
+		exitCode, _ := cmd.Flags().GetBool("exit-code")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+
+		if len(keys) == 0 {
+			return fmt.Errorf("--key is required")
+		}
+		if fromProfile == toProfile {
+			return fmt.Errorf("--from and --to must name different profiles")
+		}
+
+		fromClient, err := createClientForProfile(fromProfile)
+		if err != nil {
+			return fmt.Errorf("from profile: %w", err)
+		}
+		toClient, err := createClientForProfile(toProfile)
+		if err != nil {
+			return fmt.Errorf("to profile: %w", err)
+		}
+
+		opts := diff.Options{
+			KeyFields:           keys,
+			Fields:              fields,
+			IgnoreFields:        ignore,
+			IncludeSystemFields: includeSys,
+		}
+		result, err := diff.FetchAndCompare(context.Background(), fromClient.Core(), toClient.Core(), tableName, filter, opts)
+		if err != nil {
+			return err
+		}
+
+		if err := outputTableDiff(result, printer, profileLabel(fromProfile), profileLabel(toProfile), opts); err != nil {
+			return err
+		}
+		if exitCode && result.HasDifferences() {
+			return fmt.Errorf("%d added, %d removed, %d changed", len(result.Added), len(result.Removed), len(result.Changed))
+		}
+		return nil
+	},
+}
+
+func profileLabel(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+func outputTableDiff(result *diff.Result, printer *output.Printer, fromLabel, toLabel string, opts diff.Options) error {
+	switch {
+	case printer.Format() == "unified":
+		return diff.WriteUnified(printer.Writer(), result, fromLabel, toLabel, opts)
+	case printer.Human():
+		return outputTableDiffTable(result, printer, fromLabel, toLabel)
+	}
+	return printer.Print(result)
+}
+
+func outputTableDiffTable(result *diff.Result, printer *output.Printer, fromLabel, toLabel string) error {
+	w := printer.Writer()
+	fmt.Fprintf(w, "Comparing %s: %s → %s\n\n", result.Table, fromLabel, toLabel)
+
+	var rows []map[string]interface{}
+	for _, record := range result.Removed {
+		rows = append(rows, map[string]interface{}{"change": "removed", "key": record.Key, "from": record.FromSysID})
+	}
+	for _, record := range result.Added {
+		rows = append(rows, map[string]interface{}{"change": "added", "key": record.Key, "to": record.ToSysID})
+	}
+	for _, record := range result.Changed {
+		for i, change := range record.Changes {
+			row := map[string]interface{}{"field": change.Field, "from": change.From, "to": change.To}
+			if i == 0 {
+				row["change"], row["key"] = "changed", record.Key
+			}
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) > 0 {
+		if err := printer.Print(rows,
+			output.Column{Path: "change"},
+			output.Column{Path: "key"},
+			output.Column{Path: "field"},
+			output.Column{Path: "from", Header: fromLabel},
+			output.Column{Path: "to", Header: toLabel},
+		); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "%d added, %d removed, %d changed, %d unchanged\n", len(result.Added), len(result.Removed), len(result.Changed), result.Unchanged)
+	if len(result.DuplicateKeys) > 0 {
+		fmt.Fprintf(w, "⚠️  %d key(s) match several records and were compared using the first match: %v\n", len(result.DuplicateKeys), result.DuplicateKeys)
+	}
+	if result.MissingKeys > 0 {
+		fmt.Fprintf(w, "⚠️  %d record(s) skipped because a key field was empty\n", result.MissingKeys)
+	}
+	return nil
+}
+
+func init() {
+	tableDiffCmd.Flags().StringP("from", "", "", "Source profile (empty for the default connection)")
+	tableDiffCmd.Flags().StringP("to", "", "", "Target profile (empty for the default connection)")
+	tableDiffCmd.Flags().StringSliceP("key", "k", nil, "Key field(s) used to match records across instances")
+	tableDiffCmd.Flags().StringP("query", "q", "", "Encoded query selecting records on both sides")
+	tableDiffCmd.Flags().StringSliceP("fields", "", nil, "Fields to compare (default: all)")
+	tableDiffCmd.Flags().StringSliceP("ignore", "", nil, "Fields to ignore")
+	tableDiffCmd.Flags().BoolP("include-sys", "", false, "Also compare sys_* bookkeeping fields")
+	tableDiffCmd.Flags().BoolP("exit-code", "", false, "Exit with an error when differences are found")
+	addOutputFlags(tableDiffCmd, "", "unified")
+
+	tableCmd.AddCommand(tableDiffCmd)
+}
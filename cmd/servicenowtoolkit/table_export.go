@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/export"
+	"github.com/spf13/cobra"
+)
+
+var tableExportCmd = &cobra.Command{
+	Use:   "export [table_name]",
+	Short: "Export every matching record of a table to files",
+	Long: `Stream all records matching --query to disk one page at a time, in sys_id order.
+
+After every page the position is saved to <out>.checkpoint. Running the same
+command again after an interruption resumes where the export stopped; --restart
+discards the checkpoint. With --part-size the output is split into numbered parts
+(incidents.part001.csv, ...) of roughly that size, and --gzip compresses csv,
+ndjson and json output.
+
+References are exported as sys_ids in ndjson and json and as display values in
+csv and xlsx unless --references is given.`,
+	Example: `  servicenowtoolkit table export incident --query "active=true" --fields number,short_description,priority,assigned_to --out incidents.csv
+  servicenowtoolkit table export sys_audit --format ndjson --gzip --part-size 500MB --out audit/sys_audit.ndjson
+  servicenowtoolkit table export incident --format xlsx --references both --part-size 50MB --out incidents.xlsx`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tableName := args[0]
+		query, _ := cmd.Flags().GetString("query")
+		fields, _ := cmd.Flags().GetStringSlice("fields")
+		formatName, _ := cmd.Flags().GetString("format")
+		out, _ := cmd.Flags().GetString("out")
+		pageSize, _ := cmd.Flags().GetInt("page-size")
+		partSize, _ := cmd.Flags().GetString("part-size")
+		gzip, _ := cmd.Flags().GetBool("gzip")
+		references, _ := cmd.Flags().GetString("references")
+		restart, _ := cmd.Flags().GetBool("restart")
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		summary, _ := cmd.Flags().GetBool("summary")
+
+		format, err := export.ParseFormat(formatName)
+		if err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("references") && (format == export.FormatNDJSON || format == export.FormatJSON) {
+			references = "sys_id"
+		}
+		refMode, err := export.ParseReferenceMode(references)
+		if err != nil {
+			return err
+		}
+		maxPartBytes, err := parseByteSize(partSize)
+		if err != nil {
+			return err
+		}
+		if out == "" {
+			out = tableName + "." + string(format)
+		}
+		if strings.HasSuffix(out, ".gz") {
+			gzip = true
+		}
+
+		client, err := createClient()
+		if err != nil {
+			return err
+		}
+
+		options := export.TableOptions{
+			Query:        query,
+			Fields:       fields,
+			Format:       format,
+			Out:          out,
+			PageSize:     pageSize,
+			MaxPartBytes: maxPartBytes,
+			Gzip:         gzip,
+			References:   refMode,
+			Restart:      restart,
+		}
+		if !quiet {
+			options.Progress = printExportProgress
+		}
+		if _, err := os.Stat(export.CheckpointPath(out)); err == nil && !restart {
+			fmt.Fprintf(os.Stderr, "⏯️  Resuming export from %s\n", export.CheckpointPath(out))
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		result, err := export.ExportTableWithContext(ctx, client.Core(), tableName, options)
+		if !quiet {
+			fmt.Fprintln(os.Stderr)
+		}
+		if err != nil {
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return fmt.Errorf("export interrupted; run the same command again to resume")
+			}
+			return err
+		}
+
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "✅ Exported %d records to %d file(s) in %s\n", result.Records, len(result.Parts), result.Duration.Round(time.Second))
+		}
+		if summary {
+			return newFormatPrinter(os.Stdout, output.FormatJSON).Print(result)
+		}
+		w := newFormatPrinter(os.Stdout, output.FormatTable).Writer()
+		for _, part := range result.Parts {
+			fmt.Fprintf(w, "%s\t%d records\t%s\n", part.Path, part.Records, formatByteSize(part.Bytes))
+		}
+		return nil
+	},
+}
+
+// printExportProgress rewrites a progress line on stderr
+func printExportProgress(p export.Progress) {
+	line := fmt.Sprintf("📦 %d", p.Records)
+	if p.Total > 0 {
+		line += fmt.Sprintf("/%d (%.1f%%)", p.Total, 100*float64(p.Records)/float64(p.Total))
+	}
+	line += fmt.Sprintf(" records, %s in %d part(s), %.0f records/s", formatByteSize(p.Bytes), p.Parts, p.Rate)
+	if p.ETA > 0 {
+		line += ", ETA " + p.ETA.Round(time.Second).String()
+	}
+	fmt.Fprintf(os.Stderr, "\r%-100s", line)
+}
+
+// parseByteSize parses sizes such as 500MB, 2GB or 1048576 (bytes); "" is 0
+func parseByteSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+	}
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 500MB or 2GB)", value)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// formatByteSize formats a size in bytes with a binary unit
+func formatByteSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+func init() {
+	tableExportCmd.Flags().StringP("query", "q", "", "Encoded query selecting the exported records (no ORDERBY)")
+	tableExportCmd.Flags().StringSliceP("fields", "", nil, "Fields to export (default: all)")
+	tableExportCmd.Flags().StringP("format", "", "csv", "File format (csv, ndjson, json, xlsx)")
+	tableExportCmd.Flags().StringP("out", "O", "", "Output file (default: <table>.<format>)")
+	tableExportCmd.Flags().IntP("page-size", "", export.DefaultExportPageSize, "Records per request and checkpoint")
+	tableExportCmd.Flags().StringP("part-size", "", "", "Split the output into parts of about this size (e.g. 500MB)")
+	tableExportCmd.Flags().BoolP("gzip", "z", false, "Compress csv, ndjson and json output (implied by an --out ending in .gz)")
+	tableExportCmd.Flags().StringP("references", "", "display", "Reference values: display, sys_id or both")
+	tableExportCmd.Flags().BoolP("restart", "", false, "Discard the checkpoint of an interrupted export and start over")
+	tableExportCmd.Flags().BoolP("quiet", "", false, "Don't print progress")
+	tableExportCmd.Flags().BoolP("summary", "", false, "Print the result as JSON")
+
+	tableCmd.AddCommand(tableExportCmd)
+}
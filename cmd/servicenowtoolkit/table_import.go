@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/importer"
+	"github.com/spf13/cobra"
+)
+
+var tableImportCmd = &cobra.Command{
+	Use:   "import [table_name]",
+	Short: "Validate and load records from a CSV or JSON file",
+	Long: `Map the columns of a CSV or JSON file to table fields, validate every row
+against the table schema and load the valid rows.
+
+Rows are checked for mandatory fields, maximum lengths, choice values (labels are
+accepted and replaced by their values), numbers, booleans, dates and references,
+which must match exactly one record. Rejected rows are written to the rejects file
+with their reasons. Valid rows are created through the batch API or, when the
+mapping or --key names key fields, upserted. Empty values are not sent.
+
+Without --map every column is imported into the field of the same name. A mapping
+file (JSON, or YAML in block style) looks like:
+
+  key: [email]              # Upsert on these fields; omit to create every row
+  passthrough: false        # Also import unmapped columns named like fields
+  fields:
+    first_name: First Name  # Shorthand for column: First Name
+    email:
+      column: E-mail
+      transforms: [trim, lower]
+    active:
+      column: Status
+      map: {Active: true, Inactive: false}
+    department:
+      column: Dept
+      lookup: name          # Match cmn_department records on name
+    u_start_date:
+      column: Start
+      date: 02/01/2006      # Go layout of the column's dates
+    source:
+      value: hr_feed        # Constant
+
+Transforms: trim, lower, upper, title, collapse, digits.`,
+	Example: `  servicenowtoolkit table import sys_user --file users.csv --map users-mapping.yaml --dry-run
+  servicenowtoolkit table import sys_user --file users.csv --map users-mapping.yaml --rejects rejected-users.csv
+  servicenowtoolkit table import cmn_location --file locations.json --key name`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tableName := args[0]
+		file, _ := cmd.Flags().GetString("file")
+		mapFile, _ := cmd.Flags().GetString("map")
+		inputFormat, _ := cmd.Flags().GetString("input-format")
+		keys, _ := cmd.Flags().GetStringSlice("key")
+		rejectsFile, _ := cmd.Flags().GetString("rejects")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		chunkSize, _ := cmd.Flags().GetInt("chunk-size")
+
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+		if file == "" {
+			return fmt.Errorf("--file is required")
+		}
+		input, err := importer.ReadFile(file, inputFormat)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if len(input.Rows) == 0 {
+			return fmt.Errorf("no rows to import")
+		}
+		var mapping *importer.Mapping
+		if mapFile != "" {
+			if mapping, err = importer.LoadMapping(mapFile); err != nil {
+				return err
+			}
+		}
+
+		client, err := createClient()
+		if err != nil {
+			return err
+		}
+		if printer.Human() {
+			action := "Importing"
+			if dryRun {
+				action = "Validating"
+			}
+			fmt.Fprintf(os.Stderr, "📥 %s %d rows into %s...\n", action, len(input.Rows), tableName)
+		}
+		report, err := client.Importer(tableName).Import(input, importer.Options{
+			Mapping:   mapping,
+			Key:       keys,
+			ChunkSize: chunkSize,
+			DryRun:    dryRun,
+		})
+		if err != nil {
+			return err
+		}
+
+		if rejects := report.Rejects(); len(rejects) > 0 {
+			if rejectsFile == "" {
+				ext := filepath.Ext(file)
+				rejectsFile = strings.TrimSuffix(file, ext) + ".rejects." + input.Format
+			}
+			out, err := os.Create(rejectsFile)
+			if err != nil {
+				return fmt.Errorf("failed to create rejects file: %w", err)
+			}
+			if err := importer.WriteRejects(out, input, report); err != nil {
+				out.Close()
+				return fmt.Errorf("failed to write rejects file: %w", err)
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+			if printer.Human() {
+				fmt.Fprintf(os.Stderr, "⚠️  %d rows written to %s with their reasons\n", len(rejects), rejectsFile)
+			}
+		}
+
+		return outputImportReport(report, printer)
+	},
+}
+
+func outputImportReport(report *importer.Report, printer *output.Printer) error {
+	if !printer.Human() {
+		return printer.Print(report)
+	}
+
+	if rejects := report.Rejects(); len(rejects) > 0 {
+		const shown = 20
+		var rows []map[string]interface{}
+		for i, reject := range rejects {
+			if i == shown {
+				rows = append(rows, map[string]interface{}{
+					"line":    "...",
+					"reasons": fmt.Sprintf("%d more in the rejects file", len(rejects)-shown),
+				})
+				break
+			}
+			rows = append(rows, map[string]interface{}{
+				"line":    reject.Line,
+				"status":  reject.Status,
+				"reasons": strings.Join(reject.Reasons, "; "),
+			})
+		}
+		if err := printer.Print(rows, output.Columns("line", "status", "reasons")...); err != nil {
+			return err
+		}
+		fmt.Fprintln(printer.Writer())
+	}
+
+	if report.DryRun {
+		fmt.Fprintf(printer.Writer(), "✅ Dry run: %d of %d rows valid, %d rejected\n", report.Valid, report.Rows, report.Rejected)
+		return nil
+	}
+	fmt.Fprintf(printer.Writer(), "✅ Import completed: %d rows, %d created, %d updated, %d unchanged, %d rejected, %d failed\n",
+		report.Rows, report.Created, report.Updated, report.Unchanged, report.Rejected, report.Failed)
+	return nil
+}
+
+func init() {
+	tableImportCmd.Flags().StringP("file", "", "", "CSV or JSON file to import")
+	tableImportCmd.Flags().StringP("map", "m", "", "Mapping file (JSON or YAML)")
+	tableImportCmd.Flags().StringP("input-format", "", "", "Input file format (csv, json; default: from the extension)")
+	tableImportCmd.Flags().StringSliceP("key", "k", nil, "Key field(s) to upsert on (overrides the mapping)")
+	tableImportCmd.Flags().StringP("rejects", "", "", "Rejects file (default: <file>.rejects.<format>)")
+	tableImportCmd.Flags().BoolP("dry-run", "", false, "Validate without writing")
+	tableImportCmd.Flags().IntP("chunk-size", "", importer.DefaultChunkSize, "Rows written per batch request")
+	addOutputFlags(tableImportCmd, "")
+
+	tableCmd.AddCommand(tableImportCmd)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/spf13/cobra"
+)
+
+var tableJournalCmd = &cobra.Command{
+	Use:   "journal [table_name] [sys_id]",
+	Short: "Show or add comments and work notes",
+	Long: `Render the activity stream of a record from its journal fields (additional
+comments and work notes), oldest first, or post a new comment or work note.`,
+	Example: `  servicenowtoolkit table journal incident 46d44a5dc0a8010e0144b2c4b6b4c7e0
+  servicenowtoolkit table journal incident 46d44a5dc0a8010e0144b2c4b6b4c7e0 --type work_notes --newest-first
+  servicenowtoolkit table journal incident 46d44a5dc0a8010e0144b2c4b6b4c7e0 --add-work-note "Restarted the mail service"`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tableName, sysID := args[0], args[1]
+		entryType, _ := cmd.Flags().GetString("type")
+		comment, _ := cmd.Flags().GetString("add-comment")
+		workNote, _ := cmd.Flags().GetString("add-work-note")
+		newestFirst, _ := cmd.Flags().GetBool("newest-first")
+
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+		var elements []string
+		switch entryType {
+		case "all", "":
+		case table.JournalComments, table.JournalWorkNotes:
+			elements = []string{entryType}
+		default:
+			return fmt.Errorf("invalid --type %q (use all, comments or work_notes)", entryType)
+		}
+
+		client, err := createClient()
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		tc := client.Table(tableName)
+
+		if comment != "" {
+			if err := tc.AddCommentWithContext(ctx, sysID, comment); err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "✅ Comment added")
+		}
+		if workNote != "" {
+			if err := tc.AddWorkNoteWithContext(ctx, sysID, workNote); err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "✅ Work note added")
+		}
+		if comment != "" || workNote != "" {
+			return nil
+		}
+
+		entries, err := tc.ListJournalWithContext(ctx, sysID, elements...)
+		if err != nil {
+			return err
+		}
+		if newestFirst {
+			for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+				entries[i], entries[j] = entries[j], entries[i]
+			}
+		}
+		return outputJournal(entries, printer)
+	},
+}
+
+// outputJournal renders entries as an activity stream for text and the human formats
+func outputJournal(entries []table.JournalEntry, printer *output.Printer) error {
+	if printer.Format() != "text" && !printer.Human() {
+		return printer.Print(entries)
+	}
+	w := printer.Writer()
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No journal entries found")
+		return nil
+	}
+	for i, entry := range entries {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		icon := "💬"
+		if entry.Element == table.JournalWorkNotes {
+			icon = "📝"
+		}
+		fmt.Fprintf(w, "%s %s • %s • %s\n", icon, entry.CreatedOn.Format(table.GlideDateTimeLayout), entry.CreatedBy, entry.Label())
+		for _, line := range strings.Split(strings.TrimRight(entry.Value, "\n"), "\n") {
+			fmt.Fprintf(w, "   %s\n", line)
+		}
+	}
+	fmt.Fprintf(w, "\nTotal entries: %d\n", len(entries))
+	return nil
+}
+
+func init() {
+	tableJournalCmd.Flags().StringP("type", "", "all", "Journal entries to show (all, comments, work_notes)")
+	tableJournalCmd.Flags().StringP("add-comment", "", "", "Post an additional comment")
+	tableJournalCmd.Flags().StringP("add-work-note", "", "", "Post a work note")
+	tableJournalCmd.Flags().BoolP("newest-first", "", false, "Show the newest entries first")
+	addOutputFlags(tableJournalCmd, "", "text")
+
+	tableCmd.AddCommand(tableJournalCmd)
+}
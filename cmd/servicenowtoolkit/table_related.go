@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/related"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/spf13/cobra"
+)
+
+var tableRelatedCmd = &cobra.Command{
+	Use:   "related [table_name] [sys_id]",
+	Short: "Show the related lists of a record",
+	Long: `Discover the related lists of a record from the schema — every reference field of
+another table pointing at the record's table or one of its parents — and show how many
+related records each list holds.
+
+Use --list to fetch the records of one related list instead. For many-to-many tables,
+--expand with the list's other field embeds the records on the other side.`,
+	Example: `  servicenowtoolkit table related incident 46d44a5dc0a8010e0144b2c4b6b4c7e0
+  servicenowtoolkit table related sc_request <sys_id> --list sc_req_item.request --fields number,cat_item,stage
+  servicenowtoolkit table related sys_user_group <sys_id> --list sys_user_grmember.group --expand user`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tableName, sysID := args[0], args[1]
+		listName, _ := cmd.Flags().GetString("list")
+		fields, _ := cmd.Flags().GetStringSlice("fields")
+		expand, _ := cmd.Flags().GetStringSlice("expand")
+		all, _ := cmd.Flags().GetBool("all")
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+
+		client, err := createClient()
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		relatedClient := client.Related()
+
+		if listName == "" {
+			counts, err := relatedClient.CountsWithContext(ctx, tableName, sysID)
+			if err != nil {
+				return err
+			}
+			return outputRelatedCounts(counts, all, printer)
+		}
+
+		lists, err := relatedClient.ListsWithContext(ctx, tableName)
+		if err != nil {
+			return err
+		}
+		var list *related.List
+		for i := range lists {
+			if lists[i].Name() == listName || (lists[i].Table == listName && !strings.Contains(listName, ".")) {
+				if list != nil {
+					return fmt.Errorf("%s has several related lists on %s; use table.field", tableName, listName)
+				}
+				list = &lists[i]
+			}
+		}
+		if list == nil {
+			return fmt.Errorf("%s has no related list %s", tableName, listName)
+		}
+
+		records, err := relatedClient.FetchWithContext(ctx, *list, []string{sysID}, table.ListOptions{Fields: fields, Expand: expand})
+		if err != nil {
+			return err
+		}
+		return outputRecords(records[sysID], printer, fields...)
+	},
+}
+
+func outputRelatedCounts(counts []related.Count, all bool, printer *output.Printer) error {
+	if !all {
+		shown := counts[:0]
+		for _, count := range counts {
+			if count.Count > 0 || count.Error != "" {
+				shown = append(shown, count)
+			}
+		}
+		counts = shown
+	}
+
+	if !printer.Human() {
+		return printer.Print(counts)
+	}
+	if len(counts) == 0 {
+		fmt.Fprintln(printer.Writer(), "No related records found (use --all to list empty related lists)")
+		return nil
+	}
+	rows := make([]map[string]interface{}, len(counts))
+	for i, count := range counts {
+		notes := ""
+		if count.List.M2M {
+			notes = fmt.Sprintf("m2m → %s via %s", count.List.OtherTable, count.List.OtherField)
+		}
+		value := fmt.Sprintf("%d", count.Count)
+		if count.Error != "" {
+			value, notes = "-", "⚠️  "+count.Error
+		}
+		rows[i] = map[string]interface{}{"list": count.List.Name(), "label": count.List.Label, "count": value, "notes": notes}
+	}
+	return printer.Print(rows,
+		output.Column{Path: "list", Header: "RELATED LIST"},
+		output.Column{Path: "label"},
+		output.Column{Path: "count"},
+		output.Column{Path: "notes"},
+	)
+}
+
+func init() {
+	tableRelatedCmd.Flags().StringP("list", "", "", "Fetch the records of one related list (table.field, or table when unambiguous)")
+	tableRelatedCmd.Flags().StringSliceP("fields", "", nil, "Fields to include when fetching a related list")
+	tableRelatedCmd.Flags().StringSliceP("expand", "", nil, "Reference fields to fetch and embed when fetching a related list")
+	tableRelatedCmd.Flags().BoolP("all", "", false, "Include related lists without records")
+	addOutputFlags(tableRelatedCmd, "")
+
+	tableCmd.AddCommand(tableRelatedCmd)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
+	"github.com/spf13/cobra"
+)
+
+var tableSchemaCmd = &cobra.Command{
+	Use:   "schema [table_name]",
+	Short: "Show the full schema of a table",
+	Long: `Show a table's fields including those inherited from parent tables, with
+dictionary overrides applied and choice values resolved.
+
+Use --field to show one field in detail with its choices, and --referenced-by to
+list the fields of other tables that reference this one.`,
+	Example: `  servicenowtoolkit table schema incident
+  servicenowtoolkit table schema incident --field state
+  servicenowtoolkit table schema sys_user --referenced-by
+  servicenowtoolkit table schema incident --own --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tableName := args[0]
+		fieldName, _ := cmd.Flags().GetString("field")
+		referencedBy, _ := cmd.Flags().GetBool("referenced-by")
+		own, _ := cmd.Flags().GetBool("own")
+
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+		client, err := createClient()
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+
+		if referencedBy {
+			fields, err := client.Schema().ReferencedBy(ctx, tableName)
+			if err != nil {
+				return err
+			}
+			return outputReferencedBy(tableName, fields, printer)
+		}
+
+		tableSchema, err := client.Schema().GetTableWithContext(ctx, tableName)
+		if err != nil {
+			return err
+		}
+
+		if fieldName != "" {
+			field := tableSchema.Field(fieldName)
+			if field == nil {
+				return fmt.Errorf("table %s has no field %s", tableName, fieldName)
+			}
+			return outputSchemaField(field, printer)
+		}
+
+		fields := tableSchema.Fields
+		if own {
+			fields = tableSchema.FieldsDefinedOn(tableName)
+		}
+		return outputTableSchema(tableSchema, fields, printer)
+	},
+}
+
+var schemaFieldColumns = []output.Column{
+	{Path: "name", Header: "FIELD"},
+	{Path: "label"},
+	{Path: "type"},
+	{Path: "reference"},
+	{Path: "defined_on"},
+	{Path: "flags"},
+}
+
+// schemaFieldRow flattens a field for the field list
+func schemaFieldRow(field *schema.Field) map[string]interface{} {
+	return map[string]interface{}{
+		"name":       field.Name,
+		"label":      field.Label,
+		"type":       field.Type,
+		"reference":  field.Reference,
+		"defined_on": field.DefinedOn,
+		"flags":      schemaFieldFlags(field),
+	}
+}
+
+func outputTableSchema(tableSchema *schema.Table, fields []*schema.Field, printer *output.Printer) error {
+	if !printer.Human() {
+		data := *tableSchema
+		data.Fields = fields
+		return printer.Print(data)
+	}
+
+	fmt.Printf("📋 %s (%s)\n", tableSchema.Name, tableSchema.Label)
+	fmt.Printf("Hierarchy:  %s\n", strings.Join(tableSchema.Hierarchy, " → "))
+	if len(tableSchema.Extensions) > 0 {
+		fmt.Printf("Extended by: %s\n", strings.Join(tableSchema.Extensions, ", "))
+	}
+	fmt.Println()
+
+	rows := make([]map[string]interface{}, len(fields))
+	for i, field := range fields {
+		rows[i] = schemaFieldRow(field)
+	}
+	if err := printer.Print(rows, schemaFieldColumns...); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d field(s)\n", len(fields))
+	return nil
+}
+
+func outputSchemaField(field *schema.Field, printer *output.Printer) error {
+	if !printer.Human() {
+		return printer.Print(field)
+	}
+
+	row := map[string]interface{}{
+		"name":       field.Name,
+		"label":      field.Label,
+		"type":       field.Type,
+		"defined_on": field.DefinedOn,
+	}
+	columns := []output.Column{
+		{Path: "name", Header: "Field:"},
+		{Path: "label", Header: "Label:"},
+		{Path: "type", Header: "Type:"},
+		{Path: "defined_on", Header: "Defined on:"},
+	}
+	detail := func(path, header string, value interface{}) {
+		row[path] = value
+		columns = append(columns, output.Column{Path: path, Header: header})
+	}
+	if len(field.OverriddenOn) > 0 {
+		detail("overridden_on", "Overridden on:", strings.Join(field.OverriddenOn, ", "))
+	}
+	if field.MaxLength > 0 {
+		detail("max_length", "Max length:", field.MaxLength)
+	}
+	if field.Reference != "" {
+		detail("reference", "Reference:", field.Reference)
+	}
+	if field.ReferenceQualifier != "" {
+		detail("reference_qualifier", "Reference qualifier:", field.ReferenceQualifier)
+	}
+	if field.DefaultValue != "" {
+		detail("default_value", "Default:", field.DefaultValue)
+	}
+	if field.Dependent != "" {
+		detail("dependent", "Dependent on:", field.Dependent)
+	}
+	if flags := schemaFieldFlags(field); flags != "" {
+		detail("flags", "Flags:", flags)
+	}
+	if err := printer.Print(row, columns...); err != nil {
+		return err
+	}
+
+	if len(field.Choices) > 0 {
+		fmt.Printf("\nChoices (from %s):\n", field.ChoicesFrom)
+		return printer.Print(field.Choices, output.Columns("value", "label", "dependent_value")...)
+	}
+	return nil
+}
+
+func outputReferencedBy(tableName string, fields []*schema.Field, printer *output.Printer) error {
+	if !printer.Human() {
+		return printer.Print(fields)
+	}
+
+	fmt.Printf("🔗 Fields referencing %s:\n\n", tableName)
+	rows := make([]map[string]interface{}, len(fields))
+	for i, field := range fields {
+		rows[i] = schemaFieldRow(field)
+	}
+	columns := []output.Column{{Path: "defined_on", Header: "TABLE"}, {Path: "name", Header: "FIELD"}, {Path: "label"}}
+	if err := printer.Print(rows, columns...); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d field(s)\n", len(fields))
+	return nil
+}
+
+// schemaFieldFlags summarizes the boolean attributes of a field
+func schemaFieldFlags(field *schema.Field) string {
+	var flags []string
+	if field.Mandatory {
+		flags = append(flags, "mandatory")
+	}
+	if field.ReadOnly {
+		flags = append(flags, "read-only")
+	}
+	if field.Unique {
+		flags = append(flags, "unique")
+	}
+	if field.Calculated {
+		flags = append(flags, "calculated")
+	}
+	if len(field.Choices) > 0 {
+		flags = append(flags, fmt.Sprintf("%d choices", len(field.Choices)))
+	}
+	if !field.Active {
+		flags = append(flags, "inactive")
+	}
+	return strings.Join(flags, ",")
+}
+
+func init() {
+	tableSchemaCmd.Flags().StringP("field", "", "", "Show a single field with its choices")
+	tableSchemaCmd.Flags().BoolP("referenced-by", "", false, "List fields of other tables that reference this table")
+	tableSchemaCmd.Flags().BoolP("own", "", false, "Only show fields defined directly on the table")
+	addOutputFlags(tableSchemaCmd, "")
+
+	tableCmd.AddCommand(tableSchemaCmd)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/app/explorer"
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/undo"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [operation_id]",
+	Short: "Undo an update or delete captured with --before-image",
+	Long: `Restore records changed by a previous table delete, batch update or batch delete
+that was run with --before-image.
+
+Updated records get their previous field values back; deleted records are
+re-created with their original sys_id. Records that changed after the operation
+are reported as conflicts and left untouched unless --force is given.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store := newUndoStore()
+		printer, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+		list, _ := cmd.Flags().GetBool("list")
+		if list || len(args) == 0 {
+			return outputUndoOperations(store, printer)
+		}
+
+		op, err := store.Load(args[0])
+		if err != nil {
+			return err
+		}
+
+		client, err := createClient()
+		if err != nil {
+			return err
+		}
+		if op.InstanceURL != "" && op.InstanceURL != client.Core().InstanceURL {
+			return fmt.Errorf("operation %s was recorded against %s, not %s", op.ID, op.InstanceURL, client.Core().InstanceURL)
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		result, err := undo.UndoWithContext(context.Background(), client.Core(), op, undo.Options{Force: force, DryRun: dryRun})
+		if err != nil {
+			return fmt.Errorf("undo failed: %w", err)
+		}
+
+		if !dryRun && result.Conflicts == 0 && result.Failed == 0 {
+			op.UndoneAt = time.Now()
+			if err := store.Save(op); err != nil {
+				return fmt.Errorf("failed to update undo store: %w", err)
+			}
+		}
+
+		return outputUndoResult(result, printer, dryRun)
+	},
+}
+
+// newUndoStore returns the undo store kept next to the toolkit configuration file
+func newUndoStore() *undo.Store {
+	configDir := filepath.Dir(explorer.NewConfigManager().GetConfigPath())
+	return undo.NewStore(filepath.Join(configDir, "undo"))
+}
+
+// newUndoRecorder starts capturing before-images when enabled, returning nil otherwise
+func newUndoRecorder(client *servicenow.Client, enabled bool, description string) *undo.Recorder {
+	if !enabled {
+		return nil
+	}
+	return undo.NewRecorder(client.Core(), newUndoStore(), description)
+}
+
+// saveUndoRecorder persists captured before-images and tells the user how to undo
+func saveUndoRecorder(recorder *undo.Recorder) error {
+	if recorder == nil {
+		return nil
+	}
+	if len(recorder.Operation().AppliedEntries()) == 0 {
+		return nil
+	}
+	if err := recorder.Save(); err != nil {
+		return fmt.Errorf("failed to save before-images: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "↩️  Before-images saved. Undo with: servicenowtoolkit undo %s\n", recorder.Operation().ID)
+	return nil
+}
+
+func outputUndoOperations(store *undo.Store, printer *output.Printer) error {
+	ops, err := store.List()
+	if err != nil {
+		return err
+	}
+	if len(ops) == 0 && printer.Human() {
+		fmt.Fprintf(printer.Writer(), "No undoable operations found in %s\n", store.Dir())
+		return nil
+	}
+
+	rows := make([]map[string]interface{}, len(ops))
+	for i, op := range ops {
+		status := "undoable"
+		if !op.UndoneAt.IsZero() {
+			status = "undone"
+		}
+		rows[i] = map[string]interface{}{
+			"id":          op.ID,
+			"created":     op.CreatedAt.Format("2006-01-02 15:04:05"),
+			"records":     len(op.AppliedEntries()),
+			"status":      status,
+			"description": op.Description,
+		}
+	}
+	return printer.Print(rows, output.Columns("id", "created", "records", "status", "description")...)
+}
+
+func outputUndoResult(result *undo.Result, printer *output.Printer, dryRun bool) error {
+	if !printer.Human() {
+		return printer.Print(result)
+	}
+
+	if err := printer.Print(result.Outcomes, output.Columns("table", "sys_id", "action", "status", "message")...); err != nil {
+		return err
+	}
+
+	prefix := "✅ Undo"
+	if dryRun {
+		prefix = "🔍 Dry run"
+	}
+	fmt.Fprintf(printer.Writer(), "\n%s of %s: %d restored, %d conflicts, %d failed\n", prefix, result.OperationID, result.Restored, result.Conflicts, result.Failed)
+	if result.Conflicts > 0 {
+		fmt.Fprintln(printer.Writer(), "⚠️  Conflicting records changed after the operation; rerun with --force to overwrite them.")
+	}
+	return nil
+}
+
+func init() {
+	undoCmd.Flags().BoolP("list", "l", false, "List undoable operations")
+	undoCmd.Flags().BoolP("force", "", false, "Restore records even if they changed since the operation")
+	undoCmd.Flags().BoolP("dry-run", "", false, "Report what would be restored without writing")
+	addOutputFlags(undoCmd, "")
+
+	rootCmd.AddCommand(undoCmd)
+}
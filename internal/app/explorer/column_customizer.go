@@ -35,12 +35,25 @@ type ColumnCustomizer struct {
 	isActive       bool
 	currentPane    int // 0 = search, 1 = available, 2 = selected
 	selectedIndex  int // Index in current pane
+	status         string // Dot-walk progress or errors
 	
 	// Dimensions
 	width  int
 	height int
 }
 
+// dotWalkRequestMsg asks the model to load the fields of the table a reference field points to
+type dotWalkRequestMsg struct {
+	parent tui.FieldMetadata
+}
+
+// dotWalkFieldsMsg carries the fields of a referenced table back to the customizer
+type dotWalkFieldsMsg struct {
+	parent tui.FieldMetadata
+	fields []tui.FieldMetadata
+	err    error
+}
+
 // NewColumnCustomizer creates a new column customizer
 func NewColumnCustomizer() *ColumnCustomizer {
 	return &ColumnCustomizer{
@@ -56,6 +69,7 @@ func (cc *ColumnCustomizer) SetActive(active bool) {
 		cc.currentPane = 1 // Reset to available fields
 		cc.selectedIndex = 0
 		cc.searchQuery = ""
+		cc.status = ""
 		
 		// If we don't have any fields initialized, create mock fields as fallback
 		if len(cc.availableFields) == 0 && len(cc.selectedFields) == 0 {
@@ -271,10 +285,59 @@ func (cc *ColumnCustomizer) handleAvailableFieldsInput(msg tea.KeyMsg) (*ColumnC
 		if len(cc.filteredFields) > 0 && cc.selectedIndex < len(cc.filteredFields) {
 			cc.moveFieldToSelected(cc.selectedIndex)
 		}
+	case key.Matches(msg, key.NewBinding(key.WithKeys("."))):
+		// Offer the fields of the referenced table as dot-walked columns
+		if len(cc.filteredFields) > 0 && cc.selectedIndex < len(cc.filteredFields) {
+			field := cc.filteredFields[cc.selectedIndex]
+			if field.Reference == "" {
+				cc.status = fmt.Sprintf("%s is not a reference field", field.Name)
+				return cc, nil
+			}
+			cc.status = fmt.Sprintf("Loading fields of %s...", field.Reference)
+			return cc, func() tea.Msg { return dotWalkRequestMsg{parent: field} }
+		}
 	}
 	return cc, nil
 }
 
+// AddDotWalkFields adds the fields of a referenced table as dot-walked columns
+// (e.g. caller_id.manager) to the available fields
+func (cc *ColumnCustomizer) AddDotWalkFields(parent tui.FieldMetadata, fields []tui.FieldMetadata, err error) {
+	if err != nil {
+		cc.status = fmt.Sprintf("Failed to load fields of %s: %v", parent.Reference, err)
+		return
+	}
+
+	present := make(map[string]bool, len(cc.availableFields)+len(cc.selectedFields))
+	for _, field := range cc.availableFields {
+		present[field.Name] = true
+	}
+	for _, field := range cc.selectedFields {
+		present[field.Name] = true
+	}
+
+	parentLabel := parent.Label
+	if parentLabel == "" {
+		parentLabel = parent.Name
+	}
+	added := 0
+	for _, field := range fields {
+		walked := field
+		walked.Name = parent.Name + "." + field.Name
+		walked.Label = parentLabel + " > " + field.Label
+		if present[walked.Name] {
+			continue
+		}
+		cc.availableFields = append(cc.availableFields, walked)
+		added++
+	}
+	sort.Slice(cc.availableFields, func(i, j int) bool {
+		return cc.availableFields[i].Name < cc.availableFields[j].Name
+	})
+	cc.updateFilteredFields()
+	cc.status = fmt.Sprintf("Added %d fields of %s as %s.*", added, parent.Reference, parent.Name)
+}
+
 // handleSelectedFieldsInput handles input in the selected fields pane
 func (cc *ColumnCustomizer) handleSelectedFieldsInput(msg tea.KeyMsg) (*ColumnCustomizer, tea.Cmd) {
 	switch {
@@ -421,7 +484,16 @@ func (cc *ColumnCustomizer) View() string {
 		Align(lipgloss.Center)
 	
 	content.WriteString(titleStyle.Render("Column Customizer"))
-	content.WriteString("\n\n")
+	content.WriteString("\n")
+	if cc.status != "" {
+		statusStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("244")).
+			Italic(true).
+			Width(cc.width).
+			Align(lipgloss.Center)
+		content.WriteString(statusStyle.Render(cc.status))
+	}
+	content.WriteString("\n")
 	
 	// Search box (3 lines with border and spacing)
 	content.WriteString(cc.renderSearchBox())
@@ -592,6 +664,9 @@ func (cc *ColumnCustomizer) renderFieldList(title string, fields []tui.FieldMeta
 			if field.Label != "" && field.Label != field.Name {
 				fieldText += fmt.Sprintf(" (%s)", field.Label)
 			}
+			if field.Reference != "" {
+				fieldText += " ▸"
+			}
 			
 			// Truncate if too long
 			maxFieldWidth := width - 6
@@ -651,7 +726,7 @@ func (cc *ColumnCustomizer) renderInstructions() string {
 	case 0: // Search
 		instructions = "Type to search • Enter: go to available fields • Tab: next pane"
 	case 1: // Available
-		instructions = "↑↓/k/j: navigate • →/l/Enter: add field • Tab: next pane • /: search"
+		instructions = "↑↓/k/j: navigate • →/l/Enter: add field • .: dot-walk reference • Tab: next pane • /: search"
 	case 2: // Selected
 		instructions = "↑↓/k/j: navigate • ←/h/Enter: remove field • Shift+↑↓/K/J: reorder • Tab: next pane"
 	}
@@ -667,7 +742,7 @@ func (cc *ColumnCustomizer) calculateHelpFooterHeight() int {
 	case 0: // Search
 		instructions = "Type to search • Enter: go to available fields • Tab: next pane"
 	case 1: // Available
-		instructions = "↑↓/k/j: navigate • →/l/Enter: add field • Tab: next pane • /: search"
+		instructions = "↑↓/k/j: navigate • →/l/Enter: add field • .: dot-walk reference • Tab: next pane • /: search"
 	case 2: // Selected
 		instructions = "↑↓/k/j: navigate • ←/h/Enter: remove field • Shift+↑↓/K/J: reorder • Tab: next pane"
 	}
@@ -698,4 +773,17 @@ func (cc *ColumnCustomizer) calculateHelpFooterHeight() int {
 	}
 	
 	return footerHeight
-}
\ No newline at end of file
+}
+// loadDotWalkFieldsCmd loads the fields of the table a reference field points to
+func (m *Model) loadDotWalkFieldsCmd(parent tui.FieldMetadata) tea.Cmd {
+	return func() tea.Msg {
+		if m.fieldMetadataService == nil {
+			return dotWalkFieldsMsg{parent: parent, err: fmt.Errorf("field metadata is not available in demo mode")}
+		}
+		metadata, err := m.fieldMetadataService.GetFieldMetadata(parent.Reference)
+		if err != nil {
+			return dotWalkFieldsMsg{parent: parent, err: err}
+		}
+		return dotWalkFieldsMsg{parent: parent, fields: metadata.Fields}
+	}
+}
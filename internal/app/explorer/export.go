@@ -10,6 +10,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/export"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -31,6 +33,7 @@ type ExportFormat int
 const (
 	ExportCSV ExportFormat = iota
 	ExportJSON
+	ExportXLSX
 )
 
 // ReferenceValueMode defines how reference fields should be exported
@@ -122,7 +125,7 @@ func (ed *ExportDialog) Update(msg tea.Msg) (*ExportDialog, tea.Cmd) {
 			return ed, nil
 
 		case key.Matches(msg, key.NewBinding(key.WithKeys("right", "l"))):
-			if ed.selectedFormat < 1 { // 2 format options (0-1)
+			if ed.selectedFormat < 2 { // 3 format options (0-2)
 				ed.selectedFormat++
 			}
 			return ed, nil
@@ -241,7 +244,7 @@ func (ed *ExportDialog) renderFormatSelection() string {
 	content.WriteString(formatStyle.Render("📁 Export Format:"))
 	content.WriteString("\n")
 
-	formats := []string{"CSV", "JSON"}
+	formats := []string{"CSV", "JSON", "XLSX"}
 
 	// Render formats horizontally
 	var formatOptions []string
@@ -361,12 +364,14 @@ func (m *Model) executeExport(scope ExportScope, format ExportFormat, referenceM
 	var records []map[string]interface{}
 	var fields []string
 	var err error
+	displayValue := getDisplayValueParam(referenceMode)
 
 	switch scope {
 	case ExportCurrentView:
 		// Current view with filter and selected columns
 		records = m.records
 		fields = m.selectedColumns
+		displayValue = "all" // Loaded like the list view
 
 	case ExportCurrentViewAllFields:
 		// Current view with filter but all fields
@@ -405,6 +410,8 @@ func (m *Model) executeExport(scope ExportScope, format ExportFormat, referenceM
 		filename = fmt.Sprintf("%s_export_%s.csv", m.currentTable, timestamp)
 	case ExportJSON:
 		filename = fmt.Sprintf("%s_export_%s.json", m.currentTable, timestamp)
+	case ExportXLSX:
+		filename = fmt.Sprintf("%s_export_%s.xlsx", m.currentTable, timestamp)
 	}
 
 	// Get export directory from config
@@ -423,6 +430,8 @@ func (m *Model) executeExport(scope ExportScope, format ExportFormat, referenceM
 		err = m.exportToCSV(records, fields, filePath, referenceMode)
 	case ExportJSON:
 		err = m.exportToJSON(records, fields, filePath, referenceMode)
+	case ExportXLSX:
+		err = m.exportToXLSX(records, fields, filePath, referenceMode, displayValue)
 	}
 
 	if err != nil {
@@ -600,6 +609,42 @@ func (m *Model) exportToJSON(records []map[string]interface{}, fields []string,
 	return encoder.Encode(exportStructure)
 }
 
+// exportToXLSX exports records to an XLSX workbook with typed cells. In "Both" mode
+// reference fields get a separate sys_id column instead of "Name (sys_id)" values.
+func (m *Model) exportToXLSX(records []map[string]interface{}, fields []string, filePath string, referenceMode ReferenceValueMode, displayValue string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	options := export.XLSXOptions{
+		Sheet:        m.currentTable,
+		Fields:       fields,
+		References:   exportReferenceMode(referenceMode),
+		DisplayValue: core.DisplayValueOptions(displayValue),
+	}
+	if m.client != nil {
+		options.Location = m.client.Core().GetTimeZone()
+	}
+	if err := export.WriteXLSX(file, records, options); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// exportReferenceMode maps the dialog's reference mode to the export package's
+func exportReferenceMode(mode ReferenceValueMode) export.ReferenceMode {
+	switch mode {
+	case ReferenceSysIds:
+		return export.ReferenceSysID
+	case ReferenceBoth:
+		return export.ReferenceBoth
+	default:
+		return export.ReferenceDisplay
+	}
+}
+
 // Helper functions for demo mode
 func (m *Model) addMockFieldsToRecords(records []map[string]interface{}) []map[string]interface{} {
 	var enrichedRecords []map[string]interface{}
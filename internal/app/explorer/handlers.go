@@ -38,6 +38,9 @@ func (m Model) handleBack() (tea.Model, tea.Cmd) {
 			return m, m.loadTableRecordsWithQueryCmd(m.currentTable, m.currentQuery)
 		}
 		return m, m.loadTableRecordsCmd(m.currentTable)
+	case simpleStateJournal:
+		m.state = simpleStateRecordDetail
+		m.loading = false
 	case simpleStateXMLSearch:
 		m.state = simpleStateRecordDetail
 		m.xmlSearchQuery = ""
@@ -0,0 +1,168 @@
+package explorer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+)
+
+type journalLoadedMsg struct {
+	entries []table.JournalEntry
+}
+
+type journalErrorMsg struct {
+	err error
+}
+
+// Handle opening the activity stream of the selected record
+func (m Model) handleJournal() (tea.Model, tea.Cmd) {
+	if m.state != simpleStateRecordDetail || m.selectedRecord == nil {
+		return m, nil
+	}
+	m.state = simpleStateJournal
+	m.loading = true
+	m.journalEntries = nil
+	m.journalError = nil
+	m.journalScrollOffset = 0
+	return m, m.loadJournalCmd(getRecordField(m.selectedRecord, "sys_id"))
+}
+
+// Handle key presses in the activity stream
+func (m Model) handleJournalKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		m.scrollJournal(-1)
+		return m, nil, true
+	case key.Matches(msg, m.keys.Down):
+		m.scrollJournal(1)
+		return m, nil, true
+	case key.Matches(msg, m.keys.Refresh):
+		m.loading = true
+		return m, m.loadJournalCmd(getRecordField(m.selectedRecord, "sys_id")), true
+	}
+	return m, nil, false
+}
+
+// Load journal entries command
+func (m *Model) loadJournalCmd(recordID string) tea.Cmd {
+	return func() tea.Msg {
+		if m.client == nil {
+			// Demo mode - sample activity
+			now := time.Now()
+			return journalLoadedMsg{entries: []table.JournalEntry{
+				{Element: table.JournalComments, Value: "I still can't send email from Outlook.", CreatedBy: "abel.tuter", CreatedOn: now.Add(-2 * time.Hour)},
+				{Element: table.JournalWorkNotes, Value: "Mail relay queue is backed up, restarting the service.", CreatedBy: "beth.anglin", CreatedOn: now.Add(-90 * time.Minute)},
+				{Element: table.JournalComments, Value: "Email should be working again, please confirm.", CreatedBy: "beth.anglin", CreatedOn: now.Add(-time.Hour)},
+			}}
+		}
+
+		entries, err := m.client.Table(m.currentTable).ListJournal(recordID)
+		if err != nil {
+			return journalErrorMsg{err: err}
+		}
+		return journalLoadedMsg{entries: entries}
+	}
+}
+
+// journalLines renders the activity stream newest first, as on the form
+func (m Model) journalLines(width int) []string {
+	authorStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
+	commentStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	workNoteStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+
+	var lines []string
+	for i := len(m.journalEntries) - 1; i >= 0; i-- {
+		entry := m.journalEntries[i]
+		label := commentStyle.Render("💬 " + entry.Label())
+		if entry.Element == table.JournalWorkNotes {
+			label = workNoteStyle.Render("📝 " + entry.Label())
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s  %s",
+			authorStyle.Render(entry.CreatedBy), label, timeStyle.Render(entry.CreatedOn.Format(table.GlideDateTimeLayout))))
+		for _, line := range strings.Split(strings.TrimRight(entry.Value, "\n"), "\n") {
+			if len(line) > width-4 && width > 10 {
+				line = line[:width-7] + "..."
+			}
+			lines = append(lines, "  "+line)
+		}
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// journalHeight returns the number of activity lines that fit on screen
+func (m Model) journalHeight() int {
+	contentHeight := m.height - 3 - m.calculateHelpFooterHeight() - 1
+	height := contentHeight - 4
+	if height < 1 {
+		height = 1
+	}
+	return height
+}
+
+func (m *Model) scrollJournal(direction int) {
+	maxScroll := len(m.journalLines(m.width-6)) - m.journalHeight()
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+	m.journalScrollOffset += direction
+	if m.journalScrollOffset < 0 {
+		m.journalScrollOffset = 0
+	}
+	if m.journalScrollOffset > maxScroll {
+		m.journalScrollOffset = maxScroll
+	}
+}
+
+// Render the activity stream of the selected record
+func (m Model) renderJournal() string {
+	if m.journalError != nil {
+		return fmt.Sprintf("❌ Failed to load activity: %v\n\nPress Esc to go back.", m.journalError)
+	}
+	if m.loading && m.journalEntries == nil {
+		return "Loading activity..."
+	}
+	if len(m.journalEntries) == 0 {
+		return "No comments or work notes on this record. Press Esc to go back."
+	}
+
+	width := m.width - 6
+	if width < 20 {
+		width = 20
+	}
+	height := m.journalHeight()
+	lines := m.journalLines(width)
+
+	start := m.journalScrollOffset
+	if start > len(lines) {
+		start = len(lines)
+	}
+	end := start + height
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	content := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(1).
+		Width(width).
+		Height(height).
+		Render(strings.Join(lines[start:end], "\n"))
+
+	if len(lines) > height {
+		content += "\n" + lipgloss.NewStyle().
+			Foreground(lipgloss.Color("244")).
+			Italic(true).
+			Width(width).
+			Align(lipgloss.Center).
+			Render(fmt.Sprintf("Lines %d-%d of %d", start+1, end, len(lines)))
+	}
+	return content
+}
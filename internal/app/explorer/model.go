@@ -6,6 +6,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
 	"github.com/Krive/ServiceNow-Toolkit/internal/tui"
 )
 
@@ -28,6 +29,7 @@ const (
 	simpleStateExportDialog
 	simpleStateEditField
 	simpleStateReferenceSearch
+	simpleStateJournal
 )
 
 // Messages for async operations
@@ -88,6 +90,11 @@ type Model struct {
 	editableFields     []string // List of editable field names from current record
 	currentFieldIndex  int   // Current field index for XML navigation
 	
+	// Activity stream (journal entries) of the selected record
+	journalEntries      []table.JournalEntry
+	journalError        error
+	journalScrollOffset int
+
 	// Reference field search
 	referenceSearchQuery   string                     // Search query for reference fields
 	referenceSearchResults []map[string]interface{}   // Search results for reference picker
@@ -158,6 +165,7 @@ type simpleKeyMap struct {
 	EditField         key.Binding
 	SaveEdit          key.Binding
 	CancelEdit        key.Binding
+	Journal           key.Binding
 }
 
 func (k simpleKeyMap) ShortHelp() []key.Binding {
@@ -229,6 +237,7 @@ func New(client *servicenow.Client) *Model {
 		EditField:        key.NewBinding(key.WithKeys("shift+e", "E"), key.WithHelp("E", "edit field")),
 		SaveEdit:         key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "save edit")),
 		CancelEdit:       key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel edit")),
+		Journal:          key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "activity")),
 	}
 
 	// Initialize configuration manager and load saved settings
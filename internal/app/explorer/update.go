@@ -86,6 +86,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleReferenceSearchInput(msg)
 		}
 
+		// Handle scrolling and refresh in the activity stream
+		if m.state == simpleStateJournal {
+			if model, cmd, handled := m.handleJournalKeys(msg); handled {
+				return model, cmd
+			}
+		}
+
 		// Handle search navigation when in record detail (before regular hotkeys)
 		if m.state == simpleStateRecordDetail && len(m.xmlSearchResults) > 0 {
 			switch {
@@ -239,6 +246,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.state == simpleStateRecordDetail {
 				return m.handleEditField()
 			}
+		case key.Matches(msg, m.keys.Journal):
+			if m.state == simpleStateRecordDetail {
+				return m.handleJournal()
+			}
 		case key.Matches(msg, m.keys.ColumnCustomizer):
 			return m.handleColumnCustomizer()
 		case key.Matches(msg, m.keys.SaveView):
@@ -317,6 +328,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case dotWalkRequestMsg:
+		return m, m.loadDotWalkFieldsCmd(msg.parent)
+
+	case dotWalkFieldsMsg:
+		if m.columnCustomizer != nil {
+			m.columnCustomizer.AddDotWalkFields(msg.parent, msg.fields, msg.err)
+		}
+		return m, nil
+
+	case journalLoadedMsg:
+		m.loading = false
+		m.journalEntries = msg.entries
+		m.journalError = nil
+		m.scrollJournal(0)
+		return m, nil
+
+	case journalErrorMsg:
+		m.loading = false
+		m.journalError = msg.err
+		return m, nil
+
 	case recordXMLLoadedMsg:
 		m.loading = false
 		m.recordXML = msg.xml
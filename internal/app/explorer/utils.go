@@ -6,6 +6,8 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -406,48 +408,21 @@ func (m *Model) generateDemoRecord(tableName string, recordNum int) map[string]i
 	return baseRecord
 }
 
-// Helper function to safely extract field from ServiceNow record
+// Helper function to safely extract field from ServiceNow record.
+// For table display, the display value is preferred over the raw value (sys_id).
 func getRecordField(record map[string]interface{}, field string) string {
-	if val, ok := record[field]; ok && val != nil {
-		// Handle ServiceNow reference field objects
-		if refMap, isMap := val.(map[string]interface{}); isMap {
-			// This is a reference field with link and value
-			// For table display, prefer display_value over value (sys_id)
-			if dispVal, hasDisplay := refMap["display_value"]; hasDisplay && dispVal != nil {
-				return fmt.Sprintf("%v", dispVal)
-			}
-			if value, hasValue := refMap["value"]; hasValue && value != nil {
-				return fmt.Sprintf("%v", value)
-			}
-		}
-		return fmt.Sprintf("%v", val)
-	}
-	return ""
+	return table.NewRecord(record, core.DisplayFalse, nil).DisplayValue(field)
 }
 
-// Helper function to extract display value from ServiceNow reference field
+// Helper function to extract raw and display values from a ServiceNow field.
+// isReference is true for object-shaped values ({link, value} or {display_value, value}).
 func getRecordDisplayValue(record map[string]interface{}, field string) (value string, displayValue string, isReference bool) {
-	if val, ok := record[field]; ok && val != nil {
-		// Handle ServiceNow reference field objects
-		if refMap, isMap := val.(map[string]interface{}); isMap {
-			// This is a reference field with link and value
-			if refValue, hasValue := refMap["value"]; hasValue && refValue != nil {
-				valueStr := fmt.Sprintf("%v", refValue)
-
-				// Check if there's a display_value field
-				if dispVal, hasDisplay := refMap["display_value"]; hasDisplay && dispVal != nil {
-					return valueStr, fmt.Sprintf("%v", dispVal), true
-				}
-
-				// For now, return the sys_id as both value and display
-				return valueStr, valueStr, true
-			}
-		}
-		// Regular field
-		valueStr := fmt.Sprintf("%v", val)
-		return valueStr, valueStr, false
+	r := table.NewRecord(record, core.DisplayFalse, nil)
+	if !r.Has(field) {
+		return "", "", false
 	}
-	return "", "", false
+	_, isReference = record[field].(map[string]interface{})
+	return r.String(field), r.DisplayValue(field), isReference
 }
 
 // Generate XML representation from ServiceNow record
@@ -72,6 +72,9 @@ func (m Model) View() string {
 	case simpleStateRecordDetail:
 		headerContent = fmt.Sprintf("%s - 📄 Record XML: %s", logoWithInstance, m.currentTable)
 		headerHeight = 3
+	case simpleStateJournal:
+		headerContent = fmt.Sprintf("%s - 📝 Activity: %s %s", logoWithInstance, m.currentTable, getRecordField(m.selectedRecord, "number"))
+		headerHeight = 3
 	case simpleStateCustomTable:
 		headerContent = logoWithInstance + " - 🔧 Custom Table Input"
 		headerHeight = 3
@@ -154,6 +157,8 @@ func (m Model) View() string {
 		} else {
 			content = "No XML data available. Press Esc to go back."
 		}
+	case simpleStateJournal:
+		content = m.renderJournal()
 	case simpleStateCustomTable:
 		content = m.renderCustomTableInput()
 	case simpleStateQueryFilter:
@@ -577,18 +582,20 @@ func (m Model) getHelpText() string {
 		}
 	case simpleStateRecordDetail:
 		if len(m.xmlSearchResults) > 0 {
-			return fmt.Sprintf("↑↓/k/j: navigate fields • s: search • E: edit • n/N: next/prev match (%d/%d) • esc: back • q: quit", m.xmlSearchIndex+1, len(m.xmlSearchResults))
+			return fmt.Sprintf("↑↓/k/j: navigate fields • s: search • E: edit • a: activity • n/N: next/prev match (%d/%d) • esc: back • q: quit", m.xmlSearchIndex+1, len(m.xmlSearchResults))
 		} else {
 			if len(m.editableFields) > 0 {
 				currentField := ""
 				if m.currentFieldIndex >= 0 && m.currentFieldIndex < len(m.editableFields) {
 					currentField = m.editableFields[m.currentFieldIndex]
 				}
-				return fmt.Sprintf("↑↓/k/j: navigate fields • s: search XML • E: edit %s (%d/%d) • esc: back • q: quit", currentField, m.currentFieldIndex+1, len(m.editableFields))
+				return fmt.Sprintf("↑↓/k/j: navigate fields • s: search XML • E: edit %s (%d/%d) • a: activity • esc: back • q: quit", currentField, m.currentFieldIndex+1, len(m.editableFields))
 			} else {
-				return "↑↓/k/j: scroll • s: search XML • E: edit • esc: back • q: quit"
+				return "↑↓/k/j: scroll • s: search XML • E: edit • a: activity • esc: back • q: quit"
 			}
 		}
+	case simpleStateJournal:
+		return "↑↓/k/j: scroll • r: refresh • esc: back to record • q: quit"
 	case simpleStateEditField:
 		return "Type to edit • ctrl+v: paste • ctrl+f: reference search • ctrl+a: clear • enter: save • esc: cancel • q: quit"
 	case simpleStateReferenceSearch:
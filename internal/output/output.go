@@ -0,0 +1,517 @@
+// Package output renders command results in the formats shared by every CLI
+// command: aligned tables, JSON, NDJSON, YAML, CSV, Markdown and Go templates.
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// Format names an output format
+type Format string
+
+const (
+	FormatTable    Format = "table"    // Aligned columns, long values truncated to fit
+	FormatWide     Format = "wide"     // Aligned columns including wide-only columns, nothing truncated
+	FormatJSON     Format = "json"     // Indented JSON
+	FormatNDJSON   Format = "ndjson"   // One JSON value per line, one line per array element
+	FormatYAML     Format = "yaml"     // Block-style YAML
+	FormatCSV      Format = "csv"      // Comma-separated values with a header row
+	FormatMarkdown Format = "markdown" // Markdown table
+	FormatTemplate Format = "template" // Go template executed per array element
+)
+
+// MaxCellWidth is the widest a cell is printed in the table format
+const MaxCellWidth = 50
+
+// Formats returns the names of the shared formats
+func Formats() []string {
+	return []string{"table", "wide", "json", "ndjson", "yaml", "csv", "markdown", "template"}
+}
+
+// Column selects a value of each row for tabular formats
+type Column struct {
+	Path   string // Field of the row, dot-separated for nested values (e.g. caller_id.display_value)
+	Header string // Table and Markdown header (default: the upper-cased path)
+	Wide   bool   // Only shown in the wide format
+}
+
+// Columns builds columns from paths
+func Columns(paths ...string) []Column {
+	columns := make([]Column, len(paths))
+	for i, path := range paths {
+		columns[i] = Column{Path: path}
+	}
+	return columns
+}
+
+func (c Column) header() string {
+	if c.Header != "" {
+		return c.Header
+	}
+	return strings.ToUpper(c.Path)
+}
+
+// Options configure a Printer
+type Options struct {
+	Format   Format   // Default: table
+	Columns  []string // Replaces the columns given to Print and selects fields in JSON and YAML
+	Query    string   // jq-style path applied to the result before it is formatted
+	Template string   // Go template for the template format
+	Color    bool     // Highlight table headers
+	Width    int      // Terminal width tables are fitted to; 0 disables fitting
+	Extra    []string // Command-specific formats accepted besides the shared ones
+}
+
+// Printer writes command results in the configured format
+type Printer struct {
+	w        io.Writer
+	options  Options
+	query    *Query
+	template *template.Template
+}
+
+// NewPrinter validates the options and returns a printer writing to w
+func NewPrinter(w io.Writer, options Options) (*Printer, error) {
+	if options.Format == "" {
+		options.Format = FormatTable
+	}
+	format := string(options.Format)
+	if !slices.Contains(Formats(), format) && !slices.Contains(options.Extra, format) {
+		return nil, fmt.Errorf("unknown output format %q (use %s)", format, strings.Join(append(Formats(), options.Extra...), ", "))
+	}
+	p := &Printer{w: w, options: options}
+	if options.Query != "" {
+		query, err := CompileQuery(options.Query)
+		if err != nil {
+			return nil, err
+		}
+		p.query = query
+	}
+	switch {
+	case options.Format == FormatTemplate && options.Template == "":
+		return nil, fmt.Errorf("the template format requires a template")
+	case options.Template != "":
+		tmpl, err := template.New("output").Funcs(templateFuncs).Option("missingkey=zero").Parse(options.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		p.template = tmpl
+	}
+	return p, nil
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join":  func(sep string, v []interface{}) string { return strings.Join(cellsOf(v), sep) },
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Format returns the selected format
+func (p *Printer) Format() Format {
+	return p.options.Format
+}
+
+// Human reports whether output is meant for reading (table or wide), so commands
+// may add headings and summaries
+func (p *Printer) Human() bool {
+	return p.options.Format == FormatTable || p.options.Format == FormatWide
+}
+
+// Writer returns the writer output goes to
+func (p *Printer) Writer() io.Writer {
+	return p.w
+}
+
+// Print writes a value: usually a slice of rows or a single object. Columns select
+// and order the fields shown by tabular formats; without them every field is shown
+// in name order. Values are converted through their JSON encoding, so paths and
+// templates use JSON field names.
+func (p *Printer) Print(value interface{}, columns ...Column) error {
+	if len(p.options.Columns) > 0 {
+		columns = Columns(p.options.Columns...)
+	}
+	format := p.options.Format
+
+	// JSON of an unmodified value keeps the field order of structs
+	if p.query == nil && len(p.options.Columns) == 0 && (format == FormatJSON || format == FormatNDJSON) {
+		return p.writeJSON(value)
+	}
+
+	data, err := normalize(value)
+	if err != nil {
+		return err
+	}
+	if p.query != nil {
+		if data, err = p.query.Apply(data); err != nil {
+			return fmt.Errorf("query %s: %w", p.query, err)
+		}
+	}
+	if len(p.options.Columns) > 0 {
+		data = project(data, columns)
+	}
+
+	switch format {
+	case FormatJSON, FormatNDJSON:
+		return p.writeJSON(data)
+	case FormatYAML:
+		return writeYAML(p.w, data)
+	case FormatTemplate:
+		return p.writeTemplate(data)
+	case FormatCSV:
+		return p.writeCSV(data, columns)
+	case FormatMarkdown:
+		return p.writeMarkdown(data, columns)
+	}
+	return p.writeTable(data, columns)
+}
+
+// normalize converts a value to maps, slices and scalars through its JSON encoding
+func normalize(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// project keeps only the columns of each object
+func project(data interface{}, columns []Column) interface{} {
+	switch v := data.(type) {
+	case []interface{}:
+		rows := make([]interface{}, len(v))
+		for i, row := range v {
+			rows[i] = project(row, columns)
+		}
+		return rows
+	case map[string]interface{}:
+		row := make(map[string]interface{}, len(columns))
+		for _, column := range columns {
+			row[column.Path] = lookup(v, column.Path)
+		}
+		return row
+	}
+	return data
+}
+
+// lookup returns the value at a dot-separated path. Keys containing dots (as in
+// dot-walked record fields) are matched before nested objects.
+func lookup(value interface{}, path string) interface{} {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if v, ok := obj[path]; ok {
+		return v
+	}
+	for i := strings.IndexByte(path, '.'); i >= 0; {
+		if v, ok := obj[path[:i]]; ok {
+			if found := lookup(v, path[i+1:]); found != nil {
+				return found
+			}
+		}
+		next := strings.IndexByte(path[i+1:], '.')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return nil
+}
+
+func (p *Printer) writeJSON(value interface{}) error {
+	encoder := json.NewEncoder(p.w)
+	if p.options.Format == FormatNDJSON {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return encoder.Encode(json.RawMessage(data))
+		}
+		for _, item := range items {
+			if err := encoder.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func (p *Printer) writeTemplate(data interface{}) error {
+	items, ok := data.([]interface{})
+	if !ok {
+		items = []interface{}{data}
+	}
+	for _, item := range items {
+		var b bytes.Buffer
+		if err := p.template.Execute(&b, item); err != nil {
+			return err
+		}
+		if !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+			b.WriteByte('\n')
+		}
+		if _, err := p.w.Write(b.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rows returns the rows of a result and the columns to show. A single object is
+// one row; scalars become a VALUE column.
+func rows(data interface{}, columns []Column) ([]interface{}, []Column) {
+	items, ok := data.([]interface{})
+	if !ok {
+		items = []interface{}{data}
+	}
+	if len(columns) > 0 {
+		return items, columns
+	}
+	keys := make(map[string]bool)
+	scalars := false
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			scalars = true
+			continue
+		}
+		for key := range obj {
+			keys[key] = true
+		}
+	}
+	var paths []string
+	for key := range keys {
+		paths = append(paths, key)
+	}
+	sort.Strings(paths)
+	if scalars && len(paths) == 0 {
+		return items, []Column{{Path: "", Header: "VALUE"}}
+	}
+	return items, Columns(paths...)
+}
+
+// cell returns the text of a column of a row
+func cell(row interface{}, column Column) string {
+	if column.Path == "" {
+		return cellText(row)
+	}
+	return cellText(lookup(row, column.Path))
+}
+
+func cellText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
+
+func cellsOf(values []interface{}) []string {
+	cells := make([]string, len(values))
+	for i, v := range values {
+		cells[i] = cellText(v)
+	}
+	return cells
+}
+
+func (p *Printer) writeCSV(data interface{}, columns []Column) error {
+	items, columns := rows(data, columns)
+	if len(items) == 0 && len(columns) == 0 {
+		return nil
+	}
+	w := csv.NewWriter(p.w)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Path
+		if column.Path == "" {
+			header[i] = "value"
+		}
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, item := range items {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			record[i] = cell(item, column)
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func (p *Printer) writeMarkdown(data interface{}, columns []Column) error {
+	items, columns := rows(data, columns)
+	if len(columns) == 0 {
+		return nil
+	}
+	escape := strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+	var b strings.Builder
+	line := func(cells []string) {
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	header := make([]string, len(columns))
+	rule := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = escape.Replace(column.header())
+		rule[i] = "---"
+	}
+	line(header)
+	line(rule)
+	for _, item := range items {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = escape.Replace(cell(item, column))
+		}
+		line(cells)
+	}
+	_, err := io.WriteString(p.w, b.String())
+	return err
+}
+
+// writeTable prints aligned columns. A single object is printed as one field per
+// line.
+func (p *Printer) writeTable(data interface{}, columns []Column) error {
+	wide := p.options.Format == FormatWide
+	if !wide {
+		columns = slices.DeleteFunc(slices.Clone(columns), func(c Column) bool { return c.Wide })
+	}
+
+	if obj, ok := data.(map[string]interface{}); ok {
+		_, columns = rows(obj, columns)
+		table := make([][]string, len(columns))
+		for i, column := range columns {
+			table[i] = []string{column.header(), cell(obj, column)}
+		}
+		return p.writeAligned(nil, table, !wide)
+	}
+
+	items, columns := rows(data, columns)
+	if len(items) == 0 {
+		_, err := fmt.Fprintln(p.w, "No results found.")
+		return err
+	}
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.header()
+	}
+	table := make([][]string, len(items))
+	for r, item := range items {
+		table[r] = make([]string, len(columns))
+		for i, column := range columns {
+			table[r][i] = cell(item, column)
+		}
+	}
+	return p.writeAligned(header, table, !wide)
+}
+
+// writeAligned pads cells to column widths. When fit is set, cells are cut to
+// MaxCellWidth and the widest columns are narrowed until the table fits the
+// terminal width.
+func (p *Printer) writeAligned(header []string, table [][]string, fit bool) error {
+	columns := len(header)
+	if columns == 0 && len(table) > 0 {
+		columns = len(table[0])
+	}
+	for r := range table {
+		for i := range table[r] {
+			table[r][i] = strings.Join(strings.Fields(table[r][i]), " ")
+		}
+	}
+
+	widths := make([]int, columns)
+	measure := func(cells []string) {
+		for i, c := range cells {
+			widths[i] = max(widths[i], utf8.RuneCountInString(c))
+		}
+	}
+	measure(header)
+	for _, cells := range table {
+		measure(cells)
+	}
+	if fit {
+		for i := range widths {
+			widths[i] = min(widths[i], MaxCellWidth)
+		}
+		const gap, narrowest = 2, 8
+		for p.options.Width > 0 {
+			total := gap * (columns - 1)
+			widest := 0
+			for i, w := range widths {
+				total += w
+				if w > widths[widest] {
+					widest = i
+				}
+			}
+			if total <= p.options.Width || widths[widest] <= narrowest {
+				break
+			}
+			widths[widest] = max(narrowest, widths[widest]-(total-p.options.Width))
+		}
+	}
+
+	var b strings.Builder
+	line := func(cells []string, bold bool) {
+		for i, c := range cells {
+			c = truncate(c, widths[i])
+			if i < len(cells)-1 {
+				c += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(c)+2)
+			}
+			if bold && p.options.Color {
+				c = "\x1b[1m" + c + "\x1b[0m"
+			}
+			b.WriteString(c)
+		}
+		b.WriteString("\n")
+	}
+	if header != nil {
+		line(header, true)
+	}
+	for _, cells := range table {
+		line(cells, false)
+	}
+	_, err := io.WriteString(p.w, b.String())
+	return err
+}
+
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	if width <= 3 {
+		return string([]rune(s)[:width])
+	}
+	return string([]rune(s)[:width-3]) + "..."
+}
@@ -0,0 +1,313 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Query is a compiled jq-style path expression. It supports the identity (.),
+// field access (.name, ."name", .["name"]), array indexes (.[0], .[-1]), iteration
+// (.[]), pipes (|) and the length and keys builtins, e.g. .[].assigned_to.display_value
+type Query struct {
+	expr    string
+	stages  [][]step
+	iterate bool
+}
+
+type stepKind int
+
+const (
+	stepField stepKind = iota
+	stepIndex
+	stepIterate
+	stepLength
+	stepKeys
+)
+
+type step struct {
+	kind  stepKind
+	field string
+	index int
+}
+
+// CompileQuery parses a path expression
+func CompileQuery(expr string) (*Query, error) {
+	q := &Query{expr: expr}
+	for _, part := range splitPipes(expr) {
+		part = strings.TrimSpace(part)
+		var steps []step
+		switch part {
+		case "":
+			return nil, fmt.Errorf("invalid query %q: empty expression", expr)
+		case "length":
+			steps = []step{{kind: stepLength}}
+		case "keys":
+			steps = []step{{kind: stepKeys}}
+		default:
+			var err error
+			if steps, err = parsePath(part); err != nil {
+				return nil, fmt.Errorf("invalid query %q: %w", expr, err)
+			}
+		}
+		for _, s := range steps {
+			if s.kind == stepIterate {
+				q.iterate = true
+			}
+		}
+		q.stages = append(q.stages, steps)
+	}
+	return q, nil
+}
+
+// String returns the expression the query was compiled from
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Apply evaluates the query against a value decoded from JSON. Expressions that
+// iterate return their results as an array.
+func (q *Query) Apply(value interface{}) (interface{}, error) {
+	values := []interface{}{value}
+	for _, steps := range q.stages {
+		for _, s := range steps {
+			var next []interface{}
+			for _, v := range values {
+				results, err := s.eval(v)
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, results...)
+			}
+			values = next
+		}
+	}
+	if q.iterate {
+		if values == nil {
+			values = []interface{}{}
+		}
+		return values, nil
+	}
+	return values[0], nil
+}
+
+func (s step) eval(v interface{}) ([]interface{}, error) {
+	switch s.kind {
+	case stepField:
+		switch obj := v.(type) {
+		case nil:
+			return []interface{}{nil}, nil
+		case map[string]interface{}:
+			return []interface{}{obj[s.field]}, nil
+		}
+		return nil, fmt.Errorf("cannot index %s with %q", typeName(v), s.field)
+	case stepIndex:
+		switch arr := v.(type) {
+		case nil:
+			return []interface{}{nil}, nil
+		case []interface{}:
+			i := s.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i < 0 || i >= len(arr) {
+				return []interface{}{nil}, nil
+			}
+			return []interface{}{arr[i]}, nil
+		}
+		return nil, fmt.Errorf("cannot index %s with a number", typeName(v))
+	case stepIterate:
+		switch c := v.(type) {
+		case []interface{}:
+			return c, nil
+		case map[string]interface{}:
+			var values []interface{}
+			for _, key := range sortedKeys(c) {
+				values = append(values, c[key])
+			}
+			return values, nil
+		}
+		return nil, fmt.Errorf("cannot iterate over %s", typeName(v))
+	case stepLength:
+		switch c := v.(type) {
+		case nil:
+			return []interface{}{0}, nil
+		case []interface{}:
+			return []interface{}{len(c)}, nil
+		case map[string]interface{}:
+			return []interface{}{len(c)}, nil
+		case string:
+			return []interface{}{len([]rune(c))}, nil
+		}
+		return nil, fmt.Errorf("%s has no length", typeName(v))
+	case stepKeys:
+		switch c := v.(type) {
+		case map[string]interface{}:
+			var keys []interface{}
+			for _, key := range sortedKeys(c) {
+				keys = append(keys, key)
+			}
+			return []interface{}{keys}, nil
+		case []interface{}:
+			keys := make([]interface{}, len(c))
+			for i := range c {
+				keys[i] = i
+			}
+			return []interface{}{keys}, nil
+		}
+		return nil, fmt.Errorf("%s has no keys", typeName(v))
+	}
+	return nil, fmt.Errorf("unknown query step")
+}
+
+// parsePath parses a path such as .result[0].number or .["sys_id"]
+func parsePath(path string) ([]step, error) {
+	if !strings.HasPrefix(path, ".") {
+		return nil, fmt.Errorf("paths start with '.', got %q", path)
+	}
+	var steps []step
+	i := 1
+	for i < len(path) {
+		switch c := path[i]; {
+		case c == '.':
+			i++
+			if i < len(path) && path[i] == '[' {
+				continue
+			}
+			if i < len(path) && path[i] == '"' {
+				field, n, err := quotedField(path[i:])
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, step{kind: stepField, field: field})
+				i += n
+				continue
+			}
+			start := i
+			for i < len(path) && isIdentChar(path[i]) {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("expected a field name at %q", path[start-1:])
+			}
+			steps = append(steps, step{kind: stepField, field: path[start:i]})
+		case c == '"' && i == 1:
+			field, n, err := quotedField(path[i:])
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step{kind: stepField, field: field})
+			i += n
+		case c == '[':
+			end := strings.IndexByte(path[i:], ']')
+			if strings.HasPrefix(path[i+1:], "\"") {
+				field, n, err := quotedField(path[i+1:])
+				if err != nil {
+					return nil, err
+				}
+				end = 1 + n
+				if i+end >= len(path) || path[i+end] != ']' {
+					return nil, fmt.Errorf("expected ']' after %q", path[i:i+end])
+				}
+				steps = append(steps, step{kind: stepField, field: field})
+				i += end + 1
+				continue
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated '[' in %q", path)
+			}
+			inner := strings.TrimSpace(path[i+1 : i+end])
+			if inner == "" {
+				steps = append(steps, step{kind: stepIterate})
+			} else {
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q", inner)
+				}
+				steps = append(steps, step{kind: stepIndex, index: index})
+			}
+			i += end + 1
+		case isIdentChar(c) && i == 1:
+			// ".name" at the start of the path
+			start := i
+			for i < len(path) && isIdentChar(path[i]) {
+				i++
+			}
+			steps = append(steps, step{kind: stepField, field: path[start:i]})
+		default:
+			return nil, fmt.Errorf("unexpected %q in %q", c, path)
+		}
+	}
+	return steps, nil
+}
+
+// quotedField reads a double-quoted field name and returns it with the number of
+// bytes it used
+func quotedField(s string) (string, int, error) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			field, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid field name %s", s[:i+1])
+			}
+			return field, i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string in %q", s)
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// splitPipes splits an expression at pipes outside quoted field names
+func splitPipes(expr string) []string {
+	var parts []string
+	inQuote := false
+	start := 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			if inQuote {
+				i++
+			}
+		case '"':
+			inQuote = !inQuote
+		case '|':
+			if !inQuote {
+				parts = append(parts, expr[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, expr[start:])
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	}
+	return "a number"
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,21 @@
+package output
+
+import (
+	"os"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// Terminal reports whether f is a terminal that accepts color and its width in
+// columns (0 when f is not a terminal). Color is off when NO_COLOR is set or
+// TERM is dumb.
+func Terminal(f *os.File) (color bool, width int) {
+	if !term.IsTerminal(f.Fd()) {
+		return false, 0
+	}
+	if w, _, err := term.GetSize(f.Fd()); err == nil {
+		width = w
+	}
+	color = os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+	return color, width
+}
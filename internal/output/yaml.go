@@ -0,0 +1,56 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writeYAML writes a value decoded from JSON as a block-style YAML document.
+// Object keys are sorted.
+func writeYAML(w io.Writer, value interface{}) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(yamlNode(value)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// yamlNode converts a JSON-decoded value into a node tree. Strings are tagged
+// !!str so the encoder quotes any that would read back as another type.
+func yamlNode(value interface{}) *yaml.Node {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range sortedKeys(v) {
+			node.Content = append(node.Content, yamlScalar("!!str", key), yamlNode(v[key]))
+		}
+		return node
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			node.Content = append(node.Content, yamlNode(item))
+		}
+		return node
+	case nil:
+		return yamlScalar("!!null", "null")
+	case string:
+		return yamlScalar("!!str", v)
+	case bool:
+		return yamlScalar("!!bool", strconv.FormatBool(v))
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return yamlScalar("!!int", v.String())
+		}
+		return yamlScalar("!!float", v.String())
+	}
+	return yamlScalar("!!str", fmt.Sprint(value))
+}
+
+func yamlScalar(tag, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
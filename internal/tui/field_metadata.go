@@ -3,10 +3,10 @@ package tui
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
 )
 
 // FieldType represents the type of a field
@@ -93,121 +93,55 @@ func (fms *FieldMetadataService) GetFieldMetadata(tableName string) (*TableField
 	return metadata, nil
 }
 
-// loadFieldMetadata loads field metadata from ServiceNow
+// loadFieldMetadata loads field metadata from ServiceNow, including fields
+// inherited from parent tables and their choices
 func (fms *FieldMetadataService) loadFieldMetadata(tableName string) (*TableFieldMetadata, error) {
-	// Try multiple approaches to get field metadata
-	var records []map[string]interface{}
-	var err error
-	
-	// Approach 1: Standard query by table name
-	params := map[string]string{
-		"sysparm_query":  fmt.Sprintf("name=%s", tableName),
-		"sysparm_fields": "element,column_label,internal_type,max_length,mandatory,read_only,reference,default_value,comments,dependent",
-		"sysparm_limit":  "1000",
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-	records, err = fms.client.Table("sys_dictionary").List(params)
-	// TODO: Remove debug output or make it conditional via env var
-	// fmt.Printf("DEBUG: Standard query for table '%s': %s\n", tableName, params["sysparm_query"])
-	// fmt.Printf("DEBUG: Standard query found %d records\n", len(records))
-	
-	// Approach 2: If no records, try with table name in name field
-	if len(records) == 0 {
-		altParams := map[string]string{
-			"sysparm_query":  fmt.Sprintf("name.name=%s", tableName),
-			"sysparm_fields": "element,column_label,internal_type,max_length,mandatory,read_only,reference,default_value,comments,dependent",
-			"sysparm_limit":  "1000",
-		}
-		
-		if altRecords, altErr := fms.client.Table("sys_dictionary").List(altParams); altErr == nil {
-			records = altRecords
-			// fmt.Printf("DEBUG: Alternative query (name.name) found %d records\n", len(records))
-		} else {
-			// fmt.Printf("DEBUG: Alternative query failed: %v\n", altErr)
-		}
-	}
-	
-	// Approach 3: If still no records, try broader query including parent tables
-	if len(records) == 0 {
-		extendedParams := map[string]string{
-			"sysparm_query":  fmt.Sprintf("name=%s^ORnameSTARTSWITH%s", tableName, tableName),
-			"sysparm_fields": "element,column_label,internal_type,max_length,mandatory,read_only,reference,name",
-			"sysparm_limit":  "1000",
-		}
-		
-		if extendedRecords, extErr := fms.client.Table("sys_dictionary").List(extendedParams); extErr == nil {
-			records = extendedRecords
-			// fmt.Printf("DEBUG: Extended query found %d records\n", len(records))
-		} else {
-			// fmt.Printf("DEBUG: Extended query failed: %v\n", extErr)
-		}
-	}
-	
-	// Approach 4: If all else fails, try a simple query to see if sys_dictionary is accessible
-	if len(records) == 0 {
-		testParams := map[string]string{
-			"sysparm_limit":  "5",
-			"sysparm_fields": "element,internal_type,name",
-		}
-		
-		if _, testErr := fms.client.Table("sys_dictionary").List(testParams); testErr == nil {
-			// fmt.Printf("DEBUG: Test query succeeded - sys_dictionary is accessible, found %d records\n", len(testRecords))
-			// if len(testRecords) > 0 {
-			//     fmt.Printf("DEBUG: Sample record fields: %v\n", testRecords[0])
-			// }
-		} else {
-			// fmt.Printf("DEBUG: Test query failed - sys_dictionary may not be accessible: %v\n", testErr)
-			err = testErr
-		}
-	}
-	
-	if err != nil && len(records) == 0 {
-		return nil, fmt.Errorf("failed to load field metadata from sys_dictionary: %w", err)
+	tableSchema, err := fms.client.Schema().GetTableWithContext(ctx, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load field metadata: %w", err)
 	}
 
 	metadata := &TableFieldMetadata{
 		TableName: tableName,
-		Fields:    make([]FieldMetadata, 0),
+		Fields:    make([]FieldMetadata, 0, len(tableSchema.Fields)),
 		LoadedAt:  time.Now(),
 	}
 
-	// Process each field record
-	for _, record := range records {
-		fieldName := getString(record, "element")
-		internalType := getString(record, "internal_type")
-		
-		// Debug: Log field details for first few fields
-		// if i < 5 {
-		//     fmt.Printf("DEBUG: Field %d - element='%s', internal_type='%s', reference='%s'\n", 
-		//         i, fieldName, internalType, getString(record, "reference"))
-		// }
-		
+	for _, schemaField := range tableSchema.Fields {
 		field := FieldMetadata{
-			Name:        fieldName,
-			Label:       getString(record, "column_label"),
-			Type:        mapServiceNowFieldType(internalType),
-			MaxLength:   getInt(record, "max_length"),
-			Mandatory:   getBool(record, "mandatory"),
-			ReadOnly:    getBool(record, "read_only"),
-			Reference:   getString(record, "reference"),
-			DefaultValue: getString(record, "default_value"),
-			Description: getString(record, "comments"),
-			DependentField: getString(record, "dependent"),
-			Attributes:  make(map[string]interface{}),
+			Name:           schemaField.Name,
+			Label:          schemaField.Label,
+			Type:           mapServiceNowFieldType(schemaField.Type),
+			MaxLength:      schemaField.MaxLength,
+			Mandatory:      schemaField.Mandatory,
+			ReadOnly:       schemaField.ReadOnly,
+			Reference:      schemaField.Reference,
+			DefaultValue:   schemaField.DefaultValue,
+			Description:    schemaField.Comments,
+			DependentField: schemaField.Dependent,
+			Attributes: map[string]interface{}{
+				"defined_on": schemaField.DefinedOn,
+			},
 		}
 
-		// Load choices for choice fields
-		if field.Type == FieldTypeChoice && field.Name != "" {
-			choices, err := fms.loadFieldChoices(tableName, field.Name)
-			if err == nil {
-				field.Choices = choices
+		// Fields with choices behave as choice fields whatever their storage type
+		if len(schemaField.Choices) > 0 {
+			field.Choices = make([]FieldChoice, len(schemaField.Choices))
+			for i, choice := range schemaField.Choices {
+				field.Choices[i] = FieldChoice{Value: choice.Value, Label: choice.Label}
+			}
+			if field.Type == FieldTypeString || field.Type == FieldTypeInteger {
+				field.Type = FieldTypeChoice
 			}
 		}
 
 		metadata.Fields = append(metadata.Fields, field)
 	}
 
-	// If we still have no fields after processing, the table might not exist or user might not have access
+	// If we have no fields, the table might not exist or user might not have access
 	if len(metadata.Fields) == 0 {
 		return nil, fmt.Errorf("no field metadata found for table '%s' - table may not exist or user may not have read access to sys_dictionary", tableName)
 	}
@@ -215,37 +149,6 @@ func (fms *FieldMetadataService) loadFieldMetadata(tableName string) (*TableFiel
 	return metadata, nil
 }
 
-// loadFieldChoices loads choice options for a choice field
-func (fms *FieldMetadataService) loadFieldChoices(tableName, fieldName string) ([]FieldChoice, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	params := map[string]string{
-		"sysparm_query":  fmt.Sprintf("name=%s^element=%s", tableName, fieldName),
-		"sysparm_fields": "value,label",
-		"sysparm_limit":  "100",
-	}
-
-	records, err := fms.client.Table("sys_choice").ListWithContext(ctx, params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load field choices: %w", err)
-	}
-
-	choices := make([]FieldChoice, 0, len(records))
-	for _, record := range records {
-		choice := FieldChoice{
-			Value: getString(record, "value"),
-			Label: getString(record, "label"),
-		}
-		if choice.Label == "" {
-			choice.Label = choice.Value
-		}
-		choices = append(choices, choice)
-	}
-
-	return choices, nil
-}
-
 // GetFieldsByType returns fields of a specific type
 func (metadata *TableFieldMetadata) GetFieldsByType(fieldType FieldType) []FieldMetadata {
 	var fields []FieldMetadata
@@ -303,6 +206,25 @@ func (metadata *TableFieldMetadata) FindField(name string) *FieldMetadata {
 	return nil
 }
 
+// Schema returns the metadata as a schema table, for checks shared with the SDK
+func (metadata *TableFieldMetadata) Schema() *schema.Table {
+	table := &schema.Table{Name: metadata.TableName, Fields: make([]*schema.Field, 0, len(metadata.Fields))}
+	for _, field := range metadata.Fields {
+		schemaField := &schema.Field{
+			Name:      field.Name,
+			Label:     field.Label,
+			Type:      string(field.Type),
+			Reference: field.Reference,
+			Dependent: field.DependentField,
+		}
+		for _, choice := range field.Choices {
+			schemaField.Choices = append(schemaField.Choices, schema.Choice{Value: choice.Value, Label: choice.Label})
+		}
+		table.Fields = append(table.Fields, schemaField)
+	}
+	return table
+}
+
 // GetDisplayFields returns fields that are commonly displayed in lists
 func (metadata *TableFieldMetadata) GetDisplayFields() []FieldMetadata {
 	// Priority order for display fields
@@ -379,47 +301,3 @@ func mapServiceNowFieldType(snType string) FieldType {
 		return FieldTypeString
 	}
 }
-
-// Helper functions for extracting values from ServiceNow records
-func getString(record map[string]interface{}, key string) string {
-	if val, exists := record[key]; exists && val != nil {
-		return fmt.Sprintf("%v", val)
-	}
-	return ""
-}
-
-func getInt(record map[string]interface{}, key string) int {
-	if val, exists := record[key]; exists && val != nil {
-		switch v := val.(type) {
-		case int:
-			return v
-		case int64:
-			return int(v)
-		case float64:
-			return int(v)
-		case string:
-			if strings.TrimSpace(v) == "" {
-				return 0
-			}
-			// Try to parse string as int
-			return 0
-		}
-	}
-	return 0
-}
-
-func getBool(record map[string]interface{}, key string) bool {
-	if val, exists := record[key]; exists && val != nil {
-		switch v := val.(type) {
-		case bool:
-			return v
-		case string:
-			return strings.ToLower(v) == "true" || v == "1"
-		case int:
-			return v != 0
-		case float64:
-			return v != 0
-		}
-	}
-	return false
-}
\ No newline at end of file
@@ -1019,7 +1019,10 @@ func (m *QueryBuilderModel) BuildQuery() string {
 		return ""
 	}
 	
-	qb := query.New()
+	// Calendar dates are wall-clock values in the local timezone. Written in that
+	// timezone they're unconverted, so the instance reads them as the same
+	// wall-clock time in the session user's timezone.
+	qb := query.New().TimeZone(time.Local)
 	
 	for i, condition := range m.conditions {
 		if i > 0 {
@@ -1031,12 +1034,16 @@ func (m *QueryBuilderModel) BuildQuery() string {
 			}
 		}
 		
+		if dateRange, ok := namedDateRanges[condition.Operator]; ok {
+			qb.On(condition.Field.Name, dateRange)
+			continue
+		}
+		if condition.StartDate != nil && addDateCondition(qb, condition) {
+			continue
+		}
 		switch condition.Operator {
 		case query.OpIsEmpty, query.OpIsNotEmpty:
 			qb.Where(condition.Field.Name, condition.Operator, "")
-		case query.OpToday, query.OpYesterday, query.OpThisWeek, query.OpLastWeek,
-			 query.OpThisMonth, query.OpLastMonth, query.OpThisYear, query.OpLastYear:
-			qb.Where(condition.Field.Name, condition.Operator, "")
 		default:
 			qb.Where(condition.Field.Name, condition.Operator, condition.Value)
 		}
@@ -1045,6 +1052,42 @@ func (m *QueryBuilderModel) BuildQuery() string {
 	return qb.BuildQuery()
 }
 
+// namedDateRanges maps the date operators offered for date fields to the ranges they filter on
+var namedDateRanges = map[query.Operator]query.DateRange{
+	query.OpToday:     query.Today,
+	query.OpYesterday: query.Yesterday,
+	query.OpThisWeek:  query.ThisWeek,
+	query.OpLastWeek:  query.LastWeek,
+	query.OpThisMonth: query.ThisMonth,
+	query.OpLastMonth: query.LastMonth,
+	query.OpThisYear:  query.ThisYear,
+	query.OpLastYear:  query.LastYear,
+}
+
+// addDateCondition adds a condition on dates picked from the calendar, reporting
+// whether the operator was one for dates
+func addDateCondition(qb *query.QueryBuilder, condition QueryCondition) bool {
+	field, start := condition.Field.Name, *condition.StartDate
+	switch condition.Operator {
+	case query.OpEquals:
+		qb.OnDate(field, start)
+	case query.OpNotEquals:
+		qb.NotOnDate(field, start)
+	case query.OpGreaterThan:
+		qb.After(field, start)
+	case query.OpLessThan:
+		qb.Before(field, start)
+	case query.OpBetween:
+		if condition.EndDate == nil {
+			return false
+		}
+		qb.BetweenDates(field, start, *condition.EndDate)
+	default:
+		return false
+	}
+	return true
+}
+
 // SetActive sets the active state of the query builder
 func (m *QueryBuilderModel) SetActive(active bool) {
 	m.isActive = active
@@ -2,11 +2,12 @@ package tui
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/lint"
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
 )
 
 // ValidationError represents a query validation error
@@ -38,13 +39,18 @@ type ValidationResult struct {
 // QueryValidator handles validation of ServiceNow queries
 type QueryValidator struct {
 	tableMetadata *TableFieldMetadata
+	schema        *schema.Table // tableMetadata in the form the linter reads
 }
 
 // NewQueryValidator creates a new query validator
 func NewQueryValidator(tableMetadata *TableFieldMetadata) *QueryValidator {
-	return &QueryValidator{
+	v := &QueryValidator{
 		tableMetadata: tableMetadata,
 	}
+	if tableMetadata != nil {
+		v.schema = tableMetadata.Schema()
+	}
+	return v
 }
 
 // ValidateCondition validates a single query condition
@@ -60,26 +66,6 @@ func (v *QueryValidator) ValidateCondition(condition QueryCondition) []Validatio
 		return errors
 	}
 
-	// Check if field exists in table metadata
-	fieldExists := false
-	if v.tableMetadata != nil {
-		for _, field := range v.tableMetadata.Fields {
-			if field.Name == condition.Field.Name {
-				fieldExists = true
-				break
-			}
-		}
-	}
-
-	if !fieldExists && v.tableMetadata != nil {
-		errors = append(errors, ValidationError{
-			Field:      condition.Field.Name,
-			Message:    fmt.Sprintf("Field '%s' does not exist in table", condition.Field.Name),
-			Severity:   SeverityWarning,
-			Suggestion: "Use field search to find valid fields",
-		})
-	}
-
 	// Operator validation
 	if condition.Operator == "" {
 		errors = append(errors, ValidationError{
@@ -87,17 +73,45 @@ func (v *QueryValidator) ValidateCondition(condition QueryCondition) []Validatio
 			Message:  "Operator is required",
 			Severity: SeverityError,
 		})
-	} else {
-		// Validate operator compatibility with field type
-		errors = append(errors, v.validateOperatorForFieldType(condition.Field, condition.Operator)...)
+		return errors
 	}
 
 	// Value validation
 	errors = append(errors, v.validateValue(condition)...)
+	if len(errors) > 0 || v.schema == nil {
+		return errors
+	}
 
+	// Fields, operators and values are checked against the schema by the linter
+	for _, d := range lint.Check(v.schema, lintEncoding(condition), lint.Options{LargeTable: -1}) {
+		errors = append(errors, ValidationError{
+			Field:      condition.Field.Name,
+			Operator:   d.Operator,
+			Value:      d.Value,
+			Message:    d.Message,
+			Severity:   ValidationSeverity(d.Severity),
+			Suggestion: d.Suggestion,
+		})
+	}
 	return errors
 }
 
+// lintEncoding returns a condition in encoded form without the URL escaping of
+// QueryBuilder.Where, so values are linted as entered
+func lintEncoding(condition QueryCondition) string {
+	qb := query.New().TimeZone(time.Local)
+	if dateRange, ok := namedDateRanges[condition.Operator]; ok {
+		return qb.On(condition.Field.Name, dateRange).BuildQuery()
+	}
+	if condition.StartDate != nil && addDateCondition(qb, condition) {
+		return qb.BuildQuery()
+	}
+	if condition.Operator == query.OpIsEmpty || condition.Operator == query.OpIsNotEmpty {
+		return condition.Field.Name + string(condition.Operator)
+	}
+	return condition.Field.Name + string(condition.Operator) + condition.Value
+}
+
 // ValidateQuery validates a complete query built from conditions
 func (v *QueryValidator) ValidateQuery(conditions []QueryCondition) ValidationResult {
 	var allErrors []ValidationError
@@ -150,74 +164,6 @@ func (v *QueryValidator) ValidateQuery(conditions []QueryCondition) ValidationRe
 	}
 }
 
-// validateOperatorForFieldType checks if operator is compatible with field type
-func (v *QueryValidator) validateOperatorForFieldType(field FieldMetadata, op query.Operator) []ValidationError {
-	var errors []ValidationError
-
-	switch field.Type {
-	case FieldTypeReference:
-		// Reference fields should typically use = or !=, CONTAINS for display values
-		if op != query.OpEquals && op != query.OpNotEquals && op != query.OpContains && op != query.OpStartsWith {
-			errors = append(errors, ValidationError{
-				Field:      field.Name,
-				Operator:   string(op),
-				Message:    "Reference fields work best with =, !=, CONTAINS, or STARTSWITH operators",
-				Severity:   SeverityWarning,
-				Suggestion: "Consider using = for sys_id or CONTAINS for display names",
-			})
-		}
-
-	case FieldTypeDate, FieldTypeDateTime:
-		// Date fields should use comparison operators or range operators
-		validDateOps := []query.Operator{
-			query.OpEquals, query.OpNotEquals, query.OpGreaterThan, query.OpGreaterThanOrEqual,
-			query.OpLessThan, query.OpLessThanOrEqual, query.OpBetween, query.OpBefore, query.OpAfter,
-		}
-		isValid := false
-		for _, validOp := range validDateOps {
-			if op == validOp {
-				isValid = true
-				break
-			}
-		}
-		if !isValid {
-			errors = append(errors, ValidationError{
-				Field:      field.Name,
-				Operator:   string(op),
-				Message:    "Date fields should use comparison operators (=, !=, >, >=, <, <=) or date operators",
-				Severity:   SeverityWarning,
-				Suggestion: "Use >= for 'on or after' or <= for 'on or before'",
-			})
-		}
-
-	case FieldTypeInteger, FieldTypeDecimal:
-		// Numeric fields shouldn't use text operators
-		if op == query.OpContains || op == query.OpStartsWith || op == query.OpEndsWith {
-			errors = append(errors, ValidationError{
-				Field:      field.Name,
-				Operator:   string(op),
-				Message:    "Numeric fields should not use text operators (CONTAINS, STARTSWITH, ENDSWITH)",
-				Severity:   SeverityWarning,
-				Suggestion: "Use comparison operators (=, !=, >, >=, <, <=) for numbers",
-			})
-		}
-
-	case FieldTypeBoolean:
-		// Boolean fields should only use = or !=
-		if op != query.OpEquals && op != query.OpNotEquals {
-			errors = append(errors, ValidationError{
-				Field:      field.Name,
-				Operator:   string(op),
-				Message:    "Boolean fields should only use = or != operators",
-				Severity:   SeverityWarning,
-				Suggestion: "Use = true/false or != true/false",
-			})
-		}
-	}
-
-	return errors
-}
-
 // validateValue validates the value for a condition
 func (v *QueryValidator) validateValue(condition QueryCondition) []ValidationError {
 	var errors []ValidationError
@@ -238,107 +184,6 @@ func (v *QueryValidator) validateValue(condition QueryCondition) []ValidationErr
 		return errors
 	}
 
-	// Type-specific validation
-	switch condition.Field.Type {
-	case FieldTypeInteger:
-		if condition.Value != "" {
-			if _, err := strconv.Atoi(condition.Value); err != nil {
-				errors = append(errors, ValidationError{
-					Field:      condition.Field.Name,
-					Value:      condition.Value,
-					Message:    "Value must be a valid integer",
-					Severity:   SeverityError,
-					Suggestion: "Enter a whole number (e.g., 123)",
-				})
-			}
-		}
-
-	case FieldTypeDecimal:
-		if condition.Value != "" {
-			if _, err := strconv.ParseFloat(condition.Value, 64); err != nil {
-				errors = append(errors, ValidationError{
-					Field:      condition.Field.Name,
-					Value:      condition.Value,
-					Message:    "Value must be a valid decimal number",
-					Severity:   SeverityError,
-					Suggestion: "Enter a decimal number (e.g., 123.45)",
-				})
-			}
-		}
-
-	case FieldTypeBoolean:
-		if condition.Value != "" {
-			value := strings.ToLower(strings.TrimSpace(condition.Value))
-			if value != "true" && value != "false" && value != "1" && value != "0" {
-				errors = append(errors, ValidationError{
-					Field:      condition.Field.Name,
-					Value:      condition.Value,
-					Message:    "Boolean value must be true, false, 1, or 0",
-					Severity:   SeverityError,
-					Suggestion: "Use 'true', 'false', '1', or '0'",
-				})
-			}
-		}
-
-	case FieldTypeDate, FieldTypeDateTime:
-		if condition.Value != "" {
-			// Try to parse ServiceNow date format
-			if !v.isValidServiceNowDate(condition.Value) {
-				errors = append(errors, ValidationError{
-					Field:      condition.Field.Name,
-					Value:      condition.Value,
-					Message:    "Invalid date format",
-					Severity:   SeverityError,
-					Suggestion: "Use format: YYYY-MM-DD or YYYY-MM-DD HH:MM:SS",
-				})
-			}
-		}
-
-	case FieldTypeChoice:
-		// For choice fields, warn if value might not be a valid choice
-		if condition.Value != "" && len(condition.Field.Choices) > 0 {
-			validChoice := false
-			for _, choice := range condition.Field.Choices {
-				if choice.Value == condition.Value || choice.Label == condition.Value {
-					validChoice = true
-					break
-				}
-			}
-			if !validChoice {
-				errors = append(errors, ValidationError{
-					Field:      condition.Field.Name,
-					Value:      condition.Value,
-					Message:    "Value may not be a valid choice for this field",
-					Severity:   SeverityWarning,
-					Suggestion: "Use the choice dropdown to select valid values",
-				})
-			}
-		}
-
-	case FieldTypeString:
-		// Check for potentially problematic characters
-		if strings.Contains(condition.Value, "^") || strings.Contains(condition.Value, "=") {
-			errors = append(errors, ValidationError{
-				Field:      condition.Field.Name,
-				Value:      condition.Value,
-				Message:    "Value contains special characters that may cause issues",
-				Severity:   SeverityWarning,
-				Suggestion: "Special characters (^ =) in values may need to be escaped",
-			})
-		}
-
-		// Check for very long strings that might cause performance issues
-		if len(condition.Value) > 4000 {
-			errors = append(errors, ValidationError{
-				Field:      condition.Field.Name,
-				Value:      fmt.Sprintf("%.50s...", condition.Value),
-				Message:    "Very long values may cause performance issues",
-				Severity:   SeverityWarning,
-				Suggestion: "Consider using shorter, more specific search terms",
-			})
-		}
-	}
-
 	return errors
 }
 
@@ -460,25 +305,6 @@ func (v *QueryValidator) buildQueryString(conditions []QueryCondition) string {
 	return strings.Join(parts, "^")
 }
 
-func (v *QueryValidator) isValidServiceNowDate(dateStr string) bool {
-	// Common ServiceNow date formats
-	formats := []string{
-		"2006-01-02",
-		"2006-01-02 15:04:05",
-		"01-02-2006",
-		"01/02/2006",
-		"2006-01-02T15:04:05Z",
-	}
-
-	for _, format := range formats {
-		if _, err := time.Parse(format, dateStr); err == nil {
-			return true
-		}
-	}
-
-	return false
-}
-
 func (v *QueryValidator) hasBalancedParentheses(s string) bool {
 	count := 0
 	for _, char := range s {
@@ -510,6 +336,19 @@ func (v *QueryValidator) ValidateRawQuery(rawQuery string) ValidationResult {
 	syntaxErrors := v.validateQuerySyntax(rawQuery)
 	errors = append(errors, syntaxErrors...)
 
+	if v.schema != nil {
+		for _, d := range lint.Check(v.schema, rawQuery, lint.Options{LargeTable: -1}) {
+			errors = append(errors, ValidationError{
+				Field:      d.Field,
+				Operator:   d.Operator,
+				Value:      d.Value,
+				Message:    d.Message,
+				Severity:   ValidationSeverity(d.Severity),
+				Suggestion: d.Suggestion,
+			})
+		}
+	}
+
 	// Check for SQL injection patterns (basic check)
 	sqlPatterns := []string{
 		"drop table", "delete from", "update set", "insert into",
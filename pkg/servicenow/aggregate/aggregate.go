@@ -147,6 +147,12 @@ func (aq *AggregateQuery) Where(field string, operator query.Operator, value int
 	return aq
 }
 
+// WhereQuery replaces the WHERE conditions with those of a query builder
+func (aq *AggregateQuery) WhereQuery(qb *query.QueryBuilder) *AggregateQuery {
+	aq.queryBuilder = qb.Clone()
+	return aq
+}
+
 // And adds an AND condition
 func (aq *AggregateQuery) And() *AggregateQuery {
 	aq.queryBuilder.And()
@@ -0,0 +1,380 @@
+package bulk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/batch"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/undo"
+)
+
+// DefaultChunkSize is the number of records written per batch request
+const DefaultChunkSize = 100
+
+// DefaultMaxRecords is the most records one run may change unless raised
+const DefaultMaxRecords = 1000
+
+// DefaultSampleSize is the number of matching records shown in a preview
+const DefaultSampleSize = 5
+
+// pageSize is the number of sys_ids read per request when collecting matches
+const pageSize = 1000
+
+// Action is the change applied to every matching record
+type Action string
+
+const (
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Options controls a query-driven update or delete
+type Options struct {
+	Query        string                 // Encoded query selecting the records; required
+	Set          map[string]interface{} // Field values written by an update
+	ExpectCount  int                    // Refuse to run unless exactly this many records match; 0 skips the check
+	MaxRecords   int                    // Refuse to run when more records match (default 1000); negative disables the guard
+	ChunkSize    int                    // Records per batch request (default 100)
+	SampleSize   int                    // Matching records returned by Plan (default 5)
+	SampleFields []string               // Fields of the sample (default: sys_id, the fields set and sys_updated_on)
+	Recorder     *undo.Recorder         // Captures before-images so the run can be undone
+	Progress     func(Progress)         // Called after each chunk
+}
+
+// Plan describes the records a bulk operation would change
+type Plan struct {
+	Table  string                   `json:"table"`
+	Action Action                   `json:"action"`
+	Query  string                   `json:"query"`
+	Set    map[string]interface{}   `json:"set,omitempty"`
+	Count  int                      `json:"count"`
+	Fields []string                 `json:"fields"` // Fields of the sample records
+	Sample []map[string]interface{} `json:"sample"`
+}
+
+// Progress reports how far a run has got
+type Progress struct {
+	Done      int // Records processed, succeeded or failed
+	Succeeded int
+	Failed    int
+	Total     int
+}
+
+// Failure is a record a run could not change
+type Failure struct {
+	SysID   string `json:"sys_id"`
+	Status  int    `json:"status,omitempty"` // HTTP status of the request, 0 when it was not sent
+	Message string `json:"message"`
+}
+
+// Result summarizes a run
+type Result struct {
+	Table       string        `json:"table"`
+	Action      Action        `json:"action"`
+	Query       string        `json:"query"`
+	Matched     int           `json:"matched"`
+	Succeeded   int           `json:"succeeded"`
+	Failed      int           `json:"failed"`
+	Failures    []Failure     `json:"failures,omitempty"`
+	OperationID string        `json:"operation_id,omitempty"` // Undo operation holding the before-images
+	Duration    time.Duration `json:"duration"`
+}
+
+// GuardError reports that a safety check stopped a run before anything was written
+type GuardError struct {
+	Count  int
+	Reason string
+}
+
+func (e *GuardError) Error() string {
+	return e.Reason
+}
+
+// PlanUpdate counts and samples the records an update would change
+func PlanUpdate(client *core.Client, tableName string, options Options) (*Plan, error) {
+	return PlanWithContext(context.Background(), client, tableName, ActionUpdate, options)
+}
+
+// PlanDelete counts and samples the records a delete would remove
+func PlanDelete(client *core.Client, tableName string, options Options) (*Plan, error) {
+	return PlanWithContext(context.Background(), client, tableName, ActionDelete, options)
+}
+
+// PlanWithContext counts and samples the records an action would change
+func PlanWithContext(ctx context.Context, client *core.Client, tableName string, action Action, options Options) (*Plan, error) {
+	if err := validate(action, options); err != nil {
+		return nil, err
+	}
+	tc := table.NewTableClient(client, tableName)
+	count, err := tc.CountWithContext(ctx, options.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count matching records: %w", err)
+	}
+	plan := &Plan{Table: tableName, Action: action, Query: options.Query, Set: options.Set, Count: count, Fields: sampleFields(options)}
+
+	sampleSize := options.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = DefaultSampleSize
+	}
+	if count > 0 {
+		plan.Sample, err = tc.ListOptWithContext(ctx, table.ListOptions{
+			Query:                options.Query,
+			Fields:               plan.Fields,
+			Limit:                sampleSize,
+			DisplayValue:         core.DisplayFalse,
+			ExcludeReferenceLink: true,
+			NoCount:              true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sample records: %w", err)
+		}
+	}
+	if plan.Sample == nil {
+		plan.Sample = []map[string]interface{}{}
+	}
+	return plan, nil
+}
+
+// UpdateWhere writes options.Set to every record matching options.Query
+func UpdateWhere(client *core.Client, tableName string, options Options) (*Result, error) {
+	return UpdateWhereWithContext(context.Background(), client, tableName, options)
+}
+
+// UpdateWhereWithContext writes options.Set to every record matching options.Query with context support
+func UpdateWhereWithContext(ctx context.Context, client *core.Client, tableName string, options Options) (*Result, error) {
+	return run(ctx, client, tableName, ActionUpdate, options)
+}
+
+// DeleteWhere deletes every record matching options.Query
+func DeleteWhere(client *core.Client, tableName string, options Options) (*Result, error) {
+	return DeleteWhereWithContext(context.Background(), client, tableName, options)
+}
+
+// DeleteWhereWithContext deletes every record matching options.Query with context support
+func DeleteWhereWithContext(ctx context.Context, client *core.Client, tableName string, options Options) (*Result, error) {
+	return run(ctx, client, tableName, ActionDelete, options)
+}
+
+// run checks the guards, then collects the matching sys_ids up front, so that
+// records changed by earlier chunks don't shift later pages, and writes them in
+// chunks through the batch API. Failed records are reported and the run goes on.
+func run(ctx context.Context, client *core.Client, tableName string, action Action, options Options) (*Result, error) {
+	start := time.Now()
+	if err := validate(action, options); err != nil {
+		return nil, err
+	}
+	tc := table.NewTableClient(client, tableName)
+	count, err := tc.CountWithContext(ctx, options.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count matching records: %w", err)
+	}
+	if err := checkGuards(count, options); err != nil {
+		return nil, err
+	}
+
+	sysIDs, err := matchingIDs(ctx, tc, options.Query)
+	if err != nil {
+		return nil, err
+	}
+	// The match set may have changed since it was counted
+	if len(sysIDs) != count {
+		return nil, &GuardError{Count: len(sysIDs), Reason: fmt.Sprintf("%d records were collected but %d match; the matches changed while they were read, run again", len(sysIDs), count)}
+	}
+
+	result := &Result{Table: tableName, Action: action, Query: options.Query, Matched: len(sysIDs)}
+	if options.Recorder != nil {
+		result.OperationID = options.Recorder.Operation().ID
+	}
+	chunkSize := options.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	batchClient := batch.NewBatchClient(client)
+	for begin := 0; begin < len(sysIDs); begin += chunkSize {
+		if err := ctx.Err(); err != nil {
+			result.Duration = time.Since(start)
+			return result, err
+		}
+		chunk := sysIDs[begin:min(begin+chunkSize, len(sysIDs))]
+		writeChunk(ctx, batchClient, tableName, action, chunk, options, result)
+		// Journal each chunk, so a run that dies later can still be undone
+		if options.Recorder != nil {
+			if err := options.Recorder.Save(); err != nil {
+				result.Duration = time.Since(start)
+				return result, fmt.Errorf("failed to save before-images: %w", err)
+			}
+		}
+		if options.Progress != nil {
+			options.Progress(Progress{
+				Done:      result.Succeeded + result.Failed,
+				Succeeded: result.Succeeded,
+				Failed:    result.Failed,
+				Total:     len(sysIDs),
+			})
+		}
+	}
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// writeChunk captures before-images and sends one batch request for a chunk
+func writeChunk(ctx context.Context, batchClient *batch.BatchClient, tableName string, action Action, chunk []string, options Options, result *Result) {
+	fail := func(sysID string, status int, message string) {
+		result.Failed++
+		result.Failures = append(result.Failures, Failure{SysID: sysID, Status: status, Message: message})
+	}
+
+	// A record that can't be captured is not changed, so it can always be undone
+	var captured map[string]*undo.Entry
+	var captureErr error
+	if options.Recorder != nil {
+		undoAction, fields := undo.ActionDelete, []string(nil)
+		if action == ActionUpdate {
+			undoAction, fields = undo.ActionUpdate, setFields(options.Set)
+		}
+		captured, captureErr = options.Recorder.CaptureAll(ctx, tableName, chunk, undoAction, fields)
+	}
+
+	builder := batchClient.NewBatch()
+	var sent []string
+	for _, sysID := range chunk {
+		if options.Recorder != nil {
+			if captureErr != nil {
+				fail(sysID, 0, captureErr.Error())
+				continue
+			}
+			if captured[sysID] == nil {
+				fail(sysID, 0, "record not found when capturing its before-image")
+				continue
+			}
+		}
+		if action == ActionUpdate {
+			builder.Update(sysID, tableName, sysID, options.Set)
+		} else {
+			builder.Delete(sysID, tableName, sysID)
+		}
+		sent = append(sent, sysID)
+	}
+	if len(sent) == 0 {
+		return
+	}
+
+	batchResult, err := builder.ExecuteWithContext(ctx)
+	for _, sysID := range sent {
+		switch {
+		case err != nil:
+			fail(sysID, 0, fmt.Sprintf("batch request failed: %v", err))
+		case batchResult.Errors[sysID] != nil:
+			reqErr := batchResult.Errors[sysID]
+			fail(sysID, reqErr.StatusCode, strings.TrimSpace(reqErr.StatusText+": "+reqErr.ErrorDetail))
+		case batchResult.Results[sysID] == nil:
+			fail(sysID, 0, "no response for request in batch")
+		case batchResult.Results[sysID].StatusCode >= 300:
+			fail(sysID, batchResult.Results[sysID].StatusCode, batchResult.Results[sysID].StatusText)
+		default:
+			result.Succeeded++
+			if options.Recorder != nil {
+				var after map[string]interface{}
+				if action == ActionUpdate {
+					after, _ = batch.ExtractRecordData(batchResult.Results[sysID])
+				}
+				options.Recorder.MarkApplied(tableName, sysID, after)
+			}
+		}
+	}
+}
+
+// matchingIDs reads the sys_ids of every record matching the query. Pages are
+// keyed on sys_id and only an empty page ends the scan: ACLs can cut pages
+// short without the matches running out.
+func matchingIDs(ctx context.Context, tc *table.TableClient, encoded string) ([]string, error) {
+	var sysIDs []string
+	after := ""
+	for {
+		keyset := encoded
+		if after != "" {
+			keyset = query.AndAll(encoded, "sys_id>"+after)
+		}
+		records, err := tc.ListOptWithContext(ctx, table.ListOptions{
+			Query:                keyset + "^ORDERBYsys_id",
+			Fields:               []string{"sys_id"},
+			Limit:                pageSize,
+			DisplayValue:         core.DisplayFalse,
+			ExcludeReferenceLink: true,
+			NoCount:              true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read matching records: %w", err)
+		}
+		if len(records) == 0 {
+			return sysIDs, nil
+		}
+		last := after
+		for _, record := range records {
+			if sysID, ok := record["sys_id"].(string); ok && sysID != "" {
+				sysIDs = append(sysIDs, sysID)
+				last = max(last, sysID)
+			}
+		}
+		if last == after {
+			return nil, fmt.Errorf("failed to read matching records: no progress past sys_id %q", after)
+		}
+		after = last
+	}
+}
+
+func validate(action Action, options Options) error {
+	if strings.TrimSpace(options.Query) == "" {
+		return fmt.Errorf("a query is required: an empty query matches every record")
+	}
+	if strings.Contains(options.Query, "ORDERBY") {
+		return fmt.Errorf("matches are read in sys_id order; remove ORDERBY from the query")
+	}
+	if action == ActionUpdate && len(options.Set) == 0 {
+		return fmt.Errorf("no field values to set")
+	}
+	if options.ExpectCount < 0 {
+		return fmt.Errorf("expected count must not be negative")
+	}
+	return nil
+}
+
+// checkGuards applies the expected count and maximum records checks
+func checkGuards(count int, options Options) error {
+	if options.ExpectCount > 0 && count != options.ExpectCount {
+		return &GuardError{Count: count, Reason: fmt.Sprintf("%d records match, expected %d", count, options.ExpectCount)}
+	}
+	maxRecords := options.MaxRecords
+	if maxRecords == 0 {
+		maxRecords = DefaultMaxRecords
+	}
+	if maxRecords > 0 && count > maxRecords {
+		return &GuardError{Count: count, Reason: fmt.Sprintf("%d records match, more than the maximum of %d", count, maxRecords)}
+	}
+	return nil
+}
+
+func sampleFields(options Options) []string {
+	if len(options.SampleFields) > 0 {
+		return options.SampleFields
+	}
+	fields := []string{"sys_id"}
+	fields = append(fields, setFields(options.Set)...)
+	return append(fields, "sys_updated_on")
+}
+
+// setFields returns the names of the fields an update writes, in order
+func setFields(set map[string]interface{}) []string {
+	fields := make([]string, 0, len(set))
+	for field := range set {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-resty/resty/v2"
@@ -20,6 +23,10 @@ type Client struct {
 	rateLimiter   *ratelimit.ServiceNowLimiter
 	retryConfig   retry.Config
 	timeout       time.Duration
+	authMu        sync.Mutex
+	authClient    *resty.Client // Scratch client that auth providers apply headers to
+	timeZoneMu    sync.RWMutex
+	timeZone      *time.Location // Timezone of display values, UTC when unset
 }
 
 func NewClientBasicAuth(instanceURL, username, password string) (*Client, error) {
@@ -124,13 +131,42 @@ func (c *Client) RawRequestWithContext(ctx context.Context, method, path string,
 	})
 }
 
+// RawRequestWithHeadersContext performs a low-level API call and also returns the response headers
+// (e.g. X-Total-Count or Link for pagination)
+func (c *Client) RawRequestWithHeadersContext(ctx context.Context, method, path string, body interface{}, params map[string]string, result interface{}) (http.Header, error) {
+	endpointType := ratelimit.DetectEndpointType(path)
+	if err := c.rateLimiter.Wait(ctx, endpointType); err != nil {
+		return nil, fmt.Errorf("rate limit wait failed: %w", err)
+	}
+
+	var headers http.Header
+	err := retry.Do(ctx, c.retryConfig, func() error {
+		resp, err := c.execute(method, path, body, params)
+		if resp != nil {
+			headers = resp.Header()
+		}
+		return c.HandleResponse(resp, err, result, FormatJSON)
+	})
+	return headers, err
+}
+
 // executeRequest performs the actual HTTP request
 func (c *Client) executeRequest(method, path string, body interface{}, params map[string]string, result interface{}, format string) error {
-	if err := c.Auth.Apply(c.Client); err != nil {
-		return fmt.Errorf("failed to apply auth: %w", err)
+	resp, err := c.execute(method, path, body, params)
+	return c.HandleResponse(resp, err, result, format)
+}
+
+// execute sends an authenticated request and returns the raw response
+func (c *Client) execute(method, path string, body interface{}, params map[string]string) (*resty.Response, error) {
+	authHeaders, err := c.authHeaders()
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply auth: %w", err)
 	}
 
 	req := c.Client.R()
+	for k := range authHeaders {
+		req.SetHeader(k, authHeaders.Get(k))
+	}
 	if body != nil {
 		req.SetBody(body)
 	}
@@ -138,8 +174,22 @@ func (c *Client) executeRequest(method, path string, body interface{}, params ma
 		req.SetQueryParam(k, v)
 	}
 	
-	resp, err := req.Execute(method, path)
-	return c.HandleResponse(resp, err, result, format)
+	return req.Execute(method, path)
+}
+
+// authHeaders applies the auth provider to a private scratch client and returns the
+// resulting headers, so concurrent requests never mutate the shared resty client
+func (c *Client) authHeaders() (http.Header, error) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	if c.authClient == nil {
+		c.authClient = resty.New()
+	}
+	if err := c.Auth.Apply(c.authClient); err != nil {
+		return nil, err
+	}
+	return c.authClient.Header.Clone(), nil
 }
 
 // RawRootRequest allows low-level calls to root instance URL (e.g., for .do endpoints) with format
@@ -165,15 +215,15 @@ func (c *Client) RawRootRequestWithContext(ctx context.Context, method, path str
 
 // executeRootRequest performs the actual HTTP request to root URL
 func (c *Client) executeRootRequest(method, path string, body interface{}, params map[string]string, result interface{}, format string) error {
-	originalBase := c.Client.BaseURL
-	c.Client.SetBaseURL(c.InstanceURL)
-	defer c.Client.SetBaseURL(originalBase)
-
-	if err := c.Auth.Apply(c.Client); err != nil {
+	authHeaders, err := c.authHeaders()
+	if err != nil {
 		return fmt.Errorf("failed to apply auth: %w", err)
 	}
 
 	req := c.Client.R()
+	for k := range authHeaders {
+		req.SetHeader(k, authHeaders.Get(k))
+	}
 	if format == FormatXML {
 		req.SetHeader("Accept", "application/xml")
 	}
@@ -183,7 +233,8 @@ func (c *Client) executeRootRequest(method, path string, body interface{}, param
 	for k, v := range params {
 		req.SetQueryParam(k, v)
 	}
-	resp, err := req.Execute(method, path)
+	// An absolute URL bypasses the base URL without changing it for concurrent requests
+	resp, err := req.Execute(method, strings.TrimSuffix(c.InstanceURL, "/")+path)
 	return c.HandleResponse(resp, err, result, format)
 }
 
@@ -198,6 +249,30 @@ func (c *Client) GetTimeout() time.Duration {
 	return c.timeout
 }
 
+// SetTimeZone sets the timezone display values are rendered in (the session user's timezone)
+func (c *Client) SetTimeZone(location *time.Location) {
+	c.timeZoneMu.Lock()
+	defer c.timeZoneMu.Unlock()
+	c.timeZone = location
+}
+
+// HasTimeZone reports whether a timezone was set with SetTimeZone
+func (c *Client) HasTimeZone() bool {
+	c.timeZoneMu.RLock()
+	defer c.timeZoneMu.RUnlock()
+	return c.timeZone != nil
+}
+
+// GetTimeZone returns the timezone of display values, UTC unless set
+func (c *Client) GetTimeZone() *time.Location {
+	c.timeZoneMu.RLock()
+	defer c.timeZoneMu.RUnlock()
+	if c.timeZone == nil {
+		return time.UTC
+	}
+	return c.timeZone
+}
+
 // SetRetryConfig updates the retry configuration
 func (c *Client) SetRetryConfig(config retry.Config) {
 	c.retryConfig = config
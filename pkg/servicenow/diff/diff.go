@@ -0,0 +1,268 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+)
+
+// ChangeType classifies a record difference
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"   // Only present on the "to" side
+	ChangeRemoved ChangeType = "removed" // Only present on the "from" side
+	ChangeChanged ChangeType = "changed" // Present on both sides with different values
+)
+
+// Options controls how records are matched and compared
+type Options struct {
+	KeyFields           []string // Fields identifying the same record on both sides (e.g. name)
+	Fields              []string // Fields to compare (default: all returned fields)
+	IgnoreFields        []string // Fields never compared
+	IncludeSystemFields bool     // Compare sys_* bookkeeping fields (ignored by default)
+}
+
+// FieldChange is a single field whose value differs
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// RecordDiff describes how one record differs between the two sides
+type RecordDiff struct {
+	Key       string                 `json:"key"`
+	Type      ChangeType             `json:"type"`
+	FromSysID string                 `json:"from_sys_id,omitempty"`
+	ToSysID   string                 `json:"to_sys_id,omitempty"`
+	Changes   []FieldChange          `json:"changes,omitempty"`
+	From      map[string]interface{} `json:"-"`
+	To        map[string]interface{} `json:"-"`
+}
+
+// Result is the outcome of comparing two record sets
+type Result struct {
+	Table         string        `json:"table"`
+	KeyFields     []string      `json:"key_fields"`
+	Added         []*RecordDiff `json:"added"`
+	Removed       []*RecordDiff `json:"removed"`
+	Changed       []*RecordDiff `json:"changed"`
+	Unchanged     int           `json:"unchanged"`
+	DuplicateKeys []string      `json:"duplicate_keys,omitempty"` // Keys matching several records on one side
+	MissingKeys   int           `json:"missing_keys,omitempty"`   // Records skipped because a key field was empty
+}
+
+// HasDifferences reports whether any record was added, removed or changed
+func (r *Result) HasDifferences() bool {
+	return len(r.Added) > 0 || len(r.Removed) > 0 || len(r.Changed) > 0
+}
+
+// Compare matches two record sets by key fields and reports field-level differences
+func Compare(from, to []map[string]interface{}, opts Options) (*Result, error) {
+	if len(opts.KeyFields) == 0 {
+		return nil, fmt.Errorf("at least one key field is required")
+	}
+
+	result := &Result{
+		KeyFields: opts.KeyFields,
+		Added:     make([]*RecordDiff, 0),
+		Removed:   make([]*RecordDiff, 0),
+		Changed:   make([]*RecordDiff, 0),
+	}
+	fromIndex := result.index(from, opts.KeyFields)
+	toIndex := result.index(to, opts.KeyFields)
+
+	keys := make(map[string]bool)
+	for key := range fromIndex {
+		keys[key] = true
+	}
+	for key := range toIndex {
+		keys[key] = true
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	for _, key := range sortedKeys {
+		fromRecord, inFrom := fromIndex[key]
+		toRecord, inTo := toIndex[key]
+		switch {
+		case inFrom && !inTo:
+			result.Removed = append(result.Removed, &RecordDiff{
+				Key: key, Type: ChangeRemoved, FromSysID: Value(fromRecord["sys_id"]), From: fromRecord,
+			})
+		case !inFrom && inTo:
+			result.Added = append(result.Added, &RecordDiff{
+				Key: key, Type: ChangeAdded, ToSysID: Value(toRecord["sys_id"]), To: toRecord,
+			})
+		default:
+			changes := compareFields(fromRecord, toRecord, opts)
+			if len(changes) == 0 {
+				result.Unchanged++
+				continue
+			}
+			result.Changed = append(result.Changed, &RecordDiff{
+				Key:       key,
+				Type:      ChangeChanged,
+				FromSysID: Value(fromRecord["sys_id"]),
+				ToSysID:   Value(toRecord["sys_id"]),
+				Changes:   changes,
+				From:      fromRecord,
+				To:        toRecord,
+			})
+		}
+	}
+	return result, nil
+}
+
+// FetchAndCompare reads matching records from two instances and compares them.
+// Display values are compared so reference fields match across instances.
+func FetchAndCompare(ctx context.Context, from, to *core.Client, tableName, encodedQuery string, opts Options) (*Result, error) {
+	listOptions := table.ListOptions{
+		Query:                encodedQuery,
+		DisplayValue:         core.DisplayTrue,
+		ExcludeReferenceLink: true,
+	}
+	if len(opts.Fields) > 0 {
+		listOptions.Fields = mergeFields(opts.KeyFields, opts.Fields, []string{"sys_id"})
+	}
+
+	fromRecords, err := table.NewTableClient(from, tableName).PaginateParallel(ctx, listOptions, table.ParallelOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s from %s: %w", tableName, from.InstanceURL, err)
+	}
+	toRecords, err := table.NewTableClient(to, tableName).PaginateParallel(ctx, listOptions, table.ParallelOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s from %s: %w", tableName, to.InstanceURL, err)
+	}
+
+	result, err := Compare(fromRecords, toRecords, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.Table = tableName
+	return result, nil
+}
+
+// ComparedFields returns the fields of a record pair that Compare would look at, sorted
+func ComparedFields(from, to map[string]interface{}, opts Options) []string {
+	ignored := make(map[string]bool, len(opts.IgnoreFields)+len(opts.KeyFields))
+	for _, field := range opts.IgnoreFields {
+		ignored[field] = true
+	}
+	for _, field := range opts.KeyFields {
+		ignored[field] = true
+	}
+
+	candidates := opts.Fields
+	if len(candidates) == 0 {
+		seen := make(map[string]bool)
+		for _, record := range []map[string]interface{}{from, to} {
+			for field := range record {
+				if !seen[field] {
+					seen[field] = true
+					candidates = append(candidates, field)
+				}
+			}
+		}
+	}
+
+	var fields []string
+	for _, field := range candidates {
+		if ignored[field] {
+			continue
+		}
+		if !opts.IncludeSystemFields && strings.HasPrefix(field, "sys_") {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// compareFields returns the differing fields of two matched records
+func compareFields(from, to map[string]interface{}, opts Options) []FieldChange {
+	var changes []FieldChange
+	for _, field := range ComparedFields(from, to, opts) {
+		fromValue, toValue := Value(from[field]), Value(to[field])
+		if fromValue != toValue {
+			changes = append(changes, FieldChange{Field: field, From: fromValue, To: toValue})
+		}
+	}
+	return changes
+}
+
+// index groups records by key, recording duplicates and records without a key
+func (r *Result) index(records []map[string]interface{}, keyFields []string) map[string]map[string]interface{} {
+	index := make(map[string]map[string]interface{}, len(records))
+	duplicates := make(map[string]bool)
+	for _, record := range records {
+		key, ok := Key(record, keyFields)
+		if !ok {
+			r.MissingKeys++
+			continue
+		}
+		if _, exists := index[key]; exists {
+			if !duplicates[key] {
+				duplicates[key] = true
+				r.DuplicateKeys = append(r.DuplicateKeys, key)
+			}
+			continue
+		}
+		index[key] = record
+	}
+	return index
+}
+
+// Key builds the natural key of a record as "field=value" pairs joined with ","
+func Key(record map[string]interface{}, keyFields []string) (string, bool) {
+	parts := make([]string, len(keyFields))
+	for i, field := range keyFields {
+		value := Value(record[field])
+		if value == "" {
+			return "", false
+		}
+		parts[i] = field + "=" + value
+	}
+	return strings.Join(parts, ","), true
+}
+
+// Value renders a field value as a comparable string, unwrapping
+// {display_value, value} and {link, value} objects
+func Value(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case map[string]interface{}:
+		if display, ok := val["display_value"]; ok {
+			return Value(display)
+		}
+		return Value(val["value"])
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func mergeFields(groups ...[]string) []string {
+	seen := make(map[string]bool)
+	var fields []string
+	for _, group := range groups {
+		for _, field := range group {
+			if !seen[field] {
+				seen[field] = true
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
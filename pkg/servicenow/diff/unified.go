@@ -0,0 +1,58 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteUnified writes the result as a unified diff with one hunk per record.
+// Added and removed records list all compared fields; changed records list
+// only the fields that differ.
+func WriteUnified(w io.Writer, result *Result, fromLabel, toLabel string, opts Options) error {
+	if _, err := fmt.Fprintf(w, "--- %s/%s\n+++ %s/%s\n", fromLabel, result.Table, toLabel, result.Table); err != nil {
+		return err
+	}
+
+	records := make([]*RecordDiff, 0, len(result.Added)+len(result.Removed)+len(result.Changed))
+	records = append(records, result.Removed...)
+	records = append(records, result.Added...)
+	records = append(records, result.Changed...)
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Key < records[j].Key })
+
+	for _, record := range records {
+		var lines []string
+		switch record.Type {
+		case ChangeRemoved:
+			lines = append(lines, keyLines("-", record.From, opts.KeyFields)...)
+			for _, field := range ComparedFields(record.From, nil, opts) {
+				lines = append(lines, fmt.Sprintf("-%s: %s", field, Value(record.From[field])))
+			}
+		case ChangeAdded:
+			lines = append(lines, keyLines("+", record.To, opts.KeyFields)...)
+			for _, field := range ComparedFields(nil, record.To, opts) {
+				lines = append(lines, fmt.Sprintf("+%s: %s", field, Value(record.To[field])))
+			}
+		case ChangeChanged:
+			lines = append(lines, keyLines(" ", record.From, opts.KeyFields)...)
+			for _, change := range record.Changes {
+				lines = append(lines, fmt.Sprintf("-%s: %s", change.Field, change.From))
+				lines = append(lines, fmt.Sprintf("+%s: %s", change.Field, change.To))
+			}
+		}
+		if _, err := fmt.Fprintf(w, "@@ %s (%s) @@\n%s\n", record.Key, record.Type, strings.Join(lines, "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyLines renders the key fields of a record with a diff prefix
+func keyLines(prefix string, record map[string]interface{}, keyFields []string) []string {
+	lines := make([]string, len(keyFields))
+	for i, field := range keyFields {
+		lines[i] = fmt.Sprintf("%s%s: %s", prefix, field, Value(record[field]))
+	}
+	return lines
+}
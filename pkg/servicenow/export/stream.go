@@ -0,0 +1,651 @@
+package export
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/utils/xlsx"
+)
+
+// Format is a table export file format
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatJSON   Format = "json"
+	FormatXLSX   Format = "xlsx"
+)
+
+// ParseFormat parses csv, ndjson, json or xlsx
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(value)); format {
+	case FormatCSV, FormatNDJSON, FormatJSON, FormatXLSX:
+		return format, nil
+	}
+	return "", fmt.Errorf("invalid export format %q (use csv, ndjson, json or xlsx)", value)
+}
+
+// DefaultExportPageSize is the number of records fetched (and checkpointed) at a time
+const DefaultExportPageSize = 1000
+
+// TableOptions controls a streaming table export
+type TableOptions struct {
+	Query        string         // Encoded query (without ORDERBY: exports are ordered by sys_id)
+	Fields       []string       // Fields to export (default: all)
+	Format       Format         // File format (default csv)
+	Out          string         // Output file; parts are numbered before the extension
+	PageSize     int            // Records per request and checkpoint (default 1000)
+	MaxPartBytes int64          // Start a new part once a part reaches this size (0: one file)
+	Gzip         bool           // Compress csv, ndjson and json output
+	References   ReferenceMode  // Reference values to export
+	Restart      bool           // Ignore an existing checkpoint and start over
+	Progress     func(Progress) // Called after every page
+	Location     *time.Location // Timezone of xlsx date/times (default: the client's)
+}
+
+// Progress reports the state of a running export
+type Progress struct {
+	Records int           // Records exported, including those of resumed runs
+	Total   int           // Records matching the query when the export started
+	Parts   int           // Parts written so far
+	Bytes   int64         // Bytes written, over all parts
+	Elapsed time.Duration // Time spent in this run
+	Rate    float64       // Records per second in this run
+	ETA     time.Duration // Estimated time remaining (0 when unknown)
+}
+
+// Part is one output file of an export
+type Part struct {
+	Path    string `json:"path"`
+	Bytes   int64  `json:"bytes"`
+	Records int    `json:"records"`
+	After   string `json:"after,omitempty"` // sys_id the part starts after
+}
+
+// Checkpoint records how far an export got. It is saved next to the output after
+// every page and removed once the export completes.
+type Checkpoint struct {
+	Table        string        `json:"table"`
+	Query        string        `json:"query,omitempty"`
+	Fields       []string      `json:"fields,omitempty"`
+	Format       Format        `json:"format"`
+	Gzip         bool          `json:"gzip,omitempty"`
+	MaxPartBytes int64         `json:"max_part_bytes,omitempty"`
+	References   ReferenceMode `json:"references"`
+	Columns      []Column      `json:"columns,omitempty"`
+	After        string        `json:"after,omitempty"` // Last exported sys_id
+	Records      int           `json:"records"`
+	Parts        []Part        `json:"parts"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// TableResult summarizes a completed export
+type TableResult struct {
+	Table    string        `json:"table"`
+	Records  int           `json:"records"`
+	Parts    []Part        `json:"parts"`
+	Resumed  bool          `json:"resumed"`
+	Duration time.Duration `json:"duration"`
+}
+
+// CheckpointPath returns the checkpoint file of an export to out
+func CheckpointPath(out string) string {
+	return out + ".checkpoint"
+}
+
+// ExportTable streams every record of a table matching the query to disk
+func ExportTable(client *core.Client, tableName string, options TableOptions) (*TableResult, error) {
+	return ExportTableWithContext(context.Background(), client, tableName, options)
+}
+
+// ExportTableWithContext streams every record of a table matching the query to disk,
+// one page at a time. Pages are requested in sys_id order after the last exported
+// sys_id, so an interrupted export resumes from its checkpoint without gaps or
+// duplicates.
+func ExportTableWithContext(ctx context.Context, client *core.Client, tableName string, options TableOptions) (*TableResult, error) {
+	if options.Out == "" {
+		return nil, fmt.Errorf("an output file is required")
+	}
+	if options.Format == "" {
+		options.Format = FormatCSV
+	}
+	if options.PageSize <= 0 {
+		options.PageSize = DefaultExportPageSize
+	}
+	if strings.Contains(options.Query, "ORDERBY") {
+		return nil, fmt.Errorf("exports are ordered by sys_id; remove ORDERBY from the query")
+	}
+	if options.Gzip && options.Format == FormatXLSX {
+		return nil, fmt.Errorf("xlsx files are already compressed; gzip applies to csv, ndjson and json")
+	}
+	if options.Location == nil {
+		options.Location = client.GetTimeZone()
+	}
+
+	e := &tableExport{
+		table:   table.NewTableClient(client, tableName),
+		name:    tableName,
+		options: options,
+		display: options.References.DisplayValue(),
+	}
+	if err := e.open(); err != nil {
+		return nil, err
+	}
+	defer e.abort()
+
+	start := time.Now()
+	total, err := e.table.CountWithContext(ctx, options.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count records: %w", err)
+	}
+	exported := 0
+	for {
+		page, err := e.table.ListOptWithContext(ctx, table.ListOptions{
+			Query:        keysetQuery(options.Query, e.checkpoint.After),
+			Fields:       e.requestFields(),
+			Limit:        options.PageSize,
+			DisplayValue: e.display,
+			NoCount:      true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch records: %w", err)
+		}
+		if err := e.writePage(page); err != nil {
+			return nil, err
+		}
+		exported += len(page)
+
+		if options.Progress != nil {
+			progress := Progress{
+				Records: e.checkpoint.Records,
+				Total:   total,
+				Parts:   len(e.checkpoint.Parts),
+				Bytes:   e.bytes(),
+				Elapsed: time.Since(start),
+			}
+			if seconds := progress.Elapsed.Seconds(); seconds > 0 {
+				progress.Rate = float64(exported) / seconds
+			}
+			if progress.Rate > 0 && total > progress.Records {
+				progress.ETA = time.Duration(float64(total-progress.Records) / progress.Rate * float64(time.Second))
+			}
+			options.Progress(progress)
+		}
+		// Only an empty page ends the export: ACLs can shorten pages in the middle
+		if len(page) == 0 {
+			break
+		}
+	}
+
+	if err := e.finish(); err != nil {
+		return nil, err
+	}
+	return &TableResult{
+		Table:    tableName,
+		Records:  e.checkpoint.Records,
+		Parts:    e.checkpoint.Parts,
+		Resumed:  e.resumed,
+		Duration: time.Since(start),
+	}, nil
+}
+
+// keysetQuery restricts every ^NQ group of a query to sys_ids after the given one
+// and orders the result by sys_id
+func keysetQuery(query, after string) string {
+	groups := strings.Split(query, "^NQ")
+	for i, group := range groups {
+		if after == "" {
+			continue
+		}
+		if group == "" {
+			groups[i] = "sys_id>" + after
+		} else {
+			groups[i] = group + "^sys_id>" + after
+		}
+	}
+	if keyset := strings.Join(groups, "^NQ"); keyset != "" {
+		return keyset + "^ORDERBYsys_id"
+	}
+	return "ORDERBYsys_id"
+}
+
+type tableExport struct {
+	table      *table.TableClient
+	name       string
+	options    TableOptions
+	display    core.DisplayValueOptions
+	checkpoint Checkpoint
+	resumed    bool
+	part       partWriter
+}
+
+// open loads or starts the checkpoint and opens the part to write to
+func (e *tableExport) open() error {
+	path := CheckpointPath(e.options.Out)
+	fresh := Checkpoint{
+		Table:        e.name,
+		Query:        e.options.Query,
+		Fields:       e.options.Fields,
+		Format:       e.options.Format,
+		Gzip:         e.options.Gzip,
+		MaxPartBytes: e.options.MaxPartBytes,
+		References:   e.options.References,
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil && !e.options.Restart:
+		if err := json.Unmarshal(data, &e.checkpoint); err != nil {
+			return fmt.Errorf("failed to read checkpoint %s: %w", path, err)
+		}
+		if !sameExport(e.checkpoint, fresh) {
+			return fmt.Errorf("checkpoint %s belongs to a different export; restart to discard it", path)
+		}
+		e.resumed = true
+	case err == nil || os.IsNotExist(err):
+		e.checkpoint = fresh
+		e.checkpoint.Parts = []Part{{Path: e.partPath(1)}}
+	default:
+		return fmt.Errorf("failed to read checkpoint %s: %w", path, err)
+	}
+
+	// Parts started after the last checkpoint are written again
+	for i := len(e.checkpoint.Parts) + 1; e.options.MaxPartBytes > 0; i++ {
+		if err := os.Remove(e.partPath(i)); err != nil {
+			break
+		}
+	}
+	return e.openPart()
+}
+
+// sameExport reports whether a checkpoint was written by an export with the same options
+func sameExport(a, b Checkpoint) bool {
+	return a.Table == b.Table && a.Query == b.Query && slices.Equal(a.Fields, b.Fields) &&
+		a.Format == b.Format && a.Gzip == b.Gzip && a.MaxPartBytes == b.MaxPartBytes && a.References == b.References
+}
+
+// partPath returns the path of the index'th part (from 1)
+func (e *tableExport) partPath(index int) string {
+	path := e.options.Out
+	if e.options.Gzip {
+		path = strings.TrimSuffix(path, ".gz")
+	}
+	if e.options.MaxPartBytes > 0 {
+		ext := filepath.Ext(path)
+		path = fmt.Sprintf("%s.part%03d%s", strings.TrimSuffix(path, ext), index, ext)
+	}
+	if e.options.Gzip {
+		path += ".gz"
+	}
+	return path
+}
+
+// openPart opens the last part of the checkpoint, truncated to its checkpointed size.
+// Spreadsheets can't be appended to, so an interrupted xlsx part is written again.
+func (e *tableExport) openPart() error {
+	current := &e.checkpoint.Parts[len(e.checkpoint.Parts)-1]
+	if e.options.Format == FormatXLSX && current.Records > 0 {
+		e.checkpoint.Records -= current.Records
+		e.checkpoint.After = current.After
+		current.Records = 0
+		current.Bytes = 0
+	}
+
+	if dir := filepath.Dir(current.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	file, err := os.OpenFile(current.Path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", current.Path, err)
+	}
+	if err := file.Truncate(current.Bytes); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to truncate %s: %w", current.Path, err)
+	}
+	if _, err := file.Seek(current.Bytes, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+
+	out := &countingFile{file: file, n: current.Bytes}
+	switch e.options.Format {
+	case FormatXLSX:
+		e.part = &xlsxPart{out: out, options: e.options, display: e.display, columns: e.checkpoint.Columns, sheet: e.name}
+	default:
+		e.part = &textPart{out: out, format: e.options.Format, gzip: e.options.Gzip, columns: e.checkpoint.Columns,
+			references: e.options.References, display: e.display, records: current.Records}
+	}
+	return nil
+}
+
+// requestFields returns the fields to request: the exported ones plus sys_id
+func (e *tableExport) requestFields() []string {
+	if len(e.options.Fields) == 0 || slices.Contains(e.options.Fields, "sys_id") {
+		return e.options.Fields
+	}
+	return append(append([]string(nil), e.options.Fields...), "sys_id")
+}
+
+// writePage writes a page of records, starting new parts as they fill up, and
+// saves the checkpoint
+func (e *tableExport) writePage(page []map[string]interface{}) error {
+	if len(page) == 0 {
+		return nil
+	}
+	if e.checkpoint.Columns == nil && (e.options.Format == FormatCSV || e.options.Format == FormatXLSX) {
+		e.checkpoint.Columns = XLSXColumns(page, e.options.Fields, e.options.References)
+		e.part.setColumns(e.checkpoint.Columns)
+	}
+	keepSysID := len(e.options.Fields) == 0 || slices.Contains(e.options.Fields, "sys_id")
+
+	for _, record := range page {
+		current := &e.checkpoint.Parts[len(e.checkpoint.Parts)-1]
+		if e.options.MaxPartBytes > 0 && current.Records > 0 && e.part.size() >= e.options.MaxPartBytes {
+			if err := e.part.close(); err != nil {
+				return err
+			}
+			current.Bytes = e.part.size()
+			e.checkpoint.Parts = append(e.checkpoint.Parts, Part{Path: e.partPath(len(e.checkpoint.Parts) + 1), After: e.checkpoint.After})
+			if err := e.openPart(); err != nil {
+				return err
+			}
+			current = &e.checkpoint.Parts[len(e.checkpoint.Parts)-1]
+		}
+
+		sysID := table.NewRecord(record, e.display, nil).SysID()
+		if !keepSysID {
+			delete(record, "sys_id")
+		}
+		if err := e.part.write(record, page); err != nil {
+			return fmt.Errorf("failed to write %s: %w", current.Path, err)
+		}
+		current.Records++
+		e.checkpoint.Records++
+		e.checkpoint.After = sysID
+	}
+
+	current := &e.checkpoint.Parts[len(e.checkpoint.Parts)-1]
+	if err := e.part.flush(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", current.Path, err)
+	}
+	current.Bytes = e.part.size()
+	return e.saveCheckpoint()
+}
+
+func (e *tableExport) saveCheckpoint() error {
+	e.checkpoint.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(e.checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	// Write via a temp file so an interrupted save never leaves a truncated checkpoint
+	path := CheckpointPath(e.options.Out)
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// bytes returns the size of all parts
+func (e *tableExport) bytes() int64 {
+	var total int64
+	for _, part := range e.checkpoint.Parts[:len(e.checkpoint.Parts)-1] {
+		total += part.Bytes
+	}
+	return total + e.part.size()
+}
+
+// finish closes the last part and removes the checkpoint
+func (e *tableExport) finish() error {
+	err := e.part.close()
+	e.checkpoint.Parts[len(e.checkpoint.Parts)-1].Bytes = e.part.size()
+	e.part = nil
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(CheckpointPath(e.options.Out)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// abort releases the open part of an export that failed; the file keeps its
+// unflushed tail, which a resumed export truncates
+func (e *tableExport) abort() {
+	if e.part != nil {
+		e.part.release()
+	}
+}
+
+// partWriter writes records to one output file
+type partWriter interface {
+	setColumns(columns []Column)
+	write(record map[string]interface{}, page []map[string]interface{}) error
+	flush() error // Make everything written so far durable and resumable
+	size() int64  // Bytes written to the file
+	close() error // Complete the file
+	release()     // Close the file without completing it
+}
+
+// countingFile counts the bytes written to a file
+type countingFile struct {
+	file *os.File
+	n    int64
+}
+
+func (c *countingFile) Write(p []byte) (int, error) {
+	n, err := c.file.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// textPart writes csv, ndjson or json. Gzipped parts are written as one gzip member
+// per checkpoint, so a part truncated at a checkpoint is still a valid gzip file.
+type textPart struct {
+	out        *countingFile
+	format     Format
+	gzip       bool
+	columns    []Column
+	references ReferenceMode
+	display    core.DisplayValueOptions
+	records    int
+
+	zw  *gzip.Writer
+	buf *bufio.Writer
+	csv *csv.Writer
+}
+
+func (p *textPart) setColumns(columns []Column) {
+	p.columns = columns
+}
+
+func (p *textPart) writer() io.Writer {
+	if p.buf == nil {
+		var w io.Writer = p.out
+		if p.gzip {
+			p.zw = gzip.NewWriter(p.out)
+			w = p.zw
+		}
+		p.buf = bufio.NewWriterSize(w, 64*1024)
+		p.csv = csv.NewWriter(p.buf)
+	}
+	return p.buf
+}
+
+func (p *textPart) write(record map[string]interface{}, page []map[string]interface{}) error {
+	w := p.writer()
+	switch p.format {
+	case FormatCSV:
+		if p.records == 0 {
+			header := make([]string, len(p.columns))
+			for i, column := range p.columns {
+				header[i] = column.Header
+			}
+			if err := p.csv.Write(header); err != nil {
+				return err
+			}
+		}
+		r := table.NewRecord(record, p.display, nil)
+		row := make([]string, len(p.columns))
+		for i, column := range p.columns {
+			row[i] = column.Text(r, p.references)
+		}
+		if err := p.csv.Write(row); err != nil {
+			return err
+		}
+	case FormatJSON:
+		separator := ",\n"
+		if p.records == 0 {
+			separator = "[\n"
+		}
+		if _, err := io.WriteString(w, separator); err != nil {
+			return err
+		}
+		if err := writeJSON(w, record); err != nil {
+			return err
+		}
+	default:
+		if err := writeJSON(w, record); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	p.records++
+	return nil
+}
+
+func writeJSON(w io.Writer, record map[string]interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (p *textPart) flush() error {
+	if p.buf == nil {
+		return nil
+	}
+	p.csv.Flush()
+	if err := p.csv.Error(); err != nil {
+		return err
+	}
+	if err := p.buf.Flush(); err != nil {
+		return err
+	}
+	if p.zw != nil {
+		if err := p.zw.Close(); err != nil {
+			return err
+		}
+	}
+	p.buf, p.zw, p.csv = nil, nil, nil
+	return p.out.file.Sync()
+}
+
+func (p *textPart) size() int64 {
+	return p.out.n
+}
+
+func (p *textPart) close() error {
+	if p.format == FormatJSON {
+		closing := "\n]\n"
+		if p.records == 0 {
+			closing = "[]\n"
+		}
+		if _, err := io.WriteString(p.writer(), closing); err != nil {
+			p.release()
+			return err
+		}
+	}
+	if err := p.flush(); err != nil {
+		p.release()
+		return err
+	}
+	return p.out.file.Close()
+}
+
+func (p *textPart) release() {
+	p.out.file.Close()
+}
+
+// xlsxPart writes a workbook with a single sheet
+type xlsxPart struct {
+	out     *countingFile
+	options TableOptions
+	display core.DisplayValueOptions
+	columns []Column
+	sheet   string
+
+	workbook *xlsx.Writer
+	current  *xlsx.Sheet
+}
+
+func (p *xlsxPart) setColumns(columns []Column) {
+	p.columns = columns
+}
+
+func (p *xlsxPart) write(record map[string]interface{}, page []map[string]interface{}) error {
+	if p.current == nil {
+		p.workbook = xlsx.NewWriter(p.out)
+		rows := table.NewRecords(page, p.display, p.options.Location)
+		sheet, err := p.workbook.NewSheet(p.sheet, fitColumns(p.columns, rows, p.options.References))
+		if err != nil {
+			return err
+		}
+		p.current = sheet
+	}
+	if p.current.Rows() >= xlsx.MaxRows {
+		return fmt.Errorf("sheet is full (%d rows); set a part size", xlsx.MaxRows)
+	}
+	r := table.NewRecord(record, p.display, p.options.Location)
+	cells := make([]xlsx.Cell, len(p.columns))
+	for i, column := range p.columns {
+		cells[i] = column.Cell(r, p.options.References)
+	}
+	return p.current.WriteRow(cells...)
+}
+
+func (p *xlsxPart) flush() error {
+	return nil
+}
+
+func (p *xlsxPart) size() int64 {
+	return p.out.n
+}
+
+func (p *xlsxPart) close() error {
+	if p.workbook == nil {
+		p.workbook = xlsx.NewWriter(p.out)
+		if _, err := p.workbook.NewSheet(p.sheet, fitColumns(p.columns, nil, p.options.References)); err != nil {
+			p.release()
+			return err
+		}
+	}
+	if err := p.workbook.Close(); err != nil {
+		p.release()
+		return err
+	}
+	return p.out.file.Close()
+}
+
+func (p *xlsxPart) release() {
+	p.out.file.Close()
+}
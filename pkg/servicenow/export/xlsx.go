@@ -0,0 +1,233 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/utils/xlsx"
+)
+
+// ReferenceMode selects which values of reference fields are exported
+type ReferenceMode int
+
+const (
+	ReferenceDisplay ReferenceMode = iota // Display value (e.g. "Beth Anglin")
+	ReferenceSysID                        // sys_id
+	ReferenceBoth                         // Display value, plus a "<field> (sys_id)" column in spreadsheets
+)
+
+// DisplayValue returns the sysparm_display_value to fetch records with for the mode
+func (m ReferenceMode) DisplayValue() core.DisplayValueOptions {
+	switch m {
+	case ReferenceDisplay:
+		return core.DisplayTrue
+	case ReferenceSysID:
+		return core.DisplayFalse
+	}
+	return core.DisplayAll
+}
+
+// ParseReferenceMode parses display, sys_id or both
+func ParseReferenceMode(value string) (ReferenceMode, error) {
+	switch strings.ToLower(value) {
+	case "display", "display_value":
+		return ReferenceDisplay, nil
+	case "sys_id", "sysid", "value":
+		return ReferenceSysID, nil
+	case "both", "all":
+		return ReferenceBoth, nil
+	}
+	return 0, fmt.Errorf("invalid reference mode %q (use display, sys_id or both)", value)
+}
+
+// XLSXOptions controls spreadsheet exports
+type XLSXOptions struct {
+	Sheet        string                   // Sheet name when not grouping (default "Records")
+	Fields       []string                 // Columns in order (default: every field, sorted)
+	References   ReferenceMode            // Reference columns to write
+	DisplayValue core.DisplayValueOptions // sysparm_display_value the records were fetched with
+	GroupBy      string                   // Write one sheet per value of this field
+	Location     *time.Location           // Timezone date/times are shown in (default UTC)
+}
+
+// maxColumnWidth caps fitted column widths, in characters
+const maxColumnWidth = 60
+
+// Column is a spreadsheet column derived from a record field
+type Column struct {
+	Field  string `json:"field"`
+	Header string `json:"header"`
+	SysID  bool   `json:"sys_id,omitempty"` // Holds the sys_id of a reference field
+}
+
+// XLSXColumns returns the spreadsheet columns for the fields of records. In
+// ReferenceBoth mode reference fields get a second column with their sys_id.
+func XLSXColumns(records []map[string]interface{}, fields []string, mode ReferenceMode) []Column {
+	if len(fields) == 0 {
+		fields = RecordFields(records)
+	}
+	var columns []Column
+	for _, field := range fields {
+		columns = append(columns, Column{Field: field, Header: field})
+		if mode == ReferenceBoth && isReference(records, field) {
+			columns = append(columns, Column{Field: field, Header: field + " (sys_id)", SysID: true})
+		}
+	}
+	return columns
+}
+
+// RecordFields returns the sorted union of the fields of records
+func RecordFields(records []map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var fields []string
+	for _, record := range records {
+		for field := range record {
+			if !seen[field] {
+				seen[field] = true
+				fields = append(fields, field)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// isReference reports whether any record holds a reference value ({link, ...}) in field
+func isReference(records []map[string]interface{}, field string) bool {
+	for _, record := range records {
+		if m, ok := record[field].(map[string]interface{}); ok {
+			if _, ok := m["link"]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Text returns the value of a column for a record as text
+func (c Column) Text(r *table.Record, mode ReferenceMode) string {
+	if c.SysID || mode == ReferenceSysID {
+		return r.String(c.Field)
+	}
+	return r.DisplayValue(c.Field)
+}
+
+// Cell returns the typed cell of a column for a record: glide date/times become
+// dates in location, numbers become numeric cells and everything else text
+func (c Column) Cell(r *table.Record, mode ReferenceMode) xlsx.Cell {
+	if c.SysID {
+		return xlsx.String(r.String(c.Field))
+	}
+	return typedCell(r, c.Field, c.Text(r, mode))
+}
+
+func typedCell(r *table.Record, field, text string) xlsx.Cell {
+	switch len(text) {
+	case len(table.GlideDateTimeLayout):
+		if _, err := time.Parse(table.GlideDateTimeLayout, text); err == nil {
+			if t, err := r.Time(field); err == nil {
+				return xlsx.DateTime(t)
+			}
+		}
+	case len(table.GlideDateLayout):
+		if t, err := time.Parse(table.GlideDateLayout, text); err == nil {
+			return xlsx.Date(t)
+		}
+	}
+	if n, ok := parseNumber(text); ok {
+		return xlsx.Number(n)
+	}
+	return xlsx.String(text)
+}
+
+// parseNumber accepts numbers that survive a round trip through a spreadsheet: no
+// leading zeros (as in "007") and no more digits than a float64 holds
+func parseNumber(text string) (float64, bool) {
+	if text == "" || len(text) > 15 || strings.ContainsAny(text, "eEx_ ") {
+		return 0, false
+	}
+	digits := strings.TrimPrefix(text, "-")
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil || math.IsInf(n, 0) || math.IsNaN(n) {
+		return 0, false
+	}
+	return n, true
+}
+
+// WriteXLSX writes records as a workbook with typed cells, a styled and frozen header
+// row, and one sheet per table or, with GroupBy, per group
+func WriteXLSX(w io.Writer, records []map[string]interface{}, options XLSXOptions) error {
+	columns := XLSXColumns(records, options.Fields, options.References)
+
+	var groups []string
+	byGroup := make(map[string][]map[string]interface{})
+	if options.GroupBy == "" {
+		name := options.Sheet
+		if name == "" {
+			name = "Records"
+		}
+		groups = []string{name}
+		byGroup[name] = records
+	} else {
+		for _, record := range records {
+			group := table.NewRecord(record, options.DisplayValue, options.Location).DisplayValue(options.GroupBy)
+			if group == "" {
+				group = "(empty)"
+			}
+			if _, ok := byGroup[group]; !ok {
+				groups = append(groups, group)
+			}
+			byGroup[group] = append(byGroup[group], record)
+		}
+		sort.Strings(groups)
+	}
+
+	workbook := xlsx.NewWriter(w)
+	for _, group := range groups {
+		rows := table.NewRecords(byGroup[group], options.DisplayValue, options.Location)
+		sheet, err := workbook.NewSheet(group, fitColumns(columns, rows, options.References))
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			cells := make([]xlsx.Cell, len(columns))
+			for i, column := range columns {
+				cells[i] = column.Cell(r, options.References)
+			}
+			if err := sheet.WriteRow(cells...); err != nil {
+				return err
+			}
+		}
+	}
+	return workbook.Close()
+}
+
+// fitColumns sizes columns to their widest value among the first rows
+func fitColumns(columns []Column, rows []*table.Record, mode ReferenceMode) []xlsx.Column {
+	const sample = 500
+	sheetColumns := make([]xlsx.Column, len(columns))
+	for i, column := range columns {
+		width := utf8.RuneCountInString(column.Header) + 2
+		for j, r := range rows {
+			if j == sample {
+				break
+			}
+			if n := utf8.RuneCountInString(column.Text(r, mode)) + 2; n > width {
+				width = n
+			}
+		}
+		sheetColumns[i] = xlsx.Column{Name: column.Header, Width: math.Min(float64(width), maxColumnWidth)}
+	}
+	return sheetColumns
+}
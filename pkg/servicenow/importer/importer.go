@@ -0,0 +1,738 @@
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/batch"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+)
+
+// DefaultChunkSize is the number of rows written per batch request
+const DefaultChunkSize = 100
+
+// lookupChunkSize bounds the values per reference lookup query to keep request URLs short
+const lookupChunkSize = 100
+
+// lookupPageSize is the number of records read per page of a reference lookup
+const lookupPageSize = 1000
+
+var sysIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Row is a row of an input file
+type Row struct {
+	Line   int               // Line in a CSV file, position (from 1) in a JSON array
+	Values map[string]string // Column name to value
+}
+
+// Input is the content of an input file
+type Input struct {
+	Format  string   // csv or json
+	Columns []string // In file order
+	Rows    []Row
+}
+
+// ReadFile reads rows from a CSV or JSON file. An empty format is taken from the
+// file extension.
+func ReadFile(path, format string) (*Input, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadInput(file, format)
+}
+
+// ReadInput reads rows from CSV with a header line, or from a JSON array of objects
+func ReadInput(r io.Reader, format string) (*Input, error) {
+	switch format {
+	case "csv":
+		return readCSV(r)
+	case "json":
+		return readJSON(r)
+	}
+	return nil, fmt.Errorf("unsupported input format %q (use csv or json)", format)
+}
+
+func readCSV(r io.Reader) (*Input, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("the file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	input := &Input{Format: "csv"}
+	seen := make(map[string]bool)
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		if i == 0 {
+			column = strings.TrimPrefix(column, "\ufeff")
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("duplicate column %q", column)
+		}
+		seen[column] = true
+		input.Columns = append(input.Columns, column)
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		row := Row{Line: line, Values: make(map[string]string, len(record))}
+		for i, value := range record {
+			if i < len(input.Columns) {
+				row.Values[input.Columns[i]] = value
+			}
+		}
+		input.Rows = append(input.Rows, row)
+	}
+	return input, nil
+}
+
+func readJSON(r io.Reader) (*Input, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	var objects []map[string]interface{}
+	if err := decoder.Decode(&objects); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of objects: %w", err)
+	}
+	input := &Input{Format: "json"}
+	seen := make(map[string]bool)
+	for i, object := range objects {
+		row := Row{Line: i + 1, Values: make(map[string]string, len(object))}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				input.Columns = append(input.Columns, key)
+			}
+			switch v := object[key].(type) {
+			case nil:
+				row.Values[key] = ""
+			case string:
+				row.Values[key] = v
+			case json.Number:
+				row.Values[key] = v.String()
+			case bool:
+				row.Values[key] = strconv.FormatBool(v)
+			default:
+				data, _ := json.Marshal(v)
+				row.Values[key] = string(data)
+			}
+		}
+		input.Rows = append(input.Rows, row)
+	}
+	return input, nil
+}
+
+// Status is the outcome of importing a row
+type Status string
+
+const (
+	StatusValid     Status = "valid" // Passed validation in a dry run
+	StatusRejected  Status = "rejected"
+	StatusCreated   Status = Status(table.UpsertCreated)
+	StatusUpdated   Status = Status(table.UpsertUpdated)
+	StatusUnchanged Status = Status(table.UpsertUnchanged)
+	StatusConflict  Status = Status(table.UpsertConflict)
+	StatusFailed    Status = Status(table.UpsertFailed)
+)
+
+// RowResult is the outcome of importing one row
+type RowResult struct {
+	Row     int               `json:"row"`  // Index in Input.Rows
+	Line    int               `json:"line"` // Row.Line
+	Status  Status            `json:"status"`
+	SysID   string            `json:"sys_id,omitempty"`
+	Reasons []string          `json:"reasons,omitempty"`
+	Record  map[string]string `json:"record,omitempty"` // Field values sent (or that would be sent)
+}
+
+// Report summarizes an import
+type Report struct {
+	Table     string        `json:"table"`
+	DryRun    bool          `json:"dry_run,omitempty"`
+	Rows      int           `json:"rows"`
+	Valid     int           `json:"valid"`
+	Rejected  int           `json:"rejected"`
+	Created   int           `json:"created"`
+	Updated   int           `json:"updated"`
+	Unchanged int           `json:"unchanged"`
+	Failed    int           `json:"failed"` // Failed or conflicting writes
+	Results   []RowResult   `json:"results"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Rejects returns the rows that were rejected or failed to load
+func (r *Report) Rejects() []RowResult {
+	var rejects []RowResult
+	for _, result := range r.Results {
+		switch result.Status {
+		case StatusRejected, StatusConflict, StatusFailed:
+			rejects = append(rejects, result)
+		}
+	}
+	return rejects
+}
+
+// Options controls an import
+type Options struct {
+	Mapping   *Mapping       // Column to field mapping (default: columns map to fields of the same name)
+	Key       []string       // Match fields for upserts; overrides Mapping.Key
+	ChunkSize int            // Rows per batch request (default 100)
+	DryRun    bool           // Validate without writing
+	Location  *time.Location // Timezone of mapped dates with a time (default: the session user's)
+}
+
+// Importer validates rows against a table's schema and loads them
+type Importer struct {
+	client *core.Client
+	schema *schema.SchemaClient
+	table  string
+}
+
+// NewImporter creates an importer for a table. schemaClient may be nil, in which
+// case schemas are loaded by a client of its own.
+func NewImporter(client *core.Client, schemaClient *schema.SchemaClient, tableName string) *Importer {
+	if schemaClient == nil {
+		schemaClient = schema.NewSchemaClient(client)
+	}
+	return &Importer{client: client, schema: schemaClient, table: tableName}
+}
+
+// Import maps, validates and loads the rows of an input
+func (im *Importer) Import(input *Input, options Options) (*Report, error) {
+	return im.ImportWithContext(context.Background(), input, options)
+}
+
+// ImportWithContext maps, validates and loads the rows of an input. Problems with
+// the mapping itself (unknown fields or columns) fail the import; problems with
+// individual rows reject those rows, and the remaining rows are created or, with
+// key fields, upserted.
+func (im *Importer) ImportWithContext(ctx context.Context, input *Input, options Options) (*Report, error) {
+	start := time.Now()
+	tableSchema, err := im.schema.GetTableWithContext(ctx, im.table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the schema of %s: %w", im.table, err)
+	}
+	mapping := options.Mapping
+	if mapping == nil {
+		mapping = IdentityMapping(input.Columns)
+	}
+	mapping = withPassthrough(mapping, input.Columns, tableSchema)
+	key := options.Key
+	if len(key) == 0 {
+		key = mapping.Key
+	}
+	if err := checkMapping(mapping, key, input.Columns, tableSchema); err != nil {
+		return nil, err
+	}
+	if options.Location == nil {
+		if options.Location, err = table.SessionTimeZone(ctx, im.client); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &Report{Table: im.table, DryRun: options.DryRun, Rows: len(input.Rows)}
+	report.Results = im.prepare(input, mapping, tableSchema, options.Location)
+	if err := im.resolveReferences(ctx, report.Results, mapping, tableSchema); err != nil {
+		return nil, err
+	}
+
+	var valid []int
+	for i := range report.Results {
+		if len(report.Results[i].Reasons) > 0 {
+			report.Results[i].Status = StatusRejected
+			report.Rejected++
+			continue
+		}
+		report.Results[i].Status = StatusValid
+		valid = append(valid, i)
+	}
+	report.Valid = len(valid)
+
+	if !options.DryRun && len(valid) > 0 {
+		chunkSize := options.ChunkSize
+		if chunkSize <= 0 {
+			chunkSize = DefaultChunkSize
+		}
+		if len(key) > 0 {
+			err = im.upsert(ctx, report.Results, valid, key, chunkSize)
+		} else {
+			err = im.create(ctx, report.Results, valid, chunkSize)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, result := range report.Results {
+		switch result.Status {
+		case StatusCreated:
+			report.Created++
+		case StatusUpdated:
+			report.Updated++
+		case StatusUnchanged:
+			report.Unchanged++
+		case StatusConflict, StatusFailed:
+			report.Failed++
+		}
+	}
+	report.Duration = time.Since(start)
+	return report, nil
+}
+
+// withPassthrough adds unmapped columns named like fields to a passthrough mapping
+func withPassthrough(mapping *Mapping, columns []string, tableSchema *schema.Table) *Mapping {
+	if !mapping.Passthrough {
+		return mapping
+	}
+	extended := *mapping
+	extended.Fields = make(map[string]FieldMapping, len(mapping.Fields))
+	used := make(map[string]bool)
+	for field, fieldMapping := range mapping.Fields {
+		extended.Fields[field] = fieldMapping
+		used[fieldMapping.SourceColumn(field)] = true
+	}
+	for _, column := range columns {
+		if _, mapped := extended.Fields[column]; !mapped && !used[column] && tableSchema.Field(column) != nil {
+			extended.Fields[column] = FieldMapping{Column: column}
+		}
+	}
+	return &extended
+}
+
+// checkMapping reports mapped fields that don't exist, missing columns, unmapped
+// key fields and, when creating, unmapped mandatory fields
+func checkMapping(mapping *Mapping, key, columns []string, tableSchema *schema.Table) error {
+	hasColumn := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		hasColumn[column] = true
+	}
+	var problems []string
+	for _, field := range sortedFields(mapping) {
+		fieldMapping := mapping.Fields[field]
+		schemaField := tableSchema.Field(field)
+		if schemaField == nil {
+			problems = append(problems, fmt.Sprintf("%s has no field %s", tableSchema.Name, field))
+			continue
+		}
+		if column := fieldMapping.SourceColumn(field); column != "" && !hasColumn[column] {
+			problems = append(problems, fmt.Sprintf("field %s: the input has no column %q", field, column))
+		}
+		if fieldMapping.Date != "" && glideLayout(schemaField) == "" {
+			problems = append(problems, fmt.Sprintf("field %s: date applies to date and date/time fields, not %s", field, schemaField.Type))
+		}
+		if fieldMapping.Lookup != "" && !schemaField.IsReference() {
+			problems = append(problems, fmt.Sprintf("field %s: lookup applies to reference fields, not %s", field, schemaField.Type))
+		}
+	}
+	for _, field := range key {
+		if _, ok := mapping.Fields[field]; !ok {
+			problems = append(problems, fmt.Sprintf("key field %s is not mapped", field))
+		}
+	}
+	if len(key) == 0 {
+		for _, field := range tableSchema.Fields {
+			if _, ok := mapping.Fields[field.Name]; !ok && field.Mandatory && field.DefaultValue == "" && !field.ReadOnly {
+				problems = append(problems, fmt.Sprintf("mandatory field %s is not mapped", field.Name))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid mapping:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func sortedFields(mapping *Mapping) []string {
+	fields := make([]string, 0, len(mapping.Fields))
+	for field := range mapping.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// glideLayout returns the layout values of a date or date/time field are written in
+func glideLayout(field *schema.Field) string {
+	switch field.Type {
+	case "glide_date":
+		return table.GlideDateLayout
+	case "glide_date_time", "due_date":
+		return table.GlideDateTimeLayout
+	}
+	return ""
+}
+
+// prepare maps every row and validates the values against the schema, except for
+// the resolvability of references
+func (im *Importer) prepare(input *Input, mapping *Mapping, tableSchema *schema.Table, location *time.Location) []RowResult {
+	fields := sortedFields(mapping)
+	results := make([]RowResult, len(input.Rows))
+	for i, row := range input.Rows {
+		result := RowResult{Row: i, Line: row.Line, Record: make(map[string]string, len(fields))}
+		for _, name := range fields {
+			field := tableSchema.Field(name)
+			value, err := mapping.Fields[name].apply(name, row.Values, glideLayout(field), location)
+			if err != nil {
+				result.Reasons = append(result.Reasons, err.Error())
+				continue
+			}
+			value, reason := validateValue(field, value)
+			if reason != "" {
+				result.Reasons = append(result.Reasons, reason)
+				continue
+			}
+			result.Record[name] = value
+		}
+		results[i] = result
+	}
+	return results
+}
+
+// validateValue checks a value against a field's dictionary entry. Choice labels are
+// replaced by their values and booleans are lower-cased.
+func validateValue(field *schema.Field, value string) (string, string) {
+	if value == "" {
+		if field.Mandatory {
+			return "", fmt.Sprintf("%s is mandatory", field.Name)
+		}
+		return "", ""
+	}
+
+	if field.Choice && len(field.Choices) > 0 {
+		choice, ok := matchChoice(field.Choices, value)
+		if !ok {
+			return "", fmt.Sprintf("%s: %q is not one of the choices %s", field.Name, value, describeChoices(field.Choices))
+		}
+		value = choice
+	}
+
+	switch field.Type {
+	case "integer", "longint":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "", fmt.Sprintf("%s: %q is not an integer", field.Name, value)
+		}
+	case "decimal", "float":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "", fmt.Sprintf("%s: %q is not a number", field.Name, value)
+		}
+	case "boolean":
+		lower := strings.ToLower(value)
+		if lower != "true" && lower != "false" {
+			return "", fmt.Sprintf("%s: %q is not true or false", field.Name, value)
+		}
+		value = lower
+	case "glide_date", "glide_date_time", "due_date":
+		if _, err := time.Parse(glideLayout(field), value); err != nil {
+			return "", fmt.Sprintf("%s: %q is not in the format %s", field.Name, value, glideLayout(field))
+		}
+	default:
+		if field.MaxLength > 0 && !field.IsReference() {
+			if n := utf8.RuneCountInString(value); n > field.MaxLength {
+				return "", fmt.Sprintf("%s: %d characters exceed the maximum length of %d", field.Name, n, field.MaxLength)
+			}
+		}
+	}
+	return value, ""
+}
+
+// matchChoice matches a value or, case-insensitively, a label
+func matchChoice(choices []schema.Choice, value string) (string, bool) {
+	for _, choice := range choices {
+		if choice.Value == value {
+			return choice.Value, true
+		}
+	}
+	for _, choice := range choices {
+		if strings.EqualFold(choice.Label, value) {
+			return choice.Value, true
+		}
+	}
+	return "", false
+}
+
+func describeChoices(choices []schema.Choice) string {
+	const shown = 10
+	var parts []string
+	seen := make(map[string]bool)
+	for _, choice := range choices {
+		if seen[choice.Value] {
+			continue
+		}
+		seen[choice.Value] = true
+		if len(parts) == shown {
+			parts = append(parts, "...")
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", choice.Value, choice.Label))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// resolveReferences replaces reference values by the sys_ids of the records they
+// match, rejecting rows whose values match no record or several
+func (im *Importer) resolveReferences(ctx context.Context, results []RowResult, mapping *Mapping, tableSchema *schema.Table) error {
+	for _, name := range sortedFields(mapping) {
+		field := tableSchema.Field(name)
+		if !field.IsReference() {
+			continue
+		}
+		lookup := mapping.Fields[name].Lookup
+		if lookup == "" {
+			lookup = "sys_id"
+		}
+
+		values := make(map[string]bool)
+		for i := range results {
+			value, ok := results[i].Record[name]
+			if !ok || value == "" {
+				continue
+			}
+			if lookup == "sys_id" && !sysIDPattern.MatchString(value) {
+				results[i].Reasons = append(results[i].Reasons,
+					fmt.Sprintf("%s: %q is not a sys_id; set lookup in the mapping to match %s records on another field", name, value, field.Reference))
+				delete(results[i].Record, name)
+				continue
+			}
+			values[value] = true
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		matches, err := im.lookup(ctx, field.Reference, lookup, values)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s references: %w", name, err)
+		}
+		for i := range results {
+			value, ok := results[i].Record[name]
+			if !ok || value == "" {
+				continue
+			}
+			switch sysIDs := matches[strings.ToLower(value)]; len(sysIDs) {
+			case 0:
+				results[i].Reasons = append(results[i].Reasons, fmt.Sprintf("%s: no %s record has %s %q", name, field.Reference, lookup, value))
+			case 1:
+				results[i].Record[name] = sysIDs[0]
+			default:
+				results[i].Reasons = append(results[i].Reasons, fmt.Sprintf("%s: %d %s records have %s %q", name, len(sysIDs), field.Reference, lookup, value))
+			}
+		}
+	}
+	return nil
+}
+
+// lookup returns the sys_ids of the records of a table whose field has one of the
+// values, by lower-cased value
+func (im *Importer) lookup(ctx context.Context, tableName, field string, values map[string]bool) (map[string][]string, error) {
+	var plain, withCommas []string
+	for value := range values {
+		if strings.Contains(value, ",") {
+			withCommas = append(withCommas, value)
+		} else {
+			plain = append(plain, value)
+		}
+	}
+	sort.Strings(plain)
+	sort.Strings(withCommas)
+
+	// Values containing commas can't be part of an IN list
+	var queries []string
+	for start := 0; start < len(plain); start += lookupChunkSize {
+		end := min(start+lookupChunkSize, len(plain))
+		queries = append(queries, field+"IN"+strings.Join(plain[start:end], ","))
+	}
+	for _, value := range withCommas {
+		queries = append(queries, field+"="+value)
+	}
+
+	client := table.NewTableClient(im.client, tableName)
+	matches := make(map[string][]string)
+	for _, query := range queries {
+		// Page by sys_id until a page comes back empty, so large tables resolve fully
+		after := ""
+		for {
+			keyset := query
+			if after != "" {
+				keyset += "^sys_id>" + after
+			}
+			records, err := client.ListOptWithContext(ctx, table.ListOptions{
+				Query:        keyset + "^ORDERBYsys_id",
+				Fields:       []string{"sys_id", field},
+				Limit:        lookupPageSize,
+				DisplayValue: core.DisplayFalse,
+				NoCount:      true,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if len(records) == 0 {
+				break
+			}
+			last := after
+			for _, record := range records {
+				r := table.NewRecord(record, core.DisplayFalse, nil)
+				value := strings.ToLower(r.String(field))
+				matches[value] = append(matches[value], r.SysID())
+				last = max(last, r.SysID())
+			}
+			if last == after {
+				return nil, fmt.Errorf("no progress past sys_id %q looking up %s", after, tableName)
+			}
+			after = last
+		}
+	}
+	return matches, nil
+}
+
+// values returns the non-empty values of a prepared record. Empty values are not
+// sent, so updates never blank existing fields.
+func (r RowResult) values() map[string]interface{} {
+	values := make(map[string]interface{}, len(r.Record))
+	for field, value := range r.Record {
+		if value != "" {
+			values[field] = value
+		}
+	}
+	return values
+}
+
+// upsert writes the valid rows through the table API's upsert
+func (im *Importer) upsert(ctx context.Context, results []RowResult, valid []int, key []string, chunkSize int) error {
+	records := make([]map[string]interface{}, len(valid))
+	for i, index := range valid {
+		records[i] = results[index].values()
+	}
+	upserts, err := table.NewTableClient(im.client, im.table).UpsertManyWithContext(ctx, records, table.UpsertOptions{
+		MatchFields: key,
+		ChunkSize:   chunkSize,
+	})
+	if err != nil {
+		return fmt.Errorf("upsert failed: %w", err)
+	}
+	for i, upsert := range upserts {
+		result := &results[valid[i]]
+		if upsert == nil {
+			result.Status = StatusFailed
+			result.Reasons = []string{"not written: the upsert stopped early"}
+			continue
+		}
+		result.Status = Status(upsert.Status)
+		result.SysID = upsert.SysID
+		if upsert.Error != "" {
+			result.Reasons = []string{upsert.Error}
+		}
+	}
+	return nil
+}
+
+// create writes the valid rows as new records through the batch API
+func (im *Importer) create(ctx context.Context, results []RowResult, valid []int, chunkSize int) error {
+	batchClient := batch.NewBatchClient(im.client)
+	for start := 0; start < len(valid); start += chunkSize {
+		chunk := valid[start:min(start+chunkSize, len(valid))]
+		builder := batchClient.NewBatch()
+		for _, index := range chunk {
+			builder.Create(createRequestID(index), im.table, results[index].values())
+		}
+		batchResult, err := builder.ExecuteWithContext(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		for _, index := range chunk {
+			result := &results[index]
+			result.Status = StatusFailed
+			id := createRequestID(index)
+			switch {
+			case err != nil:
+				result.Reasons = []string{fmt.Sprintf("batch request failed: %v", err)}
+			case batchResult.Errors[id] != nil:
+				reqErr := batchResult.Errors[id]
+				result.Reasons = []string{fmt.Sprintf("%d %s: %s", reqErr.StatusCode, reqErr.StatusText, reqErr.ErrorDetail)}
+			case batchResult.Results[id] == nil:
+				result.Reasons = []string{"no response for request in batch"}
+			case batchResult.Results[id].StatusCode >= 300:
+				result.Reasons = []string{fmt.Sprintf("%d %s", batchResult.Results[id].StatusCode, batchResult.Results[id].StatusText)}
+			default:
+				result.Status = StatusCreated
+				if record, err := batch.ExtractRecordData(batchResult.Results[id]); err == nil {
+					result.SysID = table.NewRecord(record, core.DisplayFalse, nil).SysID()
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func createRequestID(index int) string {
+	return fmt.Sprintf("import_%d", index+1)
+}
+
+// WriteRejects writes the rejected and failed rows in the format of the input, with
+// their line and reasons in import_line and import_errors columns, so they can be
+// corrected and imported again
+func WriteRejects(w io.Writer, input *Input, report *Report) error {
+	rejects := report.Rejects()
+	if input.Format == "json" {
+		objects := make([]map[string]interface{}, 0, len(rejects))
+		for _, reject := range rejects {
+			object := make(map[string]interface{}, len(input.Columns)+2)
+			for column, value := range input.Rows[reject.Row].Values {
+				object[column] = value
+			}
+			object["import_line"] = reject.Line
+			object["import_errors"] = reject.Reasons
+			objects = append(objects, object)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(objects)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append(append([]string(nil), input.Columns...), "import_line", "import_errors")); err != nil {
+		return err
+	}
+	for _, reject := range rejects {
+		row := make([]string, 0, len(input.Columns)+2)
+		for _, column := range input.Columns {
+			row = append(row, input.Rows[reject.Row].Values[column])
+		}
+		row = append(row, strconv.Itoa(reject.Line), strings.Join(reject.Reasons, "; "))
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,204 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+)
+
+// Mapping describes how the columns of an input file become the fields of records
+type Mapping struct {
+	Key         []string                `json:"key,omitempty"`         // Fields matching existing records to update (upsert); empty creates every row
+	Fields      map[string]FieldMapping `json:"fields"`                // Target field name to its source
+	Passthrough bool                    `json:"passthrough,omitempty"` // Also import unmapped columns named like fields
+}
+
+// FieldMapping computes the value of one field from a row
+type FieldMapping struct {
+	Column     string            `json:"column,omitempty"`     // Source column (default: the field name)
+	Value      Scalar            `json:"value,omitempty"`      // Constant value instead of a column
+	Default    Scalar            `json:"default,omitempty"`    // Value used when the column is empty
+	Transforms []string          `json:"transforms,omitempty"` // Applied in order: trim, lower, upper, title, collapse, digits
+	Map        map[string]Scalar `json:"map,omitempty"`        // Replaces values after transforms (e.g. Yes: "true")
+	Date       string            `json:"date,omitempty"`       // Go layout the column's dates are in, e.g. 02/01/2006
+	Lookup     string            `json:"lookup,omitempty"`     // Field of the referenced table a reference value is matched on
+	constant   bool
+}
+
+// Scalar is a mapping value that may be written as a string, number or boolean
+type Scalar string
+
+// UnmarshalJSON accepts strings, numbers and booleans
+func (s *Scalar) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case nil:
+		*s = ""
+	case string:
+		*s = Scalar(v)
+	case bool:
+		*s = Scalar(strconv.FormatBool(v))
+	case float64:
+		*s = Scalar(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		return fmt.Errorf("expected a string, number or boolean, got %s", data)
+	}
+	return nil
+}
+
+// UnmarshalJSON accepts a column name as shorthand for {"column": name}
+func (f *FieldMapping) UnmarshalJSON(data []byte) error {
+	var column string
+	if err := json.Unmarshal(data, &column); err == nil {
+		*f = FieldMapping{Column: column}
+		return nil
+	}
+	type plain FieldMapping
+	var mapping plain
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&mapping); err != nil {
+		return err
+	}
+	*f = FieldMapping(mapping)
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err == nil {
+		_, f.constant = raw["value"]
+	}
+	return nil
+}
+
+// LoadMapping reads a mapping file in JSON or YAML
+func LoadMapping(path string) (*Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping: %w", err)
+	}
+	mapping, err := ParseMapping(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mapping %s: %w", path, err)
+	}
+	return mapping, nil
+}
+
+// ParseMapping parses a mapping in JSON or YAML
+func ParseMapping(data []byte) (*Mapping, error) {
+	text := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(text, "{") {
+		document, err := parseYAML(text)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = json.Marshal(document); err != nil {
+			return nil, err
+		}
+	}
+	var mapping Mapping
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&mapping); err != nil {
+		return nil, err
+	}
+	for field, fieldMapping := range mapping.Fields {
+		for _, name := range fieldMapping.Transforms {
+			if _, ok := transforms[name]; !ok {
+				return nil, fmt.Errorf("field %s: unknown transform %q (use %s)", field, name, strings.Join(TransformNames(), ", "))
+			}
+		}
+		if fieldMapping.constant && fieldMapping.Column != "" {
+			return nil, fmt.Errorf("field %s: give either a column or a value", field)
+		}
+	}
+	return &mapping, nil
+}
+
+// IdentityMapping maps every column to the field of the same name
+func IdentityMapping(columns []string) *Mapping {
+	mapping := &Mapping{Fields: make(map[string]FieldMapping, len(columns))}
+	for _, column := range columns {
+		mapping.Fields[column] = FieldMapping{Column: column}
+	}
+	return mapping
+}
+
+// SourceColumn returns the column a field is read from, or "" for constants
+func (f FieldMapping) SourceColumn(field string) string {
+	if f.constant {
+		return ""
+	}
+	if f.Column != "" {
+		return f.Column
+	}
+	return field
+}
+
+var digitsOnly = regexp.MustCompile(`\D`)
+
+var transforms = map[string]func(string) string{
+	"trim":     strings.TrimSpace,
+	"lower":    strings.ToLower,
+	"upper":    strings.ToUpper,
+	"title":    titleCase,
+	"collapse": func(s string) string { return strings.Join(strings.Fields(s), " ") },
+	"digits":   func(s string) string { return digitsOnly.ReplaceAllString(s, "") },
+}
+
+// TransformNames returns the names of the available transforms
+func TransformNames() []string {
+	names := make([]string, 0, len(transforms))
+	for name := range transforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func titleCase(s string) string {
+	runes := []rune(strings.ToLower(s))
+	for i := range runes {
+		if i == 0 || !unicode.IsLetter(runes[i-1]) && runes[i-1] != '\'' {
+			runes[i] = unicode.ToUpper(runes[i])
+		}
+	}
+	return string(runes)
+}
+
+// apply computes the value of a field from a row. Dates are parsed with the
+// mapping's layout in location and returned in the given glide layout.
+func (f FieldMapping) apply(field string, row map[string]string, glideLayout string, location *time.Location) (string, error) {
+	value := string(f.Value)
+	if !f.constant {
+		value = row[f.SourceColumn(field)]
+	}
+	for _, name := range f.Transforms {
+		value = transforms[name](value)
+	}
+	if mapped, ok := f.Map[value]; ok {
+		value = string(mapped)
+	}
+	if value == "" {
+		value = string(f.Default)
+	}
+	if f.Date != "" && value != "" && glideLayout != "" {
+		t, err := time.ParseInLocation(f.Date, value, location)
+		if err != nil {
+			return "", fmt.Errorf("%s: %q is not a date in the format %s", field, value, f.Date)
+		}
+		if glideLayout != table.GlideDateLayout {
+			t = t.UTC()
+		}
+		value = t.Format(glideLayout)
+	}
+	return value, nil
+}
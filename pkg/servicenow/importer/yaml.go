@@ -0,0 +1,68 @@
+package importer
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseYAML parses a YAML document into maps, slices, strings and booleans, the
+// shapes the JSON decoding of a mapping expects. Scalars keep their text, so 01
+// stays "01"; only booleans and nulls are typed. Aliases are followed.
+func parseYAML(data string) (interface{}, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(data), &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	return yamlValue(document.Content[0])
+}
+
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.MappingNode:
+		values := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: mapping keys must be scalars", key.Line)
+			}
+			if key.Tag == "!!merge" {
+				return nil, fmt.Errorf("line %d: merge keys are not supported", key.Line)
+			}
+			v, err := yamlValue(value)
+			if err != nil {
+				return nil, err
+			}
+			values[key.Value] = v
+		}
+		return values, nil
+	case yaml.SequenceNode:
+		values := make([]interface{}, len(node.Content))
+		for i, item := range node.Content {
+			v, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null":
+			return nil, nil
+		case "!!bool":
+			var b bool
+			if err := node.Decode(&b); err != nil {
+				return nil, err
+			}
+			return b, nil
+		}
+		return node.Value, nil
+	}
+	return nil, fmt.Errorf("line %d: unsupported YAML node", node.Line)
+}
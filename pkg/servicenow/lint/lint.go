@@ -0,0 +1,296 @@
+// Package lint checks encoded queries against the schema of the table they run
+// on, reporting unknown fields, operators that don't suit a field's type,
+// values the instance can't match and text searches that scan large tables.
+//
+// The instance accepts almost any encoded query: an unknown field or a choice
+// label where the value is expected silently matches nothing, or everything.
+// Linting before running a query turns those into diagnostics.
+package lint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
+)
+
+// DefaultLargeTable is the row count from which text searches are flagged
+const DefaultLargeTable = 100000
+
+// Severity of a diagnostic
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // The condition can't match as written
+	SeverityWarning Severity = "warning" // The query runs, but probably not as intended or slowly
+	SeverityInfo    Severity = "info"
+)
+
+// Codes of the problems diagnostics report
+const (
+	CodeSyntax         = "syntax"
+	CodeEmptyQuery     = "empty-query"
+	CodeUnknownField   = "unknown-field"
+	CodeNotChecked     = "not-checked"
+	CodeOperatorType   = "operator-type"
+	CodeMissingValue   = "missing-value"
+	CodeInvalidValue   = "invalid-value"
+	CodeInvalidChoice  = "invalid-choice"
+	CodeBadDate        = "bad-date"
+	CodeReferenceValue = "reference-value"
+	CodeUnindexedLike  = "unindexed-like"
+)
+
+// Diagnostic is a problem found in a query
+type Diagnostic struct {
+	Severity   Severity `json:"severity"`
+	Code       string   `json:"code"`
+	Condition  string   `json:"condition,omitempty"` // The condition in encoded form
+	Field      string   `json:"field,omitempty"`
+	Operator   string   `json:"operator,omitempty"`
+	Value      string   `json:"value,omitempty"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion,omitempty"`
+}
+
+// String returns the diagnostic on one line, e.g. "error: stat=1: stat is not a field of incident"
+func (d Diagnostic) String() string {
+	if d.Condition == "" {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.Severity, d.Condition, d.Message)
+}
+
+// Result is the outcome of linting a query
+type Result struct {
+	Table       string       `json:"table"`
+	Query       string       `json:"query"`
+	Rows        int          `json:"rows,omitempty"` // Records in the table, when they were counted
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Errors returns the diagnostics of severity error
+func (r *Result) Errors() []Diagnostic {
+	var errs []Diagnostic
+	for _, d := range r.Diagnostics {
+		if d.Severity == SeverityError {
+			errs = append(errs, d)
+		}
+	}
+	return errs
+}
+
+// HasErrors reports whether any diagnostic is an error
+func (r *Result) HasErrors() bool {
+	return len(r.Errors()) > 0
+}
+
+// Err returns an *Error listing the error diagnostics, or nil when there are none
+func (r *Result) Err() error {
+	errs := r.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	return &Error{Table: r.Table, Query: r.Query, Diagnostics: errs}
+}
+
+// Error is returned for queries with error diagnostics
+type Error struct {
+	Table       string
+	Query       string
+	Diagnostics []Diagnostic
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		messages[i] = d.Condition + ": " + d.Message
+		if d.Condition == "" {
+			messages[i] = d.Message
+		}
+	}
+	return fmt.Sprintf("invalid query on %s: %s", e.Table, strings.Join(messages, "; "))
+}
+
+// IsLintError reports whether err is, or wraps, an *Error
+func IsLintError(err error) (*Error, bool) {
+	var lintErr *Error
+	ok := errors.As(err, &lintErr)
+	return lintErr, ok
+}
+
+// Options tune the checks
+type Options struct {
+	// LargeTable is the row count from which text searches that can't use an
+	// index are flagged. Zero means DefaultLargeTable; negative disables the check.
+	LargeTable int
+	// Rows is the number of records in the table when already known. The Linter
+	// counts them when it's zero and a text search needs it; Check never does.
+	Rows int
+	// Indexed lists fields known to be indexed, besides sys_id, number,
+	// sys_created_on, sys_updated_on, sys_class_name and reference fields
+	Indexed []string
+}
+
+func (o Options) largeTable() int {
+	if o.LargeTable == 0 {
+		return DefaultLargeTable
+	}
+	return o.LargeTable
+}
+
+// Linter lints queries against schemas loaded from the instance
+type Linter struct {
+	client  *core.Client
+	schemas *schema.SchemaClient
+	options Options
+}
+
+// NewLinter creates a linter. schemas may be shared with other callers to reuse
+// its cache; nil creates a new schema client.
+func NewLinter(client *core.Client, schemas *schema.SchemaClient, options Options) *Linter {
+	if schemas == nil {
+		schemas = schema.NewSchemaClient(client)
+	}
+	return &Linter{client: client, schemas: schemas, options: options}
+}
+
+// Lint checks an encoded query against the schema of a table
+func (l *Linter) Lint(tableName, encoded string) (*Result, error) {
+	return l.LintWithContext(context.Background(), tableName, encoded)
+}
+
+// LintWithContext checks an encoded query against the schema of a table with
+// context support. Dot-walked fields are resolved through the referenced tables.
+// Problems with the query are diagnostics; errors are failures to load schemas.
+func (l *Linter) LintWithContext(ctx context.Context, tableName, encoded string) (*Result, error) {
+	if _, err := l.schemas.GetTableWithContext(ctx, tableName); err != nil {
+		return nil, err
+	}
+	result := &Result{Table: tableName, Query: encoded, Rows: l.options.Rows}
+	rows := l.options.Rows
+	c := &checker{
+		table:   tableName,
+		options: l.options,
+		resolve: func(name string) (*schema.Path, error) {
+			return l.schemas.ResolvePath(ctx, tableName, name)
+		},
+		fieldNames: func(name string) []string {
+			table, err := l.schemas.GetTableWithContext(ctx, name)
+			if err != nil {
+				return nil
+			}
+			return table.FieldNames()
+		},
+		rows: func() int {
+			if rows == 0 {
+				rows = l.countRows(ctx, tableName)
+				result.Rows = max(rows, 0)
+			}
+			return rows
+		},
+	}
+	diagnostics, err := c.check(encoded)
+	if err != nil {
+		return nil, err
+	}
+	result.Diagnostics = diagnostics
+	return result, nil
+}
+
+// LintBuilder checks the query of a builder against the schema of a table
+func (l *Linter) LintBuilder(tableName string, qb *query.QueryBuilder) (*Result, error) {
+	if err := qb.Err(); err != nil {
+		return nil, err
+	}
+	return l.LintWithContext(context.Background(), tableName, qb.BuildQuery())
+}
+
+// LintBuilderWithContext checks the query of a builder with context support
+func (l *Linter) LintBuilderWithContext(ctx context.Context, tableName string, qb *query.QueryBuilder) (*Result, error) {
+	if err := qb.Err(); err != nil {
+		return nil, err
+	}
+	return l.LintWithContext(ctx, tableName, qb.BuildQuery())
+}
+
+// countRows reads X-Total-Count from a one-record page. Counting is best effort:
+// -1 is returned when the count isn't available, which skips the size checks.
+func (l *Linter) countRows(ctx context.Context, tableName string) int {
+	params := map[string]string{"sysparm_fields": "sys_id", "sysparm_limit": "1"}
+	var result interface{}
+	headers, err := l.client.RawRequestWithHeadersContext(ctx, "GET", fmt.Sprintf("/table/%s", tableName), nil, params, &result)
+	if err != nil {
+		return -1
+	}
+	count, err := strconv.Atoi(headers.Get("X-Total-Count"))
+	if err != nil {
+		return -1
+	}
+	return count
+}
+
+// Check lints an encoded query against an already loaded table schema, without
+// requests. Dot-walks into tables other than those given in related can't be
+// checked and are reported as info.
+func Check(table *schema.Table, encoded string, options Options, related ...*schema.Table) []Diagnostic {
+	tables := map[string]*schema.Table{table.Name: table}
+	for _, t := range related {
+		tables[t.Name] = t
+	}
+	c := &checker{
+		table:   table.Name,
+		options: options,
+		resolve: func(name string) (*schema.Path, error) {
+			return resolveLoaded(tables, table.Name, name)
+		},
+		fieldNames: func(name string) []string {
+			if t, ok := tables[name]; ok {
+				return t.FieldNames()
+			}
+			return nil
+		},
+		rows: func() int {
+			if options.Rows == 0 {
+				return -1
+			}
+			return options.Rows
+		},
+	}
+	diagnostics, _ := c.check(encoded)
+	return diagnostics
+}
+
+// errNotLoaded is returned by resolveLoaded for dot-walks into tables it doesn't have
+var errNotLoaded = errors.New("referenced table not loaded")
+
+// resolveLoaded resolves a field path like SchemaClient.ResolvePath, using only the given tables
+func resolveLoaded(tables map[string]*schema.Table, tableName, name string) (*schema.Path, error) {
+	path := &schema.Path{Name: name}
+	current := tableName
+	segments := strings.Split(name, ".")
+	for i, segment := range segments {
+		table, ok := tables[current]
+		if !ok {
+			return nil, errNotLoaded
+		}
+		field := table.Field(segment)
+		if field == nil {
+			return nil, &schema.PathError{Table: tableName, Path: name, Segment: segment, Reason: "is not a field of " + current}
+		}
+		path.Fields = append(path.Fields, field)
+		path.Tables = append(path.Tables, current)
+		if i < len(segments)-1 {
+			if !field.IsReference() {
+				return nil, &schema.PathError{Table: tableName, Path: name, Segment: segment, Reason: "is not a reference field and cannot be dot-walked"}
+			}
+			current = field.Reference
+		}
+	}
+	return path, nil
+}
@@ -0,0 +1,457 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
+)
+
+var (
+	sysIDPattern    = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	relativePattern = regexp.MustCompile(`^@(minute|hour|dayofweek|month|quarter|year)@(ago|ahead)@\d+$`)
+)
+
+// dateFormats are the absolute date and date/time forms the instance accepts in queries
+var dateFormats = []string{"2006-01-02 15:04:05", "2006-01-02"}
+
+// alwaysIndexed are fields every table has an index on
+var alwaysIndexed = []string{"sys_id", "number", "sys_created_on", "sys_updated_on", "sys_class_name"}
+
+// checker lints the conditions of one query
+type checker struct {
+	table   string
+	options Options
+	resolve func(name string) (*schema.Path, error)
+	// fieldNames lists the fields of a table, or nil when it isn't available
+	fieldNames func(tableName string) []string
+	rows       func() int // Records in the table, or -1 when unknown
+	out        []Diagnostic
+}
+
+func (c *checker) check(encoded string) ([]Diagnostic, error) {
+	if strings.TrimSpace(encoded) == "" {
+		c.add(Diagnostic{Severity: SeverityInfo, Code: CodeEmptyQuery, Message: "The query is empty and matches every record"})
+		return c.out, nil
+	}
+	filter, err := query.ParseEncoded(encoded)
+	if err != nil {
+		c.add(Diagnostic{Severity: SeverityError, Code: CodeSyntax, Message: err.Error()})
+		return c.out, nil
+	}
+	for _, condition := range filter.Conditions() {
+		if err := c.checkCondition(condition); err != nil {
+			return nil, err
+		}
+	}
+	for _, order := range filter.OrderBy {
+		if _, err := c.field(query.Condition{Field: order.Field, Operator: "ORDERBY"}); err != nil {
+			return nil, err
+		}
+	}
+	return c.out, nil
+}
+
+func (c *checker) add(d Diagnostic) {
+	c.out = append(c.out, d)
+}
+
+// diagnose adds a diagnostic about a condition
+func (c *checker) diagnose(condition query.Condition, severity Severity, code, message, suggestion string) {
+	c.add(Diagnostic{
+		Severity:   severity,
+		Code:       code,
+		Condition:  condition.String(),
+		Field:      condition.Field,
+		Operator:   string(condition.Operator),
+		Value:      condition.Value,
+		Message:    message,
+		Suggestion: suggestion,
+	})
+}
+
+// field resolves the field of a condition. A nil field without an error means
+// the field was reported and there is nothing more to check.
+func (c *checker) field(condition query.Condition) (*schema.Path, error) {
+	path, err := c.resolve(condition.Field)
+	var pathErr *schema.PathError
+	switch {
+	case err == nil:
+		return path, nil
+	case errors.As(err, &pathErr):
+		c.diagnose(condition, SeverityError, CodeUnknownField, fmt.Sprintf("%s %s", pathErr.Segment, pathErr.Reason), c.suggestField(condition.Field, pathErr.Segment))
+		return nil, nil
+	case errors.Is(err, errNotLoaded):
+		c.diagnose(condition, SeverityInfo, CodeNotChecked, "The dot-walked table isn't loaded, so the condition wasn't checked", "")
+		return nil, nil
+	}
+	return nil, err
+}
+
+// suggestField returns a "did you mean" hint for the segment of a field path
+// that didn't resolve, from the fields of the table it was looked up on
+func (c *checker) suggestField(name, segment string) string {
+	segments := strings.Split(name, ".")
+	i := 0
+	for i < len(segments)-1 && segments[i] != segment {
+		i++
+	}
+	tableName := c.table
+	if i > 0 {
+		path, err := c.resolve(strings.Join(segments[:i], "."))
+		if err != nil {
+			return ""
+		}
+		tableName = path.Field().Reference
+	}
+	best, bestDistance := "", len(segment)/3+1
+	for _, candidate := range c.fieldNames(tableName) {
+		if d := distance(segment, candidate); d < bestDistance || d == bestDistance && best == "" {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	segments[i] = best
+	return "Did you mean " + strings.Join(segments[:i+1], ".") + "?"
+}
+
+func (c *checker) checkCondition(condition query.Condition) error {
+	path, err := c.field(condition)
+	if path == nil || err != nil {
+		return err
+	}
+	field := path.Field()
+	op, value := condition.Operator, condition.Value
+	kind := kindOf(field)
+
+	switch op {
+	case query.OpIsEmpty, query.OpIsNotEmpty, query.OpAnything, query.OpEmptyString:
+		return nil
+	case query.OpSameAs, query.OpNotSameAs:
+		return c.checkSameAs(condition)
+	}
+
+	if isDateOperator(op) && kind != kindDate {
+		c.diagnose(condition, SeverityError, CodeOperatorType, fmt.Sprintf("%s only applies to date fields; %s is %s", op, condition.Field, field.Type), "")
+		return nil
+	}
+	if isTextOperator(op) {
+		switch kind {
+		case kindNumber, kindBoolean, kindDate:
+			c.diagnose(condition, SeverityWarning, CodeOperatorType, fmt.Sprintf("%s compares text, but %s is %s", op, condition.Field, field.Type), "Use =, !=, <, > or BETWEEN")
+		}
+		c.checkTextSearch(condition, field)
+	}
+	if isOrderingOperator(op) && (kind == kindBoolean || kind == kindReference) {
+		c.diagnose(condition, SeverityWarning, CodeOperatorType, fmt.Sprintf("%s has no useful order on %s field %s", op, field.Type, condition.Field), "Use = or !=")
+	}
+
+	if value == "" {
+		if op == query.OpEquals || op == query.OpNotEquals {
+			c.diagnose(condition, SeverityWarning, CodeMissingValue, "The condition compares with an empty value", fmt.Sprintf("Use %sISEMPTY or %sISNOTEMPTY", condition.Field, condition.Field))
+		} else {
+			c.diagnose(condition, SeverityError, CodeMissingValue, fmt.Sprintf("%s needs a value", op), "")
+		}
+		return nil
+	}
+	if strings.HasPrefix(value, "javascript:") {
+		return nil // Evaluated on the instance
+	}
+
+	if len(field.Choices) > 0 && isValueOperator(op) {
+		c.checkChoices(condition, field)
+		return nil
+	}
+	switch kind {
+	case kindDate:
+		c.checkDate(condition)
+	case kindNumber:
+		for _, v := range values(op, value) {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				c.diagnose(condition, SeverityError, CodeInvalidValue, fmt.Sprintf("%q is not a number, but %s is %s", v, condition.Field, field.Type), "")
+			}
+		}
+	case kindBoolean:
+		for _, v := range values(op, value) {
+			switch strings.ToLower(v) {
+			case "true", "false", "1", "0":
+			default:
+				c.diagnose(condition, SeverityError, CodeInvalidValue, fmt.Sprintf("%q is not a boolean", v), "Use true or false")
+			}
+		}
+	case kindReference:
+		c.checkReference(condition, field)
+	}
+	return nil
+}
+
+// checkSameAs checks the field a SAMEAS or NSAMEAS condition compares with
+func (c *checker) checkSameAs(condition query.Condition) error {
+	_, err := c.resolve(condition.Value)
+	var pathErr *schema.PathError
+	switch {
+	case err == nil, errors.Is(err, errNotLoaded):
+		return nil
+	case errors.As(err, &pathErr):
+		c.diagnose(condition, SeverityError, CodeUnknownField,
+			fmt.Sprintf("%s compares with %s, but %s %s", condition.Operator, condition.Value, pathErr.Segment, pathErr.Reason),
+			c.suggestField(condition.Value, pathErr.Segment))
+		return nil
+	}
+	return err
+}
+
+// checkDate checks absolute dates, named ranges and relative dates
+func (c *checker) checkDate(condition query.Condition) {
+	op, value := condition.Operator, condition.Value
+	switch op {
+	case query.OpOn, query.OpNotOn:
+		parts := strings.Split(value, "@")
+		if len(parts) != 3 || !isDateExpression(parts[1]) || !isDateExpression(parts[2]) {
+			c.diagnose(condition, SeverityError, CodeBadDate, fmt.Sprintf("%s expects label@start@end", op), "Build it with QueryBuilder.On or OnDate")
+		}
+		return
+	case query.OpRelativeAfter, query.OpRelativeBefore, query.OpRelativeOnOrAfter, query.OpRelativeOnOrBefore, query.OpRelativeOn:
+		if !relativePattern.MatchString(value) {
+			c.diagnose(condition, SeverityError, CodeBadDate, fmt.Sprintf("%s expects @unit@ago|ahead@amount", op), "Build it with QueryBuilder.Relative")
+		}
+		return
+	case query.OpDatePart:
+		if parts := strings.SplitN(value, "@", 2); len(parts) != 2 || !strings.HasPrefix(parts[1], "javascript:gs.datePart(") {
+			c.diagnose(condition, SeverityError, CodeBadDate, "DATEPART expects label@javascript:gs.datePart(...)", "Build it with QueryBuilder.DatePartOn")
+		}
+		return
+	case query.OpBetween:
+		parts := strings.Split(value, "@")
+		if len(parts) != 2 {
+			c.diagnose(condition, SeverityError, CodeBadDate, "BETWEEN expects start@end", "")
+			return
+		}
+		for _, part := range parts {
+			if !isDateExpression(part) {
+				c.diagnose(condition, SeverityError, CodeBadDate, fmt.Sprintf("%q is not a date", part), "Use YYYY-MM-DD or YYYY-MM-DD HH:MM:SS")
+			}
+		}
+		return
+	}
+	if isTextOperator(op) {
+		return
+	}
+	for _, v := range values(op, value) {
+		if !isDateExpression(v) {
+			c.diagnose(condition, SeverityError, CodeBadDate, fmt.Sprintf("%q is not a date", v), "Use YYYY-MM-DD or YYYY-MM-DD HH:MM:SS")
+		}
+	}
+}
+
+// checkReference flags display values compared with sys_ids
+func (c *checker) checkReference(condition query.Condition, field *schema.Field) {
+	if !isValueOperator(condition.Operator) {
+		return
+	}
+	for _, v := range values(condition.Operator, condition.Value) {
+		if !sysIDPattern.MatchString(v) {
+			c.diagnose(condition, SeverityWarning, CodeReferenceValue,
+				fmt.Sprintf("%s references %s and is compared by sys_id, but %q is not a sys_id", condition.Field, field.Reference, v),
+				fmt.Sprintf("Dot-walk to the display field, e.g. %s.name%s%s", condition.Field, condition.Operator, condition.Value))
+			return
+		}
+	}
+}
+
+// checkChoices flags values that aren't choices of the field, including labels used as values
+func (c *checker) checkChoices(condition query.Condition, field *schema.Field) {
+	items := values(condition.Operator, condition.Value)
+	for i, v := range items {
+		if hasChoiceValue(field, v) {
+			continue
+		}
+		if choice, ok := choiceByLabel(field, v); ok {
+			fixed := append([]string(nil), items...)
+			fixed[i] = choice.Value
+			c.diagnose(condition, SeverityError, CodeInvalidChoice,
+				fmt.Sprintf("%q is the label of choice %s, and queries compare values", v, choice.Value),
+				fmt.Sprintf("Use %s%s%s", condition.Field, condition.Operator, strings.Join(fixed, ",")))
+			continue
+		}
+		c.diagnose(condition, SeverityError, CodeInvalidChoice, fmt.Sprintf("%q is not a choice of %s", v, condition.Field), "Valid values: "+choiceList(field))
+	}
+}
+
+// checkTextSearch flags text searches that can't use an index on large tables
+func (c *checker) checkTextSearch(condition query.Condition, field *schema.Field) {
+	threshold := c.options.largeTable()
+	if threshold < 0 {
+		return
+	}
+	if condition.Operator == query.OpStartsWith && c.indexed(condition.Field, field) {
+		return
+	}
+	rows := c.rows()
+	if rows < threshold {
+		return
+	}
+	c.diagnose(condition, SeverityWarning, CodeUnindexedLike,
+		fmt.Sprintf("%s on %s can't use an index and scans %s, which has %d records", condition.Operator, condition.Field, c.table, rows),
+		"Add a selective condition on an indexed field, or use STARTSWITH on an indexed field")
+}
+
+func (c *checker) indexed(name string, field *schema.Field) bool {
+	if schema.IsDotWalk(name) {
+		return false
+	}
+	if field.IsReference() {
+		return true
+	}
+	for _, indexed := range append(alwaysIndexed, c.options.Indexed...) {
+		if indexed == name {
+			return true
+		}
+	}
+	return false
+}
+
+type kind int
+
+const (
+	kindText kind = iota
+	kindNumber
+	kindBoolean
+	kindDate
+	kindReference
+)
+
+// kindOf groups sys_dictionary internal types by how queries compare them
+func kindOf(field *schema.Field) kind {
+	switch field.Type {
+	case "integer", "longint", "decimal", "float", "numeric", "percent_complete", "order_index":
+		return kindNumber
+	case "boolean":
+		return kindBoolean
+	case "glide_date_time", "glide_date", "due_date", "date", "datetime", "calendar_date_time":
+		return kindDate
+	}
+	if field.IsReference() {
+		return kindReference
+	}
+	return kindText
+}
+
+func isDateOperator(op query.Operator) bool {
+	switch op {
+	case query.OpOn, query.OpNotOn, query.OpDatePart,
+		query.OpRelativeAfter, query.OpRelativeBefore, query.OpRelativeOnOrAfter, query.OpRelativeOnOrBefore, query.OpRelativeOn:
+		return true
+	}
+	return false
+}
+
+func isTextOperator(op query.Operator) bool {
+	switch op {
+	case query.OpLike, query.OpNotLike, query.OpNotLikeWord, query.OpContains, query.OpDoesNotContain, query.OpStartsWith, query.OpEndsWith:
+		return true
+	}
+	return false
+}
+
+func isOrderingOperator(op query.Operator) bool {
+	switch op {
+	case query.OpGreaterThan, query.OpGreaterThanOrEqual, query.OpLessThan, query.OpLessThanOrEqual, query.OpBetween:
+		return true
+	}
+	return false
+}
+
+// isValueOperator reports whether the operator compares with whole values
+func isValueOperator(op query.Operator) bool {
+	switch op {
+	case query.OpEquals, query.OpNotEquals, query.OpIn, query.OpNotIn:
+		return true
+	}
+	return false
+}
+
+// values splits the value of IN and NOT IN into its items
+func values(op query.Operator, value string) []string {
+	if op == query.OpIn || op == query.OpNotIn {
+		return strings.Split(value, ",")
+	}
+	if op == query.OpBetween {
+		return strings.Split(value, "@")
+	}
+	return []string{value}
+}
+
+func isDateExpression(value string) bool {
+	if strings.HasPrefix(value, "javascript:") {
+		return true
+	}
+	for _, format := range dateFormats {
+		if _, err := time.Parse(format, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func hasChoiceValue(field *schema.Field, value string) bool {
+	for _, choice := range field.Choices {
+		if choice.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func choiceByLabel(field *schema.Field, label string) (schema.Choice, bool) {
+	for _, choice := range field.Choices {
+		if strings.EqualFold(choice.Label, label) {
+			return choice, true
+		}
+	}
+	return schema.Choice{}, false
+}
+
+// choiceList returns the distinct choice values of a field, with their labels
+func choiceList(field *schema.Field) string {
+	seen := make(map[string]bool)
+	var items []string
+	for _, choice := range field.Choices {
+		if seen[choice.Value] {
+			continue
+		}
+		seen[choice.Value] = true
+		items = append(items, fmt.Sprintf("%s (%s)", choice.Value, choice.Label))
+	}
+	sort.Strings(items)
+	return strings.Join(items, ", ")
+}
+
+// distance is the Levenshtein distance between two field names
+func distance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,301 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
+)
+
+// DefaultTTL is how long cached metadata is used before it is loaded again
+const DefaultTTL = 24 * time.Hour
+
+// CIBaseClass is the root of the CMDB class hierarchy
+const CIBaseClass = "cmdb_ci"
+
+const pageSize = 1000
+
+// TableInfo describes a table from sys_db_object
+type TableInfo struct {
+	Name       string `json:"name"`
+	Label      string `json:"label"`
+	SuperClass string `json:"super_class,omitempty"`
+}
+
+// CatalogItem identifies an active service catalog item
+type CatalogItem struct {
+	SysID string `json:"sys_id"`
+	Name  string `json:"name"`
+}
+
+// Cache keeps instance metadata (table names, table schemas and catalog items) in
+// local JSON files, one directory per instance. Entries older than the TTL are
+// loaded again; when that fails, as when the instance is unreachable, the stale
+// entry is used instead.
+type Cache struct {
+	client *core.Client
+	schema *schema.SchemaClient
+	dir    string
+	ttl    time.Duration
+}
+
+// entry is the file format of a cached value
+type entry struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewCache creates a cache for the client's instance under dir with DefaultTTL.
+// Schemas are loaded through schemaClient.
+func NewCache(client *core.Client, schemaClient *schema.SchemaClient, dir string) *Cache {
+	return &Cache{
+		client: client,
+		schema: schemaClient,
+		dir:    filepath.Join(dir, instanceKey(client.InstanceURL)),
+		ttl:    DefaultTTL,
+	}
+}
+
+// SetTTL changes how long cached entries are used; zero loads every entry again
+func (c *Cache) SetTTL(ttl time.Duration) {
+	c.ttl = ttl
+}
+
+// Dir returns the directory holding the instance's entries
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Clear removes every cached entry of the instance
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.dir); err != nil {
+		return fmt.Errorf("failed to clear metadata cache: %w", err)
+	}
+	return nil
+}
+
+// Tables returns every table of the instance, ordered by name
+func (c *Cache) Tables(ctx context.Context) ([]TableInfo, error) {
+	var tables []TableInfo
+	err := c.load(ctx, "tables", &tables, func(ctx context.Context) (interface{}, error) {
+		records, err := c.list(ctx, "sys_db_object", "nameISNOTEMPTY^ORDERBYname", "name,label,super_class.name")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tables: %w", err)
+		}
+		tables := make([]TableInfo, 0, len(records))
+		for _, record := range records {
+			tables = append(tables, TableInfo{
+				Name:       str(record["name"]),
+				Label:      str(record["label"]),
+				SuperClass: str(record["super_class.name"]),
+			})
+		}
+		return tables, nil
+	})
+	return tables, err
+}
+
+// CIClasses returns the CMDB classes: cmdb_ci and every table extending it
+func (c *Cache) CIClasses(ctx context.Context) ([]TableInfo, error) {
+	tables, err := c.Tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parents := make(map[string]string, len(tables))
+	for _, t := range tables {
+		parents[t.Name] = t.SuperClass
+	}
+	var classes []TableInfo
+	for _, t := range tables {
+		// Bounded walk in case super_class has a cycle
+		name := t.Name
+		for depth := 0; name != "" && name != CIBaseClass && depth < 20; depth++ {
+			name = parents[name]
+		}
+		if name == CIBaseClass {
+			classes = append(classes, t)
+		}
+	}
+	return classes, nil
+}
+
+// Table returns the schema of a table, including its choices
+func (c *Cache) Table(ctx context.Context, tableName string) (*schema.Table, error) {
+	var table *schema.Table
+	err := c.load(ctx, filepath.Join("schema", filepath.Base(tableName)), &table, func(ctx context.Context) (interface{}, error) {
+		return c.schema.GetTableWithContext(ctx, tableName)
+	})
+	return table, err
+}
+
+// ResolveField returns the field a possibly dot-walked name refers to, following
+// reference fields from tableName (e.g. caller_id.department.name on incident)
+func (c *Cache) ResolveField(ctx context.Context, tableName, name string) (*schema.Field, error) {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		table, err := c.Table(ctx, tableName)
+		if err != nil {
+			return nil, err
+		}
+		field := table.Field(part)
+		if field == nil {
+			return nil, fmt.Errorf("table %s has no field %s", tableName, part)
+		}
+		if i == len(parts)-1 {
+			return field, nil
+		}
+		if !field.IsReference() {
+			return nil, fmt.Errorf("%s.%s is not a reference field", tableName, part)
+		}
+		tableName = field.Reference
+	}
+	return nil, fmt.Errorf("empty field name")
+}
+
+// CatalogItems returns the active service catalog items, ordered by name
+func (c *Cache) CatalogItems(ctx context.Context) ([]CatalogItem, error) {
+	var items []CatalogItem
+	err := c.load(ctx, "catalog_items", &items, func(ctx context.Context) (interface{}, error) {
+		records, err := c.list(ctx, "sc_cat_item", "active=true^ORDERBYname", "sys_id,name")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load catalog items: %w", err)
+		}
+		items := make([]CatalogItem, 0, len(records))
+		for _, record := range records {
+			items = append(items, CatalogItem{SysID: str(record["sys_id"]), Name: str(record["name"])})
+		}
+		return items, nil
+	})
+	return items, err
+}
+
+// load decodes the cached entry into v when it is fresh, and otherwise fetches and
+// caches it again, falling back to the stale entry when the fetch fails
+func (c *Cache) load(ctx context.Context, name string, v interface{}, fetch func(context.Context) (interface{}, error)) error {
+	path := filepath.Join(c.dir, name+".json")
+	cached := readEntry(path)
+	if cached != nil && time.Since(cached.FetchedAt) < c.ttl {
+		return json.Unmarshal(cached.Data, v)
+	}
+
+	value, err := fetch(ctx)
+	if err != nil {
+		if cached != nil {
+			return json.Unmarshal(cached.Data, v)
+		}
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	// The cache only saves requests, so a failed write is not an error
+	_ = writeEntry(path, &entry{FetchedAt: time.Now(), Data: data})
+	return json.Unmarshal(data, v)
+}
+
+func readEntry(path string) *entry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil
+	}
+	return &e
+}
+
+func writeEntry(path string, e *entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	// Write via a temp file so concurrent completions never read a partial entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// list reads every record matching the query, page by page
+func (c *Cache) list(ctx context.Context, tableName, query, fields string) ([]map[string]interface{}, error) {
+	var records []map[string]interface{}
+	for offset := 0; ; offset += pageSize {
+		params := map[string]string{
+			"sysparm_query":                  query,
+			"sysparm_fields":                 fields,
+			"sysparm_limit":                  strconv.Itoa(pageSize),
+			"sysparm_offset":                 strconv.Itoa(offset),
+			"sysparm_display_value":          "false",
+			"sysparm_exclude_reference_link": "true",
+		}
+		var result core.Response
+		if err := c.client.RawRequestWithContext(ctx, "GET", "/table/"+tableName, nil, params, &result); err != nil {
+			return nil, err
+		}
+		page, ok := result.Result.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected response type for %s: %T", tableName, result.Result)
+		}
+		for _, item := range page {
+			if record, ok := item.(map[string]interface{}); ok {
+				records = append(records, record)
+			}
+		}
+		// ACLs can shorten a page before the end, so only an empty page ends the listing
+		if len(page) == 0 {
+			return records, nil
+		}
+	}
+}
+
+// instanceKey names the cache directory of an instance after its host
+func instanceKey(instanceURL string) string {
+	host := instanceURL
+	if u, err := url.Parse(instanceURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	key := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, strings.ToLower(host))
+	if key == "" {
+		return "default"
+	}
+	return key
+}
+
+func str(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,564 @@
+package mirror
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+)
+
+const (
+	manifestFile = "manifest.json"
+	indexFile    = "index.json"
+
+	// compactSegments is the segment count above which a sync rewrites the live records into one segment
+	compactSegments = 16
+)
+
+// trackingFields are always mirrored, whatever fields are selected
+var trackingFields = []string{"sys_id", "sys_updated_on", "sys_mod_count"}
+
+// TableConfig selects what is mirrored of a table
+type TableConfig struct {
+	Table  string   `json:"table"`
+	Query  string   `json:"query,omitempty"`  // Encoded query selecting the mirrored records
+	Fields []string `json:"fields,omitempty"` // Mirrored fields (default: all)
+}
+
+// Manifest describes the mirror of one table
+type Manifest struct {
+	TableConfig
+	HighWater    string    `json:"high_water,omitempty"` // Latest sys_updated_on mirrored (UTC glide date/time)
+	Segments     []string  `json:"segments"`
+	Records      int       `json:"records"`
+	Lines        int       `json:"lines"` // Records plus superseded versions and tombstones in the segments
+	LastFullSync time.Time `json:"last_full_sync"`
+	LastSync     time.Time `json:"last_sync"`
+}
+
+// Location is where the current version of a record is stored
+type Location struct {
+	Segment   string `json:"segment"`
+	Offset    int64  `json:"offset"`
+	Length    int    `json:"length"`
+	ModCount  string `json:"mod_count,omitempty"`
+	UpdatedOn string `json:"updated_on,omitempty"`
+}
+
+// SyncOptions controls a sync
+type SyncOptions struct {
+	Full          bool // Discard the mirror and copy every record again
+	DetectDeletes bool // After a delta sync, drop records no longer returned by the query
+	Workers       int  // Parallel page requests (default table.DefaultParallelWorkers)
+}
+
+// SyncResult reports what a sync changed
+type SyncResult struct {
+	Table    string        `json:"table"`
+	Full     bool          `json:"full"`
+	Fetched  int           `json:"fetched"`
+	Created  int           `json:"created"`
+	Updated  int           `json:"updated"`
+	Deleted  int           `json:"deleted"`
+	Records  int           `json:"records"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Mirror keeps local copies of tables in a directory: per table a manifest, NDJSON
+// segments holding record versions in the order they were synced, and an index of
+// the current version of each record by sys_id
+type Mirror struct {
+	client *core.Client
+	dir    string
+}
+
+// NewMirror creates a mirror rooted at dir. client may be nil for read-only use.
+func NewMirror(client *core.Client, dir string) *Mirror {
+	return &Mirror{client: client, dir: dir}
+}
+
+// Tables returns the mirrored table names
+func (m *Mirror) Tables() ([]string, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read mirror directory: %w", err)
+	}
+	var tables []string
+	for _, entry := range entries {
+		if _, err := os.Stat(filepath.Join(m.dir, entry.Name(), manifestFile)); entry.IsDir() && err == nil {
+			tables = append(tables, entry.Name())
+		}
+	}
+	return tables, nil
+}
+
+// Status returns the manifest of a mirrored table, or nil if the table isn't mirrored
+func (m *Mirror) Status(tableName string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(m.tableDir(tableName), manifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read mirror manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse mirror manifest of %s: %w", tableName, err)
+	}
+	return &manifest, nil
+}
+
+// Sync brings the mirror of a table up to date
+func (m *Mirror) Sync(config TableConfig, options SyncOptions) (*SyncResult, error) {
+	return m.SyncWithContext(context.Background(), config, options)
+}
+
+// SyncWithContext copies every record of the table on the first sync (or with
+// options.Full) and afterwards only those updated since the high-water mark.
+// Changing the query or fields of a mirrored table requires a full sync.
+func (m *Mirror) SyncWithContext(ctx context.Context, config TableConfig, options SyncOptions) (*SyncResult, error) {
+	if m.client == nil {
+		return nil, fmt.Errorf("mirror has no client to sync with")
+	}
+	started := time.Now()
+	manifest, err := m.Status(config.Table)
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil && !options.Full && !sameConfig(manifest.TableConfig, config) {
+		return nil, fmt.Errorf("mirror of %s was synced with query %q and fields %v; run a full sync to change them",
+			config.Table, manifest.Query, manifest.Fields)
+	}
+
+	var result *SyncResult
+	if manifest == nil || options.Full {
+		result, err = m.fullSync(ctx, config, options)
+	} else {
+		result, err = m.deltaSync(ctx, manifest, options)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Duration = time.Since(started)
+	return result, nil
+}
+
+func sameConfig(a, b TableConfig) bool {
+	return a.Table == b.Table && a.Query == b.Query && strings.Join(a.Fields, ",") == strings.Join(b.Fields, ",")
+}
+
+// fullSync writes every record into a fresh mirror directory that replaces the old one
+func (m *Mirror) fullSync(ctx context.Context, config TableConfig, options SyncOptions) (*SyncResult, error) {
+	records, err := m.fetch(ctx, config, config.Query, options)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpDir := m.tableDir(config.Table) + ".sync"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(tmpDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create mirror directory: %w", err)
+	}
+
+	now := time.Now().UTC()
+	manifest := &Manifest{TableConfig: config, LastFullSync: now, LastSync: now}
+	index := make(map[string]Location, len(records))
+	if err := appendSegment(tmpDir, manifest, index, records); err != nil {
+		return nil, err
+	}
+	if err := writeJSON(filepath.Join(tmpDir, indexFile), index); err != nil {
+		return nil, err
+	}
+	manifest.Records = len(index)
+	if err := writeJSON(filepath.Join(tmpDir, manifestFile), manifest); err != nil {
+		return nil, err
+	}
+
+	if err := os.RemoveAll(m.tableDir(config.Table)); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmpDir, m.tableDir(config.Table)); err != nil {
+		return nil, fmt.Errorf("failed to replace mirror of %s: %w", config.Table, err)
+	}
+	return &SyncResult{Table: config.Table, Full: true, Fetched: len(records), Created: len(index), Records: len(index)}, nil
+}
+
+// deltaSync appends the records updated since the high-water mark as a new segment
+func (m *Mirror) deltaSync(ctx context.Context, manifest *Manifest, options SyncOptions) (*SyncResult, error) {
+	dir := m.tableDir(manifest.Table)
+	index, err := readIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := manifest.Query
+	if manifest.HighWater != "" {
+		highWater, err := time.ParseInLocation(table.GlideDateTimeLayout, manifest.HighWater, time.UTC)
+		if err != nil {
+			return nil, fmt.Errorf("invalid high-water mark %q: %w", manifest.HighWater, err)
+		}
+		// Queries are evaluated in the session user's timezone
+		location, err := table.SessionTimeZone(ctx, m.client)
+		if err != nil {
+			return nil, err
+		}
+		encoded = query.AndAll(encoded, "sys_updated_on>="+highWater.In(location).Format(table.GlideDateTimeLayout))
+	}
+	records, err := m.fetch(ctx, manifest.TableConfig, encoded, options)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{Table: manifest.Table, Fetched: len(records)}
+	var changed []map[string]interface{}
+	for _, record := range records {
+		sysID, _ := query.FieldValue(record, "sys_id")
+		modCount, _ := query.FieldValue(record, "sys_mod_count")
+		updatedOn, _ := query.FieldValue(record, "sys_updated_on")
+		location, exists := index[sysID]
+		if exists && location.ModCount == modCount && location.UpdatedOn == updatedOn {
+			continue // Re-read at the high-water mark, unchanged
+		}
+		if exists {
+			result.Updated++
+		} else {
+			result.Created++
+		}
+		changed = append(changed, record)
+	}
+
+	if options.DetectDeletes {
+		deleted, err := m.deletedIDs(ctx, manifest, index, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, sysID := range deleted {
+			changed = append(changed, map[string]interface{}{"sys_id": sysID, deletedKey: true})
+		}
+		result.Deleted = len(deleted)
+	}
+
+	manifest.LastSync = time.Now().UTC()
+	if len(changed) > 0 {
+		if err := appendSegment(dir, manifest, index, changed); err != nil {
+			return nil, err
+		}
+	}
+	var obsolete []string
+	if len(manifest.Segments) > compactSegments || manifest.Lines > 2*len(index)+1000 {
+		if obsolete, err = compact(dir, manifest, index); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeJSON(filepath.Join(dir, indexFile), index); err != nil {
+		return nil, err
+	}
+	manifest.Records = len(index)
+	if err := writeJSON(filepath.Join(dir, manifestFile), manifest); err != nil {
+		return nil, err
+	}
+	for _, name := range obsolete {
+		os.Remove(filepath.Join(dir, name))
+	}
+	result.Records = len(index)
+	return result, nil
+}
+
+// deletedKey marks tombstone lines in segments
+const deletedKey = "__deleted"
+
+// deletedIDs lists mirrored records the query no longer returns: deleted records and
+// records that stopped matching the query
+func (m *Mirror) deletedIDs(ctx context.Context, manifest *Manifest, index map[string]Location, options SyncOptions) ([]string, error) {
+	records, err := table.NewTableClient(m.client, manifest.Table).PaginateParallel(ctx, table.ListOptions{
+		Query:        manifest.Query,
+		Fields:       []string{"sys_id"},
+		DisplayValue: core.DisplayFalse,
+	}, table.ParallelOptions{Workers: options.Workers, PageSize: 1000})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s sys_ids: %w", manifest.Table, err)
+	}
+	live := make(map[string]bool, len(records))
+	for _, record := range records {
+		sysID, _ := query.FieldValue(record, "sys_id")
+		live[sysID] = true
+	}
+	var deleted []string
+	for sysID := range index {
+		if !live[sysID] {
+			deleted = append(deleted, sysID)
+		}
+	}
+	sort.Strings(deleted)
+	return deleted, nil
+}
+
+func (m *Mirror) fetch(ctx context.Context, config TableConfig, encoded string, options SyncOptions) ([]map[string]interface{}, error) {
+	fields := config.Fields
+	if len(fields) > 0 {
+		fields = append(append([]string{}, fields...), trackingFields...)
+	}
+	records, err := table.NewTableClient(m.client, config.Table).PaginateParallel(ctx, table.ListOptions{
+		Query:                encoded,
+		Fields:               fields,
+		DisplayValue:         core.DisplayFalse,
+		ExcludeReferenceLink: true,
+	}, table.ParallelOptions{Workers: options.Workers})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", config.Table, err)
+	}
+	return records, nil
+}
+
+// appendSegment writes records (and tombstones) to a new segment, updating the index,
+// the segment list and the high-water mark
+func appendSegment(dir string, manifest *Manifest, index map[string]Location, records []map[string]interface{}) error {
+	name := fmt.Sprintf("segment-%06d.ndjson", nextSegment(manifest.Segments))
+	file, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create mirror segment: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	var offset int64
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode record: %w", err)
+		}
+		line = append(line, '\n')
+		if _, err := writer.Write(line); err != nil {
+			return fmt.Errorf("failed to write mirror segment: %w", err)
+		}
+
+		sysID, _ := query.FieldValue(record, "sys_id")
+		if deleted, _ := record[deletedKey].(bool); deleted {
+			delete(index, sysID)
+		} else {
+			updatedOn, _ := query.FieldValue(record, "sys_updated_on")
+			modCount, _ := query.FieldValue(record, "sys_mod_count")
+			index[sysID] = Location{Segment: name, Offset: offset, Length: len(line), ModCount: modCount, UpdatedOn: updatedOn}
+			if updatedOn > manifest.HighWater {
+				manifest.HighWater = updatedOn
+			}
+		}
+		offset += int64(len(line))
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write mirror segment: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to write mirror segment: %w", err)
+	}
+	manifest.Segments = append(manifest.Segments, name)
+	manifest.Lines += len(records)
+	return nil
+}
+
+func nextSegment(segments []string) int {
+	next := 1
+	for _, name := range segments {
+		var n int
+		if _, err := fmt.Sscanf(name, "segment-%06d.ndjson", &n); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	return next
+}
+
+// compact rewrites the current record versions into a single segment and returns the
+// segments it replaced, which the caller removes once the new index and manifest are saved
+func compact(dir string, manifest *Manifest, index map[string]Location) ([]string, error) {
+	reader := &Reader{dir: dir, manifest: manifest, index: index}
+	var records []map[string]interface{}
+	if err := reader.Each(func(record map[string]interface{}) error {
+		records = append(records, record)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	old := manifest.Segments
+	compacted := &Manifest{Segments: old, HighWater: manifest.HighWater}
+	fresh := make(map[string]Location, len(index))
+	if err := appendSegment(dir, compacted, fresh, records); err != nil {
+		return nil, err
+	}
+	manifest.Segments = compacted.Segments[len(old):]
+	manifest.Lines = len(records)
+	for sysID, location := range fresh {
+		index[sysID] = location
+	}
+	return old, nil
+}
+
+func readIndex(dir string) (map[string]Location, error) {
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mirror index: %w", err)
+	}
+	index := make(map[string]Location)
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse mirror index: %w", err)
+	}
+	return index, nil
+}
+
+// writeJSON writes a file atomically
+func writeJSON(path string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func (m *Mirror) tableDir(tableName string) string {
+	return filepath.Join(m.dir, tableName)
+}
+
+// Reader reads the mirror of a table
+type Reader struct {
+	dir      string
+	manifest *Manifest
+	index    map[string]Location
+}
+
+// Open opens the mirror of a table for reading
+func (m *Mirror) Open(tableName string) (*Reader, error) {
+	manifest, err := m.Status(tableName)
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("%s is not mirrored; run a sync first", tableName)
+	}
+	index, err := readIndex(m.tableDir(tableName))
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{dir: m.tableDir(tableName), manifest: manifest, index: index}, nil
+}
+
+// Manifest returns the manifest the reader was opened with
+func (r *Reader) Manifest() *Manifest {
+	return r.manifest
+}
+
+// Count returns the number of mirrored records
+func (r *Reader) Count() int {
+	return len(r.index)
+}
+
+// Get returns a record by sys_id, or nil if it isn't mirrored
+func (r *Reader) Get(sysID string) (map[string]interface{}, error) {
+	location, ok := r.index[sysID]
+	if !ok {
+		return nil, nil
+	}
+	file, err := os.Open(filepath.Join(r.dir, location.Segment))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mirror segment: %w", err)
+	}
+	defer file.Close()
+
+	line := make([]byte, location.Length)
+	if _, err := file.ReadAt(line, location.Offset); err != nil {
+		return nil, fmt.Errorf("failed to read %s from the mirror: %w", sysID, err)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, fmt.Errorf("corrupt mirror record %s: %w", sysID, err)
+	}
+	return record, nil
+}
+
+// Each calls fn with the current version of every record, in sync order
+func (r *Reader) Each(fn func(record map[string]interface{}) error) error {
+	for _, name := range r.manifest.Segments {
+		if err := r.eachInSegment(name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reader) eachInSegment(name string, fn func(record map[string]interface{}) error) error {
+	file, err := os.Open(filepath.Join(r.dir, name))
+	if err != nil {
+		return fmt.Errorf("failed to open mirror segment: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var record map[string]interface{}
+			if err := json.Unmarshal(line, &record); err != nil {
+				return fmt.Errorf("corrupt line in %s at offset %d: %w", name, offset, err)
+			}
+			sysID, _ := query.FieldValue(record, "sys_id")
+			if location, ok := r.index[sysID]; ok && location.Segment == name && location.Offset == offset {
+				if err := fn(record); err != nil {
+					return err
+				}
+			}
+			offset += int64(len(line))
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read mirror segment: %w", err)
+		}
+	}
+}
+
+// Query returns the mirrored records matching an encoded query, sorted by its ORDERBY
+// terms. limit <= 0 returns every match.
+func (r *Reader) Query(encoded string, limit int) ([]map[string]interface{}, error) {
+	filter, err := query.ParseEncoded(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	var records []map[string]interface{}
+	if err := r.Each(func(record map[string]interface{}) error {
+		if filter.Match(record) {
+			records = append(records, record)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	filter.Sort(records)
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
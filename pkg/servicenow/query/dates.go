@@ -0,0 +1,244 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Date operators of encoded queries with typed builder methods
+const (
+	OpRelativeAfter      Operator = "RELATIVEGT" // After a time relative to now
+	OpRelativeBefore     Operator = "RELATIVELT" // Before a time relative to now
+	OpRelativeOnOrAfter  Operator = "RELATIVEGE"
+	OpRelativeOnOrBefore Operator = "RELATIVELE"
+	OpRelativeOn         Operator = "RELATIVEEE"
+	OpDatePart           Operator = "DATEPART"
+)
+
+const (
+	dateTimeLayout = "2006-01-02 15:04:05"
+	dateLayout     = "2006-01-02"
+)
+
+// DateRange is a named range for ON and NOTON, such as Today or Last week. Start
+// and End are GlideSystem expressions evaluated on the instance.
+type DateRange struct {
+	Label string
+	Start string
+	End   string
+}
+
+// String returns the range in the label@start@end form ON expects
+func (r DateRange) String() string {
+	return r.Label + "@javascript:" + r.Start + "@javascript:" + r.End
+}
+
+func namedRange(label, name string) DateRange {
+	return DateRange{Label: label, Start: "gs.beginningOf" + name + "()", End: "gs.endOf" + name + "()"}
+}
+
+// Named ranges offered by the instance's date filters
+var (
+	Today         = namedRange("Today", "Today")
+	Yesterday     = namedRange("Yesterday", "Yesterday")
+	Tomorrow      = namedRange("Tomorrow", "Tomorrow")
+	ThisWeek      = namedRange("This week", "ThisWeek")
+	LastWeek      = namedRange("Last week", "LastWeek")
+	NextWeek      = namedRange("Next week", "NextWeek")
+	ThisMonth     = namedRange("This month", "ThisMonth")
+	LastMonth     = namedRange("Last month", "LastMonth")
+	NextMonth     = namedRange("Next month", "NextMonth")
+	Last3Months   = namedRange("Last 3 months", "Last3Months")
+	Last6Months   = namedRange("Last 6 months", "Last6Months")
+	Last12Months  = namedRange("Last 12 months", "Last12Months")
+	ThisQuarter   = namedRange("This quarter", "ThisQuarter")
+	LastQuarter   = namedRange("Last quarter", "LastQuarter")
+	NextQuarter   = namedRange("Next quarter", "NextQuarter")
+	Last2Quarters = namedRange("Last 2 quarters", "Last2Quarters")
+	ThisYear      = namedRange("This year", "ThisYear")
+	LastYear      = namedRange("Last year", "LastYear")
+	NextYear      = namedRange("Next year", "NextYear")
+	Last2Years    = namedRange("Last 2 years", "Last2Years")
+	CurrentHour   = namedRange("Current hour", "CurrentHour")
+	LastHour      = namedRange("Last hour", "LastHour")
+)
+
+// LastDays returns the range from the start of the day n days ago to the end of today
+func LastDays(n int) DateRange {
+	return DateRange{
+		Label: fmt.Sprintf("Last %d days", n),
+		Start: fmt.Sprintf("gs.daysAgoStart(%d)", n),
+		End:   "gs.daysAgoEnd(0)",
+	}
+}
+
+// DateUnit is the unit of a relative date condition
+type DateUnit string
+
+const (
+	UnitMinute  DateUnit = "minute"
+	UnitHour    DateUnit = "hour"
+	UnitDay     DateUnit = "dayofweek"
+	UnitMonth   DateUnit = "month"
+	UnitQuarter DateUnit = "quarter"
+	UnitYear    DateUnit = "year"
+)
+
+// DatePart is a part of a date matched by DATEPART conditions, such as Monday,
+// March or the 9 o'clock hour, regardless of the rest of the date
+type DatePart struct {
+	Label string
+	Part  string
+	Value string
+}
+
+// Weekday returns the date part of a day of the week
+func Weekday(day time.Weekday) DatePart {
+	return DatePart{Label: day.String(), Part: "dayofweek", Value: strings.ToLower(day.String())}
+}
+
+// MonthPart returns the date part of a month of the year
+func MonthPart(month time.Month) DatePart {
+	return DatePart{Label: month.String(), Part: "month", Value: strings.ToLower(month.String())}
+}
+
+// QuarterPart returns the date part of a quarter (1-4)
+func QuarterPart(quarter int) DatePart {
+	return DatePart{Label: fmt.Sprintf("Quarter %d", quarter), Part: "quarter", Value: fmt.Sprint(quarter)}
+}
+
+// WeekPart returns the date part of a week of the year (1-53)
+func WeekPart(week int) DatePart {
+	return DatePart{Label: fmt.Sprintf("Week %d", week), Part: "week", Value: fmt.Sprint(week)}
+}
+
+// HourPart returns the date part of an hour of the day (0-23)
+func HourPart(hour int) DatePart {
+	return DatePart{Label: fmt.Sprintf("Hour %d", hour), Part: "hour", Value: fmt.Sprint(hour)}
+}
+
+// YearPart returns the date part of a year
+func YearPart(year int) DatePart {
+	return DatePart{Label: fmt.Sprint(year), Part: "year", Value: fmt.Sprint(year)}
+}
+
+// TimeZone sets the timezone time.Time arguments are written in. The default is
+// UTC, but the instance reads dates in encoded queries in the session user's
+// timezone: pass the one table.SessionTimeZone loads.
+func (q *QueryBuilder) TimeZone(location *time.Location) *QueryBuilder {
+	q.location = location
+	return q
+}
+
+// glideDateTime formats t as a glide date/time in the builder's timezone
+func (q *QueryBuilder) glideDateTime(t time.Time) string {
+	return t.In(q.timeZone()).Format(dateTimeLayout)
+}
+
+func (q *QueryBuilder) timeZone() *time.Location {
+	if q.location == nil {
+		return time.UTC
+	}
+	return q.location
+}
+
+// add appends a condition whose value is already in encoded query form
+func (q *QueryBuilder) add(field string, operator Operator, value string) *QueryBuilder {
+	q.conditions = append(q.conditions, field+string(operator)+value)
+	return q
+}
+
+// On matches dates within a named range, e.g. On("opened_at", query.LastWeek)
+func (q *QueryBuilder) On(field string, r DateRange) *QueryBuilder {
+	return q.add(field, OpOn, r.String())
+}
+
+// NotOn matches dates outside a named range
+func (q *QueryBuilder) NotOn(field string, r DateRange) *QueryBuilder {
+	return q.add(field, OpNotOn, r.String())
+}
+
+// OnDate matches date/times on the day of t in the builder's timezone
+func (q *QueryBuilder) OnDate(field string, t time.Time) *QueryBuilder {
+	return q.add(field, OpOn, dayRange(t.In(q.timeZone())))
+}
+
+// NotOnDate matches date/times on any other day than that of t
+func (q *QueryBuilder) NotOnDate(field string, t time.Time) *QueryBuilder {
+	return q.add(field, OpNotOn, dayRange(t.In(q.timeZone())))
+}
+
+func dayRange(t time.Time) string {
+	day := t.Format(dateLayout)
+	return fmt.Sprintf("%s@javascript:gs.dateGenerate('%s','start')@javascript:gs.dateGenerate('%s','end')", day, day, day)
+}
+
+// After matches date/times after t
+func (q *QueryBuilder) After(field string, t time.Time) *QueryBuilder {
+	return q.add(field, OpGreaterThan, q.glideDateTime(t))
+}
+
+// Before matches date/times before t
+func (q *QueryBuilder) Before(field string, t time.Time) *QueryBuilder {
+	return q.add(field, OpLessThan, q.glideDateTime(t))
+}
+
+// OnOrAfter matches date/times at or after t
+func (q *QueryBuilder) OnOrAfter(field string, t time.Time) *QueryBuilder {
+	return q.add(field, OpGreaterThanOrEqual, q.glideDateTime(t))
+}
+
+// OnOrBefore matches date/times at or before t
+func (q *QueryBuilder) OnOrBefore(field string, t time.Time) *QueryBuilder {
+	return q.add(field, OpLessThanOrEqual, q.glideDateTime(t))
+}
+
+// BetweenDates matches date/times from start to end, both included
+func (q *QueryBuilder) BetweenDates(field string, start, end time.Time) *QueryBuilder {
+	return q.add(field, OpBetween, q.dateGenerate(start)+"@"+q.dateGenerate(end))
+}
+
+func (q *QueryBuilder) dateGenerate(t time.Time) string {
+	t = t.In(q.timeZone())
+	return fmt.Sprintf("javascript:gs.dateGenerate('%s','%s')", t.Format(dateLayout), t.Format("15:04:05"))
+}
+
+// Relative adds a condition against a time relative to now: amount units ago,
+// or ahead when amount is negative. operator is one of the OpRelative operators.
+func (q *QueryBuilder) Relative(field string, operator Operator, amount int, unit DateUnit) *QueryBuilder {
+	direction := "ago"
+	if amount < 0 {
+		direction, amount = "ahead", -amount
+	}
+	return q.add(field, operator, fmt.Sprintf("@%s@%s@%d", unit, direction, amount))
+}
+
+// NewerThan matches date/times after amount units ago, e.g. NewerThan("sys_updated_on", 15, query.UnitMinute)
+func (q *QueryBuilder) NewerThan(field string, amount int, unit DateUnit) *QueryBuilder {
+	return q.Relative(field, OpRelativeAfter, amount, unit)
+}
+
+// OlderThan matches date/times before amount units ago
+func (q *QueryBuilder) OlderThan(field string, amount int, unit DateUnit) *QueryBuilder {
+	return q.Relative(field, OpRelativeBefore, amount, unit)
+}
+
+// DatePartOn matches dates whose part equals p, e.g. DatePartOn("opened_at", query.Weekday(time.Monday))
+func (q *QueryBuilder) DatePartOn(field string, p DatePart) *QueryBuilder {
+	return q.datePart(field, p, "EE")
+}
+
+// DatePartAfter matches dates whose part is after p
+func (q *QueryBuilder) DatePartAfter(field string, p DatePart) *QueryBuilder {
+	return q.datePart(field, p, "GT")
+}
+
+// DatePartBefore matches dates whose part is before p
+func (q *QueryBuilder) DatePartBefore(field string, p DatePart) *QueryBuilder {
+	return q.datePart(field, p, "LT")
+}
+
+func (q *QueryBuilder) datePart(field string, p DatePart, comparison string) *QueryBuilder {
+	return q.add(field, OpDatePart, fmt.Sprintf("%s@javascript:gs.datePart('%s','%s','%s')", p.Label, p.Part, p.Value, comparison))
+}
@@ -0,0 +1,349 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operators of encoded queries that the builder doesn't produce but ParseEncoded accepts
+const (
+	OpAnything    Operator = "ANYTHING"
+	OpEmptyString Operator = "EMPTYSTRING"
+	OpNotLikeWord Operator = "NOT LIKE"
+)
+
+// parseOperators is ordered so that no operator is shadowed by a shorter prefix of it
+var parseOperators = []Operator{
+	OpIsNotEmpty, OpIsEmpty, OpAnything, OpEmptyString,
+	OpNotIn, OpNotLikeWord, OpNotLike, OpDoesNotContain,
+	OpStartsWith, OpEndsWith, OpContains, OpLike, OpBetween,
+	OpRelativeAfter, OpRelativeBefore, OpRelativeOnOrAfter, OpRelativeOnOrBefore, OpRelativeOn, OpDatePart,
+	OpNotSameAs, OpSameAs, OpNotOn, OpOn, OpIn,
+	OpNotEquals, OpGreaterThanOrEqual, OpLessThanOrEqual, OpGreaterThan, OpLessThan, OpEquals,
+}
+
+var dateGeneratePattern = regexp.MustCompile(`gs\.dateGenerate\('([^']*)',\s*'([^']*)'\)`)
+
+// Condition is a single field comparison of an encoded query
+type Condition struct {
+	Field    string   `json:"field"`
+	Operator Operator `json:"operator"`
+	Value    string   `json:"value,omitempty"`
+}
+
+// String returns the condition in encoded query form
+func (c Condition) String() string {
+	return c.Field + string(c.Operator) + c.Value
+}
+
+// AndAll adds a condition to every ^NQ alternative of an encoded query. Appended
+// with a plain ^, it would only constrain the last alternative.
+func AndAll(encoded, condition string) string {
+	if encoded == "" {
+		return condition
+	}
+	alternatives := strings.Split(encoded, "^NQ")
+	for i, alternative := range alternatives {
+		if alternative == "" {
+			alternatives[i] = condition
+		} else {
+			alternatives[i] = alternative + "^" + condition
+		}
+	}
+	return strings.Join(alternatives, "^NQ")
+}
+
+// Order is an ORDERBY or ORDERBYDESC term of an encoded query
+type Order struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+// Filter is a parsed encoded query. Each alternative is a list of OR groups that
+// must all match; alternatives are separated by ^NQ and match independently.
+type Filter struct {
+	Alternatives [][][]Condition `json:"alternatives"`
+	OrderBy      []Order         `json:"order_by,omitempty"`
+}
+
+// ParseEncoded parses an encoded query such as "active=true^priorityIN1,2^ORurgency=1^ORDERBYnumber".
+// As on the instance, ^OR binds tighter than ^: a^b^ORc means a AND (b OR c).
+func ParseEncoded(encoded string) (*Filter, error) {
+	filter := &Filter{}
+	var current [][]Condition
+	for _, term := range strings.Split(encoded, "^") {
+		switch {
+		case term == "" || term == "EQ" || strings.HasPrefix(term, "GROUPBY"):
+			continue
+		case term == "NQ" || strings.HasPrefix(term, "NQ") && len(term) > 2 && isFieldStart(term[2]):
+			if len(current) > 0 {
+				filter.Alternatives = append(filter.Alternatives, current)
+			}
+			current = nil
+			if term == "NQ" {
+				continue
+			}
+			term = term[2:]
+		case strings.HasPrefix(term, "ORDERBYDESC"):
+			filter.OrderBy = append(filter.OrderBy, Order{Field: strings.TrimPrefix(term, "ORDERBYDESC"), Desc: true})
+			continue
+		case strings.HasPrefix(term, "ORDERBY"):
+			filter.OrderBy = append(filter.OrderBy, Order{Field: strings.TrimPrefix(term, "ORDERBY")})
+			continue
+		case term == "NOT" || term == "NOT(":
+			return nil, fmt.Errorf("^NOT groups are not supported")
+		case strings.HasPrefix(term, "OR") && len(term) > 2 && isFieldStart(term[2]):
+			if len(current) == 0 {
+				return nil, fmt.Errorf("%q: ^OR without a preceding condition", term)
+			}
+			condition, err := parseCondition(term[2:])
+			if err != nil {
+				return nil, err
+			}
+			current[len(current)-1] = append(current[len(current)-1], condition)
+			continue
+		}
+
+		condition, err := parseCondition(term)
+		if err != nil {
+			return nil, err
+		}
+		current = append(current, []Condition{condition})
+	}
+	if len(current) > 0 {
+		filter.Alternatives = append(filter.Alternatives, current)
+	}
+	return filter, nil
+}
+
+// parseCondition splits a term at the first operator following a field name
+func parseCondition(term string) (Condition, error) {
+	for i := 1; i < len(term); i++ {
+		if !isFieldChar(term[i-1]) {
+			break
+		}
+		for _, op := range parseOperators {
+			if strings.HasPrefix(term[i:], string(op)) {
+				return Condition{Field: term[:i], Operator: op, Value: term[i+len(op):]}, nil
+			}
+		}
+	}
+	return Condition{}, fmt.Errorf("%q is not a field condition", term)
+}
+
+func isFieldStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c == '_'
+}
+
+func isFieldChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.'
+}
+
+// Conditions returns every condition of the filter
+func (f *Filter) Conditions() []Condition {
+	var conditions []Condition
+	for _, alternative := range f.Alternatives {
+		for _, group := range alternative {
+			conditions = append(conditions, group...)
+		}
+	}
+	return conditions
+}
+
+// Validate reports conditions that Match can't evaluate locally, such as
+// javascript: values and relative date operators
+func (f *Filter) Validate() error {
+	for _, condition := range f.Conditions() {
+		switch condition.Operator {
+		case OpOn, OpNotOn, OpBetween:
+			if _, _, err := dateRange(condition.Value); err != nil {
+				return fmt.Errorf("%s: %w", condition, err)
+			}
+		case OpRelativeAfter, OpRelativeBefore, OpRelativeOnOrAfter, OpRelativeOnOrBefore, OpRelativeOn, OpDatePart:
+			return fmt.Errorf("%s: relative dates can't be evaluated locally", condition)
+		default:
+			if strings.HasPrefix(condition.Value, "javascript:") {
+				return fmt.Errorf("%s: javascript values can't be evaluated locally", condition)
+			}
+		}
+	}
+	return nil
+}
+
+// Match reports whether a record matches the filter. Values are compared as the
+// Table API returns them: {value, link} references by sys_id, numbers numerically
+// and everything else, including glide date/times, as case-insensitive strings.
+func (f *Filter) Match(record map[string]interface{}) bool {
+	if len(f.Alternatives) == 0 {
+		return true
+	}
+	for _, alternative := range f.Alternatives {
+		if matchAll(alternative, record) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAll(groups [][]Condition, record map[string]interface{}) bool {
+	for _, group := range groups {
+		matched := false
+		for _, condition := range group {
+			if condition.Match(record) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Match evaluates the condition against a record
+func (c Condition) Match(record map[string]interface{}) bool {
+	value, present := FieldValue(record, c.Field)
+	lower, want := strings.ToLower(value), strings.ToLower(c.Value)
+
+	switch c.Operator {
+	case OpEquals:
+		return compare(value, c.Value) == 0
+	case OpNotEquals:
+		return compare(value, c.Value) != 0
+	case OpLessThan:
+		return value != "" && compare(value, c.Value) < 0
+	case OpLessThanOrEqual:
+		return value != "" && compare(value, c.Value) <= 0
+	case OpGreaterThan:
+		return value != "" && compare(value, c.Value) > 0
+	case OpGreaterThanOrEqual:
+		return value != "" && compare(value, c.Value) >= 0
+	case OpIsEmpty:
+		return value == ""
+	case OpIsNotEmpty:
+		return value != ""
+	case OpAnything:
+		return true
+	case OpEmptyString:
+		return present && value == ""
+	case OpStartsWith:
+		return strings.HasPrefix(lower, want)
+	case OpEndsWith:
+		return strings.HasSuffix(lower, want)
+	case OpContains, OpLike:
+		return strings.Contains(lower, want)
+	case OpDoesNotContain, OpNotLike, OpNotLikeWord:
+		return !strings.Contains(lower, want)
+	case OpIn, OpNotIn:
+		in := false
+		for _, candidate := range strings.Split(c.Value, ",") {
+			if compare(value, candidate) == 0 {
+				in = true
+				break
+			}
+		}
+		return in == (c.Operator == OpIn)
+	case OpSameAs, OpNotSameAs:
+		other, _ := FieldValue(record, c.Value)
+		return (compare(value, other) == 0) == (c.Operator == OpSameAs)
+	case OpOn, OpNotOn, OpBetween:
+		start, end, err := dateRange(c.Value)
+		if err != nil || value == "" {
+			return false
+		}
+		within := compare(value, start) >= 0 && compare(value, end) <= 0
+		return within == (c.Operator != OpNotOn)
+	}
+	return false
+}
+
+// dateRange returns the bounds of an ON or BETWEEN value: "2024-07-01", "start@end",
+// or the label@javascript:gs.dateGenerate(...)@javascript:gs.dateGenerate(...) form
+// the list filter produces
+func dateRange(value string) (string, string, error) {
+	if matches := dateGeneratePattern.FindAllStringSubmatch(value, -1); len(matches) == 2 {
+		return generatedDate(matches[0]), generatedDate(matches[1]), nil
+	}
+	if strings.Contains(value, "javascript:") {
+		return "", "", fmt.Errorf("relative dates can't be evaluated locally")
+	}
+	parts := strings.Split(value, "@")
+	switch {
+	case len(parts) == 1 && len(value) == len("2006-01-02"):
+		return value + " 00:00:00", value + " 23:59:59", nil
+	case len(parts) == 2:
+		return parts[0], parts[1], nil
+	}
+	return "", "", fmt.Errorf("unsupported date range %q", value)
+}
+
+func generatedDate(match []string) string {
+	switch match[2] {
+	case "start":
+		return match[1] + " 00:00:00"
+	case "end":
+		return match[1] + " 23:59:59"
+	}
+	return match[1] + " " + match[2]
+}
+
+// compare orders two values numerically when both are numbers, else as case-insensitive strings
+func compare(a, b string) int {
+	if x, err := strconv.ParseFloat(a, 64); err == nil {
+		if y, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// FieldValue returns a record field as a string: references and display/value pairs
+// give their value, embedded records their sys_id
+func FieldValue(record map[string]interface{}, field string) (string, bool) {
+	value, ok := record[field]
+	if !ok {
+		return "", false
+	}
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case map[string]interface{}:
+		if inner, ok := v["value"]; ok {
+			return fmt.Sprint(inner), true
+		}
+		if sysID, ok := v["sys_id"]; ok {
+			return fmt.Sprint(sysID), true
+		}
+		return "", true
+	}
+	return fmt.Sprint(value), true
+}
+
+// Sort orders records by the filter's ORDERBY terms
+func (f *Filter) Sort(records []map[string]interface{}) {
+	if len(f.OrderBy) == 0 {
+		return
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		for _, order := range f.OrderBy {
+			a, _ := FieldValue(records[i], order.Field)
+			b, _ := FieldValue(records[j], order.Field)
+			if c := compare(a, b); c != 0 {
+				return (c < 0) != order.Desc
+			}
+		}
+		return false
+	})
+}
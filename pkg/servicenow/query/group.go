@@ -0,0 +1,190 @@
+package query
+
+import (
+	"errors"
+	"strings"
+)
+
+var errNotGroup = errors.New("query: Not can't be applied to a group; negate its conditions instead")
+
+// Err returns the error of a query that can't be encoded, such as Not before a group
+func (q *QueryBuilder) Err() error {
+	if q.err != nil {
+		return q.err
+	}
+	for _, group := range q.groups {
+		if err := group.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AndGroup adds a nested group of conditions that must match along with the
+// rest of the query, e.g. state=1 AND (priority=1 OR priority=2):
+//
+//	New().Equals("state", 1).AndGroup(func(g *QueryBuilder) {
+//		g.Equals("priority", 1).Or().Equals("priority", 2)
+//	})
+//
+// Ordering, fields, limit and offset set on the group are ignored. Not negates
+// the condition after it; a group can't be negated, and Not before one makes
+// Err return an error.
+func (q *QueryBuilder) AndGroup(build func(g *QueryBuilder)) *QueryBuilder {
+	return q.addGroup(OpAnd, build)
+}
+
+// OrGroup adds a nested group of conditions as an alternative to the condition
+// before it. As with Or, ^OR binds tighter than ^, so a^b followed by OrGroup(g)
+// means a AND (b OR g).
+func (q *QueryBuilder) OrGroup(build func(g *QueryBuilder)) *QueryBuilder {
+	return q.addGroup(OpOr, build)
+}
+
+func (q *QueryBuilder) addGroup(join Operator, build func(g *QueryBuilder)) *QueryBuilder {
+	group := New().TimeZone(q.location)
+	build(group)
+	if len(group.conditions) == 0 {
+		return q
+	}
+	if n := len(q.conditions); n > 0 && Operator(q.conditions[n-1]) == OpNot && q.err == nil {
+		q.err = errNotGroup
+	}
+	if n := len(q.conditions); n > 0 && !isJoin(q.conditions[n-1]) {
+		q.conditions = append(q.conditions, string(join))
+	}
+	if q.groups == nil {
+		q.groups = make(map[int]*QueryBuilder)
+	}
+	q.groups[len(q.conditions)] = group
+	q.conditions = append(q.conditions, "")
+	return q
+}
+
+func isJoin(token string) bool {
+	switch Operator(token) {
+	case OpAnd, OpOr, OpNewQuery, OpNot:
+		return true
+	}
+	return false
+}
+
+// normalForm is a boolean expression in the shape encoded queries can express:
+// alternatives joined by ^NQ, each a list of clauses joined by ^ that must all
+// match, each clause a list of conditions joined by ^OR. An empty normalForm
+// matches every record.
+type normalForm [][][]string
+
+// compile turns the builder's conditions and nested groups into normal form,
+// reading its chain with the instance's precedence: ^OR binds tighter than ^,
+// and ^NQ separates alternatives. Conditions without a join between them are
+// ANDed, and ^NOT stays in front of the condition it negates.
+func (q *QueryBuilder) compile() normalForm {
+	var result, alternative, clause normalForm
+	closeClause := func() {
+		if clause != nil {
+			alternative = alternative.and(clause)
+			clause = nil
+		}
+	}
+	closeAlternative := func() {
+		closeClause()
+		switch {
+		case alternative == nil:
+		case result == nil:
+			result = alternative
+		default:
+			result = result.or(alternative)
+		}
+		alternative = nil
+	}
+
+	join := OpAnd
+	negate := false
+	for i, token := range q.conditions {
+		if Operator(token) == OpNot {
+			negate = true
+			continue
+		}
+		if isJoin(token) {
+			if Operator(token) == OpNewQuery {
+				closeAlternative()
+			}
+			join = Operator(token)
+			continue
+		}
+		operand := normalForm{{{token}}}
+		if group, ok := q.groups[i]; ok {
+			operand = group.compile()
+		} else if negate {
+			operand = normalForm{{{strings.TrimPrefix(string(OpNot), string(OpAnd)) + token}}}
+		}
+		negate = false
+		if join == OpOr && clause != nil {
+			clause = clause.or(operand)
+		} else {
+			closeClause()
+			clause = operand
+		}
+		join = OpAnd
+	}
+	closeAlternative()
+	return result
+}
+
+// and returns both expressions combined with AND. Every alternative of one is
+// paired with every alternative of the other.
+func (f normalForm) and(other normalForm) normalForm {
+	if len(f) == 0 {
+		return other
+	}
+	if len(other) == 0 {
+		return f
+	}
+	var result normalForm
+	for _, a := range f {
+		for _, b := range other {
+			clauses := make([][]string, 0, len(a)+len(b))
+			clauses = append(clauses, a...)
+			result = append(result, append(clauses, b...))
+		}
+	}
+	return result
+}
+
+// or returns both expressions combined with OR. When each is a single
+// alternative and one of them a single clause, OR distributes over its
+// clauses, so (a^b) OR c becomes a^ORc^b^ORc without ^NQ. Anything else
+// becomes separate ^NQ alternatives.
+func (f normalForm) or(other normalForm) normalForm {
+	if len(f) == 0 || len(other) == 0 {
+		return nil // one side matches every record
+	}
+	if len(f) == 1 && len(other) == 1 && (len(f[0]) == 1 || len(other[0]) == 1) {
+		var clauses [][]string
+		for _, a := range f[0] {
+			for _, b := range other[0] {
+				clause := make([]string, 0, len(a)+len(b))
+				clause = append(clause, a...)
+				clauses = append(clauses, append(clause, b...))
+			}
+		}
+		return normalForm{clauses}
+	}
+	result := make(normalForm, 0, len(f)+len(other))
+	result = append(result, f...)
+	return append(result, other...)
+}
+
+// String returns the expression as an encoded query
+func (f normalForm) String() string {
+	alternatives := make([]string, len(f))
+	for i, alternative := range f {
+		clauses := make([]string, len(alternative))
+		for j, clause := range alternative {
+			clauses[j] = strings.Join(clause, string(OpOr))
+		}
+		alternatives[i] = strings.Join(clauses, string(OpAnd))
+	}
+	return strings.Join(alternatives, string(OpNewQuery))
+}
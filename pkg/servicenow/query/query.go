@@ -5,15 +5,19 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // QueryBuilder provides a fluent interface for building ServiceNow encoded queries
 type QueryBuilder struct {
 	conditions []string
+	groups     map[int]*QueryBuilder // Nested groups by their index in conditions
 	orderBy    []string
 	fields     []string
 	limit      int
 	offset     int
+	location   *time.Location // Timezone of time.Time arguments (default UTC)
+	err        error          // Set when the conditions can't be encoded, see Err
 }
 
 // Operator represents query operators
@@ -88,6 +92,12 @@ func (q *QueryBuilder) Where(field string, operator Operator, value interface{})
 	return q
 }
 
+// WhereRaw adds a condition with the value as written, without the URL escaping
+// Where applies. The value must not contain the ^ separator.
+func (q *QueryBuilder) WhereRaw(field string, operator Operator, value string) *QueryBuilder {
+	return q.add(field, operator, value)
+}
+
 // Equals is a convenience method for equality comparison
 func (q *QueryBuilder) Equals(field string, value interface{}) *QueryBuilder {
 	return q.Where(field, OpEquals, value)
@@ -145,8 +155,14 @@ func (q *QueryBuilder) NotIn(field string, values []interface{}) *QueryBuilder {
 	return q.Where(field, OpNotIn, valueStr)
 }
 
-// Between checks if field value is between two values
+// Between checks if field value is between two values. Dates can be given as
+// time.Time, as with BetweenDates.
 func (q *QueryBuilder) Between(field string, start, end interface{}) *QueryBuilder {
+	if startTime, ok := start.(time.Time); ok {
+		if endTime, ok := end.(time.Time); ok {
+			return q.BetweenDates(field, startTime, endTime)
+		}
+	}
 	value := fmt.Sprintf("javascript:gs.dateGenerate('%s','%s')", formatValue(start), formatValue(end))
 	return q.Where(field, OpBetween, value)
 }
@@ -222,7 +238,7 @@ func (q *QueryBuilder) Build() map[string]string {
 	
 	// Build encoded query
 	if len(q.conditions) > 0 {
-		params["sysparm_query"] = strings.Join(q.conditions, "")
+		params["sysparm_query"] = q.BuildQuery()
 	}
 	
 	// Add fields
@@ -253,6 +269,9 @@ func (q *QueryBuilder) BuildQuery() string {
 	if len(q.conditions) == 0 {
 		return ""
 	}
+	if len(q.groups) > 0 {
+		return q.compile().String()
+	}
 	return strings.Join(q.conditions, "")
 }
 
@@ -264,9 +283,18 @@ func (q *QueryBuilder) Clone() *QueryBuilder {
 		fields:     make([]string, len(q.fields)),
 		limit:      q.limit,
 		offset:     q.offset,
+		location:   q.location,
+		err:        q.err,
 	}
 	
 	copy(clone.conditions, q.conditions)
+	if len(q.groups) > 0 {
+		// Groups are not changed once added, so they can be shared
+		clone.groups = make(map[int]*QueryBuilder, len(q.groups))
+		for i, group := range q.groups {
+			clone.groups[i] = group
+		}
+	}
 	copy(clone.orderBy, q.orderBy)
 	copy(clone.fields, q.fields)
 	
@@ -276,6 +304,7 @@ func (q *QueryBuilder) Clone() *QueryBuilder {
 // Reset clears all conditions and settings
 func (q *QueryBuilder) Reset() *QueryBuilder {
 	q.conditions = q.conditions[:0]
+	q.groups = nil
 	q.orderBy = q.orderBy[:0]
 	q.fields = q.fields[:0]
 	q.limit = 0
@@ -288,7 +317,7 @@ func (q *QueryBuilder) String() string {
 	var parts []string
 	
 	if len(q.conditions) > 0 {
-		parts = append(parts, fmt.Sprintf("WHERE: %s", strings.Join(q.conditions, "")))
+		parts = append(parts, fmt.Sprintf("WHERE: %s", q.BuildQuery()))
 	}
 	
 	if len(q.fields) > 0 {
@@ -361,12 +390,13 @@ func InactiveRecords() *QueryBuilder {
 
 // RecentRecords creates a query for records created in the last N days
 func RecentRecords(days int) *QueryBuilder {
-	return New().Where("sys_created_on", OpGreaterThan, fmt.Sprintf("javascript:gs.daysAgoStart(%d)", days))
+	return New().On("sys_created_on", LastDays(days))
 }
 
-// UpdatedSince creates a query for records updated since a specific date
-func UpdatedSince(date string) *QueryBuilder {
-	return New().Where("sys_updated_on", OpGreaterThan, date)
+// UpdatedSince creates a query for records updated after since, written in
+// location: the session timezone table.SessionTimeZone loads, or UTC when nil
+func UpdatedSince(since time.Time, location *time.Location) *QueryBuilder {
+	return New().TimeZone(location).After("sys_updated_on", since)
 }
 
 // ByState creates a query for records with specific state
@@ -0,0 +1,239 @@
+package related
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/aggregate"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+)
+
+// DefaultM2MTables are many-to-many tables that don't follow the <a>_m2m_<b> naming convention
+var DefaultM2MTables = []string{
+	"sys_user_grmember",
+	"sys_user_has_role",
+	"sys_group_has_role",
+	"task_ci",
+	"task_cmdb_ci_service",
+	"sc_cat_item_catalog",
+	"sc_cat_item_category",
+}
+
+// parentChunkSize bounds the parent sys_ids per IN query to keep request URLs short
+const parentChunkSize = 100
+
+// countWorkers is the number of related lists counted concurrently
+const countWorkers = 4
+
+// List is a related list: the records of Table whose reference Field points at the parent
+type List struct {
+	Table      string `json:"table"`
+	Field      string `json:"field"`
+	Label      string `json:"label"`                 // Label of the reference field
+	References string `json:"references"`            // Table the field references: the parent table or one of its ancestors
+	M2M        bool   `json:"m2m,omitempty"`         // Table links the parent to records of OtherTable
+	OtherField string `json:"other_field,omitempty"` // For m2m tables, the field referencing the other side
+	OtherTable string `json:"other_table,omitempty"`
+}
+
+// Name identifies the related list as table.field
+func (l List) Name() string {
+	return l.Table + "." + l.Field
+}
+
+// Query returns the encoded query selecting the related records of the given parents
+func (l List) Query(parentIDs ...string) string {
+	if len(parentIDs) == 1 {
+		return l.Field + "=" + parentIDs[0]
+	}
+	return l.Field + "IN" + strings.Join(parentIDs, ",")
+}
+
+// Count is the number of related records of one parent in a related list
+type Count struct {
+	List  List   `json:"list"`
+	Count int    `json:"count"`
+	Error string `json:"error,omitempty"` // Set when the list could not be counted, e.g. no read access
+}
+
+// RelatedClient discovers related lists from the schema and fetches related records
+type RelatedClient struct {
+	client *core.Client
+	schema *schema.SchemaClient
+}
+
+// NewRelatedClient creates a related list client. schemaClient may be nil, in which
+// case schemas are loaded (and cached) by a client of its own.
+func NewRelatedClient(client *core.Client, schemaClient *schema.SchemaClient) *RelatedClient {
+	if schemaClient == nil {
+		schemaClient = schema.NewSchemaClient(client)
+	}
+	return &RelatedClient{client: client, schema: schemaClient}
+}
+
+// Lists returns the related lists of a table
+func (r *RelatedClient) Lists(tableName string) ([]List, error) {
+	return r.ListsWithContext(context.Background(), tableName)
+}
+
+// ListsWithContext returns the related lists of a table: every active reference field
+// pointing at the table or one of its ancestors, so that task_sla.task is a related list
+// of incident. Many-to-many tables are flagged with the field leading to the other side.
+func (r *RelatedClient) ListsWithContext(ctx context.Context, tableName string) ([]List, error) {
+	parent, err := r.schema.GetTableWithContext(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	var lists []List
+	seen := make(map[string]bool)
+	for _, target := range parent.Hierarchy {
+		fields, err := r.schema.ReferencedBy(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range fields {
+			list := List{Table: field.DefinedOn, Field: field.Name, Label: field.Label, References: target}
+			if !field.Active || seen[list.Name()] {
+				continue
+			}
+			seen[list.Name()] = true
+			lists = append(lists, list)
+		}
+	}
+
+	for i := range lists {
+		if !isM2MTable(lists[i].Table) {
+			continue
+		}
+		if err := r.resolveOtherSide(ctx, &lists[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(lists, func(i, j int) bool {
+		if lists[i].Table != lists[j].Table {
+			return lists[i].Table < lists[j].Table
+		}
+		return lists[i].Field < lists[j].Field
+	})
+	return lists, nil
+}
+
+// resolveOtherSide finds the reference field of an m2m table that doesn't point at the parent
+func (r *RelatedClient) resolveOtherSide(ctx context.Context, list *List) error {
+	m2m, err := r.schema.GetTableWithContext(ctx, list.Table)
+	if err != nil {
+		return err
+	}
+	for _, field := range m2m.References() {
+		if field.Name == list.Field || strings.HasPrefix(field.Name, "sys_") || field.DefinedOn != list.Table {
+			continue
+		}
+		list.M2M = true
+		list.OtherField = field.Name
+		list.OtherTable = field.Reference
+		return nil
+	}
+	return nil
+}
+
+func isM2MTable(tableName string) bool {
+	if strings.Contains(tableName, "_m2m_") {
+		return true
+	}
+	for _, name := range DefaultM2MTables {
+		if name == tableName {
+			return true
+		}
+	}
+	return false
+}
+
+// Fetch returns the related records of the given parents, grouped by parent sys_id
+func (r *RelatedClient) Fetch(list List, parentIDs []string, options table.ListOptions) (map[string][]map[string]interface{}, error) {
+	return r.FetchWithContext(context.Background(), list, parentIDs, options)
+}
+
+// FetchWithContext returns the related records of one or many parents, grouped by parent
+// sys_id. Parents are queried in bulk with IN queries; options.Query further filters the
+// related records and options.Expand can embed the other side of an m2m list.
+func (r *RelatedClient) FetchWithContext(ctx context.Context, list List, parentIDs []string, options table.ListOptions) (map[string][]map[string]interface{}, error) {
+	if options.DisplayValue == core.DisplayTrue && options.ExcludeReferenceLink {
+		return nil, fmt.Errorf("related records can't be grouped by parent with display values and no reference links")
+	}
+	if len(options.Fields) > 0 {
+		options.Fields = append(append([]string{}, options.Fields...), list.Field)
+	}
+
+	filter := options.Query
+	related := make(map[string][]map[string]interface{}, len(parentIDs))
+	tc := table.NewTableClient(r.client, list.Table)
+	for start := 0; start < len(parentIDs); start += parentChunkSize {
+		end := start + parentChunkSize
+		if end > len(parentIDs) {
+			end = len(parentIDs)
+		}
+		options.Query = list.Query(parentIDs[start:end]...)
+		if filter != "" {
+			options.Query += "^" + filter
+		}
+		records, err := tc.PaginateParallel(ctx, options, table.ParallelOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", list.Name(), err)
+		}
+		for _, record := range records {
+			parentID := table.NewRecord(record, options.DisplayValue, nil).Reference(list.Field).SysID
+			related[parentID] = append(related[parentID], record)
+		}
+	}
+	return related, nil
+}
+
+// Counts returns the number of related records of a record in each related list
+func (r *RelatedClient) Counts(tableName, sysID string) ([]Count, error) {
+	return r.CountsWithContext(context.Background(), tableName, sysID)
+}
+
+// CountsWithContext counts the related records of a record in each related list. Lists
+// that can't be counted (typically for lack of read access) carry an Error instead.
+func (r *RelatedClient) CountsWithContext(ctx context.Context, tableName, sysID string) ([]Count, error) {
+	lists, err := r.ListsWithContext(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]Count, len(lists))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < countWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				count, err := aggregate.NewAggregateClient(r.client, lists[i].Table).CountRecordsWithRawQueryContext(ctx, lists[i].Query(sysID))
+				counts[i] = Count{List: lists[i], Count: count}
+				if err != nil {
+					counts[i].Error = err.Error()
+				}
+			}
+		}()
+	}
+	for i := range lists {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
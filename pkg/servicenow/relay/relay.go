@@ -0,0 +1,420 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/watch"
+)
+
+// Delivery defaults used when a config leaves them unset
+const (
+	DefaultMaxAttempts = 5
+	DefaultBaseDelay   = time.Second
+	DefaultMaxDelay    = time.Minute
+	DefaultTimeout     = 10 * time.Second
+)
+
+// Headers set on every delivery
+const (
+	SignatureHeader = "X-Relay-Signature" // sha256=<hex HMAC-SHA256 of the body>, when the target has a secret
+	EventHeader     = "X-Relay-Event"     // Event type: created, updated or deleted
+	DeliveryHeader  = "X-Relay-Delivery"  // Stable ID of the event, identical across retries
+)
+
+// Config describes what a relay watches and where it delivers events
+type Config struct {
+	Sources        []Source     `json:"sources"`
+	Targets        []Target     `json:"targets"`
+	Interval       Duration     `json:"interval,omitempty"`         // Time between polls (default watch.DefaultInterval)
+	MaxAttempts    int          `json:"max_attempts,omitempty"`     // Delivery attempts per event and target (default DefaultMaxAttempts)
+	BaseDelay      Duration     `json:"base_delay,omitempty"`       // First retry delay, doubled per attempt (default DefaultBaseDelay)
+	MaxDelay       Duration     `json:"max_delay,omitempty"`        // Retry delay cap (default DefaultMaxDelay)
+	CheckpointDir  string       `json:"checkpoint_dir,omitempty"`   // Directory of the per-source checkpoint files
+	DeadLetterFile string       `json:"dead_letter_file,omitempty"` // NDJSON file receiving undeliverable events
+	Since          time.Time    `json:"since,omitempty"`            // Start of sources without a checkpoint (default: now)
+	HTTPClient     *http.Client `json:"-"`
+}
+
+// Source is a watched table query
+type Source struct {
+	Name           string   `json:"name,omitempty"` // Names the checkpoint file (default: table and query)
+	Table          string   `json:"table"`
+	Query          string   `json:"query,omitempty"`
+	Fields         []string `json:"fields,omitempty"`
+	IncludeDeletes bool     `json:"deletes,omitempty"`
+	Targets        []string `json:"targets,omitempty"` // Target names receiving the events (default: all)
+}
+
+// Target is an HTTP endpoint receiving events
+type Target struct {
+	Name        string            `json:"name"`
+	URL         string            `json:"url"`
+	Secret      string            `json:"secret,omitempty"`     // HMAC-SHA256 signing key
+	SecretEnv   string            `json:"secret_env,omitempty"` // Environment variable holding the signing key
+	Fields      []string          `json:"fields,omitempty"`     // Record fields sent to this target (default: all)
+	Template    string            `json:"template,omitempty"`   // text/template rendering the body from the event (default: the event as JSON)
+	ContentType string            `json:"content_type,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Timeout     Duration          `json:"timeout,omitempty"` // Per-request timeout (default DefaultTimeout)
+
+	template *template.Template
+}
+
+// Duration is a time.Duration read from strings such as "30s" in config files
+type Duration time.Duration
+
+// UnmarshalJSON accepts Go duration strings
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("durations are strings such as \"30s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON writes durations as Go duration strings
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// DeadLetter is an event that could not be delivered to a target
+type DeadLetter struct {
+	Time     time.Time   `json:"time"`
+	Target   string      `json:"target"`
+	URL      string      `json:"url"`
+	Attempts int         `json:"attempts"`
+	Error    string      `json:"error"`
+	Event    watch.Event `json:"event"`
+}
+
+// LoadConfig reads a relay config file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read relay config: %w", err)
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse relay config %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Relay watches table queries and POSTs their events to HTTP targets
+type Relay struct {
+	client     *core.Client
+	config     Config
+	targets    map[string]*Target
+	httpClient *http.Client
+	deadMu     sync.Mutex
+}
+
+// NewRelay validates a config and creates a relay
+func NewRelay(client *core.Client, config Config) (*Relay, error) {
+	if len(config.Sources) == 0 || len(config.Targets) == 0 {
+		return nil, fmt.Errorf("relay config needs at least one source and one target")
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if config.BaseDelay <= 0 {
+		config.BaseDelay = Duration(DefaultBaseDelay)
+	}
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = Duration(DefaultMaxDelay)
+	}
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	r := &Relay{client: client, config: config, targets: make(map[string]*Target), httpClient: httpClient}
+	for i := range config.Targets {
+		target := config.Targets[i]
+		if target.Name == "" {
+			return nil, fmt.Errorf("target %d has no name", i+1)
+		}
+		if r.targets[target.Name] != nil {
+			return nil, fmt.Errorf("duplicate target %s", target.Name)
+		}
+		parsed, err := url.Parse(target.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("target %s: invalid URL %q", target.Name, target.URL)
+		}
+		if target.SecretEnv != "" {
+			target.Secret = os.Getenv(target.SecretEnv)
+			if target.Secret == "" {
+				return nil, fmt.Errorf("target %s: environment variable %s is not set", target.Name, target.SecretEnv)
+			}
+		}
+		if target.Template != "" {
+			tmpl, err := template.New(target.Name).Funcs(template.FuncMap{"json": toJSON}).Parse(target.Template)
+			if err != nil {
+				return nil, fmt.Errorf("target %s: invalid template: %w", target.Name, err)
+			}
+			target.template = tmpl
+		}
+		if target.Timeout <= 0 {
+			target.Timeout = Duration(DefaultTimeout)
+		}
+		r.targets[target.Name] = &target
+	}
+	for i, source := range config.Sources {
+		if source.Table == "" {
+			return nil, fmt.Errorf("source %d has no table", i+1)
+		}
+		for _, name := range source.Targets {
+			if r.targets[name] == nil {
+				return nil, fmt.Errorf("source %s: unknown target %s", source.Table, name)
+			}
+		}
+	}
+	return r, nil
+}
+
+// Run watches every source until ctx is cancelled or a source fails. Events a target
+// keeps rejecting go to the dead-letter file so they don't hold up the others; the
+// checkpoint of a source only advances once its events were delivered or dead-lettered.
+func (r *Relay) Run(ctx context.Context) error {
+	return r.run(ctx, false)
+}
+
+// RunOnce polls every source once and delivers the events
+func (r *Relay) RunOnce(ctx context.Context) error {
+	return r.run(ctx, true)
+}
+
+func (r *Relay) run(ctx context.Context, once bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(r.config.Sources))
+	var wg sync.WaitGroup
+	for _, source := range r.config.Sources {
+		watcher, err := watch.NewWatcher(r.client, source.Table, r.watchOptions(source))
+		if err != nil {
+			return err
+		}
+		wg.Add(1)
+		go func(source Source) {
+			defer wg.Done()
+			deliver := func(event watch.Event) error { return r.dispatch(ctx, source, event) }
+			var err error
+			if once {
+				err = watcher.RunOnce(ctx, deliver)
+			} else {
+				err = watcher.Run(ctx, deliver)
+			}
+			if err != nil && ctx.Err() == nil {
+				errs <- fmt.Errorf("source %s: %w", sourceName(source), err)
+				cancel()
+			}
+		}(source)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+func (r *Relay) watchOptions(source Source) watch.Options {
+	options := watch.Options{
+		Query:          source.Query,
+		Fields:         source.Fields,
+		Interval:       time.Duration(r.config.Interval),
+		Since:          r.config.Since,
+		IncludeDeletes: source.IncludeDeletes,
+	}
+	if r.config.CheckpointDir != "" {
+		options.CheckpointFile = filepath.Join(r.config.CheckpointDir, sourceName(source)+".json")
+	}
+	return options
+}
+
+func sourceName(source Source) string {
+	if source.Name != "" {
+		return source.Name
+	}
+	return strings.TrimSuffix(watch.CheckpointName(source.Table, source.Query), ".json")
+}
+
+// dispatch delivers an event to the targets of its source, dead-lettering failures
+func (r *Relay) dispatch(ctx context.Context, source Source, event watch.Event) error {
+	names := source.Targets
+	if len(names) == 0 {
+		for _, target := range r.config.Targets {
+			names = append(names, target.Name)
+		}
+	}
+	for _, name := range names {
+		target := r.targets[name]
+		attempts, err := r.Deliver(ctx, target, event)
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := r.deadLetter(DeadLetter{
+			Time:     time.Now().UTC(),
+			Target:   target.Name,
+			URL:      target.URL,
+			Attempts: attempts,
+			Error:    err.Error(),
+			Event:    event,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Deliver POSTs an event to a target, retrying network errors, 429 and 5xx responses
+// with exponential backoff. It returns the number of attempts made.
+func (r *Relay) Deliver(ctx context.Context, target *Target, event watch.Event) (int, error) {
+	body, err := target.Render(event)
+	if err != nil {
+		return 0, err
+	}
+
+	delay := time.Duration(r.config.BaseDelay)
+	for attempt := 1; ; attempt++ {
+		retryable, err := r.post(ctx, target, event, body)
+		if err == nil {
+			return attempt, nil
+		}
+		if !retryable || attempt >= r.config.MaxAttempts {
+			return attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > time.Duration(r.config.MaxDelay) {
+			delay = time.Duration(r.config.MaxDelay)
+		}
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth retrying
+func (r *Relay) post(ctx context.Context, target *Target, event watch.Event, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(target.Timeout))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	contentType := target.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set(EventHeader, string(event.Type))
+	req.Header.Set(DeliveryHeader, DeliveryID(event))
+	if target.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(target.Secret, body))
+	}
+	for key, value := range target.Headers {
+		req.Header.Set(key, os.ExpandEnv(value))
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("POST %s: %w", target.URL, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("POST %s: %s", target.URL, resp.Status)
+}
+
+// Render builds the request body of an event for the target, applying its field
+// filter and template
+func (t *Target) Render(event watch.Event) ([]byte, error) {
+	if len(t.Fields) > 0 && event.Record != nil {
+		filtered := make(map[string]interface{}, len(t.Fields))
+		for _, field := range t.Fields {
+			if value, ok := event.Record[field]; ok {
+				filtered[field] = value
+			}
+		}
+		event.Record = filtered
+	}
+
+	if t.template == nil {
+		return json.Marshal(event)
+	}
+	var buf bytes.Buffer
+	if err := t.template.Execute(&buf, event); err != nil {
+		return nil, fmt.Errorf("target %s: failed to render template: %w", t.Name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Sign returns the signature header value of a body: sha256= followed by the hex
+// HMAC-SHA256 of the body keyed with the secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// DeliveryID identifies an event so that receivers can drop duplicate deliveries
+func DeliveryID(event watch.Event) string {
+	return fmt.Sprintf("%s:%s:%s:%d", event.Table, event.SysID, event.Type, event.ModCount)
+}
+
+// deadLetter appends an undeliverable event to the dead-letter file
+func (r *Relay) deadLetter(letter DeadLetter) error {
+	if r.config.DeadLetterFile == "" {
+		return fmt.Errorf("failed to deliver %s to %s: %s", DeliveryID(letter.Event), letter.Target, letter.Error)
+	}
+
+	r.deadMu.Lock()
+	defer r.deadMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(r.config.DeadLetterFile), 0700); err != nil {
+		return fmt.Errorf("failed to create dead-letter directory: %w", err)
+	}
+	file, err := os.OpenFile(r.config.DeadLetterFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	defer file.Close()
+	if err := json.NewEncoder(file).Encode(letter); err != nil {
+		return fmt.Errorf("failed to write dead letter: %w", err)
+	}
+	return nil
+}
+
+func toJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	return string(data), err
+}
@@ -0,0 +1,368 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+)
+
+// DefaultCacheTTL is how long a loaded table schema is reused
+const DefaultCacheTTL = time.Hour
+
+// maxHierarchyDepth guards against cycles in sys_db_object.super_class
+const maxHierarchyDepth = 20
+
+const pageSize = 1000
+
+const dictionaryFields = "name,element,column_label,internal_type,max_length,mandatory,read_only,unique,calculated,active,choice,reference,reference_qual,default_value,dependent,comments"
+
+// SchemaClient loads table schemas from sys_db_object, sys_dictionary,
+// sys_dictionary_override and sys_choice and caches them
+type SchemaClient struct {
+	client   *core.Client
+	ttl      time.Duration
+	language string
+
+	mu    sync.Mutex
+	cache map[string]*Table
+}
+
+// NewSchemaClient creates a schema client with DefaultCacheTTL and English choices
+func NewSchemaClient(client *core.Client) *SchemaClient {
+	return &SchemaClient{
+		client:   client,
+		ttl:      DefaultCacheTTL,
+		language: "en",
+		cache:    make(map[string]*Table),
+	}
+}
+
+// SetCacheTTL changes how long schemas are cached; zero disables caching
+func (s *SchemaClient) SetCacheTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ttl = ttl
+}
+
+// SetLanguage selects the sys_choice language (default "en")
+func (s *SchemaClient) SetLanguage(language string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.language = language
+	s.cache = make(map[string]*Table)
+}
+
+// Invalidate drops a cached table schema, or all schemas when tableName is empty
+func (s *SchemaClient) Invalidate(tableName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tableName == "" {
+		s.cache = make(map[string]*Table)
+		return
+	}
+	delete(s.cache, tableName)
+}
+
+// GetTable returns the full schema of a table
+func (s *SchemaClient) GetTable(tableName string) (*Table, error) {
+	return s.GetTableWithContext(context.Background(), tableName)
+}
+
+// GetTableWithContext returns the full schema of a table, using the cache when fresh
+func (s *SchemaClient) GetTableWithContext(ctx context.Context, tableName string) (*Table, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[tableName]
+	ttl, language := s.ttl, s.language
+	s.mu.Unlock()
+	if ok && time.Since(cached.LoadedAt) < ttl {
+		return cached, nil
+	}
+
+	table, err := s.load(ctx, tableName, language)
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		s.mu.Lock()
+		s.cache[tableName] = table
+		s.mu.Unlock()
+	}
+	return table, nil
+}
+
+// Hierarchy returns the table followed by each parent table up to the root
+func (s *SchemaClient) Hierarchy(ctx context.Context, tableName string) ([]string, error) {
+	table, err := s.GetTableWithContext(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return table.Hierarchy, nil
+}
+
+// ReferencedBy returns the fields of any table that reference the given table
+func (s *SchemaClient) ReferencedBy(ctx context.Context, tableName string) ([]*Field, error) {
+	records, err := s.list(ctx, "sys_dictionary", "reference="+tableName+"^elementISNOTEMPTY^ORDERBYname^ORDERBYelement", dictionaryFields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load references to %s: %w", tableName, err)
+	}
+	fields := make([]*Field, 0, len(records))
+	for _, record := range records {
+		fields = append(fields, fieldFromDictionary(record))
+	}
+	return fields, nil
+}
+
+// load resolves the extension chain and merges dictionary, override and choice data
+func (s *SchemaClient) load(ctx context.Context, tableName, language string) (*Table, error) {
+	table := &Table{Name: tableName, LoadedAt: time.Now()}
+
+	// Walk sys_db_object.super_class up to the root table
+	current := tableName
+	for depth := 0; current != "" && depth < maxHierarchyDepth; depth++ {
+		records, err := s.list(ctx, "sys_db_object", "name="+current, "name,label,super_class.name")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load table %s: %w", current, err)
+		}
+		if len(records) == 0 {
+			if depth == 0 {
+				return nil, fmt.Errorf("table %s not found in sys_db_object", tableName)
+			}
+			break
+		}
+		parent := str(records[0]["super_class.name"])
+		if depth == 0 {
+			table.Label = str(records[0]["label"])
+			table.SuperClass = parent
+		}
+		table.Hierarchy = append(table.Hierarchy, current)
+		current = parent
+	}
+
+	extensions, err := s.list(ctx, "sys_db_object", "super_class.name="+tableName+"^ORDERBYname", "name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load extensions of %s: %w", tableName, err)
+	}
+	table.Extensions = make([]string, 0, len(extensions))
+	for _, record := range extensions {
+		table.Extensions = append(table.Extensions, str(record["name"]))
+	}
+
+	if err := s.loadFields(ctx, table); err != nil {
+		return nil, err
+	}
+	if err := s.applyOverrides(ctx, table); err != nil {
+		return nil, err
+	}
+	if err := s.loadChoices(ctx, table, language); err != nil {
+		return nil, err
+	}
+	table.sortFields()
+	return table, nil
+}
+
+// loadFields reads dictionary entries of the whole hierarchy; the most specific table wins
+func (s *SchemaClient) loadFields(ctx context.Context, table *Table) error {
+	records, err := s.list(ctx, "sys_dictionary", "nameIN"+strings.Join(table.Hierarchy, ",")+"^elementISNOTEMPTY", dictionaryFields)
+	if err != nil {
+		return fmt.Errorf("failed to load dictionary for %s: %w", table.Name, err)
+	}
+
+	rank := hierarchyRank(table.Hierarchy)
+	fields := make(map[string]*Field)
+	for _, record := range records {
+		field := fieldFromDictionary(record)
+		if existing, ok := fields[field.Name]; ok && rank[existing.DefinedOn] <= rank[field.DefinedOn] {
+			continue
+		}
+		fields[field.Name] = field
+	}
+
+	table.Fields = make([]*Field, 0, len(fields))
+	for _, field := range fields {
+		table.Fields = append(table.Fields, field)
+	}
+	table.index = nil
+	return nil
+}
+
+// applyOverrides applies sys_dictionary_override entries from the root down, so the
+// override closest to the table wins
+func (s *SchemaClient) applyOverrides(ctx context.Context, table *Table) error {
+	if len(table.Hierarchy) < 2 {
+		return nil
+	}
+	records, err := s.list(ctx, "sys_dictionary_override", "nameIN"+strings.Join(table.Hierarchy, ","),
+		"name,element,mandatory_override,mandatory,read_only_override,read_only,default_value_override,default_value,reference_qual_override,reference_qual,dependent_override,dependent")
+	if err != nil {
+		// Overrides need admin access on many instances; the base schema is still useful
+		if snErr, ok := core.IsServiceNowError(err); ok && (snErr.Type == core.ErrorTypeAuthorization || snErr.Type == core.ErrorTypeNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to load dictionary overrides for %s: %w", table.Name, err)
+	}
+
+	rank := hierarchyRank(table.Hierarchy)
+	sort.SliceStable(records, func(i, j int) bool {
+		return rank[str(records[i]["name"])] > rank[str(records[j]["name"])]
+	})
+	for _, record := range records {
+		field := table.Field(str(record["element"]))
+		if field == nil {
+			continue
+		}
+		if truthy(record["mandatory_override"]) {
+			field.Mandatory = truthy(record["mandatory"])
+		}
+		if truthy(record["read_only_override"]) {
+			field.ReadOnly = truthy(record["read_only"])
+		}
+		if truthy(record["default_value_override"]) {
+			field.DefaultValue = str(record["default_value"])
+		}
+		if truthy(record["reference_qual_override"]) {
+			field.ReferenceQualifier = str(record["reference_qual"])
+		}
+		if truthy(record["dependent_override"]) {
+			field.Dependent = str(record["dependent"])
+		}
+		field.OverriddenOn = append(field.OverriddenOn, str(record["name"]))
+	}
+	return nil
+}
+
+// loadChoices reads sys_choice for the hierarchy; each field takes its choices from the
+// most specific table defining any, as the platform does
+func (s *SchemaClient) loadChoices(ctx context.Context, table *Table, language string) error {
+	query := "nameIN" + strings.Join(table.Hierarchy, ",") + "^inactive=false"
+	if language != "" {
+		query += "^language=" + language
+	}
+	records, err := s.list(ctx, "sys_choice", query+"^ORDERBYsequence", "name,element,value,label,dependent_value,sequence")
+	if err != nil {
+		return fmt.Errorf("failed to load choices for %s: %w", table.Name, err)
+	}
+
+	rank := hierarchyRank(table.Hierarchy)
+	for _, record := range records {
+		field := table.Field(str(record["element"]))
+		if field == nil {
+			continue
+		}
+		source := str(record["name"])
+		if field.ChoicesFrom != "" && field.ChoicesFrom != source {
+			if rank[source] > rank[field.ChoicesFrom] {
+				continue
+			}
+			field.Choices = nil
+		}
+		field.ChoicesFrom = source
+		label := str(record["label"])
+		if label == "" {
+			label = str(record["value"])
+		}
+		sequence, _ := strconv.Atoi(str(record["sequence"]))
+		field.Choices = append(field.Choices, Choice{
+			Value:          str(record["value"]),
+			Label:          label,
+			DependentValue: str(record["dependent_value"]),
+			Sequence:       sequence,
+		})
+	}
+	for _, field := range table.Fields {
+		sort.SliceStable(field.Choices, func(i, j int) bool { return field.Choices[i].Sequence < field.Choices[j].Sequence })
+	}
+	return nil
+}
+
+// list reads every record matching the query, page by page
+func (s *SchemaClient) list(ctx context.Context, tableName, query, fields string) ([]map[string]interface{}, error) {
+	var records []map[string]interface{}
+	for offset := 0; ; offset += pageSize {
+		params := map[string]string{
+			"sysparm_query":                  query,
+			"sysparm_fields":                 fields,
+			"sysparm_limit":                  strconv.Itoa(pageSize),
+			"sysparm_offset":                 strconv.Itoa(offset),
+			"sysparm_display_value":          "false",
+			"sysparm_exclude_reference_link": "true",
+		}
+		var result core.Response
+		if err := s.client.RawRequestWithContext(ctx, "GET", "/table/"+tableName, nil, params, &result); err != nil {
+			return nil, err
+		}
+		page, ok := result.Result.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected response type for %s: %T", tableName, result.Result)
+		}
+		for _, item := range page {
+			if record, ok := item.(map[string]interface{}); ok {
+				records = append(records, record)
+			}
+		}
+		if len(page) < pageSize {
+			return records, nil
+		}
+	}
+}
+
+func fieldFromDictionary(record map[string]interface{}) *Field {
+	maxLength, _ := strconv.Atoi(str(record["max_length"]))
+	choice := str(record["choice"])
+	return &Field{
+		Name:               str(record["element"]),
+		Label:              str(record["column_label"]),
+		Type:               str(record["internal_type"]),
+		MaxLength:          maxLength,
+		Mandatory:          truthy(record["mandatory"]),
+		ReadOnly:           truthy(record["read_only"]),
+		Unique:             truthy(record["unique"]),
+		Calculated:         truthy(record["calculated"]),
+		Active:             str(record["active"]) != "false",
+		Choice:             choice != "" && choice != "0",
+		Reference:          str(record["reference"]),
+		ReferenceQualifier: str(record["reference_qual"]),
+		DefaultValue:       str(record["default_value"]),
+		Dependent:          str(record["dependent"]),
+		Comments:           str(record["comments"]),
+		DefinedOn:          str(record["name"]),
+	}
+}
+
+// hierarchyRank maps each table to its distance from the leaf table
+func hierarchyRank(hierarchy []string) map[string]int {
+	rank := make(map[string]int, len(hierarchy))
+	for i, name := range hierarchy {
+		rank[name] = i
+	}
+	return rank
+}
+
+func str(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case map[string]interface{}:
+		return str(val["value"])
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func truthy(v interface{}) bool {
+	switch val := v.(type) {
+	case bool:
+		return val
+	case string:
+		return val == "true" || val == "1"
+	default:
+		return false
+	}
+}
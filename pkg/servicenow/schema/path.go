@@ -0,0 +1,94 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Path is a dot-walked field such as caller_id.manager.name resolved against the schema
+type Path struct {
+	Name   string   `json:"name"`
+	Fields []*Field `json:"fields"` // One per segment; all but the last are references
+	Tables []string `json:"tables"` // Table each segment is read from
+}
+
+// Field returns the field the path ends on
+func (p *Path) Field() *Field {
+	return p.Fields[len(p.Fields)-1]
+}
+
+// Table returns the table the last field of the path is read from
+func (p *Path) Table() string {
+	return p.Tables[len(p.Tables)-1]
+}
+
+// IsDotWalk reports whether the path crosses at least one reference
+func (p *Path) IsDotWalk() bool {
+	return len(p.Fields) > 1
+}
+
+// PathError describes a field path that does not match the schema
+type PathError struct {
+	Table   string // Table the path starts on
+	Path    string
+	Segment string // Segment that failed to resolve
+	Reason  string
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("invalid field %q on %s: %s %s", e.Path, e.Table, e.Segment, e.Reason)
+}
+
+// IsDotWalk reports whether a field name walks through a reference
+func IsDotWalk(name string) bool {
+	return strings.Contains(name, ".")
+}
+
+// ResolvePath resolves a plain or dot-walked field name starting at the given table,
+// loading the schema of every referenced table along the way
+func (s *SchemaClient) ResolvePath(ctx context.Context, tableName, name string) (*Path, error) {
+	path := &Path{Name: name}
+	current := tableName
+	segments := strings.Split(name, ".")
+	for i, segment := range segments {
+		if segment == "" {
+			return nil, &PathError{Table: tableName, Path: name, Segment: fmt.Sprintf("segment %d", i+1), Reason: "is empty"}
+		}
+		table, err := s.GetTableWithContext(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		field := table.Field(segment)
+		if field == nil {
+			return nil, &PathError{Table: tableName, Path: name, Segment: segment, Reason: "is not a field of " + current}
+		}
+		path.Fields = append(path.Fields, field)
+		path.Tables = append(path.Tables, current)
+
+		if i < len(segments)-1 {
+			if !field.IsReference() {
+				return nil, &PathError{Table: tableName, Path: name, Segment: segment, Reason: "is not a reference field and cannot be dot-walked"}
+			}
+			current = field.Reference
+		}
+	}
+	return path, nil
+}
+
+// ValidateFields checks plain and dot-walked field names against the schema of a table.
+// All invalid names are reported together; schema load failures are returned as is.
+func (s *SchemaClient) ValidateFields(ctx context.Context, tableName string, names []string) error {
+	var errs []error
+	for _, name := range names {
+		if _, err := s.ResolvePath(ctx, tableName, name); err != nil {
+			var pathErr *PathError
+			if !errors.As(err, &pathErr) {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,140 @@
+package schema
+
+import (
+	"sort"
+	"time"
+)
+
+// Choice is a sys_choice entry of a choice field
+type Choice struct {
+	Value          string `json:"value"`
+	Label          string `json:"label"`
+	DependentValue string `json:"dependent_value,omitempty"` // Value of the dependent field this choice applies to
+	Sequence       int    `json:"sequence"`
+}
+
+// Field is a column of a table after inheritance and dictionary overrides are applied
+type Field struct {
+	Name               string   `json:"name"`
+	Label              string   `json:"label"`
+	Type               string   `json:"type"` // sys_dictionary internal_type, e.g. string, reference, glide_date_time
+	MaxLength          int      `json:"max_length,omitempty"`
+	Mandatory          bool     `json:"mandatory,omitempty"`
+	ReadOnly           bool     `json:"read_only,omitempty"`
+	Unique             bool     `json:"unique,omitempty"`
+	Calculated         bool     `json:"calculated,omitempty"`
+	Active             bool     `json:"active"`
+	Choice             bool     `json:"choice,omitempty"` // Rendered as a choice list
+	Reference          string   `json:"reference,omitempty"`
+	ReferenceQualifier string   `json:"reference_qualifier,omitempty"`
+	DefaultValue       string   `json:"default_value,omitempty"`
+	Dependent          string   `json:"dependent,omitempty"` // Field whose value filters the choices
+	Comments           string   `json:"comments,omitempty"`
+	Choices            []Choice `json:"choices,omitempty"`
+	DefinedOn          string   `json:"defined_on"`              // Table whose dictionary entry defines the field
+	OverriddenOn       []string `json:"overridden_on,omitempty"` // Tables with a sys_dictionary_override for the field
+	ChoicesFrom        string   `json:"choices_from,omitempty"`  // Table the choices were loaded from
+}
+
+// IsReference reports whether the field references another table
+func (f *Field) IsReference() bool {
+	return f.Reference != ""
+}
+
+// ChoicesFor returns the choices available when the dependent field has the given value.
+// Choices without a dependent value are always available.
+func (f *Field) ChoicesFor(dependentValue string) []Choice {
+	var choices []Choice
+	for _, choice := range f.Choices {
+		if choice.DependentValue == "" || choice.DependentValue == dependentValue {
+			choices = append(choices, choice)
+		}
+	}
+	return choices
+}
+
+// ChoiceLabel returns the label of a choice value, or the value itself when unknown
+func (f *Field) ChoiceLabel(value string) string {
+	for _, choice := range f.Choices {
+		if choice.Value == value {
+			return choice.Label
+		}
+	}
+	return value
+}
+
+// Table is the resolved schema of a table including inherited fields
+type Table struct {
+	Name       string    `json:"name"`
+	Label      string    `json:"label"`
+	SuperClass string    `json:"super_class,omitempty"`
+	Hierarchy  []string  `json:"hierarchy"`  // This table first, then each parent up to the root
+	Extensions []string  `json:"extensions"` // Tables directly extending this one
+	Fields     []*Field  `json:"fields"`     // Sorted by name
+	LoadedAt   time.Time `json:"loaded_at"`
+
+	index map[string]*Field
+}
+
+// Field returns the named field, or nil if the table has no such field
+func (t *Table) Field(name string) *Field {
+	if t.index == nil {
+		t.index = make(map[string]*Field, len(t.Fields))
+		for _, field := range t.Fields {
+			t.index[field.Name] = field
+		}
+	}
+	return t.index[name]
+}
+
+// FieldNames returns the names of all fields, sorted
+func (t *Table) FieldNames() []string {
+	names := make([]string, len(t.Fields))
+	for i, field := range t.Fields {
+		names[i] = field.Name
+	}
+	return names
+}
+
+// References returns the reference fields of the table
+func (t *Table) References() []*Field {
+	return t.filter(func(f *Field) bool { return f.IsReference() })
+}
+
+// ChoiceFields returns fields that have choices
+func (t *Table) ChoiceFields() []*Field {
+	return t.filter(func(f *Field) bool { return len(f.Choices) > 0 })
+}
+
+// FieldsDefinedOn returns the fields whose dictionary entry lives on the given table
+func (t *Table) FieldsDefinedOn(tableName string) []*Field {
+	return t.filter(func(f *Field) bool { return f.DefinedOn == tableName })
+}
+
+// Extends reports whether the table is, or extends, the given table
+func (t *Table) Extends(tableName string) bool {
+	for _, name := range t.Hierarchy {
+		if name == tableName {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Table) filter(match func(*Field) bool) []*Field {
+	var fields []*Field
+	for _, field := range t.Fields {
+		if match(field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// sortFields orders fields by name and rebuilds the lookup index, so a cached
+// table can be read concurrently
+func (t *Table) sortFields() {
+	sort.Slice(t.Fields, func(i, j int) bool { return t.Fields[i].Name < t.Fields[j].Name })
+	t.index = nil
+	t.Field("")
+}
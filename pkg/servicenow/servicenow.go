@@ -13,13 +13,19 @@ import (
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/cmdb"
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/identity"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/importer"
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/importset"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/metadata"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/related"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/watch"
 )
 
 // Client represents the main ServiceNow SDK client
 type Client struct {
-	core *core.Client
+	core   *core.Client
+	schema *schema.SchemaClient
 }
 
 // Config holds configuration options for the ServiceNow client
@@ -79,7 +85,8 @@ func NewClient(config Config) (*Client, error) {
 	}
 
 	return &Client{
-		core: coreClient,
+		core:   coreClient,
+		schema: schema.NewSchemaClient(coreClient),
 	}, nil
 }
 
@@ -119,9 +126,9 @@ func NewClientAPIKey(instanceURL, apiKey string) (*Client, error) {
 	})
 }
 
-// Table returns a table client for the specified table name
+// Table returns a table client for the specified table name, sharing this client's schema cache
 func (c *Client) Table(tableName string) *table.TableClient {
-	return table.NewTableClient(c.core, tableName)
+	return table.NewTableClient(c.core, tableName).WithSchema(c.schema)
 }
 
 // Attachment returns an attachment client
@@ -159,6 +166,31 @@ func (c *Client) Identity() *identity.IdentityClient {
 	return identity.NewIdentityClient(c.core)
 }
 
+// Schema returns the schema client; loaded table schemas are cached for the lifetime of this client
+func (c *Client) Schema() *schema.SchemaClient {
+	return c.schema
+}
+
+// Related returns a related list client sharing this client's schema cache
+func (c *Client) Related() *related.RelatedClient {
+	return related.NewRelatedClient(c.core, c.schema)
+}
+
+// Importer returns an importer for a table sharing this client's schema cache
+func (c *Client) Importer(tableName string) *importer.Importer {
+	return importer.NewImporter(c.core, c.schema, tableName)
+}
+
+// Metadata returns the on-disk metadata cache of this client's instance under dir
+func (c *Client) Metadata(dir string) *metadata.Cache {
+	return metadata.NewCache(c.core, c.schema, dir)
+}
+
+// Watch creates a watcher reporting changes to the records of a table
+func (c *Client) Watch(tableName string, options watch.Options) (*watch.Watcher, error) {
+	return watch.NewWatcher(c.core, tableName, options)
+}
+
 // Core returns the underlying core client for advanced usage
 // This allows access to raw HTTP client functionality when needed
 func (c *Client) Core() *core.Client {
@@ -0,0 +1,147 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenSymbol
+)
+
+type token struct {
+	kind   tokenKind
+	text   string // Identifier, number, unquoted string or symbol
+	pos    int    // 1-based offset in the statement
+	quoted bool   // Identifier quoted with backticks, which is never a keyword
+}
+
+// keyword reports whether the token is the given keyword, which is matched case-insensitively
+func (t token) keyword(word string) bool {
+	return t.kind == tokenIdent && !t.quoted && strings.EqualFold(t.text, word)
+}
+
+// reserved reports whether the token is a keyword that can't be read as a name
+func (t token) reserved() bool {
+	return t.kind == tokenIdent && !t.quoted && isKeyword(t.text)
+}
+
+func (t token) symbol(s string) bool {
+	return t.kind == tokenSymbol && t.text == s
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of statement"
+	case tokenString:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+	if t.quoted {
+		return "`" + t.text + "`"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// keywords can't be used as field or table names unless quoted with backticks,
+// as in ORDER BY `order`
+var keywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "AND": true, "OR": true, "NOT": true,
+	"IN": true, "IS": true, "EMPTY": true, "NULL": true, "LIKE": true, "BETWEEN": true,
+	"GROUP": true, "HAVING": true, "ORDER": true, "BY": true, "ASC": true, "DESC": true,
+	"LIMIT": true, "OFFSET": true, "AS": true, "TRUE": true, "FALSE": true,
+}
+
+func isKeyword(text string) bool {
+	return keywords[strings.ToUpper(text)]
+}
+
+// lex splits a statement into tokens. Strings are quoted with ' or ", doubling
+// the quote to include it; names are quoted with backticks.
+func lex(input string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(input) {
+		c := input[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case isIdentStart(c):
+			for i < len(input) && isIdentChar(input[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: input[start:i], pos: start + 1})
+		case isDigit(c) || (c == '-' && i+1 < len(input) && isDigit(input[i+1])):
+			i++
+			for i < len(input) && (isDigit(input[i]) || input[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: input[start:i], pos: start + 1})
+		case c == '\'' || c == '"':
+			var text strings.Builder
+			i++
+			for {
+				if i >= len(input) {
+					return nil, &SyntaxError{Pos: start + 1, Message: "unterminated string"}
+				}
+				if input[i] == c {
+					if i+1 < len(input) && input[i+1] == c {
+						text.WriteByte(c)
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				text.WriteByte(input[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text.String(), pos: start + 1})
+		case c == '`':
+			i++
+			for i < len(input) && isIdentChar(input[i]) {
+				i++
+			}
+			if i >= len(input) || input[i] != '`' || i == start+1 {
+				return nil, &SyntaxError{Pos: start + 1, Message: "backticks must enclose a field or table name"}
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenIdent, text: input[start+1 : i-1], pos: start + 1, quoted: true})
+		default:
+			symbol := ""
+			for _, s := range []string{"<=", ">=", "!=", "<>", "=", "<", ">", "(", ")", ",", "*", ";"} {
+				if strings.HasPrefix(input[i:], s) {
+					symbol = s
+					break
+				}
+			}
+			if symbol == "" {
+				return nil, &SyntaxError{Pos: start + 1, Message: fmt.Sprintf("unexpected character %q", c)}
+			}
+			i += len(symbol)
+			tokens = append(tokens, token{kind: tokenSymbol, text: symbol, pos: start + 1})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(input) + 1}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isIdentChar accepts dots so dot-walked fields like caller_id.name are one identifier
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '.'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,516 @@
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/aggregate"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+)
+
+// expr is a WHERE expression: a condition or a logical of conditions and logicals
+type expr interface{}
+
+// logical joins terms with AND, or with OR. Nested terms always use the other
+// join, as parsing flattens a AND (b AND c) into a AND b AND c.
+type logical struct {
+	or    bool
+	terms []expr
+}
+
+// dateBetween is a BETWEEN of two date literals, compiled with the builder's
+// typed date operators
+type dateBetween struct {
+	field      string
+	start, end time.Time
+}
+
+var aggregateFunctions = map[string]aggregate.AggregateType{
+	"COUNT":    aggregate.Count,
+	"SUM":      aggregate.Sum,
+	"AVG":      aggregate.Avg,
+	"MIN":      aggregate.Min,
+	"MAX":      aggregate.Max,
+	"STDDEV":   aggregate.StdDev,
+	"VARIANCE": aggregate.Variance,
+}
+
+var comparisons = map[string]query.Operator{
+	"=":  query.OpEquals,
+	"!=": query.OpNotEquals,
+	"<>": query.OpNotEquals,
+	"<":  query.OpLessThan,
+	"<=": query.OpLessThanOrEqual,
+	">":  query.OpGreaterThan,
+	">=": query.OpGreaterThanOrEqual,
+}
+
+type parser struct {
+	tokens  []token
+	pos     int
+	table   string
+	isField FieldChecker
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return &SyntaxError{Pos: t.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// accept consumes the next token when it's the keyword
+func (p *parser) accept(word string) bool {
+	if p.peek().keyword(word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(word string) error {
+	if t := p.next(); !t.keyword(word) {
+		return p.errorf(t, "expected %s, found %s", word, t)
+	}
+	return nil
+}
+
+func (p *parser) expectSymbol(s string) error {
+	if t := p.next(); !t.symbol(s) {
+		return p.errorf(t, "expected %q, found %s", s, t)
+	}
+	return nil
+}
+
+// name reads a field or table name
+func (p *parser) name(what string) (string, error) {
+	t := p.next()
+	if t.kind != tokenIdent || t.reserved() {
+		return "", p.nameError(t, what)
+	}
+	return t.text, nil
+}
+
+// nameError reports a token that isn't a name, suggesting backticks for keywords
+func (p *parser) nameError(t token, what string) error {
+	if t.reserved() {
+		return p.errorf(t, "expected %s, found keyword %s; quote it as `%s` to use it as a name", what, t.text, t.text)
+	}
+	return p.errorf(t, "expected %s, found %s", what, t)
+}
+
+func (p *parser) count() (int, error) {
+	t := p.next()
+	n, err := strconv.Atoi(t.text)
+	if t.kind != tokenNumber || err != nil || n < 0 {
+		return 0, p.errorf(t, "expected a non-negative whole number, found %s", t)
+	}
+	return n, nil
+}
+
+func (p *parser) statement() (*Statement, error) {
+	s := &Statement{}
+	if err := p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+	if err := p.selectList(s); err != nil {
+		return nil, err
+	}
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	table, err := p.name("a table name")
+	if err != nil {
+		return nil, err
+	}
+	s.Table = table
+	p.table = table
+
+	if p.accept("WHERE") {
+		if s.where, err = p.or(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("GROUP") {
+		if err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			field, err := p.name("a field to group by")
+			if err != nil {
+				return nil, err
+			}
+			s.GroupBy = append(s.GroupBy, field)
+			if !p.peek().symbol(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	if t := p.peek(); t.keyword("HAVING") {
+		return nil, p.errorf(t, "HAVING is not supported")
+	}
+	if p.accept("ORDER") {
+		if err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			field, err := p.name("a field to order by")
+			if err != nil {
+				return nil, err
+			}
+			order := query.Order{Field: field}
+			if p.accept("DESC") {
+				order.Desc = true
+			} else {
+				p.accept("ASC")
+			}
+			s.OrderBy = append(s.OrderBy, order)
+			if !p.peek().symbol(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	if p.accept("LIMIT") {
+		if s.Limit, err = p.count(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("OFFSET") {
+		if s.Offset, err = p.count(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek().symbol(";") {
+		p.next()
+	}
+	if t := p.next(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+	return s, s.check()
+}
+
+// selectList reads * or a list of fields and aggregate functions
+func (p *parser) selectList(s *Statement) error {
+	if p.peek().symbol("*") {
+		p.next()
+		return nil
+	}
+	for {
+		t := p.next()
+		if t.kind != tokenIdent || t.reserved() {
+			return p.nameError(t, "a field or aggregate function")
+		}
+		aggType, isFunction := aggregateFunctions[strings.ToUpper(t.text)]
+		if isFunction && !t.quoted && p.peek().symbol("(") {
+			p.next()
+			field := ""
+			if p.peek().symbol("*") && aggType == aggregate.Count {
+				p.next()
+			} else {
+				var err error
+				if field, err = p.name("a field"); err != nil {
+					return err
+				}
+			}
+			if err := p.expectSymbol(")"); err != nil {
+				return err
+			}
+			agg := aggregate.AggregateField{Field: field, Type: aggType}
+			if p.accept("AS") {
+				alias, err := p.name("an alias")
+				if err != nil {
+					return err
+				}
+				agg.Alias = alias
+			}
+			s.Aggregates = append(s.Aggregates, agg)
+		} else {
+			if p.peek().keyword("AS") {
+				return p.errorf(p.peek(), "aliases are only supported on aggregate functions")
+			}
+			s.Fields = append(s.Fields, t.text)
+		}
+		if !p.peek().symbol(",") {
+			return nil
+		}
+		p.next()
+	}
+}
+
+// or reads terms joined by OR, which binds looser than AND
+func (p *parser) or() (expr, error) {
+	return p.join("OR", p.and)
+}
+
+func (p *parser) and() (expr, error) {
+	return p.join("AND", p.primary)
+}
+
+func (p *parser) join(word string, term func() (expr, error)) (expr, error) {
+	var terms []expr
+	for {
+		e, err := term()
+		if err != nil {
+			return nil, err
+		}
+		if l, ok := e.(*logical); ok && l.or == (word == "OR") {
+			terms = append(terms, l.terms...)
+		} else {
+			terms = append(terms, e)
+		}
+		if !p.accept(word) {
+			break
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return &logical{or: word == "OR", terms: terms}, nil
+}
+
+func (p *parser) primary() (expr, error) {
+	t := p.peek()
+	if t.symbol("(") {
+		p.next()
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expectSymbol(")")
+	}
+	if t.keyword("NOT") {
+		return nil, p.errorf(t, "NOT is only supported in NOT IN, NOT LIKE and IS NOT EMPTY")
+	}
+	return p.predicate()
+}
+
+// predicate reads a comparison of a field and translates it to an encoded condition
+func (p *parser) predicate() (expr, error) {
+	field, err := p.name("a field")
+	if err != nil {
+		return nil, err
+	}
+	t := p.next()
+	if op, ok := comparisons[t.text]; ok && t.kind == tokenSymbol {
+		v := p.peek()
+		if v.kind == tokenIdent && !v.reserved() {
+			// Names on the right are fields
+			p.next()
+			if op != query.OpEquals && op != query.OpNotEquals {
+				return nil, p.errorf(v, "only = and != compare two fields; quote %s to compare with text", v.text)
+			}
+			if err := p.checkField(v); err != nil {
+				return nil, err
+			}
+			if op == query.OpEquals {
+				return query.Condition{Field: field, Operator: query.OpSameAs, Value: v.text}, nil
+			}
+			return query.Condition{Field: field, Operator: query.OpNotSameAs, Value: v.text}, nil
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		return query.Condition{Field: field, Operator: op, Value: value}, nil
+	}
+
+	not := t.keyword("NOT")
+	if not {
+		t = p.next()
+	}
+	switch {
+	case t.keyword("IN"):
+		values, err := p.list()
+		if err != nil {
+			return nil, err
+		}
+		op := query.OpIn
+		if not {
+			op = query.OpNotIn
+		}
+		return query.Condition{Field: field, Operator: op, Value: strings.Join(values, ",")}, nil
+	case t.keyword("LIKE"):
+		return p.like(field, not)
+	case t.keyword("BETWEEN") && !not:
+		return p.between(field, t)
+	case t.keyword("IS") && !not:
+		op := query.OpIsEmpty
+		if p.accept("NOT") {
+			op = query.OpIsNotEmpty
+		}
+		if !p.accept("EMPTY") && !p.accept("NULL") {
+			return nil, p.errorf(p.peek(), "expected EMPTY or NULL, found %s", p.peek())
+		}
+		return query.Condition{Field: field, Operator: op}, nil
+	}
+	if not {
+		return nil, p.errorf(t, "expected IN or LIKE after NOT, found %s", t)
+	}
+	return nil, p.errorf(t, "expected a comparison, IN, LIKE, BETWEEN or IS after %s, found %s", field, t)
+}
+
+// between reads the bounds of BETWEEN. Two date literals become a date range
+// whose end includes the whole day when it has no time; other values are
+// compared as they are.
+func (p *parser) between(field string, t token) (expr, error) {
+	startToken := p.peek()
+	start, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("AND"); err != nil {
+		return nil, err
+	}
+	endToken := p.peek()
+	end, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	startDate, _, startIsDate := parseDate(startToken)
+	endDate, wholeDay, endIsDate := parseDate(endToken)
+	switch {
+	case startIsDate && endIsDate:
+		if wholeDay {
+			endDate = endDate.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		return dateBetween{field: field, start: startDate, end: endDate}, nil
+	case startIsDate || endIsDate:
+		return nil, p.errorf(t, "BETWEEN bounds must both be dates")
+	}
+	if strings.Contains(start+end, "@") {
+		return nil, p.errorf(t, "BETWEEN values can't contain @")
+	}
+	return query.Condition{Field: field, Operator: query.OpBetween, Value: start + "@" + end}, nil
+}
+
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02 15:04:05"
+)
+
+// parseDate reads a quoted date/time or date literal; wholeDay is set for dates
+func parseDate(t token) (date time.Time, wholeDay bool, ok bool) {
+	if t.kind != tokenString {
+		return time.Time{}, false, false
+	}
+	if date, err := time.Parse(dateTimeLayout, t.text); err == nil {
+		return date, false, true
+	}
+	if date, err := time.Parse(dateLayout, t.text); err == nil {
+		return date, true, true
+	}
+	return time.Time{}, false, false
+}
+
+// checkField makes sure a name on the right of a comparison is a field rather
+// than text left unquoted. Dot-walked and backtick-quoted names are fields;
+// other names must be fields of the table according to the FieldChecker.
+func (p *parser) checkField(t token) error {
+	if t.quoted || strings.Contains(t.text, ".") {
+		return nil
+	}
+	if p.isField == nil {
+		return p.errorf(t, "quote %s as '%s' to compare with text, or as `%s` to compare with the field", t.text, t.text, t.text)
+	}
+	known, err := p.isField(p.table, t.text)
+	if err != nil {
+		return err
+	}
+	if !known {
+		return p.errorf(t, "%s is not a field of %s; quote it as '%s' to compare with text", t.text, p.table, t.text)
+	}
+	return nil
+}
+
+// value reads a literal. Encoded queries can't escape ^, so values containing it are rejected.
+func (p *parser) value() (string, error) {
+	t := p.next()
+	var value string
+	switch {
+	case t.kind == tokenString || t.kind == tokenNumber:
+		value = t.text
+	case t.keyword("TRUE"), t.keyword("FALSE"):
+		value = strings.ToLower(t.text)
+	default:
+		return "", p.errorf(t, "expected a value, found %s", t)
+	}
+	if strings.Contains(value, "^") {
+		return "", p.errorf(t, "values can't contain ^")
+	}
+	return value, nil
+}
+
+// list reads a parenthesized list of values for IN
+func (p *parser) list() ([]string, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	var values []string
+	for {
+		t := p.peek()
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(value, ",") {
+			return nil, p.errorf(t, "IN values can't contain commas")
+		}
+		values = append(values, value)
+		if !p.peek().symbol(",") {
+			break
+		}
+		p.next()
+	}
+	return values, p.expectSymbol(")")
+}
+
+// like translates a LIKE pattern: %text% searches, text% and %text match the
+// start and end, and a pattern without % is an equality. _ is not a wildcard.
+func (p *parser) like(field string, not bool) (expr, error) {
+	t := p.peek()
+	pattern, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	leading, trailing := strings.HasPrefix(pattern, "%"), strings.HasSuffix(pattern, "%") && len(pattern) > 1
+	text := strings.TrimSuffix(strings.TrimPrefix(pattern, "%"), "%")
+	if strings.Contains(text, "%") {
+		return nil, p.errorf(t, "%% is only supported at the start and end of a LIKE pattern")
+	}
+	if text == "" && (leading || trailing) {
+		return nil, p.errorf(t, "LIKE pattern has no text; use IS NOT EMPTY")
+	}
+
+	var op query.Operator
+	switch {
+	case leading && trailing:
+		op = query.OpLike
+		if not {
+			op = query.OpNotLike
+		}
+	case not && !leading && !trailing:
+		op = query.OpNotEquals
+	case not:
+		return nil, p.errorf(t, "NOT LIKE only supports '%%text%%' patterns")
+	case leading:
+		op = query.OpEndsWith
+	case trailing:
+		op = query.OpStartsWith
+	default:
+		op = query.OpEquals
+	}
+	return query.Condition{Field: field, Operator: op, Value: text}, nil
+}
@@ -0,0 +1,213 @@
+// Package sql compiles a small SQL-like language to encoded queries and
+// aggregate queries, e.g.
+//
+//	SELECT number, short_description FROM incident
+//	WHERE priority <= 2 AND (state IN (1,2) OR assigned_to IS EMPTY)
+//	ORDER BY sys_created_on DESC LIMIT 50
+//
+// WHERE supports =, !=, <>, <, <=, >, >=, [NOT] IN (...), [NOT] LIKE, BETWEEN
+// ... AND ..., IS [NOT] EMPTY (or NULL), AND, OR and parentheses. Strings are
+// quoted with ' or ", and names with backticks. A quoted or dot-walked name on
+// the right of = or != is a field, as in opened_by = `assigned_to`. COUNT(*),
+// COUNT, SUM, AVG, MIN, MAX, STDDEV and VARIANCE with GROUP BY compile to
+// aggregate queries. BETWEEN two dates, such as '2024-01-01' AND '2024-01-31',
+// includes the whole end day. Names that are keywords must be quoted, as in
+// ORDER BY `order`.
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/aggregate"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+)
+
+// SyntaxError is returned for statements that can't be parsed or compiled
+type SyntaxError struct {
+	Pos     int // 1-based offset of the problem in the statement
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Pos, e.Message)
+}
+
+// Statement is a parsed SELECT statement
+type Statement struct {
+	Table      string
+	Fields     []string                   // Selected fields; empty for SELECT *
+	Aggregates []aggregate.AggregateField // Aggregate functions; empty for record queries
+	GroupBy    []string
+	OrderBy    []query.Order
+	Limit      int // Zero when the statement has no LIMIT
+	Offset     int
+	where      expr
+}
+
+// FieldChecker reports whether name is a field of a table
+type FieldChecker func(table, name string) (bool, error)
+
+// Parse parses a SELECT statement. An unquoted name on the right of = or != must
+// be dot-walked or quoted with backticks to compare with a field, as it could
+// be text; ParseWithFields also accepts the table's own fields.
+func Parse(statement string) (*Statement, error) {
+	return ParseWithFields(statement, nil)
+}
+
+// ParseWithFields parses a SELECT statement, checking unquoted names on the
+// right of = or != with isField
+func ParseWithFields(statement string, isField FieldChecker) (*Statement, error) {
+	tokens, err := lex(statement)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, isField: isField}
+	return p.statement()
+}
+
+// check applies the rules of aggregate statements
+func (s *Statement) check() error {
+	if len(s.Aggregates) == 0 {
+		if len(s.GroupBy) > 0 {
+			return &SyntaxError{Pos: 1, Message: "GROUP BY needs an aggregate function such as COUNT(*)"}
+		}
+		return nil
+	}
+	grouped := make(map[string]bool, len(s.GroupBy))
+	for _, field := range s.GroupBy {
+		grouped[field] = true
+	}
+	for _, field := range s.Fields {
+		if !grouped[field] {
+			return &SyntaxError{Pos: 1, Message: fmt.Sprintf("%s must be in GROUP BY or in an aggregate function", field)}
+		}
+	}
+	for _, order := range s.OrderBy {
+		if !grouped[order.Field] {
+			return &SyntaxError{Pos: 1, Message: fmt.Sprintf("aggregate results can only be ordered by GROUP BY fields, not %s", order.Field)}
+		}
+	}
+	return nil
+}
+
+// IsAggregate reports whether the statement selects aggregate functions
+func (s *Statement) IsAggregate() bool {
+	return len(s.Aggregates) > 0
+}
+
+// Where returns a query builder holding the WHERE conditions
+func (s *Statement) Where() *query.QueryBuilder {
+	qb := query.New()
+	if s.where != nil {
+		build(qb, s.where)
+	}
+	return qb
+}
+
+// build adds an expression to a builder, grouping nested logicals
+func build(qb *query.QueryBuilder, e expr) {
+	l, ok := e.(*logical)
+	if !ok {
+		switch condition := e.(type) {
+		case dateBetween:
+			qb.BetweenDates(condition.field, condition.start, condition.end)
+		case query.Condition:
+			qb.WhereRaw(condition.Field, condition.Operator, condition.Value)
+		}
+		return
+	}
+	for i, term := range l.terms {
+		if nested, ok := term.(*logical); ok {
+			if l.or {
+				qb.OrGroup(func(g *query.QueryBuilder) { build(g, nested) })
+			} else {
+				qb.AndGroup(func(g *query.QueryBuilder) { build(g, nested) })
+			}
+			continue
+		}
+		if i > 0 {
+			if l.or {
+				qb.Or()
+			} else {
+				qb.And()
+			}
+		}
+		build(qb, term)
+	}
+}
+
+// Builder returns a query builder with the conditions, fields, limit and offset
+// of the statement. The builder can't hold ORDER BY as encoded terms; use Query
+// or ListOptions for the full query.
+func (s *Statement) Builder() *query.QueryBuilder {
+	qb := s.Where()
+	if len(s.Fields) > 0 {
+		qb.Fields(s.Fields...)
+	}
+	if s.Limit > 0 {
+		qb.Limit(s.Limit)
+	}
+	if s.Offset > 0 {
+		qb.Offset(s.Offset)
+	}
+	return qb
+}
+
+// Query returns the encoded query (sysparm_query), including ORDERBY terms
+func (s *Statement) Query() string {
+	terms := []string{}
+	if where := s.Where().BuildQuery(); where != "" {
+		terms = append(terms, where)
+	}
+	for _, order := range s.OrderBy {
+		if order.Desc {
+			terms = append(terms, "ORDERBYDESC"+order.Field)
+		} else {
+			terms = append(terms, "ORDERBY"+order.Field)
+		}
+	}
+	return strings.Join(terms, "^")
+}
+
+// Params returns the Table API parameters of the statement
+func (s *Statement) Params() map[string]string {
+	params := s.Builder().Build()
+	delete(params, "sysparm_query")
+	if encoded := s.Query(); encoded != "" {
+		params["sysparm_query"] = encoded
+	}
+	return params
+}
+
+// ListOptions returns the statement as options for TableClient.ListOpt
+func (s *Statement) ListOptions() table.ListOptions {
+	return table.ListOptions{Query: s.Query(), Fields: s.Fields, Limit: s.Limit, Offset: s.Offset}
+}
+
+// Aggregate returns the statement as an aggregate query on its table
+func (s *Statement) Aggregate(client *core.Client) *aggregate.AggregateQuery {
+	aq := aggregate.NewAggregateClient(client, s.Table).NewQuery().WhereQuery(s.Where())
+	for _, agg := range s.Aggregates {
+		aq.Aggregate(agg.Field, agg.Type, agg.Alias)
+	}
+	for _, field := range s.GroupBy {
+		aq.GroupByField(field, "")
+	}
+	for _, order := range s.OrderBy {
+		if order.Desc {
+			aq.OrderByDesc(order.Field)
+		} else {
+			aq.OrderByAsc(order.Field)
+		}
+	}
+	if s.Limit > 0 {
+		aq.Limit(s.Limit)
+	}
+	if s.Offset > 0 {
+		aq.Offset(s.Offset)
+	}
+	return aq
+}
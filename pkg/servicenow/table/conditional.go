@@ -0,0 +1,185 @@
+package table
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+)
+
+// DefaultMergeAttempts is the number of writes UpdateWithMerge tries before giving up
+const DefaultMergeAttempts = 3
+
+// ExpectedVersion describes the record state a conditional update was based on.
+// Empty fields are not checked.
+type ExpectedVersion struct {
+	ModCount  string                 // sys_mod_count
+	UpdatedOn string                 // sys_updated_on
+	Fields    map[string]interface{} // Additional field values that must be unchanged
+}
+
+// VersionOf captures the version of a previously read record. The listed
+// fields are also compared on update.
+func VersionOf(record map[string]interface{}, fields ...string) ExpectedVersion {
+	version := ExpectedVersion{
+		ModCount:  stringify(record["sys_mod_count"]),
+		UpdatedOn: stringify(record["sys_updated_on"]),
+	}
+	if len(fields) > 0 {
+		version.Fields = make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			version.Fields[field] = record[field]
+		}
+	}
+	return version
+}
+
+// FieldDiff is a single mismatch between the expected and current record
+type FieldDiff struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// ConflictError is returned when a record changed since the expected version
+type ConflictError struct {
+	Table   string                 `json:"table"`
+	SysID   string                 `json:"sys_id"`
+	Current map[string]interface{} `json:"current"` // Record as it is now on the server
+	Diffs   []FieldDiff            `json:"diffs"`
+}
+
+func (e *ConflictError) Error() string {
+	fields := make([]string, len(e.Diffs))
+	for i, diff := range e.Diffs {
+		fields[i] = diff.Field
+	}
+	return fmt.Sprintf("update conflict on %s/%s: record changed (%s)", e.Table, e.SysID, strings.Join(fields, ", "))
+}
+
+// IsConflictError checks if an error is a ConflictError
+func IsConflictError(err error) (*ConflictError, bool) {
+	var conflictErr *ConflictError
+	if errors.As(err, &conflictErr) {
+		return conflictErr, true
+	}
+	return nil, false
+}
+
+// MergeFunc resolves a conflict by returning the updates to write on top of
+// conflict.Current. Returning an error aborts the retry loop.
+type MergeFunc func(conflict *ConflictError, updates map[string]interface{}) (map[string]interface{}, error)
+
+// UpdateIfVersion performs a partial update only if the record still matches expected
+func (t *TableClient) UpdateIfVersion(sysID string, expected ExpectedVersion, record map[string]interface{}) (map[string]interface{}, error) {
+	return t.UpdateIfVersionWithContext(context.Background(), sysID, expected, record)
+}
+
+// UpdateIfVersionWithContext performs a conditional partial update with context support.
+// The Table API has no conditional write, so the version is checked immediately
+// before the PATCH; this narrows but does not close the race window.
+func (t *TableClient) UpdateIfVersionWithContext(ctx context.Context, sysID string, expected ExpectedVersion, record map[string]interface{}) (map[string]interface{}, error) {
+	current, err := t.getRaw(ctx, sysID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read current version: %w", err)
+	}
+
+	if diffs := compareVersion(expected, current); len(diffs) > 0 {
+		return nil, &ConflictError{Table: t.name, SysID: sysID, Current: current, Diffs: diffs}
+	}
+	return t.UpdateWithContext(ctx, sysID, record)
+}
+
+// UpdateWithMerge performs a conditional update, calling merge on each conflict
+// and retrying against the new server version up to maxAttempts times
+func (t *TableClient) UpdateWithMerge(sysID string, expected ExpectedVersion, record map[string]interface{}, merge MergeFunc, maxAttempts int) (map[string]interface{}, error) {
+	return t.UpdateWithMergeContext(context.Background(), sysID, expected, record, merge, maxAttempts)
+}
+
+// UpdateWithMergeContext performs a conditional update with merge retries and context support
+func (t *TableClient) UpdateWithMergeContext(ctx context.Context, sysID string, expected ExpectedVersion, record map[string]interface{}, merge MergeFunc, maxAttempts int) (map[string]interface{}, error) {
+	if merge == nil {
+		return nil, fmt.Errorf("merge function is required")
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMergeAttempts
+	}
+
+	updates := record
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		updated, err := t.UpdateIfVersionWithContext(ctx, sysID, expected, updates)
+		if err == nil {
+			return updated, nil
+		}
+		conflict, ok := IsConflictError(err)
+		if !ok {
+			return nil, err
+		}
+		lastErr = err
+
+		updates, err = merge(conflict, updates)
+		if err != nil {
+			return nil, fmt.Errorf("merge aborted: %w", err)
+		}
+		if updates == nil {
+			return nil, conflict
+		}
+
+		checked := make([]string, 0, len(expected.Fields))
+		for field := range expected.Fields {
+			checked = append(checked, field)
+		}
+		expected = VersionOf(conflict.Current, checked...)
+	}
+	return nil, fmt.Errorf("giving up after %d attempts: %w", maxAttempts, lastErr)
+}
+
+// compareVersion returns the differences between the expected version and the current record
+func compareVersion(expected ExpectedVersion, current map[string]interface{}) []FieldDiff {
+	var diffs []FieldDiff
+	if expected.ModCount != "" {
+		if actual := stringify(current["sys_mod_count"]); actual != expected.ModCount {
+			diffs = append(diffs, FieldDiff{Field: "sys_mod_count", Expected: expected.ModCount, Actual: actual})
+		}
+	}
+	if expected.UpdatedOn != "" {
+		if actual := stringify(current["sys_updated_on"]); actual != expected.UpdatedOn {
+			diffs = append(diffs, FieldDiff{Field: "sys_updated_on", Expected: expected.UpdatedOn, Actual: actual})
+		}
+	}
+
+	fields := make([]string, 0, len(expected.Fields))
+	for field := range expected.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		want := stringify(expected.Fields[field])
+		if actual := stringify(current[field]); actual != want {
+			diffs = append(diffs, FieldDiff{Field: field, Expected: want, Actual: actual})
+		}
+	}
+	return diffs
+}
+
+// getRaw reads the raw (non display) values of a record
+func (t *TableClient) getRaw(ctx context.Context, sysID string) (map[string]interface{}, error) {
+	params := map[string]string{
+		"sysparm_display_value":          "false",
+		"sysparm_exclude_reference_link": "true",
+	}
+	var result core.Response
+	err := t.client.RawRequestWithContext(ctx, "GET", fmt.Sprintf("/table/%s/%s", t.name, sysID), nil, params, &result)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := result.Result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type for get: %T", result.Result)
+	}
+	return record, nil
+}
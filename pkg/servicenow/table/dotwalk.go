@@ -0,0 +1,208 @@
+package table
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
+)
+
+// expandChunkSize bounds the sys_ids per IN query to keep request URLs short
+const expandChunkSize = 200
+
+// Nest reshapes the flat dot-walked keys the Table API returns ("caller_id.manager.name")
+// into nested objects. A reference that is both returned itself and dot-walked keeps
+// its own value as sys_id in the nested object. The input record is not modified.
+func Nest(record map[string]interface{}) map[string]interface{} {
+	root := &nestNode{}
+	for key, value := range record {
+		root.insert(strings.Split(key, "."), value)
+	}
+	return root.object()
+}
+
+// NestRecords applies Nest to each record
+func NestRecords(records []map[string]interface{}) []map[string]interface{} {
+	nested := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		nested[i] = Nest(record)
+	}
+	return nested
+}
+
+type nestNode struct {
+	value    interface{}
+	hasValue bool
+	children map[string]*nestNode
+}
+
+func (n *nestNode) insert(path []string, value interface{}) {
+	if len(path) == 0 {
+		n.value, n.hasValue = value, true
+		return
+	}
+	if n.children == nil {
+		n.children = make(map[string]*nestNode)
+	}
+	child, ok := n.children[path[0]]
+	if !ok {
+		child = &nestNode{}
+		n.children[path[0]] = child
+	}
+	child.insert(path[1:], value)
+}
+
+func (n *nestNode) object() map[string]interface{} {
+	result := make(map[string]interface{}, len(n.children))
+	for name, child := range n.children {
+		if len(child.children) == 0 {
+			result[name] = child.value
+			continue
+		}
+		nested := child.object()
+		if _, ok := nested["sys_id"]; !ok && child.hasValue && child.value != nil {
+			nested["sys_id"] = referenceValue(child.value)
+		}
+		result[name] = nested
+	}
+	return result
+}
+
+// referenceValue returns the sys_id of a reference in any of its value shapes, or its
+// display value when that is all the API returned
+func referenceValue(v interface{}) string {
+	ref := NewRecord(map[string]interface{}{"ref": v}, core.DisplayFalse, nil).Reference("ref")
+	if ref.SysID != "" {
+		return ref.SysID
+	}
+	return ref.DisplayValue
+}
+
+// Expand fetches the records referenced by options.Expand and embeds them in place
+func (t *TableClient) Expand(records []map[string]interface{}, options ListOptions) error {
+	return t.ExpandWithContext(context.Background(), records, options)
+}
+
+// ExpandWithContext fetches the records referenced by options.Expand and embeds them in
+// place of the reference values. Each entry names a reference field; dot-walked entries
+// ("caller_id.manager") expand the embedded records further. Referenced records are
+// fetched with one sys_idIN query per referenced table, using the DisplayValue and
+// ExcludeReferenceLink settings of options. References that cannot be read are left as is.
+func (t *TableClient) ExpandWithContext(ctx context.Context, records []map[string]interface{}, options ListOptions) error {
+	if len(options.Expand) == 0 || len(records) == 0 {
+		return nil
+	}
+
+	// Group the requested paths by their first reference field
+	children := make(map[string][]string)
+	for _, path := range options.Expand {
+		field, rest, _ := strings.Cut(path, ".")
+		if field == "" {
+			return fmt.Errorf("invalid expand path %q", path)
+		}
+		if _, ok := children[field]; !ok {
+			children[field] = nil
+		}
+		if rest != "" {
+			children[field] = append(children[field], rest)
+		}
+	}
+	fields := make([]string, 0, len(children))
+	for field := range children {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	// Collect the referenced sys_ids per table so each table is queried once
+	var tableSchema *schema.Table
+	fieldTables := make(map[string]string, len(fields))
+	tableIDs := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		for _, record := range records {
+			ref := NewRecord(record, options.DisplayValue, t.client.GetTimeZone()).Reference(field)
+			if ref.SysID == "" {
+				continue
+			}
+			refTable := ref.Table
+			if refTable == "" {
+				refTable = fieldTables[field]
+			}
+			if refTable == "" {
+				if tableSchema == nil {
+					var err error
+					if tableSchema, err = t.schemaClient().GetTableWithContext(ctx, t.name); err != nil {
+						return fmt.Errorf("failed to resolve reference tables for expansion: %w", err)
+					}
+				}
+				schemaField := tableSchema.Field(field)
+				if schemaField == nil || !schemaField.IsReference() {
+					return fmt.Errorf("cannot expand %s: not a reference field of %s", field, t.name)
+				}
+				refTable = schemaField.Reference
+			}
+			fieldTables[field] = refTable
+			if key := refTable + "/" + ref.SysID; !seen[key] {
+				seen[key] = true
+				tableIDs[refTable] = append(tableIDs[refTable], ref.SysID)
+			}
+		}
+	}
+
+	fetched := make(map[string]map[string]interface{})
+	for refTable, ids := range tableIDs {
+		for start := 0; start < len(ids); start += expandChunkSize {
+			end := start + expandChunkSize
+			if end > len(ids) {
+				end = len(ids)
+			}
+			related, err := NewTableClient(t.client, refTable).WithSchema(t.schemaClient()).ListOptWithContext(ctx, ListOptions{
+				Query:                "sys_idIN" + strings.Join(ids[start:end], ","),
+				Limit:                end - start,
+				DisplayValue:         options.DisplayValue,
+				ExcludeReferenceLink: options.ExcludeReferenceLink,
+				NoCount:              true,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to expand references to %s: %w", refTable, err)
+			}
+			for _, record := range related {
+				fetched[refTable+"/"+NewRecord(record, options.DisplayValue, nil).SysID()] = record
+			}
+		}
+	}
+
+	// Embed a copy per reference so nested expansion never touches shared maps
+	for _, field := range fields {
+		refTable := fieldTables[field]
+		var embedded []map[string]interface{}
+		for _, record := range records {
+			ref := NewRecord(record, options.DisplayValue, nil).Reference(field)
+			if ref.Table == "" {
+				ref.Table = refTable
+			}
+			related, ok := fetched[ref.Table+"/"+ref.SysID]
+			if ref.SysID == "" || !ok {
+				continue
+			}
+			clone := make(map[string]interface{}, len(related))
+			for k, v := range related {
+				clone[k] = v
+			}
+			record[field] = clone
+			embedded = append(embedded, clone)
+		}
+
+		if len(children[field]) > 0 && len(embedded) > 0 {
+			nestedOptions := options
+			nestedOptions.Expand = children[field]
+			if err := NewTableClient(t.client, refTable).WithSchema(t.schemaClient()).ExpandWithContext(ctx, embedded, nestedOptions); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package table
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+)
+
+// History sources
+const (
+	HistorySourceAudit       = "sys_audit"
+	HistorySourceHistoryLine = "sys_history_line"
+)
+
+// HistoryEntry is a single field change of a record
+type HistoryEntry struct {
+	Field              string    `json:"field"`
+	Label              string    `json:"label,omitempty"` // Field label, from sys_history_line when available
+	OldValue           string    `json:"old_value"`
+	NewValue           string    `json:"new_value"`
+	OldDisplayValue    string    `json:"old_display_value,omitempty"` // From sys_history_line when available
+	NewDisplayValue    string    `json:"new_display_value,omitempty"`
+	User               string    `json:"user"`
+	Timestamp          time.Time `json:"timestamp"`
+	RecordCheckpoint   int       `json:"record_checkpoint"` // sys_mod_count the change produced
+	InternalCheckpoint string    `json:"internal_checkpoint,omitempty"`
+	Reason             string    `json:"reason,omitempty"`
+	Source             string    `json:"source"`
+}
+
+// HistoryOptions filters the history of a record
+type HistoryOptions struct {
+	Fields []string  // Only changes to these fields
+	Since  time.Time // Only changes at or after this time (zero for all)
+	Until  time.Time // Only changes at or before this time (zero for all)
+}
+
+// History returns the field changes of a record, oldest first
+func (t *TableClient) History(sysID string, options HistoryOptions) ([]HistoryEntry, error) {
+	return t.HistoryWithContext(context.Background(), sysID, options)
+}
+
+// HistoryWithContext returns the field changes of a record from sys_audit, oldest first.
+// Labels and display values are added from sys_history_line when a history set has been
+// generated for the record; when sys_audit is unreadable, sys_history_line is used alone.
+func (t *TableClient) HistoryWithContext(ctx context.Context, sysID string, options HistoryOptions) ([]HistoryEntry, error) {
+	audit, auditErr := t.auditEntries(ctx, sysID, options)
+	if auditErr != nil {
+		var snErr *core.ServiceNowError
+		if !errors.As(auditErr, &snErr) || snErr.Type != core.ErrorTypeAuthorization {
+			return nil, auditErr
+		}
+	}
+
+	lines, err := t.historyLineEntries(ctx, sysID, options)
+	if err != nil {
+		if auditErr != nil {
+			return nil, auditErr
+		}
+		// sys_history_line is optional enrichment
+		lines = nil
+	}
+
+	entries := audit
+	if auditErr != nil {
+		entries = lines
+	} else {
+		mergeHistoryLines(entries, lines)
+	}
+	entries = filterHistory(entries, options)
+	sortHistory(entries)
+	return entries, nil
+}
+
+// RecordAt reconstructs the raw field values of a record at the given time from its
+// current values and audit history
+func (t *TableClient) RecordAt(sysID string, at time.Time) (map[string]string, error) {
+	return t.RecordAtWithContext(context.Background(), sysID, at)
+}
+
+// RecordAtWithContext reconstructs a record at the given time with context support
+func (t *TableClient) RecordAtWithContext(ctx context.Context, sysID string, at time.Time) (map[string]string, error) {
+	current, err := t.getRaw(ctx, sysID)
+	if err != nil {
+		return nil, err
+	}
+	created, err := NewRecord(current, core.DisplayFalse, t.client.GetTimeZone()).Time("sys_created_on")
+	if err == nil && at.Before(created) {
+		return nil, fmt.Errorf("record %s did not exist at %s (created %s)", sysID, at.Format(time.RFC3339), created.Format(time.RFC3339))
+	}
+
+	history, err := t.HistoryWithContext(ctx, sysID, HistoryOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return ReconstructAt(current, history, at), nil
+}
+
+// ReconstructAt rolls the current values of a record back to the given time by undoing,
+// newest first, every change made after it
+func ReconstructAt(current map[string]interface{}, history []HistoryEntry, at time.Time) map[string]string {
+	state := make(map[string]string, len(current))
+	for field, value := range current {
+		state[field] = stringify(value)
+	}
+
+	changes := make([]HistoryEntry, len(history))
+	copy(changes, history)
+	sortHistory(changes)
+	for i := len(changes) - 1; i >= 0; i-- {
+		change := changes[i]
+		if !change.Timestamp.After(at) {
+			break
+		}
+		if isHistoryMarker(change.Field) {
+			continue
+		}
+		state[change.Field] = change.OldValue
+	}
+	return state
+}
+
+func (t *TableClient) auditEntries(ctx context.Context, sysID string, options HistoryOptions) ([]HistoryEntry, error) {
+	records, err := NewTableClient(t.client, "sys_audit").PaginateParallel(ctx, ListOptions{
+		Query:        "documentkey=" + sysID + historyFieldQuery("fieldname", options.Fields) + "^ORDERBYsys_created_on",
+		Fields:       []string{"fieldname", "oldvalue", "newvalue", "user", "sys_created_on", "record_checkpoint", "internal_checkpoint", "reason"},
+		DisplayValue: core.DisplayFalse,
+	}, ParallelOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]HistoryEntry, 0, len(records))
+	for _, record := range records {
+		r := NewRecord(record, core.DisplayFalse, t.client.GetTimeZone())
+		timestamp, _ := r.Time("sys_created_on")
+		checkpoint, _ := r.Int("record_checkpoint")
+		entries = append(entries, HistoryEntry{
+			Field:              r.String("fieldname"),
+			OldValue:           r.String("oldvalue"),
+			NewValue:           r.String("newvalue"),
+			User:               r.String("user"),
+			Timestamp:          timestamp,
+			RecordCheckpoint:   checkpoint,
+			InternalCheckpoint: r.String("internal_checkpoint"),
+			Reason:             r.String("reason"),
+			Source:             HistorySourceAudit,
+		})
+	}
+	return entries, nil
+}
+
+func (t *TableClient) historyLineEntries(ctx context.Context, sysID string, options HistoryOptions) ([]HistoryEntry, error) {
+	records, err := NewTableClient(t.client, "sys_history_line").PaginateParallel(ctx, ListOptions{
+		Query:        "set.id=" + sysID + historyFieldQuery("field", options.Fields) + "^ORDERBYupdate_time",
+		Fields:       []string{"field", "label", "old", "new", "old_value", "new_value", "user_name", "update_time", "update", "internal_checkpoint"},
+		DisplayValue: core.DisplayFalse,
+	}, ParallelOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]HistoryEntry, 0, len(records))
+	for _, record := range records {
+		r := NewRecord(record, core.DisplayFalse, t.client.GetTimeZone())
+		timestamp, _ := r.Time("update_time")
+		checkpoint, _ := r.Int("update")
+		entries = append(entries, HistoryEntry{
+			Field:              r.String("field"),
+			Label:              r.String("label"),
+			OldValue:           r.String("old_value"),
+			NewValue:           r.String("new_value"),
+			OldDisplayValue:    r.String("old"),
+			NewDisplayValue:    r.String("new"),
+			User:               r.String("user_name"),
+			Timestamp:          timestamp,
+			RecordCheckpoint:   checkpoint,
+			InternalCheckpoint: r.String("internal_checkpoint"),
+			Source:             HistorySourceHistoryLine,
+		})
+	}
+	return entries, nil
+}
+
+// mergeHistoryLines copies labels and display values onto the matching audit entries
+func mergeHistoryLines(entries, lines []HistoryEntry) {
+	if len(lines) == 0 {
+		return
+	}
+	byCheckpoint := make(map[string]HistoryEntry, len(lines))
+	for _, line := range lines {
+		byCheckpoint[line.InternalCheckpoint+"/"+line.Field] = line
+	}
+	for i := range entries {
+		line, ok := byCheckpoint[entries[i].InternalCheckpoint+"/"+entries[i].Field]
+		if !ok {
+			continue
+		}
+		entries[i].Label = line.Label
+		entries[i].OldDisplayValue = line.OldDisplayValue
+		entries[i].NewDisplayValue = line.NewDisplayValue
+	}
+}
+
+func filterHistory(entries []HistoryEntry, options HistoryOptions) []HistoryEntry {
+	if options.Since.IsZero() && options.Until.IsZero() {
+		return entries
+	}
+	filtered := entries[:0]
+	for _, entry := range entries {
+		if !options.Since.IsZero() && entry.Timestamp.Before(options.Since) {
+			continue
+		}
+		if !options.Until.IsZero() && entry.Timestamp.After(options.Until) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered
+}
+
+// sortHistory orders entries by time, then by the update they belong to and field name
+func sortHistory(entries []HistoryEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Timestamp.Equal(entries[j].Timestamp) {
+			return entries[i].Timestamp.Before(entries[j].Timestamp)
+		}
+		if entries[i].RecordCheckpoint != entries[j].RecordCheckpoint {
+			return entries[i].RecordCheckpoint < entries[j].RecordCheckpoint
+		}
+		if entries[i].InternalCheckpoint != entries[j].InternalCheckpoint {
+			return entries[i].InternalCheckpoint < entries[j].InternalCheckpoint
+		}
+		return entries[i].Field < entries[j].Field
+	})
+}
+
+func historyFieldQuery(column string, fields []string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	return "^" + column + "IN" + strings.Join(fields, ",")
+}
+
+// isHistoryMarker reports audit rows that record an event rather than a field value
+func isHistoryMarker(field string) bool {
+	return field == "DELETED" || field == "INSERTED"
+}
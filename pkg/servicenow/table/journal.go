@@ -0,0 +1,106 @@
+package table
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+)
+
+// Journal fields available on task tables
+const (
+	JournalComments  = "comments"
+	JournalWorkNotes = "work_notes"
+)
+
+// JournalEntry is one entry of a journal field (sys_journal_field)
+type JournalEntry struct {
+	SysID     string    `json:"sys_id"`
+	Element   string    `json:"element"` // Journal field, e.g. comments or work_notes
+	Value     string    `json:"value"`
+	CreatedBy string    `json:"created_by"` // user_name of the author
+	CreatedOn time.Time `json:"created_on"`
+}
+
+// Label returns the form label of the entry's journal field
+func (e JournalEntry) Label() string {
+	switch e.Element {
+	case JournalComments:
+		return "Additional comments"
+	case JournalWorkNotes:
+		return "Work notes"
+	default:
+		return e.Element
+	}
+}
+
+// ListJournal retrieves the journal entries of a record, oldest first.
+// Pass elements to restrict to specific journal fields.
+func (t *TableClient) ListJournal(sysID string, elements ...string) ([]JournalEntry, error) {
+	return t.ListJournalWithContext(context.Background(), sysID, elements...)
+}
+
+// ListJournalWithContext retrieves the journal entries of a record with context support
+func (t *TableClient) ListJournalWithContext(ctx context.Context, sysID string, elements ...string) ([]JournalEntry, error) {
+	// element_id is the record's sys_id; the name column holds the record's class,
+	// which may be a child of t.name, so it is not filtered on
+	encodedQuery := "element_id=" + sysID
+	if len(elements) > 0 {
+		encodedQuery += "^elementIN" + strings.Join(elements, ",")
+	}
+	records, err := NewTableClient(t.client, "sys_journal_field").PaginateParallel(ctx, ListOptions{
+		Query:        encodedQuery + "^ORDERBYsys_created_on",
+		Fields:       []string{"sys_id", "element", "value", "sys_created_by", "sys_created_on"},
+		DisplayValue: core.DisplayFalse,
+	}, ParallelOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load journal for %s: %w", sysID, err)
+	}
+
+	location := t.client.GetTimeZone()
+	entries := make([]JournalEntry, 0, len(records))
+	for _, record := range records {
+		r := NewRecord(record, core.DisplayFalse, location)
+		createdOn, _ := r.Time("sys_created_on")
+		entries = append(entries, JournalEntry{
+			SysID:     r.SysID(),
+			Element:   r.String("element"),
+			Value:     r.String("value"),
+			CreatedBy: r.String("sys_created_by"),
+			CreatedOn: createdOn,
+		})
+	}
+	return entries, nil
+}
+
+// AddComment posts an additional comment (customer visible) to a record
+func (t *TableClient) AddComment(sysID, text string) error {
+	return t.AddCommentWithContext(context.Background(), sysID, text)
+}
+
+// AddCommentWithContext posts an additional comment with context support
+func (t *TableClient) AddCommentWithContext(ctx context.Context, sysID, text string) error {
+	return t.addJournalEntry(ctx, sysID, JournalComments, text)
+}
+
+// AddWorkNote posts a work note (internal) to a record
+func (t *TableClient) AddWorkNote(sysID, text string) error {
+	return t.AddWorkNoteWithContext(context.Background(), sysID, text)
+}
+
+// AddWorkNoteWithContext posts a work note with context support
+func (t *TableClient) AddWorkNoteWithContext(ctx context.Context, sysID, text string) error {
+	return t.addJournalEntry(ctx, sysID, JournalWorkNotes, text)
+}
+
+func (t *TableClient) addJournalEntry(ctx context.Context, sysID, element, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("%s text cannot be empty", element)
+	}
+	if _, err := t.UpdateWithContext(ctx, sysID, map[string]interface{}{element: text}); err != nil {
+		return fmt.Errorf("failed to add %s to %s: %w", element, sysID, err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package table
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/aggregate"
+)
+
+// DefaultParallelWorkers is the number of pages fetched concurrently when not configured
+const DefaultParallelWorkers = 4
+
+// ParallelOptions configures concurrent page fetching
+type ParallelOptions struct {
+	PageSize int // Records per page (default 100)
+	Workers  int // Concurrent page requests (default 4); the client's rate limiter still applies
+}
+
+// PageFunc receives pages in order. Returning an error stops fetching.
+type PageFunc func(page int, records []map[string]interface{}) error
+
+// Count returns the number of records matching an encoded query
+func (t *TableClient) Count(encodedQuery string) (int, error) {
+	return t.CountWithContext(context.Background(), encodedQuery)
+}
+
+// CountWithContext returns the number of records matching an encoded query with context support.
+// It reads X-Total-Count from a one-record page and falls back to the Aggregate API.
+func (t *TableClient) CountWithContext(ctx context.Context, encodedQuery string) (int, error) {
+	if err := t.validateQuery(ctx, encodedQuery); err != nil {
+		return 0, err
+	}
+	params := map[string]string{
+		"sysparm_fields": "sys_id",
+		"sysparm_limit":  "1",
+	}
+	if encodedQuery != "" {
+		params["sysparm_query"] = encodedQuery
+	}
+	var result interface{}
+	headers, err := t.client.RawRequestWithHeadersContext(ctx, "GET", fmt.Sprintf("/table/%s", t.name), nil, params, &result)
+	if err != nil {
+		return 0, err
+	}
+	if total := headers.Get("X-Total-Count"); total != "" {
+		if count, err := strconv.Atoi(total); err == nil {
+			return count, nil
+		}
+	}
+	return aggregate.NewAggregateClient(t.client, t.name).CountRecordsWithRawQueryContext(ctx, encodedQuery)
+}
+
+// PaginateParallel fetches all matching records using concurrent page requests
+// and returns them in query order
+func (t *TableClient) PaginateParallel(ctx context.Context, options ListOptions, parallel ParallelOptions) ([]map[string]interface{}, error) {
+	var allRecords []map[string]interface{}
+	err := t.PaginateParallelFunc(ctx, options, parallel, func(page int, records []map[string]interface{}) error {
+		allRecords = append(allRecords, records...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allRecords, nil
+}
+
+// PaginateParallelFunc counts matching records, fetches page ranges with a bounded
+// worker pool and delivers pages to fn in order. The first error cancels all
+// outstanding requests. Queries without ORDERBY are ordered by sys_id so that
+// offsets stay stable across concurrent requests.
+func (t *TableClient) PaginateParallelFunc(ctx context.Context, options ListOptions, parallel ParallelOptions, fn PageFunc) error {
+	pageSize := parallel.PageSize
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	workers := parallel.Workers
+	if workers <= 0 {
+		workers = DefaultParallelWorkers
+	}
+	if !strings.Contains(options.Query, "ORDERBY") {
+		if options.Query == "" {
+			options.Query = "ORDERBYsys_id"
+		} else {
+			options.Query += "^ORDERBYsys_id"
+		}
+	}
+
+	total, err := t.CountWithContext(ctx, options.Query)
+	if err != nil {
+		return fmt.Errorf("failed to count records: %w", err)
+	}
+	pages := (total + pageSize - 1) / pageSize
+	if pages == 0 {
+		return nil
+	}
+	if workers > pages {
+		workers = pages
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type pageResult struct {
+		index   int
+		records []map[string]interface{}
+		err     error
+	}
+	jobs := make(chan int)
+	results := make(chan pageResult, workers)
+	// window bounds how far fetching may run ahead of the in-order consumer
+	window := make(chan struct{}, workers*2)
+
+	go func() {
+		defer close(jobs)
+		for i := 0; i < pages; i++ {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				pageOptions := options
+				pageOptions.Limit = pageSize
+				pageOptions.Offset = i * pageSize
+				records, err := t.ListOptWithContext(ctx, pageOptions)
+				select {
+				case results <- pageResult{index: i, records: records, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int][]map[string]interface{})
+	next := 0
+	for result := range results {
+		if result.err != nil {
+			return fmt.Errorf("failed to fetch page %d: %w", result.index+1, result.err)
+		}
+		pending[result.index] = result.records
+		for {
+			records, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			if err := fn(next, records); err != nil {
+				return err
+			}
+			<-window
+			next++
+		}
+	}
+	if next < pages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("fetched %d of %d pages", next, pages)
+	}
+	return nil
+}
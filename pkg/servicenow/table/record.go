@@ -0,0 +1,341 @@
+package table
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+)
+
+// Glide date/time layouts used by the Table API for raw (sysparm_display_value=false) values
+const (
+	GlideDateTimeLayout = "2006-01-02 15:04:05"
+	GlideDateLayout     = "2006-01-02"
+	GlideTimeLayout     = "15:04:05"
+)
+
+// Reference is a normalized reference field value
+type Reference struct {
+	SysID        string `json:"sys_id,omitempty"`
+	Table        string `json:"table,omitempty"` // Parsed from the link when present
+	DisplayValue string `json:"display_value,omitempty"`
+	Link         string `json:"link,omitempty"`
+}
+
+// Record wraps a Table API record and normalizes the value shapes the API returns:
+// plain strings, {link, value} references and {display_value, value} pairs, plus
+// referenced records embedded by ExpandWithContext.
+type Record struct {
+	fields   map[string]interface{}
+	display  bool           // Plain strings are display values (sysparm_display_value=true)
+	location *time.Location // Timezone of display values
+}
+
+// NewRecord wraps a record map. display is the sysparm_display_value the record was
+// fetched with; location is the timezone display values are rendered in (nil for UTC).
+func NewRecord(fields map[string]interface{}, display core.DisplayValueOptions, location *time.Location) *Record {
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	return &Record{fields: fields, display: display == core.DisplayTrue, location: location}
+}
+
+// Map returns the underlying record map
+func (r *Record) Map() map[string]interface{} {
+	return r.fields
+}
+
+// MarshalJSON encodes the record as its underlying map
+func (r *Record) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.fields)
+}
+
+// Has reports whether the record contains a non-null value for the field
+func (r *Record) Has(field string) bool {
+	v, ok := r.fields[field]
+	return ok && v != nil
+}
+
+// IsEmpty reports whether the field is missing or has an empty value
+func (r *Record) IsEmpty(field string) bool {
+	return r.String(field) == ""
+}
+
+// Fields returns the field names of the record, sorted
+func (r *Record) Fields() []string {
+	names := make([]string, 0, len(r.fields))
+	for name := range r.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SysID returns the record's sys_id
+func (r *Record) SysID() string {
+	return r.String("sys_id")
+}
+
+// String returns the raw value of a field (the sys_id for references, the stored
+// value for choices). For records fetched with display values only, the display
+// value is all that is available and is returned instead.
+func (r *Record) String(field string) string {
+	switch v := r.fields[field].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}:
+		if value, ok := v["value"]; ok {
+			return stringify(value)
+		}
+		if _, ok := v["sys_id"]; ok {
+			// Expanded reference
+			return NewRecord(v, core.DisplayFalse, nil).SysID()
+		}
+		return stringify(v["display_value"])
+	default:
+		return stringify(v)
+	}
+}
+
+// DisplayValue returns the display value of a field, falling back to the raw value
+// when the record was fetched without display values
+func (r *Record) DisplayValue(field string) string {
+	switch v := r.fields[field].(type) {
+	case map[string]interface{}:
+		if display, ok := v["display_value"]; ok {
+			return stringify(display)
+		}
+		return stringify(v["value"])
+	default:
+		return r.String(field)
+	}
+}
+
+// Int parses an integer field. Grouping separators in display values are ignored.
+func (r *Record) Int(field string) (int, error) {
+	value, display, err := r.scalar(field)
+	if err != nil {
+		return 0, err
+	}
+	if display {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("field %s is not an integer: %q", field, value)
+	}
+	return i, nil
+}
+
+// Float parses a decimal, float or currency amount field
+func (r *Record) Float(field string) (float64, error) {
+	value, display, err := r.scalar(field)
+	if err != nil {
+		return 0, err
+	}
+	if display {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("field %s is not a number: %q", field, value)
+	}
+	return f, nil
+}
+
+// Bool reports whether a boolean field is true
+func (r *Record) Bool(field string) bool {
+	switch strings.ToLower(r.String(field)) {
+	case "true", "1", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// Time parses a glide_date_time, glide_date or glide_time field and returns it in
+// the record's timezone. Raw date/time values are stored in UTC; display values
+// are already in the record's timezone. Dates carry no timezone and are returned
+// as midnight in the record's timezone.
+func (r *Record) Time(field string) (time.Time, error) {
+	value, display, err := r.scalar(field)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	source := time.UTC
+	if display {
+		source = r.location
+	}
+	if t, err := time.ParseInLocation(GlideDateTimeLayout, value, source); err == nil {
+		return t.In(r.location), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(r.location), nil
+	}
+	if t, err := time.ParseInLocation(GlideDateLayout, value, r.location); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(GlideTimeLayout, value, source); err == nil {
+		return t.In(r.location), nil
+	}
+	return time.Time{}, fmt.Errorf("field %s is not a glide date/time: %q", field, value)
+}
+
+// Reference returns a reference field's sys_id, table and display value. Only the
+// information present in the response is filled in: the table needs reference links
+// and the display value needs sysparm_display_value=true or all.
+func (r *Record) Reference(field string) Reference {
+	switch v := r.fields[field].(type) {
+	case map[string]interface{}:
+		if _, ok := v["sys_id"]; ok {
+			// Expanded reference
+			return Reference{SysID: NewRecord(v, core.DisplayFalse, nil).SysID()}
+		}
+		ref := Reference{
+			SysID:        stringify(v["value"]),
+			DisplayValue: stringify(v["display_value"]),
+			Link:         stringify(v["link"]),
+		}
+		if idx := strings.Index(ref.Link, "/table/"); idx >= 0 {
+			parts := strings.Split(ref.Link[idx+len("/table/"):], "/")
+			ref.Table = parts[0]
+			if ref.SysID == "" && len(parts) > 1 {
+				// Display-only references still carry the sys_id in the link
+				ref.SysID = parts[1]
+			}
+		}
+		return ref
+	case nil:
+		return Reference{}
+	default:
+		if r.display {
+			return Reference{DisplayValue: stringify(v)}
+		}
+		return Reference{SysID: stringify(v)}
+	}
+}
+
+// scalar returns the value best suited for parsing and whether it is a display value
+func (r *Record) scalar(field string) (string, bool, error) {
+	if !r.Has(field) {
+		return "", false, fmt.Errorf("field %s is not present", field)
+	}
+	value := r.String(field)
+	display := r.display
+	if m, ok := r.fields[field].(map[string]interface{}); ok {
+		_, hasValue := m["value"]
+		display = !hasValue
+	}
+	if value == "" {
+		return "", display, fmt.Errorf("field %s is empty", field)
+	}
+	return value, display, nil
+}
+
+// NewRecords wraps a list of record maps
+func NewRecords(records []map[string]interface{}, display core.DisplayValueOptions, location *time.Location) []*Record {
+	wrapped := make([]*Record, len(records))
+	for i, record := range records {
+		wrapped[i] = NewRecord(record, display, location)
+	}
+	return wrapped
+}
+
+// ListRecords retrieves records as Record values
+func (t *TableClient) ListRecords(params map[string]string) ([]*Record, error) {
+	return t.ListRecordsWithContext(context.Background(), params)
+}
+
+// ListRecordsWithContext retrieves records as Record values with context support
+func (t *TableClient) ListRecordsWithContext(ctx context.Context, params map[string]string) ([]*Record, error) {
+	records, err := t.ListWithContext(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return NewRecords(records, core.DisplayValueOptions(params["sysparm_display_value"]), t.client.GetTimeZone()), nil
+}
+
+// GetRecord retrieves a single record with both raw and display values
+func (t *TableClient) GetRecord(sysID string) (*Record, error) {
+	return t.GetRecordWithContext(context.Background(), sysID)
+}
+
+// GetRecordWithContext retrieves a single record with both raw and display values
+func (t *TableClient) GetRecordWithContext(ctx context.Context, sysID string) (*Record, error) {
+	var result core.Response
+	params := map[string]string{"sysparm_display_value": string(core.DisplayAll)}
+	err := t.client.RawRequestWithContext(ctx, "GET", fmt.Sprintf("/table/%s/%s", t.name, sysID), nil, params, &result)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := result.Result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type for get: %T", result.Result)
+	}
+	return NewRecord(record, core.DisplayAll, t.client.GetTimeZone()), nil
+}
+
+// SessionTimeZone returns the timezone the instance reads datetimes in encoded
+// queries and display values in. It's loaded with LoadTimeZone and set on the
+// client the first time, unless the client already has one.
+func SessionTimeZone(ctx context.Context, client *core.Client) (*time.Location, error) {
+	if client.HasTimeZone() {
+		return client.GetTimeZone(), nil
+	}
+	location, err := LoadTimeZone(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	client.SetTimeZone(location)
+	return location, nil
+}
+
+// LoadTimeZone looks up the session user's timezone, falling back to the instance
+// default (glide.sys.default.tz) and then UTC. Pass the result to core.Client.SetTimeZone
+// so Record.Time interprets display values correctly.
+func LoadTimeZone(ctx context.Context, client *core.Client) (*time.Location, error) {
+	name := ""
+	users, err := NewTableClient(client, "sys_user").ListWithContext(ctx, map[string]string{
+		"sysparm_query":  "sys_id=javascript:gs.getUserID()",
+		"sysparm_fields": "time_zone",
+		"sysparm_limit":  "1",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user timezone: %w", err)
+	}
+	if len(users) > 0 {
+		name = stringify(users[0]["time_zone"])
+	}
+	if name == "" {
+		properties, err := NewTableClient(client, "sys_properties").ListWithContext(ctx, map[string]string{
+			"sysparm_query":  "name=glide.sys.default.tz",
+			"sysparm_fields": "value",
+			"sysparm_limit":  "1",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load instance timezone: %w", err)
+		}
+		if len(properties) > 0 {
+			name = stringify(properties[0]["value"])
+		}
+	}
+	if name == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q: %w", name, err)
+	}
+	return location, nil
+}
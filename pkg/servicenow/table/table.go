@@ -5,14 +5,23 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/lint"
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
 )
 
 type TableClient struct {
 	client *core.Client
 	name   string
+
+	linter    *lint.Linter // Set by WithQueryValidation
+	validated sync.Map     // Queries that passed validation
+
+	schemaMu sync.Mutex
+	schemas  *schema.SchemaClient // Set by WithSchema, or created on first use
 }
 
 func NewTableClient(client *core.Client, name string) *TableClient {
@@ -26,6 +35,9 @@ func (t *TableClient) List(params map[string]string) ([]map[string]interface{},
 
 // ListWithContext retrieves records from the table with context support
 func (t *TableClient) ListWithContext(ctx context.Context, params map[string]string) ([]map[string]interface{}, error) {
+	if err := t.validateQuery(ctx, params["sysparm_query"]); err != nil {
+		return nil, err
+	}
 	var result core.Response
 	err := t.client.RawRequestWithContext(ctx, "GET", fmt.Sprintf("/table/%s", t.name), nil, params, &result)
 	if err != nil {
@@ -140,6 +152,9 @@ func (t *TableClient) ListWithQuery(qb *query.QueryBuilder) ([]map[string]interf
 
 // ListWithQueryContext executes a query using the query builder with context support
 func (t *TableClient) ListWithQueryContext(ctx context.Context, qb *query.QueryBuilder) ([]map[string]interface{}, error) {
+	if err := qb.Err(); err != nil {
+		return nil, err
+	}
 	params := qb.Build()
 	return t.ListWithContext(ctx, params)
 }
@@ -167,6 +182,7 @@ func (t *TableClient) Contains(field string, value interface{}) *TableQuery {
 type TableQuery struct {
 	table   *TableClient
 	builder *query.QueryBuilder
+	expand  []string
 }
 
 // Where adds another condition (chainable)
@@ -235,6 +251,12 @@ func (tq *TableQuery) Offset(offset int) *TableQuery {
 	return tq
 }
 
+// Expand embeds the records referenced by these fields in the results (chainable)
+func (tq *TableQuery) Expand(fields ...string) *TableQuery {
+	tq.expand = append(tq.expand, fields...)
+	return tq
+}
+
 // Execute runs the query and returns results
 func (tq *TableQuery) Execute() ([]map[string]interface{}, error) {
 	return tq.ExecuteWithContext(context.Background())
@@ -242,7 +264,14 @@ func (tq *TableQuery) Execute() ([]map[string]interface{}, error) {
 
 // ExecuteWithContext runs the query with context support and returns results
 func (tq *TableQuery) ExecuteWithContext(ctx context.Context) ([]map[string]interface{}, error) {
-	return tq.table.ListWithQueryContext(ctx, tq.builder)
+	records, err := tq.table.ListWithQueryContext(ctx, tq.builder)
+	if err != nil {
+		return nil, err
+	}
+	if err := tq.table.ExpandWithContext(ctx, records, ListOptions{Expand: tq.expand}); err != nil {
+		return nil, err
+	}
+	return records, nil
 }
 
 // ExecuteOne runs the query and returns the first result
@@ -269,10 +298,16 @@ func (tq *TableQuery) Count() (int, error) {
 
 // CountWithContext returns the number of records matching the query with context support
 func (tq *TableQuery) CountWithContext(ctx context.Context) (int, error) {
+	if err := tq.builder.Err(); err != nil {
+		return 0, err
+	}
 	// Clone the builder and set up for count
 	countBuilder := tq.builder.Clone().Fields("sys_id").Limit(0)
 	params := countBuilder.Build()
 	params["sysparm_action"] = "getRecordCount"
+	if err := tq.table.validateQuery(ctx, params["sysparm_query"]); err != nil {
+		return 0, err
+	}
 	
 	var result core.Response
 	err := tq.table.client.RawRequestWithContext(ctx, "GET", fmt.Sprintf("/table/%s", tq.table.name), nil, params, &result)
@@ -303,11 +338,29 @@ type ListOptions struct {
 	View                     string                   // sysparm_view
 	NoCount                  bool                     // sysparm_no_count = true
 	SuppressPaginationHeader bool                     // sysparm_suppress_pagination_header = true
+	Expand                   []string                 // Reference fields to fetch and embed (see ExpandWithContext)
 	// Add more as needed (e.g., InputDisplayValue for POST)
 }
 
 // ListOpt performs List with type-safe options
 func (t *TableClient) ListOpt(options ListOptions) ([]map[string]interface{}, error) {
+	return t.ListOptWithContext(context.Background(), options)
+}
+
+// ListOptWithContext performs List with type-safe options and context support
+func (t *TableClient) ListOptWithContext(ctx context.Context, options ListOptions) ([]map[string]interface{}, error) {
+	records, err := t.ListWithContext(ctx, options.Params())
+	if err != nil {
+		return nil, err
+	}
+	if err := t.ExpandWithContext(ctx, records, options); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Params converts the options to sysparm query parameters
+func (options ListOptions) Params() map[string]string {
 	params := map[string]string{}
 	if options.Query != "" {
 		params["sysparm_query"] = options.Query
@@ -336,7 +389,7 @@ func (t *TableClient) ListOpt(options ListOptions) ([]map[string]interface{}, er
 	if options.SuppressPaginationHeader {
 		params["sysparm_suppress_pagination_header"] = "true"
 	}
-	return t.List(params)
+	return params
 }
 
 // Paginate fetches all records by auto-paginating (calls ListOpt repeatedly)
@@ -369,46 +422,56 @@ func getString(v interface{}) string {
 	return ""
 }
 
-func getBool(v interface{}) bool {
-	if b, ok := v.(bool); ok {
-		return b
-	}
-	return false
-}
-
 func parseInt(s string) int {
 	i, _ := strconv.Atoi(s)
 	return i
 }
 
-// GetSchema retrieves table metadata via sys_dictionary (JSON)
-func (t *TableClient) GetSchema() ([]core.ColumnMetadata, error) {
-	dictClient := NewTableClient(t.client, "sys_dictionary")
-	params := map[string]string{
-		"sysparm_query":  fmt.Sprintf("name=%s^elementISNOTEMPTY", t.name),
-		"sysparm_fields": "element,column_label,internal_type,max_length,mandatory,read_only,unique,reference,choice,calculated",
+// WithSchema makes the client load schemas through a shared schema client, so its
+// cache is reused across table clients
+func (t *TableClient) WithSchema(schemas *schema.SchemaClient) *TableClient {
+	t.schemaMu.Lock()
+	defer t.schemaMu.Unlock()
+	t.schemas = schemas
+	return t
+}
+
+// schemaClient returns the schema client, creating one on first use
+func (t *TableClient) schemaClient() *schema.SchemaClient {
+	t.schemaMu.Lock()
+	defer t.schemaMu.Unlock()
+	if t.schemas == nil {
+		t.schemas = schema.NewSchemaClient(t.client)
 	}
-	records, err := dictClient.List(params)
+	return t.schemas
+}
+
+// GetSchema retrieves the table's columns, including fields inherited from parent tables
+func (t *TableClient) GetSchema() ([]core.ColumnMetadata, error) {
+	return t.GetSchemaWithContext(context.Background())
+}
+
+// GetSchemaWithContext retrieves the table's columns with context support.
+// Use the schema package directly for choices, overrides and the extension chain.
+func (t *TableClient) GetSchemaWithContext(ctx context.Context) ([]core.ColumnMetadata, error) {
+	tableSchema, err := t.schemaClient().GetTableWithContext(ctx, t.name)
 	if err != nil {
 		return nil, err
 	}
-	var columns []core.ColumnMetadata
-	for _, rec := range records {
-		name := getString(rec["element"])
-		if name == "" {
-			continue
-		}
+	columns := make([]core.ColumnMetadata, 0, len(tableSchema.Fields))
+	for _, field := range tableSchema.Fields {
 		columns = append(columns, core.ColumnMetadata{
-			Name:       name,
-			Label:      getString(rec["column_label"]),
-			Type:       getString(rec["internal_type"]),
-			MaxLength:  parseInt(getString(rec["max_length"])),
-			Mandatory:  getBool(rec["mandatory"]),
-			ReadOnly:   getBool(rec["read_only"]),
-			Unique:     getBool(rec["unique"]),
-			Reference:  getString(rec["reference"]),
-			Choice:     getBool(rec["choice"]),
-			Calculated: getBool(rec["calculated"]),
+			Name:         field.Name,
+			Label:        field.Label,
+			Type:         field.Type,
+			MaxLength:    field.MaxLength,
+			DefaultValue: field.DefaultValue,
+			Mandatory:    field.Mandatory,
+			ReadOnly:     field.ReadOnly,
+			Unique:       field.Unique,
+			Reference:    field.Reference,
+			Choice:       field.Choice || len(field.Choices) > 0,
+			Calculated:   field.Calculated,
 		})
 	}
 	return columns, nil
@@ -420,6 +483,9 @@ func (t *TableClient) GetKeys(query string) ([]string, error) {
 	if query != "" {
 		params["sysparm_query"] = query
 	}
+	if err := t.validateQuery(context.Background(), query); err != nil {
+		return nil, err
+	}
 	var result core.Response
 	err := t.client.RawRequest("GET", fmt.Sprintf("/table/%s", t.name), nil, params, &result)
 	if err != nil {
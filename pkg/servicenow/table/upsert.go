@@ -0,0 +1,290 @@
+package table
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/batch"
+)
+
+// UpsertStatus describes what happened to a record during an upsert
+type UpsertStatus string
+
+const (
+	UpsertCreated   UpsertStatus = "created"
+	UpsertUpdated   UpsertStatus = "updated"
+	UpsertUnchanged UpsertStatus = "unchanged"
+	UpsertConflict  UpsertStatus = "conflict"
+	UpsertFailed    UpsertStatus = "failed"
+)
+
+// DefaultUpsertChunkSize is the number of records resolved and written per batch
+const DefaultUpsertChunkSize = 100
+
+// UpsertOptions configures UpsertMany
+type UpsertOptions struct {
+	MatchFields []string // Natural key fields used to find existing records (e.g. number, correlation_id)
+	ChunkSize   int      // Records per lookup query and batch request (default 100)
+}
+
+// UpsertResult is the per-record outcome of an upsert
+type UpsertResult struct {
+	Index   int                    `json:"index"`             // Position of the record in the input
+	Key     string                 `json:"key"`               // Natural key values joined with "|"
+	Status  UpsertStatus           `json:"status"`            // created, updated, unchanged, conflict or failed
+	SysID   string                 `json:"sys_id,omitempty"`  // sys_id of the created or updated record
+	Matches []string               `json:"matches,omitempty"` // sys_ids of all matches when Status is conflict
+	Record  map[string]interface{} `json:"record,omitempty"`  // Record returned by ServiceNow
+	Error   string                 `json:"error,omitempty"`
+}
+
+// Upsert creates or updates a single record matched by natural key fields
+func (t *TableClient) Upsert(matchFields []string, record map[string]interface{}) (*UpsertResult, error) {
+	return t.UpsertWithContext(context.Background(), matchFields, record)
+}
+
+// UpsertWithContext creates or updates a single record with context support
+func (t *TableClient) UpsertWithContext(ctx context.Context, matchFields []string, record map[string]interface{}) (*UpsertResult, error) {
+	results, err := t.UpsertManyWithContext(ctx, []map[string]interface{}{record}, UpsertOptions{MatchFields: matchFields})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// UpsertMany creates or updates records matched by natural key fields
+func (t *TableClient) UpsertMany(records []map[string]interface{}, options UpsertOptions) ([]*UpsertResult, error) {
+	return t.UpsertManyWithContext(context.Background(), records, options)
+}
+
+// UpsertManyWithContext creates or updates records with context support.
+// Existing records are resolved with one IN-query per chunk, and the resulting
+// creates and updates are sent through the batch API.
+func (t *TableClient) UpsertManyWithContext(ctx context.Context, records []map[string]interface{}, options UpsertOptions) ([]*UpsertResult, error) {
+	if len(options.MatchFields) == 0 {
+		return nil, fmt.Errorf("at least one match field is required for upsert")
+	}
+	chunkSize := options.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultUpsertChunkSize
+	}
+
+	results := make([]*UpsertResult, len(records))
+	seen := make(map[string]int)
+	for start := 0; start < len(records); start += chunkSize {
+		end := start + chunkSize
+		if end > len(records) {
+			end = len(records)
+		}
+		if err := t.upsertChunk(ctx, records, start, end, options.MatchFields, seen, results); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// upsertChunk resolves and writes records[start:end]
+func (t *TableClient) upsertChunk(ctx context.Context, records []map[string]interface{}, start, end int, matchFields []string, seen map[string]int, results []*UpsertResult) error {
+	// Collect key values and the fields needed to detect unchanged records
+	keyValues := make(map[string]map[string]bool)
+	fieldSet := map[string]bool{"sys_id": true}
+	pending := make([]int, 0, end-start)
+	for i := start; i < end; i++ {
+		key, ok := upsertKey(records[i], matchFields)
+		results[i] = &UpsertResult{Index: i, Key: key}
+		if !ok {
+			results[i].Status = UpsertFailed
+			results[i].Error = fmt.Sprintf("record is missing match field(s) %s", strings.Join(matchFields, ", "))
+			continue
+		}
+		if first, dup := seen[key]; dup {
+			results[i].Status = UpsertConflict
+			results[i].Error = fmt.Sprintf("duplicate key in input (first seen at index %d)", first)
+			continue
+		}
+		seen[key] = i
+		for _, field := range matchFields {
+			if keyValues[field] == nil {
+				keyValues[field] = make(map[string]bool)
+			}
+			keyValues[field][stringify(records[i][field])] = true
+		}
+		for field := range records[i] {
+			fieldSet[field] = true
+		}
+		pending = append(pending, i)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	existing, err := t.lookupByKeys(ctx, matchFields, keyValues, fieldSet)
+	if err != nil {
+		return fmt.Errorf("failed to resolve existing records: %w", err)
+	}
+
+	builder := batch.NewBatchClient(t.client).NewBatch()
+	requests := 0
+	for _, i := range pending {
+		result := results[i]
+		matches := existing[result.Key]
+		switch {
+		case len(matches) > 1:
+			result.Status = UpsertConflict
+			for _, match := range matches {
+				result.Matches = append(result.Matches, getString(match["sys_id"]))
+			}
+			result.Error = fmt.Sprintf("%d records match key %s", len(matches), result.Key)
+		case len(matches) == 1:
+			result.SysID = getString(matches[0]["sys_id"])
+			if recordUnchanged(records[i], matches[0]) {
+				result.Status = UpsertUnchanged
+				result.Record = matches[0]
+				continue
+			}
+			result.Status = UpsertUpdated
+			builder.Update(upsertRequestID(i), t.name, result.SysID, records[i])
+			requests++
+		default:
+			result.Status = UpsertCreated
+			builder.Create(upsertRequestID(i), t.name, records[i])
+			requests++
+		}
+	}
+	if requests == 0 {
+		return nil
+	}
+
+	batchResult, err := builder.ExecuteWithContext(ctx)
+	if err != nil {
+		return err
+	}
+	for _, i := range pending {
+		result := results[i]
+		if result.Status != UpsertCreated && result.Status != UpsertUpdated {
+			continue
+		}
+		id := upsertRequestID(i)
+		if reqErr, failed := batchResult.GetError(id); failed {
+			result.Status = UpsertFailed
+			result.Error = fmt.Sprintf("%d %s: %s", reqErr.StatusCode, reqErr.StatusText, reqErr.ErrorDetail)
+			continue
+		}
+		reqResult, ok := batchResult.GetResult(id)
+		if !ok {
+			result.Status = UpsertFailed
+			result.Error = "no response for request in batch"
+			continue
+		}
+		if reqResult.StatusCode >= 300 {
+			result.Status = UpsertFailed
+			result.Error = fmt.Sprintf("%d %s", reqResult.StatusCode, reqResult.StatusText)
+			continue
+		}
+		if record, err := batch.ExtractRecordData(reqResult); err == nil {
+			result.Record = record
+			if sysID := getString(record["sys_id"]); sysID != "" {
+				result.SysID = sysID
+			}
+		}
+	}
+	return nil
+}
+
+// lookupByKeys fetches existing records whose match fields are in the given value sets,
+// grouped by their natural key
+func (t *TableClient) lookupByKeys(ctx context.Context, matchFields []string, keyValues map[string]map[string]bool, fieldSet map[string]bool) (map[string][]map[string]interface{}, error) {
+	var conditions []string
+	for _, field := range matchFields {
+		var plain, withCommas []string
+		for value := range keyValues[field] {
+			if strings.Contains(value, ",") {
+				withCommas = append(withCommas, value)
+			} else {
+				plain = append(plain, value)
+			}
+		}
+		sort.Strings(plain)
+		sort.Strings(withCommas)
+		// Values containing commas can't be part of an IN list, so they're ORed in with =
+		var terms []string
+		if len(plain) > 0 {
+			terms = append(terms, fmt.Sprintf("%sIN%s", field, strings.Join(plain, ",")))
+		}
+		for _, value := range withCommas {
+			terms = append(terms, fmt.Sprintf("%s=%s", field, value))
+		}
+		conditions = append(conditions, strings.Join(terms, "^OR"))
+	}
+	fields := make([]string, 0, len(fieldSet))
+	for field := range fieldSet {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	params := map[string]string{
+		"sysparm_query":                  strings.Join(conditions, "^"),
+		"sysparm_fields":                 strings.Join(fields, ","),
+		"sysparm_display_value":          "false",
+		"sysparm_exclude_reference_link": "true",
+	}
+	records, err := t.ListWithContext(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// The per-field IN conditions can over-match composite keys, so group by the full key
+	existing := make(map[string][]map[string]interface{})
+	for _, record := range records {
+		key, ok := upsertKey(record, matchFields)
+		if !ok {
+			continue
+		}
+		existing[key] = append(existing[key], record)
+	}
+	return existing, nil
+}
+
+// upsertKey joins the match field values of a record
+func upsertKey(record map[string]interface{}, matchFields []string) (string, bool) {
+	parts := make([]string, len(matchFields))
+	for i, field := range matchFields {
+		value := stringify(record[field])
+		if value == "" {
+			return "", false
+		}
+		parts[i] = value
+	}
+	return strings.Join(parts, "|"), true
+}
+
+// recordUnchanged reports whether every field of the input already has the same value on the server
+func recordUnchanged(record, existing map[string]interface{}) bool {
+	for field, value := range record {
+		if stringify(value) != stringify(existing[field]) {
+			return false
+		}
+	}
+	return true
+}
+
+// stringify renders a field value the way ServiceNow returns raw values
+func stringify(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case map[string]interface{}:
+		// Reference fields returned with links ({link, value})
+		return getString(val["value"])
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func upsertRequestID(index int) string {
+	return fmt.Sprintf("upsert_%d", index+1)
+}
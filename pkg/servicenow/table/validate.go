@@ -0,0 +1,41 @@
+package table
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/lint"
+)
+
+// WithQueryValidation turns on pre-flight validation: encoded queries are linted
+// against the table schema before list, count and key requests are sent, and
+// queries with error diagnostics fail with a *lint.Error without reaching the
+// instance. Warnings don't stop requests. A nil linter validates with default
+// options, without counting rows.
+func (t *TableClient) WithQueryValidation(linter *lint.Linter) *TableClient {
+	if linter == nil {
+		linter = lint.NewLinter(t.client, t.schemaClient(), lint.Options{LargeTable: -1})
+	}
+	t.linter = linter
+	return t
+}
+
+// validateQuery lints a query when validation is on. Queries that passed are
+// remembered, so paginating doesn't lint every page.
+func (t *TableClient) validateQuery(ctx context.Context, encoded string) error {
+	if t.linter == nil || encoded == "" {
+		return nil
+	}
+	if _, ok := t.validated.Load(encoded); ok {
+		return nil
+	}
+	result, err := t.linter.LintWithContext(ctx, t.name, encoded)
+	if err != nil {
+		return fmt.Errorf("failed to validate query: %w", err)
+	}
+	if err := result.Err(); err != nil {
+		return err
+	}
+	t.validated.Store(encoded, true)
+	return nil
+}
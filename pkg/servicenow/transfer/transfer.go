@@ -0,0 +1,451 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+)
+
+// DefaultReferenceKeys are the natural keys used to find referenced records on the target
+var DefaultReferenceKeys = map[string][]string{
+	"sys_user":           {"user_name"},
+	"sys_user_group":     {"name"},
+	"sys_user_role":      {"name"},
+	"core_company":       {"name"},
+	"cmn_location":       {"name"},
+	"cmn_department":     {"name"},
+	"cmn_cost_center":    {"name"},
+	"sc_catalog":         {"title"},
+	"sc_category":        {"title"},
+	"sc_cat_item":        {"name"},
+	"sys_db_object":      {"name"},
+	"sys_choice":         {"name", "element", "value"},
+	"cmdb_ci":            {"name"},
+	"task":               {"number"},
+	"incident":           {"number"},
+	"problem":            {"number"},
+	"change_request":     {"number"},
+	"sc_request":         {"number"},
+	"sc_req_item":        {"number"},
+	"kb_knowledge":       {"number"},
+	"sys_script":         {"name", "collection"},
+	"sys_script_include": {"api_name"},
+}
+
+// fallbackReferenceKeys are tried in order when a table has no configured key
+var fallbackReferenceKeys = []string{"user_name", "number", "name"}
+
+// systemFields are maintained by the platform and never written to the target
+var systemFields = map[string]bool{
+	"sys_mod_count":  true,
+	"sys_updated_on": true,
+	"sys_updated_by": true,
+	"sys_created_on": true,
+	"sys_created_by": true,
+	"sys_tags":       true,
+}
+
+// Options controls how records are copied
+type Options struct {
+	KeyFields        []string            // Fields matching copied records on the target (default sys_id, preserving sys_ids)
+	Fields           []string            // Fields to copy (default: all)
+	SkipFields       []string            // Fields never copied
+	ReferenceKeys    map[string][]string // Natural keys per referenced table, overriding DefaultReferenceKeys
+	FollowReferences bool                // Copy referenced records that do not exist on the target
+	MaxDepth         int                 // How many reference levels to follow (default 1)
+	DryRun           bool                // Resolve references without writing to the target
+}
+
+// Status describes the outcome for one record or reference
+type Status string
+
+const (
+	StatusCreated    Status = "created"
+	StatusUpdated    Status = "updated"
+	StatusUnchanged  Status = "unchanged"
+	StatusResolved   Status = "resolved"   // Reference found on the target
+	StatusUnresolved Status = "unresolved" // Reference left empty on the target
+	StatusPlanned    Status = "planned"    // Dry run: record would be written
+	StatusConflict   Status = "conflict"
+	StatusFailed     Status = "failed"
+)
+
+// Mapping links a source sys_id to its target sys_id
+type Mapping struct {
+	Table       string `json:"table"`
+	SourceSysID string `json:"source_sys_id"`
+	TargetSysID string `json:"target_sys_id,omitempty"`
+	Key         string `json:"key,omitempty"`
+	Status      Status `json:"status"`
+	Message     string `json:"message,omitempty"`
+	Depth       int    `json:"depth"` // 0 for requested records, >0 for followed references
+}
+
+// Report is the source→target mapping produced by a copy
+type Report struct {
+	Records    []*Mapping `json:"records"`    // Records written (or planned) on the target
+	References []*Mapping `json:"references"` // Reference values resolved on the target
+}
+
+// Counts returns the number of copied records per status
+func (r *Report) Counts() map[Status]int {
+	counts := make(map[Status]int)
+	for _, mapping := range r.Records {
+		counts[mapping.Status]++
+	}
+	return counts
+}
+
+// Unresolved returns references that could not be mapped to a target record
+func (r *Report) Unresolved() []*Mapping {
+	var unresolved []*Mapping
+	for _, mapping := range r.References {
+		if mapping.Status == StatusUnresolved {
+			unresolved = append(unresolved, mapping)
+		}
+	}
+	return unresolved
+}
+
+// Copier copies records between two instances, remapping reference fields
+type Copier struct {
+	source *core.Client
+	target *core.Client
+	opts   Options
+
+	mu       sync.Mutex
+	resolved map[string]*Mapping // "table/source_sys_id" → mapping
+	report   *Report
+}
+
+// NewCopier creates a copier from source to target
+func NewCopier(source, target *core.Client, opts Options) *Copier {
+	if len(opts.KeyFields) == 0 {
+		opts.KeyFields = []string{"sys_id"}
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = 1
+	}
+	return &Copier{
+		source:   source,
+		target:   target,
+		opts:     opts,
+		resolved: make(map[string]*Mapping),
+		report:   &Report{Records: make([]*Mapping, 0), References: make([]*Mapping, 0)},
+	}
+}
+
+// Copy copies the records of tableName matching encodedQuery to the target
+func (c *Copier) Copy(ctx context.Context, tableName, encodedQuery string) (*Report, error) {
+	if _, err := c.copyRecords(ctx, tableName, encodedQuery, c.opts.KeyFields, c.opts.Fields, 0); err != nil {
+		return c.report, err
+	}
+	return c.report, nil
+}
+
+// copyRecords copies matching records and returns their mappings
+func (c *Copier) copyRecords(ctx context.Context, tableName, encodedQuery string, keyFields, fields []string, depth int) ([]*Mapping, error) {
+	listOptions := table.ListOptions{
+		Query:        encodedQuery,
+		DisplayValue: core.DisplayFalse,
+	}
+	if len(fields) > 0 {
+		listOptions.Fields = mergeFields(keyFields, fields, []string{"sys_id"})
+	}
+	// Reference links are kept: {link, value} objects identify reference fields and their tables
+	records, err := table.NewTableClient(c.source, tableName).PaginateParallel(ctx, listOptions, table.ParallelOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from source: %w", tableName, err)
+	}
+
+	skip := make(map[string]bool, len(c.opts.SkipFields))
+	for _, field := range c.opts.SkipFields {
+		skip[field] = true
+	}
+	preserveSysID := len(keyFields) == 1 && keyFields[0] == "sys_id"
+
+	payloads := make([]map[string]interface{}, len(records))
+	mappings := make([]*Mapping, len(records))
+	for i, record := range records {
+		sourceID := valueOf(record["sys_id"])
+		payload := make(map[string]interface{}, len(record))
+		for field, value := range record {
+			if skip[field] || systemFields[field] || (field == "sys_id" && !preserveSysID) {
+				continue
+			}
+			refTable, refID, isRef := referenceOf(value)
+			if !isRef {
+				payload[field] = valueOf(value)
+				continue
+			}
+			if refID == "" {
+				payload[field] = ""
+				continue
+			}
+			reference, err := c.resolveReference(ctx, refTable, refID, depth)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case reference.TargetSysID != "":
+				payload[field] = reference.TargetSysID
+			case reference.Status == StatusUnresolved:
+				// Clear the field so the target doesn't keep a stale reference
+				payload[field] = ""
+			}
+		}
+		payloads[i] = payload
+		key, _ := recordKey(record, keyFields)
+		mappings[i] = &Mapping{Table: tableName, SourceSysID: sourceID, Key: key, Depth: depth}
+	}
+
+	if c.opts.DryRun {
+		for _, mapping := range mappings {
+			mapping.Status = StatusPlanned
+			if preserveSysID {
+				mapping.TargetSysID = mapping.SourceSysID
+			}
+		}
+	} else if len(payloads) > 0 {
+		results, err := table.NewTableClient(c.target, tableName).UpsertManyWithContext(ctx, payloads, table.UpsertOptions{MatchFields: keyFields})
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s to target: %w", tableName, err)
+		}
+		for i, result := range results {
+			if result == nil {
+				continue
+			}
+			mappings[i].TargetSysID = result.SysID
+			mappings[i].Status = upsertStatus(result.Status)
+			mappings[i].Message = result.Error
+		}
+	}
+
+	c.mu.Lock()
+	c.report.Records = append(c.report.Records, mappings...)
+	for _, mapping := range mappings {
+		if mapping.TargetSysID != "" {
+			c.resolved[mapping.Table+"/"+mapping.SourceSysID] = mapping
+		}
+	}
+	c.mu.Unlock()
+	return mappings, nil
+}
+
+// resolveReference maps a referenced source record to the target. The mapping has
+// no TargetSysID when the reference is unresolved, planned or still being copied.
+func (c *Copier) resolveReference(ctx context.Context, refTable, sourceID string, depth int) (*Mapping, error) {
+	cacheKey := refTable + "/" + sourceID
+	c.mu.Lock()
+	if mapping, ok := c.resolved[cacheKey]; ok {
+		c.mu.Unlock()
+		return mapping, nil
+	}
+	c.mu.Unlock()
+
+	mapping := &Mapping{Table: refTable, SourceSysID: sourceID, Depth: depth + 1}
+	c.remember(cacheKey, mapping)
+
+	// Records installed by the platform or plugins usually share sys_ids across instances
+	if exists, err := c.existsOnTarget(ctx, refTable, "sys_id="+sourceID); err != nil {
+		return nil, err
+	} else if len(exists) == 1 {
+		mapping.TargetSysID = sourceID
+		mapping.Key = "sys_id=" + sourceID
+		mapping.Status = StatusResolved
+		return mapping, nil
+	}
+
+	source, err := table.NewTableClient(c.source, refTable).ListWithContext(ctx, map[string]string{
+		"sysparm_query":                  "sys_id=" + sourceID,
+		"sysparm_limit":                  "1",
+		"sysparm_display_value":          "false",
+		"sysparm_exclude_reference_link": "true",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read referenced %s record %s: %w", refTable, sourceID, err)
+	}
+	if len(source) == 0 {
+		mapping.Status = StatusUnresolved
+		mapping.Message = "referenced record not found on source"
+		return mapping, nil
+	}
+
+	keyFields := c.referenceKeyFields(refTable, source[0])
+	key, ok := recordKey(source[0], keyFields)
+	if !ok {
+		mapping.Status = StatusUnresolved
+		mapping.Message = fmt.Sprintf("no natural key configured for %s (use ReferenceKeys)", refTable)
+		return mapping, nil
+	}
+	mapping.Key = key
+
+	matches, err := c.existsOnTarget(ctx, refTable, keyQuery(source[0], keyFields))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(matches) == 1:
+		mapping.TargetSysID = valueOf(matches[0]["sys_id"])
+		mapping.Status = StatusResolved
+		return mapping, nil
+	case len(matches) > 1:
+		mapping.Status = StatusUnresolved
+		mapping.Message = fmt.Sprintf("%d target records match %s", len(matches), key)
+		return mapping, nil
+	}
+
+	if !c.opts.FollowReferences || depth >= c.opts.MaxDepth {
+		mapping.Status = StatusUnresolved
+		mapping.Message = "no matching record on target"
+		return mapping, nil
+	}
+
+	copied, err := c.copyRecords(ctx, refTable, "sys_id="+sourceID, keyFields, nil, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	if c.opts.DryRun {
+		mapping.Status = StatusPlanned
+		mapping.Message = "would be copied to target"
+		return mapping, nil
+	}
+	if len(copied) == 1 && copied[0].TargetSysID != "" {
+		mapping.TargetSysID = copied[0].TargetSysID
+		mapping.Status = StatusResolved
+		mapping.Message = "copied to target"
+		return mapping, nil
+	}
+	mapping.Status = StatusUnresolved
+	mapping.Message = "failed to copy referenced record"
+	return mapping, nil
+}
+
+// remember records a reference mapping in the cache and the report
+func (c *Copier) remember(cacheKey string, mapping *Mapping) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resolved[cacheKey] = mapping
+	c.report.References = append(c.report.References, mapping)
+}
+
+// existsOnTarget returns up to two target records matching the query
+func (c *Copier) existsOnTarget(ctx context.Context, tableName, encodedQuery string) ([]map[string]interface{}, error) {
+	records, err := table.NewTableClient(c.target, tableName).ListWithContext(ctx, map[string]string{
+		"sysparm_query":  encodedQuery,
+		"sysparm_fields": "sys_id",
+		"sysparm_limit":  "2",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s on target: %w", tableName, err)
+	}
+	return records, nil
+}
+
+// referenceKeyFields picks the natural key for a referenced table
+func (c *Copier) referenceKeyFields(refTable string, record map[string]interface{}) []string {
+	if keys, ok := c.opts.ReferenceKeys[refTable]; ok {
+		return keys
+	}
+	if keys, ok := DefaultReferenceKeys[refTable]; ok {
+		return keys
+	}
+	for _, field := range fallbackReferenceKeys {
+		if valueOf(record[field]) != "" {
+			return []string{field}
+		}
+	}
+	return nil
+}
+
+// referenceOf detects {link, value} reference objects and extracts the referenced table
+func referenceOf(v interface{}) (refTable, sysID string, ok bool) {
+	ref, isMap := v.(map[string]interface{})
+	if !isMap {
+		return "", "", false
+	}
+	link, hasLink := ref["link"].(string)
+	if !hasLink {
+		return "", "", false
+	}
+	sysID = valueOf(ref["value"])
+	idx := strings.Index(link, "/table/")
+	if idx < 0 {
+		return "", "", false
+	}
+	parts := strings.Split(link[idx+len("/table/"):], "/")
+	return parts[0], sysID, parts[0] != ""
+}
+
+// recordKey joins key field values as "field=value" pairs
+func recordKey(record map[string]interface{}, keyFields []string) (string, bool) {
+	if len(keyFields) == 0 {
+		return "", false
+	}
+	parts := make([]string, len(keyFields))
+	for i, field := range keyFields {
+		value := valueOf(record[field])
+		if value == "" {
+			return "", false
+		}
+		parts[i] = field + "=" + value
+	}
+	return strings.Join(parts, ","), true
+}
+
+// keyQuery builds an encoded query matching the key field values of a record
+func keyQuery(record map[string]interface{}, keyFields []string) string {
+	conditions := make([]string, len(keyFields))
+	for i, field := range keyFields {
+		conditions[i] = field + "=" + valueOf(record[field])
+	}
+	return strings.Join(conditions, "^")
+}
+
+func upsertStatus(status table.UpsertStatus) Status {
+	switch status {
+	case table.UpsertCreated:
+		return StatusCreated
+	case table.UpsertUpdated:
+		return StatusUpdated
+	case table.UpsertUnchanged:
+		return StatusUnchanged
+	case table.UpsertConflict:
+		return StatusConflict
+	default:
+		return StatusFailed
+	}
+}
+
+func valueOf(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case map[string]interface{}:
+		return valueOf(val["value"])
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func mergeFields(groups ...[]string) []string {
+	seen := make(map[string]bool)
+	var fields []string
+	for _, group := range groups {
+		for _, field := range group {
+			if !seen[field] {
+				seen[field] = true
+				fields = append(fields, field)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,504 @@
+package undo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+)
+
+// Action identifies the destructive operation a before-image was captured for
+type Action string
+
+const (
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Entry holds the before-image of a single record touched by an operation
+type Entry struct {
+	Table          string                 `json:"table"`
+	SysID          string                 `json:"sys_id"`
+	Action         Action                 `json:"action"`
+	Fields         []string               `json:"fields,omitempty"` // Fields changed by an update
+	Before         map[string]interface{} `json:"before"`
+	AfterModCount  string                 `json:"after_mod_count,omitempty"`
+	AfterUpdatedOn string                 `json:"after_updated_on,omitempty"`
+	Applied        bool                   `json:"applied"`
+}
+
+// Operation groups the before-images captured for one update or delete run
+type Operation struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	InstanceURL string    `json:"instance_url"`
+	CreatedAt   time.Time `json:"created_at"`
+	UndoneAt    time.Time `json:"undone_at,omitempty"`
+	Entries     []*Entry  `json:"entries"`
+}
+
+// AppliedEntries returns the entries whose forward operation succeeded
+func (o *Operation) AppliedEntries() []*Entry {
+	var entries []*Entry
+	for _, entry := range o.Entries {
+		if entry.Applied {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Store persists operations as JSON files in a local directory
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store rooted at dir (created on first save)
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory backing the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save writes an operation to the store, replacing any previous version
+func (s *Store) Save(op *Operation) error {
+	if op.ID == "" {
+		return fmt.Errorf("operation ID is required")
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create undo store: %w", err)
+	}
+	data, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode operation: %w", err)
+	}
+	// Write via a temp file so an interrupted save never leaves a truncated image
+	tmpPath := s.path(op.ID) + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write operation: %w", err)
+	}
+	return os.Rename(tmpPath, s.path(op.ID))
+}
+
+// Load reads an operation by ID
+func (s *Store) Load(id string) (*Operation, error) {
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("operation %s not found in %s", id, s.dir)
+		}
+		return nil, fmt.Errorf("failed to read operation: %w", err)
+	}
+	var op Operation
+	if err := json.Unmarshal(data, &op); err != nil {
+		return nil, fmt.Errorf("failed to parse operation %s: %w", id, err)
+	}
+	return &op, nil
+}
+
+// List returns all stored operations, newest first
+func (s *Store) List() ([]*Operation, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Operation{}, nil
+		}
+		return nil, fmt.Errorf("failed to read undo store: %w", err)
+	}
+	var ops []*Operation
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		op, err := s.Load(strings.TrimSuffix(file.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].CreatedAt.After(ops[j].CreatedAt)
+	})
+	return ops, nil
+}
+
+// Delete removes an operation from the store
+func (s *Store) Delete(id string) error {
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete operation: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+".json")
+}
+
+// Recorder captures before-images ahead of updates and deletes
+type Recorder struct {
+	client *core.Client
+	store  *Store
+	op     *Operation
+	mu     sync.Mutex
+	index  map[string]*Entry
+}
+
+// NewRecorder starts a new operation that will be persisted to store
+func NewRecorder(client *core.Client, store *Store, description string) *Recorder {
+	return &Recorder{
+		client: client,
+		store:  store,
+		op: &Operation{
+			ID:          generateOperationID(),
+			Description: description,
+			InstanceURL: client.InstanceURL,
+			CreatedAt:   time.Now(),
+			Entries:     make([]*Entry, 0),
+		},
+		index: make(map[string]*Entry),
+	}
+}
+
+// Operation returns the operation being recorded
+func (r *Recorder) Operation() *Operation {
+	return r.op
+}
+
+// Capture fetches the full record and stores its before-image.
+// fields lists the fields an update is about to change and is ignored for deletes.
+func (r *Recorder) Capture(ctx context.Context, tableName, sysID string, action Action, fields []string) (*Entry, error) {
+	before, err := fetchRecord(ctx, r.client, tableName, sysID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture before-image of %s/%s: %w", tableName, sysID, err)
+	}
+	entry := &Entry{
+		Table:  tableName,
+		SysID:  sysID,
+		Action: action,
+		Before: before,
+	}
+	if action == ActionUpdate {
+		entry.Fields = append([]string(nil), fields...)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.op.Entries = append(r.op.Entries, entry)
+	r.index[entryKey(tableName, sysID)] = entry
+	return entry, nil
+}
+
+// CaptureAll fetches several records with one query and stores their
+// before-images, returning the entries by sys_id. Records the query doesn't
+// return, e.g. because they were deleted meanwhile, have no entry.
+func (r *Recorder) CaptureAll(ctx context.Context, tableName string, sysIDs []string, action Action, fields []string) (map[string]*Entry, error) {
+	records, err := fetchRecords(ctx, r.client, tableName, sysIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture before-images of %d %s records: %w", len(sysIDs), tableName, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make(map[string]*Entry, len(records))
+	for _, before := range records {
+		sysID := stringValue(before["sys_id"])
+		if sysID == "" || entries[sysID] != nil {
+			continue
+		}
+		entry := &Entry{
+			Table:  tableName,
+			SysID:  sysID,
+			Action: action,
+			Before: before,
+		}
+		if action == ActionUpdate {
+			entry.Fields = append([]string(nil), fields...)
+		}
+		r.op.Entries = append(r.op.Entries, entry)
+		r.index[entryKey(tableName, sysID)] = entry
+		entries[sysID] = entry
+	}
+	return entries, nil
+}
+
+// MarkApplied records that the forward operation succeeded. after is the
+// record returned by the update (nil for deletes) and provides the version
+// used to detect later changes.
+func (r *Recorder) MarkApplied(tableName, sysID string, after map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.index[entryKey(tableName, sysID)]
+	if !ok {
+		return
+	}
+	entry.Applied = true
+	if after != nil {
+		entry.AfterModCount = stringValue(after["sys_mod_count"])
+		entry.AfterUpdatedOn = stringValue(after["sys_updated_on"])
+	}
+}
+
+// Save persists the operation if at least one entry was applied
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.op.AppliedEntries()) == 0 {
+		return nil
+	}
+	return r.store.Save(r.op)
+}
+
+// Status describes the outcome of restoring a single entry
+type Status string
+
+const (
+	StatusRestored  Status = "restored"
+	StatusRecreated Status = "recreated"
+	StatusConflict  Status = "conflict"
+	StatusFailed    Status = "failed"
+)
+
+// Outcome reports what happened to one entry during an undo
+type Outcome struct {
+	Table   string `json:"table"`
+	SysID   string `json:"sys_id"`
+	Action  Action `json:"action"`
+	Status  Status `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Result summarizes an undo run
+type Result struct {
+	OperationID string     `json:"operation_id"`
+	Outcomes    []*Outcome `json:"outcomes"`
+	Restored    int        `json:"restored"`
+	Conflicts   int        `json:"conflicts"`
+	Failed      int        `json:"failed"`
+}
+
+// Options controls how an operation is undone
+type Options struct {
+	Force  bool // Overwrite records that changed since the operation
+	DryRun bool // Detect conflicts without writing
+}
+
+// Undo restores the before-images of an operation
+func Undo(client *core.Client, op *Operation, opts Options) (*Result, error) {
+	return UndoWithContext(context.Background(), client, op, opts)
+}
+
+// UndoWithContext restores the before-images of an operation with context support
+func UndoWithContext(ctx context.Context, client *core.Client, op *Operation, opts Options) (*Result, error) {
+	if !op.UndoneAt.IsZero() && !opts.Force {
+		return nil, fmt.Errorf("operation %s was already undone at %s", op.ID, op.UndoneAt.Format(time.RFC3339))
+	}
+
+	result := &Result{OperationID: op.ID, Outcomes: make([]*Outcome, 0)}
+	for _, entry := range op.AppliedEntries() {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		var outcome *Outcome
+		switch entry.Action {
+		case ActionUpdate:
+			outcome = restoreUpdate(ctx, client, entry, opts)
+		case ActionDelete:
+			outcome = restoreDelete(ctx, client, entry, opts)
+		default:
+			outcome = newOutcome(entry, StatusFailed, fmt.Sprintf("unknown action %q", entry.Action))
+		}
+		switch outcome.Status {
+		case StatusRestored, StatusRecreated:
+			result.Restored++
+		case StatusConflict:
+			result.Conflicts++
+		case StatusFailed:
+			result.Failed++
+		}
+		result.Outcomes = append(result.Outcomes, outcome)
+	}
+	return result, nil
+}
+
+// restoreUpdate writes back the previous values of the fields an update changed
+func restoreUpdate(ctx context.Context, client *core.Client, entry *Entry, opts Options) *Outcome {
+	current, err := fetchRecord(ctx, client, entry.Table, entry.SysID)
+	if err != nil {
+		return newOutcome(entry, StatusFailed, err.Error())
+	}
+	if changed, reason := changedSince(entry, current); changed && !opts.Force {
+		return newOutcome(entry, StatusConflict, reason)
+	}
+
+	fields := entry.Fields
+	if len(fields) == 0 {
+		fields = restorableFields(entry.Before)
+	}
+	payload := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		payload[field] = entry.Before[field]
+	}
+	if opts.DryRun {
+		return newOutcome(entry, StatusRestored, fmt.Sprintf("would restore %d field(s)", len(payload)))
+	}
+
+	params := map[string]string{"sysparm_input_display_value": "false"}
+	path := fmt.Sprintf("/table/%s/%s", entry.Table, entry.SysID)
+	if err := client.RawRequestWithContext(ctx, "PATCH", path, payload, params, nil); err != nil {
+		return newOutcome(entry, StatusFailed, err.Error())
+	}
+	return newOutcome(entry, StatusRestored, fmt.Sprintf("restored %d field(s)", len(payload)))
+}
+
+// restoreDelete re-creates a deleted record with its original sys_id
+func restoreDelete(ctx context.Context, client *core.Client, entry *Entry, opts Options) *Outcome {
+	if _, err := fetchRecord(ctx, client, entry.Table, entry.SysID); err == nil {
+		return newOutcome(entry, StatusConflict, "a record with this sys_id already exists")
+	} else if snErr, ok := core.IsServiceNowError(err); !ok || snErr.Type != core.ErrorTypeNotFound {
+		return newOutcome(entry, StatusFailed, err.Error())
+	}
+
+	payload := make(map[string]interface{})
+	for _, field := range restorableFields(entry.Before) {
+		payload[field] = entry.Before[field]
+	}
+	payload["sys_id"] = entry.SysID
+	if opts.DryRun {
+		return newOutcome(entry, StatusRecreated, "would re-create record")
+	}
+
+	// Raw values are posted back as-is so references keep their original sys_ids
+	params := map[string]string{"sysparm_input_display_value": "false"}
+	var created core.Response
+	if err := client.RawRequestWithContext(ctx, "POST", fmt.Sprintf("/table/%s", entry.Table), payload, params, &created); err != nil {
+		return newOutcome(entry, StatusFailed, err.Error())
+	}
+	if record, ok := created.Result.(map[string]interface{}); ok {
+		if newID := stringValue(record["sys_id"]); newID != "" && newID != entry.SysID {
+			return newOutcome(entry, StatusRecreated, fmt.Sprintf("re-created with new sys_id %s (instance rejected original)", newID))
+		}
+	}
+	return newOutcome(entry, StatusRecreated, "")
+}
+
+// changedSince reports whether the record was modified after the operation ran
+func changedSince(entry *Entry, current map[string]interface{}) (bool, string) {
+	if entry.AfterModCount != "" {
+		if modCount := stringValue(current["sys_mod_count"]); modCount != entry.AfterModCount {
+			return true, fmt.Sprintf("sys_mod_count is %s, expected %s", modCount, entry.AfterModCount)
+		}
+		return false, ""
+	}
+	if entry.AfterUpdatedOn != "" {
+		if updatedOn := stringValue(current["sys_updated_on"]); updatedOn != entry.AfterUpdatedOn {
+			return true, fmt.Sprintf("sys_updated_on is %s, expected %s", updatedOn, entry.AfterUpdatedOn)
+		}
+	}
+	return false, ""
+}
+
+// systemFields are maintained by the platform and cannot be written back
+var systemFields = map[string]bool{
+	"sys_id":         true,
+	"sys_mod_count":  true,
+	"sys_updated_on": true,
+	"sys_updated_by": true,
+	"sys_created_on": true,
+	"sys_created_by": true,
+	"sys_tags":       true,
+}
+
+// restorableFields returns the before-image fields that can be written back
+func restorableFields(before map[string]interface{}) []string {
+	var fields []string
+	for field := range before {
+		if !systemFields[field] {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// fetchRecord reads the raw (non display) values of a record
+func fetchRecord(ctx context.Context, client *core.Client, tableName, sysID string) (map[string]interface{}, error) {
+	params := map[string]string{
+		"sysparm_display_value":          "false",
+		"sysparm_exclude_reference_link": "true",
+	}
+	var result core.Response
+	if err := client.RawRequestWithContext(ctx, "GET", fmt.Sprintf("/table/%s/%s", tableName, sysID), nil, params, &result); err != nil {
+		return nil, err
+	}
+	record, ok := result.Result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type for get: %T", result.Result)
+	}
+	return record, nil
+}
+
+// fetchRecords reads the raw values of the records with the given sys_ids
+func fetchRecords(ctx context.Context, client *core.Client, tableName string, sysIDs []string) ([]map[string]interface{}, error) {
+	params := map[string]string{
+		"sysparm_query":                  "sys_idIN" + strings.Join(sysIDs, ","),
+		"sysparm_limit":                  fmt.Sprintf("%d", len(sysIDs)),
+		"sysparm_display_value":          "false",
+		"sysparm_exclude_reference_link": "true",
+		"sysparm_no_count":               "true",
+	}
+	var result core.Response
+	if err := client.RawRequestWithContext(ctx, "GET", fmt.Sprintf("/table/%s", tableName), nil, params, &result); err != nil {
+		return nil, err
+	}
+	items, ok := result.Result.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type for list: %T", result.Result)
+	}
+	records := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if record, ok := item.(map[string]interface{}); ok {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func newOutcome(entry *Entry, status Status, message string) *Outcome {
+	return &Outcome{
+		Table:   entry.Table,
+		SysID:   entry.SysID,
+		Action:  entry.Action,
+		Status:  status,
+		Message: message,
+	}
+}
+
+func entryKey(tableName, sysID string) string {
+	return tableName + "/" + sysID
+}
+
+func stringValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// generateOperationID generates a unique operation ID
+func generateOperationID() string {
+	return fmt.Sprintf("op_%d", time.Now().UnixNano())
+}
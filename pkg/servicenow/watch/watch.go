@@ -0,0 +1,403 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+)
+
+// DefaultInterval is the time between polls when not configured
+const DefaultInterval = 30 * time.Second
+
+// DefaultPageSize is the number of changed records read per request when not configured
+const DefaultPageSize = 100
+
+// EventType classifies a record change
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+// Event is a change to a watched record
+type Event struct {
+	Type      EventType              `json:"type"`
+	Table     string                 `json:"table"`
+	SysID     string                 `json:"sys_id"`
+	ModCount  int                    `json:"mod_count"`
+	Timestamp time.Time              `json:"timestamp"`        // sys_updated_on, or when the record was deleted
+	User      string                 `json:"user,omitempty"`   // sys_updated_by, or who deleted the record
+	Record    map[string]interface{} `json:"record,omitempty"` // Not available for deletions
+}
+
+// Checkpoint is the persisted position of a watcher. Changes at the high-water mark
+// itself are remembered so that re-reading that second never repeats an event.
+type Checkpoint struct {
+	Table           string          `json:"table"`
+	Query           string          `json:"query,omitempty"`
+	HighWater       time.Time       `json:"high_water"`
+	Seen            map[string]int  `json:"seen,omitempty"` // sys_id → sys_mod_count of changes at HighWater
+	DeleteHighWater time.Time       `json:"delete_high_water,omitempty"`
+	DeletesSeen     map[string]bool `json:"deletes_seen,omitempty"` // sys_audit_delete sys_ids at DeleteHighWater
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// Options controls what a watcher polls and where it keeps its checkpoint
+type Options struct {
+	Query          string        // Encoded query the watched records must match
+	Fields         []string      // Fields included in event records (default: all)
+	Interval       time.Duration // Time between polls (default DefaultInterval)
+	PageSize       int           // Records per request (default DefaultPageSize)
+	Since          time.Time     // Where to start without a checkpoint (default: now)
+	IncludeDeletes bool          // Also report deletions recorded in sys_audit_delete
+	CheckpointFile string        // Load the checkpoint from and save it to this file after each poll
+}
+
+// Watcher polls a table for records changed since its high-water mark
+type Watcher struct {
+	client     *core.Client
+	table      string
+	options    Options
+	checkpoint Checkpoint
+}
+
+// NewWatcher creates a watcher, resuming from options.CheckpointFile when it exists
+func NewWatcher(client *core.Client, tableName string, options Options) (*Watcher, error) {
+	if options.Interval <= 0 {
+		options.Interval = DefaultInterval
+	}
+	if options.PageSize <= 0 {
+		options.PageSize = DefaultPageSize
+	}
+	start := options.Since
+	if start.IsZero() {
+		start = time.Now()
+	}
+	start = start.UTC().Truncate(time.Second)
+
+	w := &Watcher{
+		client:  client,
+		table:   tableName,
+		options: options,
+		checkpoint: Checkpoint{
+			Table:           tableName,
+			Query:           options.Query,
+			HighWater:       start,
+			DeleteHighWater: start,
+		},
+	}
+
+	if options.CheckpointFile != "" {
+		checkpoint, err := LoadCheckpoint(options.CheckpointFile)
+		if err != nil {
+			return nil, err
+		}
+		if checkpoint != nil {
+			if checkpoint.Table != tableName || checkpoint.Query != options.Query {
+				return nil, fmt.Errorf("checkpoint %s belongs to %s with query %q; use another checkpoint file", options.CheckpointFile, checkpoint.Table, checkpoint.Query)
+			}
+			if checkpoint.DeleteHighWater.IsZero() {
+				checkpoint.DeleteHighWater = checkpoint.HighWater
+			}
+			w.checkpoint = *checkpoint
+		}
+	}
+	return w, nil
+}
+
+// Checkpoint returns the current position of the watcher
+func (w *Watcher) Checkpoint() Checkpoint {
+	return w.checkpoint
+}
+
+// Poll reads the changes made since the last poll, oldest first, advances the
+// checkpoint and saves it when a checkpoint file is configured
+func (w *Watcher) Poll(ctx context.Context) ([]Event, error) {
+	events, err := w.poll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.save(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// poll reads the changes since the checkpoint and advances it in memory
+func (w *Watcher) poll(ctx context.Context) ([]Event, error) {
+	if _, err := table.SessionTimeZone(ctx, w.client); err != nil {
+		return nil, err
+	}
+	events, err := w.pollChanges(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if w.options.IncludeDeletes {
+		deletes, err := w.pollDeletes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, deletes...)
+	}
+	w.checkpoint.UpdatedAt = time.Now().UTC()
+	return events, nil
+}
+
+// save writes the checkpoint file, if any
+func (w *Watcher) save() error {
+	if w.options.CheckpointFile == "" {
+		return nil
+	}
+	return SaveCheckpoint(w.options.CheckpointFile, w.checkpoint)
+}
+
+// Run polls until ctx is cancelled, passing each event to fn. An error from fn stops
+// the watcher.
+func (w *Watcher) Run(ctx context.Context, fn func(Event) error) error {
+	for {
+		if err := w.RunOnce(ctx, fn); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.options.Interval):
+		}
+	}
+}
+
+// RunOnce polls once and passes each event to fn. The checkpoint is only saved once
+// every event was handled, so events fn failed on are seen again after a restart.
+func (w *Watcher) RunOnce(ctx context.Context, fn func(Event) error) error {
+	before := w.checkpoint
+	events, err := w.poll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := fn(event); err != nil {
+			w.checkpoint = before
+			return err
+		}
+	}
+	return w.save()
+}
+
+// Events runs the watcher in the background and delivers events on a channel. The
+// error channel receives the reason the watcher stopped; both channels are then closed.
+func (w *Watcher) Events(ctx context.Context) (<-chan Event, <-chan error) {
+	events := make(chan Event)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(events)
+		errs <- w.Run(ctx, func(event Event) error {
+			select {
+			case events <- event:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return events, errs
+}
+
+// pollChanges reads records updated at or after the high-water mark
+func (w *Watcher) pollChanges(ctx context.Context) ([]Event, error) {
+	encoded := query.AndAll(w.options.Query, "sys_updated_on>="+w.queryTime(w.checkpoint.HighWater)) + "^ORDERBYsys_updated_on"
+	fields := w.options.Fields
+	if len(fields) > 0 {
+		fields = append(append([]string{}, fields...), "sys_id", "sys_mod_count", "sys_created_on", "sys_updated_on", "sys_updated_by")
+	}
+
+	tc := table.NewTableClient(w.client, w.table)
+	var events []Event
+	highWater, seen := w.checkpoint.HighWater, w.checkpoint.Seen
+	nextSeen := make(map[string]int)
+	for offset := 0; ; offset += w.options.PageSize {
+		records, err := tc.ListOptWithContext(ctx, table.ListOptions{
+			Query:        encoded,
+			Fields:       fields,
+			Limit:        w.options.PageSize,
+			Offset:       offset,
+			DisplayValue: core.DisplayFalse,
+			NoCount:      true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to poll %s: %w", w.table, err)
+		}
+
+		for _, record := range records {
+			r := table.NewRecord(record, core.DisplayFalse, nil)
+			sysID := r.SysID()
+			modCount, _ := r.Int("sys_mod_count")
+			updatedOn, err := r.Time("sys_updated_on")
+			if err != nil || sysID == "" {
+				continue
+			}
+			if updatedOn.Equal(w.checkpoint.HighWater) {
+				if previous, ok := seen[sysID]; ok && previous >= modCount {
+					continue
+				}
+			}
+
+			eventType := EventUpdated
+			if createdOn, err := r.Time("sys_created_on"); modCount == 0 || (err == nil && createdOn.Equal(updatedOn)) {
+				eventType = EventCreated
+			}
+			events = append(events, Event{
+				Type:      eventType,
+				Table:     w.table,
+				SysID:     sysID,
+				ModCount:  modCount,
+				Timestamp: updatedOn,
+				User:      r.String("sys_updated_by"),
+				Record:    record,
+			})
+
+			if updatedOn.After(highWater) {
+				highWater = updatedOn
+				nextSeen = make(map[string]int)
+			}
+			if updatedOn.Equal(highWater) {
+				nextSeen[sysID] = modCount
+			}
+		}
+		if len(records) < w.options.PageSize {
+			break
+		}
+	}
+
+	if highWater.Equal(w.checkpoint.HighWater) {
+		// Nothing newer: keep remembering what was already seen at this second
+		for sysID, modCount := range seen {
+			if current, ok := nextSeen[sysID]; !ok || current < modCount {
+				nextSeen[sysID] = modCount
+			}
+		}
+	}
+	w.checkpoint.HighWater = highWater
+	w.checkpoint.Seen = nextSeen
+	return events, nil
+}
+
+// pollDeletes reads sys_audit_delete entries of the table at or after the delete high-water
+// mark. The watch query can't be applied to deleted records, so every deletion is reported.
+func (w *Watcher) pollDeletes(ctx context.Context) ([]Event, error) {
+	records, err := table.NewTableClient(w.client, "sys_audit_delete").PaginateParallel(ctx, table.ListOptions{
+		Query:        "tablename=" + w.table + "^sys_created_on>=" + w.queryTime(w.checkpoint.DeleteHighWater) + "^ORDERBYsys_created_on",
+		Fields:       []string{"sys_id", "documentkey", "sys_created_on", "sys_created_by"},
+		DisplayValue: core.DisplayFalse,
+	}, table.ParallelOptions{PageSize: w.options.PageSize})
+	if err != nil {
+		return nil, fmt.Errorf("failed to poll deletions of %s: %w", w.table, err)
+	}
+
+	var events []Event
+	highWater, seen := w.checkpoint.DeleteHighWater, w.checkpoint.DeletesSeen
+	nextSeen := make(map[string]bool)
+	for _, record := range records {
+		r := table.NewRecord(record, core.DisplayFalse, nil)
+		deletedOn, err := r.Time("sys_created_on")
+		if err != nil {
+			continue
+		}
+		if deletedOn.Equal(w.checkpoint.DeleteHighWater) && seen[r.SysID()] {
+			continue
+		}
+		events = append(events, Event{
+			Type:      EventDeleted,
+			Table:     w.table,
+			SysID:     r.String("documentkey"),
+			Timestamp: deletedOn,
+			User:      r.String("sys_created_by"),
+		})
+		if deletedOn.After(highWater) {
+			highWater = deletedOn
+			nextSeen = make(map[string]bool)
+		}
+		if deletedOn.Equal(highWater) {
+			nextSeen[r.SysID()] = true
+		}
+	}
+
+	if highWater.Equal(w.checkpoint.DeleteHighWater) {
+		for sysID := range seen {
+			nextSeen[sysID] = true
+		}
+	}
+	w.checkpoint.DeleteHighWater = highWater
+	w.checkpoint.DeletesSeen = nextSeen
+	return events, nil
+}
+
+// queryTime formats a time for an encoded query, which ServiceNow evaluates in the
+// session user's timezone, loaded by poll
+func (w *Watcher) queryTime(t time.Time) string {
+	return t.In(w.client.GetTimeZone()).Format(table.GlideDateTimeLayout)
+}
+
+// LoadCheckpoint reads a checkpoint file, returning nil when it doesn't exist yet
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	return &checkpoint, nil
+}
+
+// SaveCheckpoint writes a checkpoint file atomically
+func SaveCheckpoint(path string, checkpoint Checkpoint) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	// Write via a temp file so an interrupted save never leaves a truncated checkpoint
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// CheckpointName returns a file name for the checkpoint of a table and query
+func CheckpointName(tableName, query string) string {
+	name := tableName
+	if query != "" {
+		var b strings.Builder
+		for _, r := range query {
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+				b.WriteRune(r)
+			} else {
+				b.WriteRune('-')
+			}
+		}
+		name += "_" + b.String()
+		if len(name) > 120 {
+			name = name[:120]
+		}
+	}
+	return name + ".json"
+}
@@ -0,0 +1,380 @@
+// Package xlsx writes Office Open XML spreadsheets without external dependencies.
+// Rows are streamed into the archive as they are written, so sheets of any size
+// can be produced with constant memory.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxRows is the number of rows a worksheet can hold, header included
+const MaxRows = 1048576
+
+// maxSheetName is the longest sheet name Excel accepts
+const maxSheetName = 31
+
+// Cell styles, indexes into cellXfs of styles.xml
+const (
+	styleDefault  = 0
+	styleHeader   = 1
+	styleDateTime = 2
+	styleDate     = 3
+)
+
+type cellKind int
+
+const (
+	kindEmpty cellKind = iota
+	kindString
+	kindNumber
+	kindBool
+	kindDateTime
+	kindDate
+)
+
+// Cell is a typed worksheet cell
+type Cell struct {
+	kind   cellKind
+	text   string
+	number float64
+	time   time.Time
+}
+
+// String returns a text cell
+func String(s string) Cell {
+	if s == "" {
+		return Cell{}
+	}
+	return Cell{kind: kindString, text: s}
+}
+
+// Number returns a numeric cell
+func Number(n float64) Cell {
+	return Cell{kind: kindNumber, number: n}
+}
+
+// Bool returns a boolean cell
+func Bool(b bool) Cell {
+	if b {
+		return Cell{kind: kindBool, number: 1}
+	}
+	return Cell{kind: kindBool}
+}
+
+// DateTime returns a date/time cell showing the wall clock time of t
+func DateTime(t time.Time) Cell {
+	return Cell{kind: kindDateTime, time: t}
+}
+
+// Date returns a date cell
+func Date(t time.Time) Cell {
+	return Cell{kind: kindDate, time: t}
+}
+
+// Column describes a worksheet column
+type Column struct {
+	Name  string
+	Width float64 // Width in characters (default: fitted to the name, at least 10)
+}
+
+// Writer writes a workbook to an io.Writer. Sheets are written one after the other;
+// starting a new sheet finishes the previous one.
+type Writer struct {
+	zip     *zip.Writer
+	sheets  []string
+	filters []string // autoFilter range of each sheet, empty for sheets without columns
+	sheet   *Sheet
+	err     error
+}
+
+// NewWriter creates a workbook writer
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zip: zip.NewWriter(w)}
+}
+
+// Sheet is a worksheet being written
+type Sheet struct {
+	writer  *bufio.Writer
+	columns int
+	rows    int
+}
+
+// NewSheet finishes the current sheet and starts a new one with a styled, frozen
+// header row. Names are shortened and made unique as Excel requires.
+func (w *Writer) NewSheet(name string, columns []Column) (*Sheet, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	if err := w.finishSheet(); err != nil {
+		return nil, err
+	}
+
+	name = w.uniqueName(name)
+	part, err := w.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheets)+1))
+	if err != nil {
+		w.err = err
+		return nil, err
+	}
+	w.sheets = append(w.sheets, name)
+	sheet := &Sheet{writer: bufio.NewWriter(part), columns: len(columns)}
+	w.sheet = sheet
+
+	b := sheet.writer
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	if len(columns) > 0 {
+		b.WriteString("<cols>")
+		for i, column := range columns {
+			width := column.Width
+			if width <= 0 {
+				width = math.Max(10, float64(utf8.RuneCountInString(column.Name))+2)
+			}
+			fmt.Fprintf(b, `<col min="%d" max="%d" width="%.1f" customWidth="1"/>`, i+1, i+1, width)
+		}
+		b.WriteString("</cols>")
+	}
+	b.WriteString("<sheetData>")
+
+	header := make([]Cell, len(columns))
+	for i, column := range columns {
+		header[i] = String(column.Name)
+	}
+	if err := sheet.writeRow(header, styleHeader); err != nil {
+		w.err = err
+		return nil, err
+	}
+	return sheet, nil
+}
+
+// WriteRow appends a row of cells
+func (s *Sheet) WriteRow(cells ...Cell) error {
+	if s.rows >= MaxRows {
+		return fmt.Errorf("sheet is full (%d rows)", MaxRows)
+	}
+	return s.writeRow(cells, styleDefault)
+}
+
+// Rows returns the number of rows written, header included
+func (s *Sheet) Rows() int {
+	return s.rows
+}
+
+func (s *Sheet) writeRow(cells []Cell, style int) error {
+	s.rows++
+	b := s.writer
+	fmt.Fprintf(b, `<row r="%d">`, s.rows)
+	for i, cell := range cells {
+		ref := columnName(i) + strconv.Itoa(s.rows)
+		cellStyle := style
+		switch cell.kind {
+		case kindEmpty:
+			continue
+		case kindString:
+			fmt.Fprintf(b, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">`, ref, styleAttr(cellStyle))
+			xml.EscapeText(b, []byte(sanitize(cell.text)))
+			b.WriteString("</t></is></c>")
+		case kindNumber:
+			fmt.Fprintf(b, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr(cellStyle), strconv.FormatFloat(cell.number, 'g', -1, 64))
+		case kindBool:
+			fmt.Fprintf(b, `<c r="%s" t="b"%s><v>%d</v></c>`, ref, styleAttr(cellStyle), int(cell.number))
+		case kindDateTime, kindDate:
+			if cellStyle == styleDefault {
+				cellStyle = styleDateTime
+				if cell.kind == kindDate {
+					cellStyle = styleDate
+				}
+			}
+			fmt.Fprintf(b, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr(cellStyle), strconv.FormatFloat(serial(cell.time), 'f', -1, 64))
+		}
+	}
+	_, err := b.WriteString("</row>")
+	return err
+}
+
+func styleAttr(style int) string {
+	if style == styleDefault {
+		return ""
+	}
+	return fmt.Sprintf(` s="%d"`, style)
+}
+
+// serial converts a wall clock time to an Excel date serial number (days since 1899-12-30)
+func serial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	return wall.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24
+}
+
+// sanitize drops characters XML 1.0 can't represent
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || r >= 0x20 && r != 0xFFFE && r != 0xFFFF {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// columnName returns the letters of a zero-based column index: A, B, ..., Z, AA, ...
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+func (w *Writer) finishSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+	b := w.sheet.writer
+	b.WriteString("</sheetData>")
+	filter := ""
+	if w.sheet.columns > 0 {
+		filter = fmt.Sprintf("$A$1:$%s$%d", columnName(w.sheet.columns-1), w.sheet.rows)
+		fmt.Fprintf(b, `<autoFilter ref="%s"/>`, strings.ReplaceAll(filter, "$", ""))
+	}
+	w.filters = append(w.filters, filter)
+	b.WriteString("</worksheet>")
+	w.sheet = nil
+	if err := b.Flush(); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
+
+func (w *Writer) uniqueName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.Trim(name, "'"))
+	if name == "" {
+		name = "Sheet"
+	}
+	base := truncate(name, maxSheetName)
+	candidate := base
+	for n := 2; w.hasSheet(candidate); n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		candidate = truncate(base, maxSheetName-len(suffix)) + suffix
+	}
+	return candidate
+}
+
+func (w *Writer) hasSheet(name string) bool {
+	for _, existing := range w.sheets {
+		if strings.EqualFold(existing, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(s string, runes int) string {
+	if utf8.RuneCountInString(s) <= runes {
+		return s
+	}
+	return string([]rune(s)[:runes])
+}
+
+// Close finishes the last sheet and writes the workbook parts. A workbook needs at
+// least one sheet; an empty one is added if none was written.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.sheets) == 0 {
+		if _, err := w.NewSheet("Sheet1", nil); err != nil {
+			return err
+		}
+	}
+	if err := w.finishSheet(); err != nil {
+		return err
+	}
+
+	var contentTypes, workbook, rels strings.Builder
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	rels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, name := range w.sheets {
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeAttr(name), i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets>`)
+	// Excel expects a hidden defined name for each sheet's autoFilter
+	var names strings.Builder
+	for i, name := range w.sheets {
+		if w.filters[i] != "" {
+			fmt.Fprintf(&names, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">'%s'!%s</definedName>`,
+				i, escapeAttr(strings.ReplaceAll(name, "'", "''")), w.filters[i])
+		}
+	}
+	if names.Len() > 0 {
+		workbook.WriteString(`<definedNames>` + names.String() + `</definedNames>`)
+	}
+	workbook.WriteString(`</workbook>`)
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`, len(w.sheets)+1)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", rels.String()},
+		{"xl/styles.xml", stylesXML},
+	}
+	for _, part := range parts {
+		f, err := w.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+	return w.zip.Close()
+}
+
+func escapeAttr(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// stylesXML defines the cell formats referenced by the style constants: default,
+// bold header on a grey fill, date/time and date
+const stylesXML = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd\ hh:mm:ss"/><numFmt numFmtId="165" formatCode="yyyy\-mm\-dd"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="3"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFD9E1F2"/><bgColor indexed="64"/></patternFill></fill></fills>` +
+	`<borders count="2"><border><left/><right/><top/><bottom/><diagonal/></border>` +
+	`<border><left/><right/><top/><bottom style="thin"><color auto="1"/></bottom><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="2" borderId="1" xfId="0" applyFont="1" applyFill="1" applyBorder="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
@@ -1,11 +1,19 @@
 package testutils
 
 import (
+	"time"
+
 	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
 )
 
 // NewMockClient creates a properly initialized mock client for testing
 func NewMockClient(baseURL string) (*core.Client, error) {
 	// Use the proper constructor to ensure all fields are initialized correctly
-	return core.NewClientBasicAuth(baseURL, "test", "test")
+	client, err := core.NewClientBasicAuth(baseURL, "test", "test")
+	if err != nil {
+		return nil, err
+	}
+	// Mock instances run in UTC, so clients don't look up the session timezone
+	client.SetTimeZone(time.UTC)
+	return client, nil
 }
\ No newline at end of file
@@ -0,0 +1,267 @@
+package unit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/batch"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/bulk"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/undo"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+// bulkServer serves count, list, single-record and batch requests for incident
+// records rec000..rec{n-1}. Batch requests for the sys_ids in failing are
+// returned as unserviced. pageCap, when set, cuts list pages short as ACLs do;
+// hidden records are counted but never listed.
+type bulkServer struct {
+	mu       sync.Mutex
+	count    int
+	hidden   int
+	failing  map[string]bool
+	pageCap  int
+	batches  [][]batch.RestRequest
+	queries  []string
+	fetched  int
+	captures int
+	patchSet map[string]interface{}
+}
+
+func (s *bulkServer) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/now/batch":
+			var request batch.BatchRequest
+			json.NewDecoder(r.Body).Decode(&request)
+			s.batches = append(s.batches, request.RestRequests)
+			response := batch.BatchResponse{BatchRequestID: request.BatchRequestID}
+			for _, req := range request.RestRequests {
+				if s.failing[req.ID] {
+					response.UnservicedRequests = append(response.UnservicedRequests, batch.UnservicedRequest{
+						ID: req.ID, StatusCode: 403, StatusText: "Forbidden", ErrorDetail: "ACL",
+					})
+					continue
+				}
+				body, _ := base64.StdEncoding.DecodeString(req.Body)
+				if len(body) > 0 {
+					json.Unmarshal(body, &s.patchSet)
+				}
+				encoded := base64.StdEncoding.EncodeToString([]byte(`{"result":{"sys_id":"` + req.ID + `","state":"7"}}`))
+				response.ServicedRequests = append(response.ServicedRequests, batch.ServicedRequest{
+					ID: req.ID, StatusCode: 200, StatusText: "OK", Body: encoded,
+				})
+			}
+			json.NewEncoder(w).Encode(response)
+		case strings.HasPrefix(r.URL.Path, "/api/now/table/incident/"):
+			// Before-image of one record
+			s.fetched++
+			sysID := strings.TrimPrefix(r.URL.Path, "/api/now/table/incident/")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"result": map[string]interface{}{"sys_id": sysID, "state": "6", "sys_mod_count": "1"},
+			})
+		case r.URL.Path == "/api/now/table/incident":
+			query := r.URL.Query()
+			w.Header().Set("X-Total-Count", strconv.Itoa(s.count+s.hidden))
+			limit, _ := strconv.Atoi(query.Get("sysparm_limit"))
+			offset, _ := strconv.Atoi(query.Get("sysparm_offset"))
+			if s.pageCap > 0 {
+				limit = min(limit, s.pageCap)
+			}
+			encoded := query.Get("sysparm_query")
+			if strings.HasPrefix(encoded, "sys_idIN") {
+				// Before-images of a chunk
+				s.captures++
+				limit = s.count
+			} else {
+				s.queries = append(s.queries, encoded)
+			}
+			after := ""
+			if i := strings.Index(encoded, "^sys_id>"); i >= 0 {
+				after = strings.SplitN(encoded[i+len("^sys_id>"):], "^", 2)[0]
+			}
+			result := []map[string]interface{}{}
+			for i := offset; i < s.count && len(result) < limit; i++ {
+				sysID := "rec" + pad3(i)
+				if sysID <= after || (strings.HasPrefix(encoded, "sys_idIN") && !strings.Contains(encoded, sysID)) {
+					continue
+				}
+				result = append(result, map[string]interface{}{"sys_id": sysID, "state": "6"})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}
+}
+
+func pad3(i int) string {
+	s := strconv.Itoa(i)
+	return strings.Repeat("0", 3-len(s)) + s
+}
+
+func TestBulk_PlanCountsAndSamples(t *testing.T) {
+	state := &bulkServer{count: 12}
+	server := httptest.NewServer(state.handler(t))
+	defer server.Close()
+	client, _ := testutils.NewMockClient(server.URL)
+
+	plan, err := bulk.PlanUpdate(client, "incident", bulk.Options{
+		Query: "state=6",
+		Set:   map[string]interface{}{"state": "7"},
+	})
+	if err != nil {
+		t.Fatalf("PlanUpdate failed: %v", err)
+	}
+	if plan.Count != 12 || len(plan.Sample) != bulk.DefaultSampleSize {
+		t.Errorf("Expected 12 matches and %d samples, got %d and %d", bulk.DefaultSampleSize, plan.Count, len(plan.Sample))
+	}
+	if strings.Join(plan.Fields, ",") != "sys_id,state,sys_updated_on" {
+		t.Errorf("Unexpected sample fields: %v", plan.Fields)
+	}
+	if len(state.batches) != 0 {
+		t.Error("Expected a plan to write nothing")
+	}
+
+	if _, err := bulk.PlanDelete(client, "incident", bulk.Options{Query: "  "}); err == nil {
+		t.Error("Expected an empty query to be refused")
+	}
+	if _, err := bulk.PlanUpdate(client, "incident", bulk.Options{Query: "state=6"}); err == nil {
+		t.Error("Expected an update without values to be refused")
+	}
+}
+
+func TestBulk_UpdateWhereChunksAndRecordsBeforeImages(t *testing.T) {
+	state := &bulkServer{count: 7, failing: map[string]bool{"rec004": true}}
+	server := httptest.NewServer(state.handler(t))
+	defer server.Close()
+	client, _ := testutils.NewMockClient(server.URL)
+
+	store := undo.NewStore(t.TempDir())
+	recorder := undo.NewRecorder(client, store, "update-where")
+	var progress []bulk.Progress
+	result, err := bulk.UpdateWhereWithContext(context.Background(), client, "incident", bulk.Options{
+		Query:       "state=6",
+		Set:         map[string]interface{}{"state": "7"},
+		ChunkSize:   3,
+		ExpectCount: 7,
+		Recorder:    recorder,
+		Progress:    func(p bulk.Progress) { progress = append(progress, p) },
+	})
+	if err != nil {
+		t.Fatalf("UpdateWhere failed: %v", err)
+	}
+
+	if result.Matched != 7 || result.Succeeded != 6 || result.Failed != 1 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if len(result.Failures) != 1 || result.Failures[0].SysID != "rec004" || result.Failures[0].Status != 403 {
+		t.Errorf("Expected rec004 to fail with 403, got %+v", result.Failures)
+	}
+	if len(state.batches) != 3 || len(state.batches[0]) != 3 || len(state.batches[2]) != 1 {
+		t.Errorf("Expected batches of 3, 3 and 1, got %d batches", len(state.batches))
+	}
+	if req := state.batches[0][0]; req.Method != batch.MethodPATCH || req.URL != "/api/now/table/incident/rec000" {
+		t.Errorf("Unexpected batch request: %+v", req)
+	}
+	if state.patchSet["state"] != "7" {
+		t.Errorf("Expected state=7 to be written, got %v", state.patchSet)
+	}
+	if !strings.HasSuffix(state.queries[len(state.queries)-1], "^ORDERBYsys_id") {
+		t.Errorf("Expected matches to be read in sys_id order, got %q", state.queries[len(state.queries)-1])
+	}
+	if len(progress) != 3 || progress[2].Done != 7 || progress[2].Failed != 1 {
+		t.Errorf("Unexpected progress: %+v", progress)
+	}
+
+	if state.fetched != 0 || state.captures != 3 || len(recorder.Operation().Entries) != 7 || len(recorder.Operation().AppliedEntries()) != 6 {
+		t.Errorf("Expected 7 before-images in 3 queries and 6 applied entries, got %d entries from %d queries and %d single reads, %d applied",
+			len(recorder.Operation().Entries), state.captures, state.fetched, len(recorder.Operation().AppliedEntries()))
+	}
+	if result.OperationID != recorder.Operation().ID {
+		t.Errorf("Expected the undo operation ID in the result, got %q", result.OperationID)
+	}
+	// The journal is written as the run goes, not left to the caller
+	if saved, err := store.Load(result.OperationID); err != nil || len(saved.AppliedEntries()) != 6 {
+		t.Errorf("Expected the journal with 6 applied entries to be saved, got %v", err)
+	}
+}
+
+func TestBulk_MatchesSurviveShortPages(t *testing.T) {
+	state := &bulkServer{count: 7, pageCap: 2}
+	server := httptest.NewServer(state.handler(t))
+	defer server.Close()
+	client, _ := testutils.NewMockClient(server.URL)
+
+	result, err := bulk.DeleteWhere(client, "incident", bulk.Options{Query: "active=false^NQpriority=5"})
+	if err != nil {
+		t.Fatalf("DeleteWhere failed: %v", err)
+	}
+	if result.Matched != 7 || result.Succeeded != 7 {
+		t.Errorf("Expected pages cut short not to drop matches, got %+v", result)
+	}
+	last := state.queries[len(state.queries)-1]
+	if last != "active=false^sys_id>rec006^NQpriority=5^sys_id>rec006^ORDERBYsys_id" {
+		t.Errorf("Expected keyset paging on every alternative until an empty page, got %q", last)
+	}
+}
+
+func TestBulk_DeleteWhereGuards(t *testing.T) {
+	state := &bulkServer{count: 25}
+	server := httptest.NewServer(state.handler(t))
+	defer server.Close()
+	client, _ := testutils.NewMockClient(server.URL)
+
+	_, err := bulk.DeleteWhere(client, "incident", bulk.Options{Query: "active=false", ExpectCount: 24})
+	var guard *bulk.GuardError
+	if !errors.As(err, &guard) || guard.Count != 25 {
+		t.Errorf("Expected an expected-count guard error, got %v", err)
+	}
+
+	_, err = bulk.DeleteWhere(client, "incident", bulk.Options{Query: "active=false", MaxRecords: 20})
+	if !errors.As(err, &guard) {
+		t.Errorf("Expected a max-records guard error, got %v", err)
+	}
+	if len(state.batches) != 0 {
+		t.Fatal("Expected guards to stop the run before anything was deleted")
+	}
+
+	result, err := bulk.DeleteWhere(client, "incident", bulk.Options{Query: "active=false", MaxRecords: -1})
+	if err != nil {
+		t.Fatalf("DeleteWhere failed: %v", err)
+	}
+	if result.Succeeded != 25 || len(state.batches) != 1 || state.batches[0][0].Method != batch.MethodDELETE {
+		t.Errorf("Expected 25 deletes in one batch, got %+v", result)
+	}
+}
+
+func TestBulk_RejectsOrderByAndPartialMatches(t *testing.T) {
+	state := &bulkServer{count: 25, hidden: 5}
+	server := httptest.NewServer(state.handler(t))
+	defer server.Close()
+	client, _ := testutils.NewMockClient(server.URL)
+
+	// ORDERBY would take precedence over the sys_id keyset order and skip records
+	if _, err := bulk.DeleteWhere(client, "incident", bulk.Options{Query: "active=true^ORDERBYnumber", MaxRecords: -1}); err == nil || !strings.Contains(err.Error(), "ORDERBY") {
+		t.Errorf("Expected ORDERBY to be rejected, got %v", err)
+	}
+
+	_, err := bulk.DeleteWhere(client, "incident", bulk.Options{Query: "active=true", MaxRecords: -1})
+	var guard *bulk.GuardError
+	if !errors.As(err, &guard) || guard.Count != 25 {
+		t.Errorf("Expected a guard error when fewer records are collected than counted, got %v", err)
+	}
+	if len(state.batches) != 0 {
+		t.Fatal("Expected nothing to be deleted")
+	}
+}
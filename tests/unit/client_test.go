@@ -231,6 +231,9 @@ func TestClientRawRootRequest(t *testing.T) {
 		if r.Header.Get("Accept") != "application/xml" {
 			t.Errorf("Expected Accept: application/xml, got %s", r.Header.Get("Accept"))
 		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			t.Errorf("Expected basic auth on the request, got %q", r.Header.Get("Authorization"))
+		}
 
 		// Return mock XML response
 		w.Header().Set("Content-Type", "application/xml")
@@ -246,6 +249,9 @@ func TestClientRawRootRequest(t *testing.T) {
 	// Make root request with XML format
 	var result map[string]interface{}
 	err = client.RawRootRequest("GET", "/xmlstats.do", nil, nil, &result, core.FormatXML)
+	if client.Client.BaseURL != client.BaseURL {
+		t.Errorf("Expected the shared base URL to stay %s, got %s", client.BaseURL, client.Client.BaseURL)
+	}
 	
 	if err != nil {
 		t.Fatalf("RawRootRequest failed: %v", err)
@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+// newVersionedServer serves a single incident whose sys_mod_count increases on every PATCH
+func newVersionedServer(t *testing.T, record map[string]interface{}, patches *[]map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case "GET":
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": record})
+		case "PATCH":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			*patches = append(*patches, body)
+			for k, v := range body {
+				record[k] = v
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": record})
+		default:
+			t.Errorf("Unexpected %s request", r.Method)
+		}
+	}))
+}
+
+func TestTableClient_UpdateIfVersion_Success(t *testing.T) {
+	var patches []map[string]interface{}
+	record := map[string]interface{}{"sys_id": "abc", "sys_mod_count": "4", "state": "1"}
+	server := newVersionedServer(t, record, &patches)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	tc := table.NewTableClient(client, "incident")
+
+	_, err := tc.UpdateIfVersion("abc", table.ExpectedVersion{ModCount: "4"}, map[string]interface{}{"state": "2"})
+	if err != nil {
+		t.Fatalf("Expected update to succeed, got %v", err)
+	}
+	if len(patches) != 1 {
+		t.Errorf("Expected 1 PATCH, got %d", len(patches))
+	}
+}
+
+func TestTableClient_UpdateIfVersion_Conflict(t *testing.T) {
+	var patches []map[string]interface{}
+	record := map[string]interface{}{"sys_id": "abc", "sys_mod_count": "5", "state": "3"}
+	server := newVersionedServer(t, record, &patches)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	tc := table.NewTableClient(client, "incident")
+
+	expected := table.ExpectedVersion{ModCount: "4", Fields: map[string]interface{}{"state": "1"}}
+	_, err := tc.UpdateIfVersion("abc", expected, map[string]interface{}{"state": "2"})
+
+	conflict, ok := table.IsConflictError(err)
+	if !ok {
+		t.Fatalf("Expected ConflictError, got %v", err)
+	}
+	if len(patches) != 0 {
+		t.Error("Conflicting update must not be written")
+	}
+	if len(conflict.Diffs) != 2 {
+		t.Fatalf("Expected 2 diffs, got %+v", conflict.Diffs)
+	}
+	if conflict.Diffs[0].Field != "sys_mod_count" || conflict.Diffs[0].Actual != "5" {
+		t.Errorf("Unexpected mod count diff: %+v", conflict.Diffs[0])
+	}
+	if conflict.Diffs[1].Field != "state" || conflict.Diffs[1].Expected != "1" || conflict.Diffs[1].Actual != "3" {
+		t.Errorf("Unexpected field diff: %+v", conflict.Diffs[1])
+	}
+	if conflict.Current["sys_mod_count"] != "5" {
+		t.Error("ConflictError should carry the current server record")
+	}
+}
+
+func TestTableClient_UpdateWithMerge(t *testing.T) {
+	var patches []map[string]interface{}
+	record := map[string]interface{}{"sys_id": "abc", "sys_mod_count": "5", "work_notes": "other"}
+	server := newVersionedServer(t, record, &patches)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	tc := table.NewTableClient(client, "incident")
+
+	merges := 0
+	merge := func(conflict *table.ConflictError, updates map[string]interface{}) (map[string]interface{}, error) {
+		merges++
+		merged := map[string]interface{}{"state": updates["state"], "merged_from": conflict.Current["sys_mod_count"]}
+		return merged, nil
+	}
+
+	_, err := tc.UpdateWithMerge("abc", table.ExpectedVersion{ModCount: "4"}, map[string]interface{}{"state": "2"}, merge, 0)
+	if err != nil {
+		t.Fatalf("Expected merged update to succeed, got %v", err)
+	}
+	if merges != 1 {
+		t.Errorf("Expected merge to be called once, got %d", merges)
+	}
+	if len(patches) != 1 || patches[0]["merged_from"] != "5" {
+		t.Errorf("Expected merged payload to be written, got %v", patches)
+	}
+}
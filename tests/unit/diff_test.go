@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/diff"
+)
+
+func TestDiff_Compare(t *testing.T) {
+	from := []map[string]interface{}{
+		{"sys_id": "d1", "name": "Service Desk", "description": "Tier 1", "sys_updated_on": "2024-01-01 00:00:00"},
+		{"sys_id": "d2", "name": "Network", "description": "Routers"},
+		{"sys_id": "d3", "name": "Database", "description": "DBAs"},
+		{"sys_id": "d4", "description": "no name"},
+	}
+	to := []map[string]interface{}{
+		{"sys_id": "p1", "name": "Service Desk", "description": "Tier 1", "sys_updated_on": "2025-06-01 00:00:00"},
+		{"sys_id": "p2", "name": "Network", "description": "Routers and switches"},
+		{"sys_id": "p5", "name": "Security", "description": "SOC"},
+	}
+
+	result, err := diff.Compare(from, to, diff.Options{KeyFields: []string{"name"}})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+
+	if result.Unchanged != 1 {
+		t.Errorf("Expected sys_ fields to be ignored and 1 unchanged record, got %d", result.Unchanged)
+	}
+	if len(result.Added) != 1 || result.Added[0].Key != "name=Security" {
+		t.Errorf("Unexpected added records: %+v", result.Added)
+	}
+	if len(result.Removed) != 1 || result.Removed[0].FromSysID != "d3" {
+		t.Errorf("Unexpected removed records: %+v", result.Removed)
+	}
+	if len(result.Changed) != 1 {
+		t.Fatalf("Expected 1 changed record, got %d", len(result.Changed))
+	}
+	change := result.Changed[0].Changes[0]
+	if change.Field != "description" || change.From != "Routers" || change.To != "Routers and switches" {
+		t.Errorf("Unexpected field change: %+v", change)
+	}
+	if result.MissingKeys != 1 {
+		t.Errorf("Expected 1 record without key, got %d", result.MissingKeys)
+	}
+}
+
+func TestDiff_CompareIncludeSystemFieldsAndDisplayValues(t *testing.T) {
+	from := []map[string]interface{}{
+		{"name": "A", "manager": map[string]interface{}{"display_value": "Jo Smith", "value": "u1"}, "sys_updated_on": "1"},
+	}
+	to := []map[string]interface{}{
+		{"name": "A", "manager": map[string]interface{}{"display_value": "Jo Smith", "value": "u9"}, "sys_updated_on": "2"},
+	}
+
+	result, _ := diff.Compare(from, to, diff.Options{KeyFields: []string{"name"}, IncludeSystemFields: true})
+	if len(result.Changed) != 1 || len(result.Changed[0].Changes) != 1 || result.Changed[0].Changes[0].Field != "sys_updated_on" {
+		t.Errorf("Expected only sys_updated_on to differ, got %+v", result.Changed)
+	}
+}
+
+func TestDiff_WriteUnified(t *testing.T) {
+	from := []map[string]interface{}{{"name": "Network", "description": "Routers"}}
+	to := []map[string]interface{}{
+		{"name": "Network", "description": "Switches"},
+		{"name": "Security", "description": "SOC"},
+	}
+	opts := diff.Options{KeyFields: []string{"name"}}
+	result, _ := diff.Compare(from, to, opts)
+	result.Table = "sys_user_group"
+
+	var buf bytes.Buffer
+	if err := diff.WriteUnified(&buf, result, "dev", "prod", opts); err != nil {
+		t.Fatalf("WriteUnified failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"--- dev/sys_user_group\n+++ prod/sys_user_group",
+		"@@ name=Network (changed) @@\n name: Network\n-description: Routers\n+description: Switches",
+		"@@ name=Security (added) @@\n+name: Security\n+description: SOC",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Unified output missing %q:\n%s", want, out)
+		}
+	}
+}
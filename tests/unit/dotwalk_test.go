@@ -0,0 +1,193 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/tui"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+func TestNest(t *testing.T) {
+	record := map[string]interface{}{
+		"number":                 "INC0010001",
+		"caller_id":              map[string]interface{}{"link": "https://x/api/now/table/sys_user/u1", "value": "u1"},
+		"caller_id.name":         "Abel Tuter",
+		"caller_id.manager.name": "Beth Anglin",
+	}
+
+	nested := table.Nest(record)
+	caller, ok := nested["caller_id"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected caller_id to be nested, got %T", nested["caller_id"])
+	}
+	if caller["sys_id"] != "u1" || caller["name"] != "Abel Tuter" {
+		t.Errorf("Unexpected caller: %v", caller)
+	}
+	manager, _ := caller["manager"].(map[string]interface{})
+	if manager["name"] != "Beth Anglin" {
+		t.Errorf("Expected nested manager name, got %v", caller["manager"])
+	}
+	if nested["number"] != "INC0010001" {
+		t.Errorf("Expected plain fields to be kept, got %v", nested["number"])
+	}
+	if _, ok := record["caller_id.name"]; !ok {
+		t.Error("Nest must not modify its input")
+	}
+}
+
+func TestTableClient_ListOptExpand(t *testing.T) {
+	var mu sync.Mutex
+	queries := map[string][]string{}
+	users := map[string]map[string]interface{}{
+		"u1": {"sys_id": "u1", "name": "Abel Tuter", "manager": map[string]interface{}{"link": "https://x/api/now/table/sys_user/u3", "value": "u3"}},
+		"u2": {"sys_id": "u2", "name": "Beth Anglin", "manager": ""},
+		"u3": {"sys_id": "u3", "name": "Fred Luddy", "manager": ""},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tableName := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		query := r.URL.Query().Get("sysparm_query")
+		mu.Lock()
+		queries[tableName] = append(queries[tableName], query)
+		mu.Unlock()
+
+		var result []map[string]interface{}
+		switch tableName {
+		case "incident":
+			result = []map[string]interface{}{
+				{"number": "INC1", "caller_id": map[string]interface{}{"link": "https://x/api/now/table/sys_user/u1", "value": "u1"}, "assigned_to": map[string]interface{}{"link": "https://x/api/now/table/sys_user/u2", "value": "u2"}},
+				{"number": "INC2", "caller_id": map[string]interface{}{"link": "https://x/api/now/table/sys_user/u1", "value": "u1"}, "assigned_to": ""},
+			}
+		case "sys_user":
+			ids := strings.TrimPrefix(strings.SplitN(query, "^", 2)[0], "sys_idIN")
+			for _, id := range strings.Split(ids, ",") {
+				if user, ok := users[id]; ok {
+					result = append(result, user)
+				}
+			}
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(result)))
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	records, err := table.NewTableClient(client, "incident").ListOpt(table.ListOptions{
+		Expand: []string{"caller_id.manager", "assigned_to"},
+	})
+	if err != nil {
+		t.Fatalf("ListOpt failed: %v", err)
+	}
+
+	// One IN query for caller_id and assigned_to together, one for the managers
+	if len(queries["sys_user"]) != 2 {
+		t.Fatalf("Expected 2 sys_user queries, got %v", queries["sys_user"])
+	}
+	if queries["sys_user"][0] != "sys_idINu2,u1" {
+		t.Errorf("Expected callers and assignees in one query, got %s", queries["sys_user"][0])
+	}
+
+	caller, ok := records[0]["caller_id"].(map[string]interface{})
+	if !ok || caller["name"] != "Abel Tuter" {
+		t.Fatalf("Expected embedded caller, got %v", records[0]["caller_id"])
+	}
+	manager, ok := caller["manager"].(map[string]interface{})
+	if !ok || manager["name"] != "Fred Luddy" {
+		t.Errorf("Expected embedded manager, got %v", caller["manager"])
+	}
+	if records[1]["assigned_to"] != "" {
+		t.Errorf("Expected empty reference to be left alone, got %v", records[1]["assigned_to"])
+	}
+
+	r := table.NewRecord(records[0], "", nil)
+	if r.String("caller_id") != "u1" || r.Reference("assigned_to").SysID != "u2" {
+		t.Errorf("Expected expanded references to keep their sys_id, got %q / %q", r.String("caller_id"), r.Reference("assigned_to").SysID)
+	}
+}
+
+func TestTableClient_ExpandReusesSchemaCache(t *testing.T) {
+	var mu sync.Mutex
+	schemaRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tableName := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		query := r.URL.Query().Get("sysparm_query")
+		result := []map[string]interface{}{}
+		switch tableName {
+		case "sys_db_object", "sys_dictionary", "sys_dictionary_override", "sys_choice":
+			mu.Lock()
+			schemaRequests++
+			mu.Unlock()
+			switch query {
+			case "name=incident":
+				result = append(result, map[string]interface{}{"name": "incident", "label": "Incident", "super_class.name": ""})
+			case "nameINincident^elementISNOTEMPTY":
+				result = append(result, map[string]interface{}{"name": "incident", "element": "caller_id", "internal_type": "reference", "reference": "sys_user"})
+			}
+		case "sys_user":
+			result = append(result, map[string]interface{}{"sys_id": "u1", "name": "Abel Tuter"})
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	tc := table.NewTableClient(client, "incident")
+	// Without reference links the referenced table comes from the schema
+	expand := func() {
+		records := []map[string]interface{}{{"number": "INC1", "caller_id": "u1"}}
+		if err := tc.Expand(records, table.ListOptions{Expand: []string{"caller_id"}}); err != nil {
+			t.Fatalf("Expand failed: %v", err)
+		}
+		if caller, ok := records[0]["caller_id"].(map[string]interface{}); !ok || caller["name"] != "Abel Tuter" {
+			t.Fatalf("Expected embedded caller, got %v", records[0]["caller_id"])
+		}
+	}
+	expand()
+	loaded := schemaRequests
+	if loaded == 0 {
+		t.Fatal("Expected the schema to be loaded")
+	}
+	expand()
+	if schemaRequests != loaded {
+		t.Errorf("Expected later expansions to reuse the cached schema, got %d schema requests after %d", schemaRequests, loaded)
+	}
+}
+
+func TestQueryValidator_AcceptsDotWalkPaths(t *testing.T) {
+	metadata := &tui.TableFieldMetadata{TableName: "incident", Fields: []tui.FieldMetadata{
+		{Name: "number", Type: tui.FieldTypeString},
+		{Name: "caller_id", Type: tui.FieldTypeReference, Reference: "sys_user"},
+	}}
+	validator := tui.NewQueryValidator(metadata)
+
+	errs := validator.ValidateCondition(tui.QueryCondition{
+		Field:    tui.FieldMetadata{Name: "caller_id.name", Type: tui.FieldTypeString},
+		Operator: query.OpEquals,
+		Value:    "Abel Tuter",
+	})
+	for _, err := range errs {
+		if err.Severity == tui.SeverityError {
+			t.Errorf("Expected a dot-walked field to be accepted, got %s", err.Message)
+		}
+	}
+	if result := validator.ValidateRawQuery("caller_id.name=Abel Tuter^number=INC1"); !result.IsValid {
+		t.Errorf("Expected a dot-walked raw query to be valid, got %+v", result.Errors)
+	}
+	if result := validator.ValidateRawQuery("number.name=x"); result.IsValid {
+		t.Error("Expected dot-walking a non-reference field to be rejected")
+	}
+}
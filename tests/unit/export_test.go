@@ -0,0 +1,161 @@
+package unit
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/export"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+func newExportServer(t *testing.T, count int) *httptest.Server {
+	var records []map[string]interface{}
+	for i := 1; i <= count; i++ {
+		records = append(records, map[string]interface{}{
+			"sys_id":   fmt.Sprintf("s%03d", i),
+			"number":   fmt.Sprintf("INC%07d", i),
+			"priority": strconv.Itoa(i%4 + 1),
+		})
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter, err := query.ParseEncoded(r.URL.Query().Get("sysparm_query"))
+		if err != nil {
+			t.Errorf("Bad query: %v", err)
+		}
+		var matches []map[string]interface{}
+		for _, record := range records {
+			if filter.Match(record) {
+				matches = append(matches, record)
+			}
+		}
+		filter.Sort(matches)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("sysparm_limit"))
+		if limit > 0 && len(matches) > limit {
+			matches = matches[:limit]
+		}
+		if matches == nil {
+			matches = []map[string]interface{}{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", strconv.Itoa(count))
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": matches})
+	}))
+}
+
+func TestExportTable_ResumesGzippedNDJSON(t *testing.T) {
+	server := newExportServer(t, 25)
+	defer server.Close()
+	client, _ := testutils.NewMockClient(server.URL)
+	out := filepath.Join(t.TempDir(), "incident.ndjson.gz")
+
+	// Interrupt the export after its first page
+	ctx, cancel := context.WithCancel(context.Background())
+	options := export.TableOptions{
+		Format:     export.FormatNDJSON,
+		Out:        out,
+		PageSize:   10,
+		Gzip:       true,
+		References: export.ReferenceSysID,
+		Progress:   func(export.Progress) { cancel() },
+	}
+	if _, err := export.ExportTableWithContext(ctx, client, "incident", options); err == nil {
+		t.Fatal("Expected the interrupted export to fail")
+	}
+	if _, err := os.Stat(export.CheckpointPath(out)); err != nil {
+		t.Fatalf("Expected a checkpoint: %v", err)
+	}
+
+	var progress []export.Progress
+	options.Progress = func(p export.Progress) { progress = append(progress, p) }
+	result, err := export.ExportTable(client, "incident", options)
+	if err != nil {
+		t.Fatalf("Resumed export failed: %v", err)
+	}
+	if !result.Resumed || result.Records != 25 || len(result.Parts) != 1 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if last := progress[len(progress)-1]; last.Records != 25 || last.Total != 25 {
+		t.Errorf("Unexpected final progress: %+v", last)
+	}
+	if _, err := os.Stat(export.CheckpointPath(out)); !os.IsNotExist(err) {
+		t.Errorf("Expected the checkpoint to be removed, got %v", err)
+	}
+
+	file, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Invalid gzip: %v", err)
+	}
+	scanner := bufio.NewScanner(reader)
+	var ids []string
+	for scanner.Scan() {
+		var record map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Invalid line %q: %v", scanner.Text(), err)
+		}
+		ids = append(ids, record["sys_id"].(string))
+	}
+	if len(ids) != 25 || ids[0] != "s001" || ids[10] != "s011" || ids[24] != "s025" {
+		t.Errorf("Expected s001..s025 once each, got %v", ids)
+	}
+}
+
+func TestExportTable_SplitsCSVParts(t *testing.T) {
+	server := newExportServer(t, 30)
+	defer server.Close()
+	client, _ := testutils.NewMockClient(server.URL)
+	out := filepath.Join(t.TempDir(), "incident.csv")
+
+	result, err := export.ExportTable(client, "incident", export.TableOptions{
+		Query:        "priority!=1",
+		Fields:       []string{"number", "priority"},
+		Format:       export.FormatCSV,
+		Out:          out,
+		PageSize:     7,
+		MaxPartBytes: 150,
+	})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(result.Parts) < 2 || filepath.Base(result.Parts[0].Path) != "incident.part001.csv" {
+		t.Fatalf("Expected numbered parts, got %+v", result.Parts)
+	}
+
+	total := 0
+	for _, part := range result.Parts {
+		file, err := os.Open(part.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, err := csv.NewReader(file).ReadAll()
+		file.Close()
+		if err != nil {
+			t.Fatalf("Invalid CSV in %s: %v", part.Path, err)
+		}
+		if rows[0][0] != "number" || rows[0][1] != "priority" || len(rows[0]) != 2 {
+			t.Errorf("Expected a number,priority header in %s, got %v", part.Path, rows[0])
+		}
+		if len(rows)-1 != part.Records {
+			t.Errorf("%s has %d rows, expected %d", part.Path, len(rows)-1, part.Records)
+		}
+		total += len(rows) - 1
+	}
+	if total != 23 || result.Records != 23 {
+		t.Errorf("Expected the 23 records with priority other than 1, got %d", total)
+	}
+}
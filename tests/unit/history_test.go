@@ -0,0 +1,115 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+func newHistoryServer(t *testing.T, auditStatus int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var result interface{}
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/table/sys_audit"):
+			if auditStatus != http.StatusOK {
+				w.WriteHeader(auditStatus)
+				json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"message": "ACL"}})
+				return
+			}
+			result = []map[string]interface{}{
+				// Returned out of order to exercise sorting
+				{"fieldname": "state", "oldvalue": "2", "newvalue": "6", "user": "beth.anglin", "sys_created_on": "2024-07-02 08:00:00", "record_checkpoint": "3", "internal_checkpoint": "c3"},
+				{"fieldname": "state", "oldvalue": "1", "newvalue": "2", "user": "beth.anglin", "sys_created_on": "2024-07-01 10:00:00", "record_checkpoint": "1", "internal_checkpoint": "c1"},
+				{"fieldname": "assigned_to", "oldvalue": "", "newvalue": "u2", "user": "beth.anglin", "sys_created_on": "2024-07-01 10:00:00", "record_checkpoint": "1", "internal_checkpoint": "c1"},
+				{"fieldname": "priority", "oldvalue": "3", "newvalue": "1", "user": "admin", "sys_created_on": "2024-07-01 12:00:00", "record_checkpoint": "2", "internal_checkpoint": "c2"},
+			}
+		case strings.HasSuffix(r.URL.Path, "/table/sys_history_line"):
+			result = []map[string]interface{}{
+				{"field": "state", "label": "State", "old": "New", "new": "In Progress", "old_value": "1", "new_value": "2", "user_name": "beth.anglin", "update_time": "2024-07-01 10:00:00", "update": "1", "internal_checkpoint": "c1"},
+			}
+		case strings.HasSuffix(r.URL.Path, "/table/incident/inc1"):
+			result = map[string]interface{}{
+				"sys_id": "inc1", "state": "6", "priority": "1", "assigned_to": "u2", "sys_created_on": "2024-07-01 09:00:00",
+			}
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+		if records, ok := result.([]map[string]interface{}); ok {
+			w.Header().Set("X-Total-Count", strconv.Itoa(len(records)))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+}
+
+func TestTableClient_History(t *testing.T) {
+	server := newHistoryServer(t, http.StatusOK)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	entries, err := table.NewTableClient(client, "incident").History("inc1", table.HistoryOptions{})
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(entries))
+	}
+	if entries[0].Field != "assigned_to" || entries[1].Field != "state" || entries[3].RecordCheckpoint != 3 {
+		t.Errorf("Expected chronological order, got %+v", entries)
+	}
+	if entries[1].Label != "State" || entries[1].OldDisplayValue != "New" || entries[1].Source != table.HistorySourceAudit {
+		t.Errorf("Expected sys_history_line enrichment, got %+v", entries[1])
+	}
+
+	since := time.Date(2024, 7, 1, 11, 0, 0, 0, time.UTC)
+	recent, _ := table.NewTableClient(client, "incident").History("inc1", table.HistoryOptions{Since: since})
+	if len(recent) != 2 {
+		t.Errorf("Expected 2 changes since %v, got %d", since, len(recent))
+	}
+}
+
+func TestTableClient_HistoryFallsBackToHistoryLine(t *testing.T) {
+	server := newHistoryServer(t, http.StatusForbidden)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	entries, err := table.NewTableClient(client, "incident").History("inc1", table.HistoryOptions{})
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Source != table.HistorySourceHistoryLine {
+		t.Errorf("Expected sys_history_line entries, got %+v", entries)
+	}
+}
+
+func TestTableClient_RecordAt(t *testing.T) {
+	server := newHistoryServer(t, http.StatusOK)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	tc := table.NewTableClient(client, "incident")
+
+	state, err := tc.RecordAt("inc1", time.Date(2024, 7, 1, 11, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("RecordAt failed: %v", err)
+	}
+	if state["state"] != "2" || state["priority"] != "3" || state["assigned_to"] != "u2" {
+		t.Errorf("Unexpected reconstruction: state=%s priority=%s assigned_to=%s", state["state"], state["priority"], state["assigned_to"])
+	}
+
+	state, _ = tc.RecordAt("inc1", time.Date(2024, 7, 1, 9, 30, 0, 0, time.UTC))
+	if state["state"] != "1" || state["assigned_to"] != "" {
+		t.Errorf("Expected original values, got state=%s assigned_to=%s", state["state"], state["assigned_to"])
+	}
+
+	if _, err := tc.RecordAt("inc1", time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Expected an error before the record was created")
+	}
+}
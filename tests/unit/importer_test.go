@@ -0,0 +1,201 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/batch"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/importer"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+const importMapping = `
+# Users from the HR feed
+fields:
+  user_name:
+    column: Login
+    transforms: [trim, lower]
+  email:
+    column: E-mail
+    transforms:
+      - trim
+      - lower
+  department:
+    column: Dept
+    lookup: name
+  u_level: Level
+  active:
+    column: Status
+    map: {Active: true, Inactive: false}
+  source:
+    value: 'hr feed'   # Constant
+`
+
+const importCSV = `Login,E-mail,Dept,Level,Status
+ JDoe ,JDoe@Example.com,Finance,Senior,Active
+averyveryverylongname,a@example.com,Finance,Junior,Active
+bsmith,b@example.com,Legal,Junior,Active
+cjones,c@example.com,IT,Boss,Inactive
+`
+
+func TestImporter_ValidatesAndCreates(t *testing.T) {
+	responses := map[string][]map[string]interface{}{
+		"sys_db_object|name=sys_user":                         {{"name": "sys_user", "label": "User", "super_class.name": ""}},
+		"sys_db_object|super_class.name=sys_user^ORDERBYname": nil,
+		"sys_dictionary|nameINsys_user^elementISNOTEMPTY": {
+			{"name": "sys_user", "element": "user_name", "internal_type": "string", "max_length": "10", "mandatory": "true"},
+			{"name": "sys_user", "element": "email", "internal_type": "string", "max_length": "100"},
+			{"name": "sys_user", "element": "department", "internal_type": "reference", "reference": "cmn_department"},
+			{"name": "sys_user", "element": "u_level", "internal_type": "string", "max_length": "40", "choice": "1"},
+			{"name": "sys_user", "element": "active", "internal_type": "boolean"},
+			{"name": "sys_user", "element": "source", "internal_type": "string", "max_length": "40"},
+		},
+		"sys_choice|nameINsys_user^inactive=false^language=en^ORDERBYsequence": {
+			{"name": "sys_user", "element": "u_level", "value": "1", "label": "Junior", "sequence": "1"},
+			{"name": "sys_user", "element": "u_level", "value": "2", "label": "Senior", "sequence": "2"},
+		},
+		"cmn_department|nameINFinance,IT,Legal^ORDERBYsys_id": {
+			{"sys_id": "d1", "name": "Finance"},
+			{"sys_id": "d2", "name": "IT"},
+		},
+		// Lookups page by sys_id until a page is empty
+		"cmn_department|nameINFinance,IT,Legal^sys_id>d2^ORDERBYsys_id": nil,
+	}
+	var batchRequest batch.BatchRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/batch") {
+			json.NewDecoder(r.Body).Decode(&batchRequest)
+			var serviced []map[string]interface{}
+			for _, req := range batchRequest.RestRequests {
+				encoded, _ := json.Marshal(map[string]interface{}{"result": map[string]interface{}{"sys_id": "new_" + req.ID}})
+				serviced = append(serviced, map[string]interface{}{
+					"id": req.ID, "status_code": 201, "status_text": "Created",
+					"body": base64.StdEncoding.EncodeToString(encoded),
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"serviced_requests": serviced, "unserviced_requests": []interface{}{}})
+			return
+		}
+		tableName := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		result, ok := responses[tableName+"|"+r.URL.Query().Get("sysparm_query")]
+		if !ok {
+			t.Errorf("Unexpected request %s|%s", tableName, r.URL.Query().Get("sysparm_query"))
+		}
+		if result == nil {
+			result = []map[string]interface{}{}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+	defer server.Close()
+
+	mapping, err := importer.ParseMapping([]byte(importMapping))
+	if err != nil {
+		t.Fatalf("ParseMapping failed: %v", err)
+	}
+	input, err := importer.ReadInput(strings.NewReader(importCSV), "csv")
+	if err != nil {
+		t.Fatalf("ReadInput failed: %v", err)
+	}
+
+	client, _ := testutils.NewMockClient(server.URL)
+	report, err := importer.NewImporter(client, nil, "sys_user").Import(input, importer.Options{Mapping: mapping})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	if report.Rows != 4 || report.Valid != 1 || report.Rejected != 3 || report.Created != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if len(batchRequest.RestRequests) != 1 {
+		t.Fatalf("Expected one create, got %d", len(batchRequest.RestRequests))
+	}
+	body, _ := base64.StdEncoding.DecodeString(batchRequest.RestRequests[0].Body)
+	var created map[string]string
+	json.Unmarshal(body, &created)
+	expected := map[string]string{"user_name": "jdoe", "email": "jdoe@example.com", "department": "d1", "u_level": "2", "active": "true", "source": "hr feed"}
+	for field, value := range expected {
+		if created[field] != value {
+			t.Errorf("Expected %s=%q, got %q", field, value, created[field])
+		}
+	}
+	if report.Results[0].SysID != "new_import_1" {
+		t.Errorf("Expected the created sys_id, got %q", report.Results[0].SysID)
+	}
+
+	var rejects bytes.Buffer
+	if err := importer.WriteRejects(&rejects, input, report); err != nil {
+		t.Fatalf("WriteRejects failed: %v", err)
+	}
+	rows, err := csv.NewReader(&rejects).ReadAll()
+	if err != nil || len(rows) != 4 {
+		t.Fatalf("Expected a header and 3 rejects, got %v (%v)", rows, err)
+	}
+	reasons := map[string]string{"3": "maximum length of 10", "4": `no cmn_department record has name "Legal"`, "5": "not one of the choices"}
+	for _, row := range rows[1:] {
+		if want := reasons[row[5]]; want == "" || !strings.Contains(row[6], want) {
+			t.Errorf("Line %s: expected a reason containing %q, got %q", row[5], want, row[6])
+		}
+	}
+}
+
+func TestParseMapping_YAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		field    string
+		expected importer.FieldMapping
+	}{
+		{"shorthand column", "fields:\n  email: E-mail\n", "email", importer.FieldMapping{Column: "E-mail"}},
+		{"quoted column", "fields:\n  email: 'E-mail: work'  # comment\n", "email", importer.FieldMapping{Column: "E-mail: work"}},
+		{"numbers keep their text", "fields:\n  u_level:\n    column: Level\n    default: 01\n", "u_level", importer.FieldMapping{Column: "Level", Default: "01"}},
+		{"booleans", "fields:\n  active:\n    column: Status\n    map: {Active: true, Inactive: false}\n", "active",
+			importer.FieldMapping{Column: "Status", Map: map[string]importer.Scalar{"Active": "true", "Inactive": "false"}}},
+		{"block lists", "fields:\n  name:\n    transforms:\n      - trim\n      - title\n", "name", importer.FieldMapping{Transforms: []string{"trim", "title"}}},
+		{"flow mappings", "fields: {email: {column: Mail, transforms: [lower]}}", "email", importer.FieldMapping{Column: "Mail", Transforms: []string{"lower"}}},
+		{"multi-line scalar", "fields:\n  note:\n    value: >-\n      imported from\n      the hr feed\n", "note", importer.FieldMapping{Value: "imported from the hr feed"}},
+		{"anchors", "fields:\n  email:\n    transforms: &clean [trim, lower]\n  user_name:\n    transforms: *clean\n", "user_name", importer.FieldMapping{Transforms: []string{"trim", "lower"}}},
+		{"date layout", "fields:\n  start_date:\n    column: Start\n    date: 02/01/2006\n", "start_date", importer.FieldMapping{Column: "Start", Date: "02/01/2006"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := importer.ParseMapping([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("ParseMapping failed: %v", err)
+			}
+			got := mapping.Fields[tt.field]
+			if got.Column != tt.expected.Column || got.Default != tt.expected.Default || got.Date != tt.expected.Date ||
+				got.Value != tt.expected.Value || strings.Join(got.Transforms, ",") != strings.Join(tt.expected.Transforms, ",") {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+			for value, mapped := range tt.expected.Map {
+				if got.Map[value] != mapped {
+					t.Errorf("Expected map %s=%s, got %q", value, mapped, got.Map[value])
+				}
+			}
+		})
+	}
+}
+
+func TestImporter_RejectsInvalidMapping(t *testing.T) {
+	if _, err := importer.ParseMapping([]byte("fields:\n  email:\n    column: E-mail\n    transforms: [shout]\n")); err == nil {
+		t.Error("Expected an unknown transform to be rejected")
+	}
+	if _, err := importer.ParseMapping([]byte("fields:\n  email:\n    colum: E-mail\n")); err == nil {
+		t.Error("Expected an unknown mapping key to be rejected")
+	}
+	mapping, err := importer.ParseMapping([]byte(`{"key": ["email"], "fields": {"email": "E-mail", "active": {"value": true}}}`))
+	if err != nil {
+		t.Fatalf("Expected a JSON mapping to parse: %v", err)
+	}
+	if mapping.Fields["email"].Column != "E-mail" || mapping.Fields["active"].Value != "true" || mapping.Key[0] != "email" {
+		t.Errorf("Unexpected mapping: %+v", mapping)
+	}
+}
@@ -0,0 +1,75 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+func TestTableClient_ListJournal(t *testing.T) {
+	var journalQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/table/sys_journal_field") {
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+		journalQuery = r.URL.Query().Get("sysparm_query")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", "2")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": []map[string]interface{}{
+				{"sys_id": "j1", "element": "comments", "value": "Printer is jammed", "sys_created_by": "abel.tuter", "sys_created_on": "2024-07-01 09:00:00"},
+				{"sys_id": "j2", "element": "work_notes", "value": "Cleared paper path", "sys_created_by": "beth.anglin", "sys_created_on": "2024-07-01 10:15:00"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	entries, err := table.NewTableClient(client, "incident").ListJournal("inc1", table.JournalComments, table.JournalWorkNotes)
+	if err != nil {
+		t.Fatalf("ListJournal failed: %v", err)
+	}
+
+	if journalQuery != "element_id=inc1^elementINcomments,work_notes^ORDERBYsys_created_on" {
+		t.Errorf("Unexpected journal query: %s", journalQuery)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[1].Label() != "Work notes" || entries[1].CreatedBy != "beth.anglin" || entries[1].Value != "Cleared paper path" {
+		t.Errorf("Unexpected entry: %+v", entries[1])
+	}
+	if entries[0].CreatedOn.Hour() != 9 || entries[0].CreatedOn.Minute() != 0 {
+		t.Errorf("Expected parsed creation time, got %v", entries[0].CreatedOn)
+	}
+}
+
+func TestTableClient_AddWorkNote(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" || !strings.HasSuffix(r.URL.Path, "/table/incident/inc1") {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]interface{}{"sys_id": "inc1"}})
+	}))
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	tc := table.NewTableClient(client, "incident")
+	if err := tc.AddWorkNote("inc1", "Escalated to network team"); err != nil {
+		t.Fatalf("AddWorkNote failed: %v", err)
+	}
+	if body["work_notes"] != "Escalated to network team" || len(body) != 1 {
+		t.Errorf("Unexpected PATCH body: %v", body)
+	}
+	if err := tc.AddComment("inc1", "   "); err == nil {
+		t.Error("Expected an error for an empty comment")
+	}
+}
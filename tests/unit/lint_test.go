@@ -0,0 +1,192 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/lint"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+func lintTables() (*schema.Table, *schema.Table) {
+	incident := &schema.Table{Name: "incident", Fields: []*schema.Field{
+		{Name: "active", Type: "boolean"},
+		{Name: "caller_id", Type: "reference", Reference: "sys_user"},
+		{Name: "number", Type: "string"},
+		{Name: "opened_at", Type: "glide_date_time"},
+		{Name: "priority", Type: "integer"},
+		{Name: "short_description", Type: "string"},
+		{Name: "state", Type: "integer", Choices: []schema.Choice{
+			{Value: "1", Label: "New"}, {Value: "2", Label: "In Progress"}, {Value: "6", Label: "Resolved"},
+		}},
+	}}
+	user := &schema.Table{Name: "sys_user", Fields: []*schema.Field{
+		{Name: "manager", Type: "reference", Reference: "sys_user"},
+		{Name: "name", Type: "string"},
+	}}
+	return incident, user
+}
+
+func TestLintCheck(t *testing.T) {
+	incident, user := lintTables()
+	tests := []struct {
+		name       string
+		query      string
+		rows       int
+		code       string // Expected code of the only diagnostic; empty for none
+		severity   lint.Severity
+		suggestion string
+	}{
+		{name: "valid", query: "active=true^priority<=2^stateIN1,2^caller_id.manager.name=Beth^ORDERBYDESCopened_at"},
+		{name: "builder dates", query: query.New().On("opened_at", query.LastWeek).And().NewerThan("opened_at", 2, query.UnitHour).BuildQuery()},
+		{name: "javascript value", query: "caller_id=javascript:gs.getUserID()"},
+		{name: "syntax", query: "active=true^bogus", code: lint.CodeSyntax, severity: lint.SeverityError},
+		{name: "empty", query: "", code: lint.CodeEmptyQuery, severity: lint.SeverityInfo},
+		{name: "unknown field", query: "stat=1", code: lint.CodeUnknownField, severity: lint.SeverityError, suggestion: "Did you mean state?"},
+		{name: "unknown dot-walk", query: "caller_id.manager.nam=Beth", code: lint.CodeUnknownField, severity: lint.SeverityError, suggestion: "Did you mean caller_id.manager.name?"},
+		{name: "dot-walk through a non-reference", query: "number.name=x", code: lint.CodeUnknownField, severity: lint.SeverityError},
+		{name: "unknown order field", query: "active=true^ORDERBYopend_at", code: lint.CodeUnknownField, severity: lint.SeverityError, suggestion: "Did you mean opened_at?"},
+		{name: "choice label", query: "stateIN1,Resolved", code: lint.CodeInvalidChoice, severity: lint.SeverityError, suggestion: "Use stateIN1,6"},
+		{name: "unknown choice", query: "state=9", code: lint.CodeInvalidChoice, severity: lint.SeverityError},
+		{name: "not a number", query: "priority>high", code: lint.CodeInvalidValue, severity: lint.SeverityError},
+		{name: "not a boolean", query: "active=yes", code: lint.CodeInvalidValue, severity: lint.SeverityError},
+		{name: "bad date", query: "opened_at>2024-13-01", code: lint.CodeBadDate, severity: lint.SeverityError},
+		{name: "bad relative date", query: "opened_atRELATIVEGT@day@ago@3", code: lint.CodeBadDate, severity: lint.SeverityError},
+		{name: "date operator on text", query: "short_descriptionON" + query.Today.String(), code: lint.CodeOperatorType, severity: lint.SeverityError},
+		{name: "text operator on number", query: "priorityLIKE1", code: lint.CodeOperatorType, severity: lint.SeverityWarning},
+		{name: "display value as reference", query: "caller_id=Abel Tuter", code: lint.CodeReferenceValue, severity: lint.SeverityWarning, suggestion: "Dot-walk to the display field, e.g. caller_id.name=Abel Tuter"},
+		{name: "empty value", query: "caller_id=", code: lint.CodeMissingValue, severity: lint.SeverityWarning},
+		{name: "missing value", query: "priorityIN", code: lint.CodeMissingValue, severity: lint.SeverityError},
+		{name: "unknown same-as field", query: "opened_atSAMEASclosed_at", code: lint.CodeUnknownField, severity: lint.SeverityError},
+		{name: "text search on a large table", query: "short_descriptionLIKEvpn", rows: 250000, code: lint.CodeUnindexedLike, severity: lint.SeverityWarning},
+		{name: "text search on a small table", query: "short_descriptionLIKEvpn", rows: 500},
+		{name: "starts with on an indexed field", query: "numberSTARTSWITHINC", rows: 250000},
+		{name: "starts with on an unindexed field", query: "short_descriptionSTARTSWITHvpn", rows: 250000, code: lint.CodeUnindexedLike, severity: lint.SeverityWarning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics := lint.Check(incident, tt.query, lint.Options{Rows: tt.rows}, user)
+			if tt.code == "" {
+				if len(diagnostics) != 0 {
+					t.Fatalf("Expected no diagnostics for %q, got %+v", tt.query, diagnostics)
+				}
+				return
+			}
+			if len(diagnostics) != 1 {
+				t.Fatalf("Expected one diagnostic for %q, got %+v", tt.query, diagnostics)
+			}
+			d := diagnostics[0]
+			if d.Code != tt.code || d.Severity != tt.severity {
+				t.Errorf("Expected %s %s, got %s %s: %s", tt.severity, tt.code, d.Severity, d.Code, d.Message)
+			}
+			if tt.suggestion != "" && d.Suggestion != tt.suggestion {
+				t.Errorf("Expected suggestion %q, got %q", tt.suggestion, d.Suggestion)
+			}
+		})
+	}
+
+	// Dot-walks into tables that weren't given can't be checked
+	diagnostics := lint.Check(incident, "caller_id.name=Beth", lint.Options{})
+	if len(diagnostics) != 1 || diagnostics[0].Code != lint.CodeNotChecked || diagnostics[0].Severity != lint.SeverityInfo {
+		t.Errorf("Expected a not-checked info, got %+v", diagnostics)
+	}
+}
+
+// newLintServer serves schemas from newSchemaServer and incident lists with a
+// total count of rows, recording the incident queries it receives
+func newLintServer(t *testing.T, rows string, queries *[]string) *httptest.Server {
+	var requests int32
+	schemaServer := newSchemaServer(t, &requests)
+	target, _ := url.Parse(schemaServer.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/now/table/incident" {
+			proxy.ServeHTTP(w, r)
+			return
+		}
+		*queries = append(*queries, r.URL.Query().Get("sysparm_query"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", rows)
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": []map[string]interface{}{{"sys_id": "abc"}}})
+	}))
+	t.Cleanup(func() {
+		server.Close()
+		schemaServer.Close()
+	})
+	return server
+}
+
+func TestLinter_Lint(t *testing.T) {
+	var queries []string
+	server := newLintServer(t, "5000", &queries)
+	client, _ := testutils.NewMockClient(server.URL)
+
+	linter := lint.NewLinter(client, nil, lint.Options{LargeTable: 1000})
+	result, err := linter.Lint("incident", "assigned_to.nam=Beth^stat=1^short_descriptionLIKEvpn^category=Network")
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	codes := make([]string, len(result.Diagnostics))
+	for i, d := range result.Diagnostics {
+		codes[i] = d.Code
+	}
+	if strings.Join(codes, ",") != "unknown-field,unknown-field,unindexed-like,invalid-choice" {
+		t.Fatalf("Unexpected diagnostics: %+v", result.Diagnostics)
+	}
+	if result.Diagnostics[0].Suggestion != "Did you mean assigned_to.name?" || result.Diagnostics[1].Suggestion != "Did you mean state?" {
+		t.Errorf("Expected suggestions from the schemas, got %q and %q", result.Diagnostics[0].Suggestion, result.Diagnostics[1].Suggestion)
+	}
+	if result.Rows != 5000 || len(queries) != 1 {
+		t.Errorf("Expected one count request finding 5000 rows, got %d rows and %d requests", result.Rows, len(queries))
+	}
+	if err := result.Err(); err == nil || !strings.Contains(err.Error(), "stat=1: stat is not a field of incident") {
+		t.Errorf("Expected the errors in Err, got %v", err)
+	}
+
+	builder := query.New().Equals("state", "6").And().StartsWith("number", "INC")
+	if result, err := linter.LintBuilder("incident", builder); err != nil || len(result.Diagnostics) != 0 {
+		t.Errorf("Expected a clean builder query, got %+v (%v)", result, err)
+	}
+}
+
+func TestTableClient_WithQueryValidation(t *testing.T) {
+	var queries []string
+	server := newLintServer(t, "1", &queries)
+	client, _ := testutils.NewMockClient(server.URL)
+
+	incidents := table.NewTableClient(client, "incident").WithQueryValidation(nil)
+	_, err := incidents.ListOpt(table.ListOptions{Query: "state=Resolved"})
+	lintErr, ok := lint.IsLintError(err)
+	if !ok || len(lintErr.Diagnostics) != 1 || lintErr.Diagnostics[0].Code != lint.CodeInvalidChoice {
+		t.Fatalf("Expected an invalid choice error, got %v", err)
+	}
+	if _, err := incidents.Count("stat=6"); err == nil {
+		t.Error("Expected Count to validate its query")
+	}
+	if len(queries) != 0 {
+		t.Fatalf("Expected invalid queries not to be sent, got %v", queries)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := incidents.ListOpt(table.ListOptions{Query: "state=6^short_descriptionLIKEvpn"}); err != nil {
+			t.Fatalf("Expected warnings not to stop the request: %v", err)
+		}
+	}
+	if len(queries) != 2 {
+		t.Errorf("Expected both valid requests to be sent, got %v", queries)
+	}
+
+	// Without validation the query goes to the instance as is
+	if _, err := table.NewTableClient(client, "incident").ListOpt(table.ListOptions{Query: "state=Resolved"}); err != nil {
+		t.Errorf("Expected no validation by default: %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/metadata"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+func newMetadataServer(t *testing.T, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("sysparm_offset") != "0" {
+			// Listings end with an empty page; requests counts the listings
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": []interface{}{}})
+			return
+		}
+		atomic.AddInt32(requests, 1)
+		var result []map[string]interface{}
+		switch r.URL.Path {
+		case "/api/now/table/sys_db_object":
+			if r.URL.Query().Get("sysparm_fields") != "name,label,super_class.name" {
+				t.Errorf("Unexpected fields: %s", r.URL.Query().Get("sysparm_fields"))
+			}
+			result = []map[string]interface{}{
+				{"name": "cmdb_ci", "label": "Configuration Item", "super_class.name": ""},
+				{"name": "cmdb_ci_computer", "label": "Computer", "super_class.name": "cmdb_ci_hardware"},
+				{"name": "cmdb_ci_hardware", "label": "Hardware", "super_class.name": "cmdb_ci"},
+				{"name": "incident", "label": "Incident", "super_class.name": "task"},
+				{"name": "task", "label": "Task", "super_class.name": ""},
+			}
+		case "/api/now/table/sc_cat_item":
+			if r.URL.Query().Get("sysparm_query") != "active=true^ORDERBYname" {
+				t.Errorf("Unexpected query: %s", r.URL.Query().Get("sysparm_query"))
+			}
+			result = []map[string]interface{}{{"sys_id": "060f3afa3731300054b6a3549dbe5d3e", "name": "Standard Laptop"}}
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+}
+
+func TestMetadataCache_Tables(t *testing.T) {
+	var requests int32
+	server := newMetadataServer(t, &requests)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	dir := t.TempDir()
+	cache := metadata.NewCache(client, schema.NewSchemaClient(client), dir)
+	ctx := context.Background()
+
+	tables, err := cache.Tables(ctx)
+	if err != nil {
+		t.Fatalf("Tables failed: %v", err)
+	}
+	if len(tables) != 5 || tables[3].Name != "incident" || tables[3].Label != "Incident" || tables[3].SuperClass != "task" {
+		t.Fatalf("Unexpected tables: %+v", tables)
+	}
+
+	classes, err := cache.CIClasses(ctx)
+	if err != nil {
+		t.Fatalf("CIClasses failed: %v", err)
+	}
+	if len(classes) != 3 || classes[0].Name != "cmdb_ci" || classes[1].Name != "cmdb_ci_computer" || classes[2].Name != "cmdb_ci_hardware" {
+		t.Errorf("Expected cmdb_ci and the tables extending it, got %+v", classes)
+	}
+
+	items, err := cache.CatalogItems(ctx)
+	if err != nil {
+		t.Fatalf("CatalogItems failed: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != "Standard Laptop" {
+		t.Errorf("Unexpected catalog items: %+v", items)
+	}
+	if requests != 2 {
+		t.Errorf("Expected the class list to reuse the cached tables, got %d requests", requests)
+	}
+
+	// A new cache on the same directory reads the files instead of the instance
+	cache = metadata.NewCache(client, schema.NewSchemaClient(client), dir)
+	if _, err := cache.Tables(ctx); err != nil {
+		t.Fatalf("Tables failed: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected fresh entries to be read from disk, got %d requests", requests)
+	}
+
+	cache.SetTTL(0)
+	if _, err := cache.Tables(ctx); err != nil {
+		t.Fatalf("Tables failed: %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("Expected an expired entry to be loaded again, got %d requests", requests)
+	}
+}
+
+func TestMetadataCache_PagesPastShortPages(t *testing.T) {
+	// ACLs can hide records from a page; the listing continues until a page is empty
+	pages := map[string][]map[string]interface{}{
+		"0":    {{"sys_id": "a", "name": "Laptop"}},
+		"1000": {{"sys_id": "b", "name": "Monitor"}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := pages[r.URL.Query().Get("sysparm_offset")]
+		if result == nil {
+			result = []map[string]interface{}{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	items, err := metadata.NewCache(client, schema.NewSchemaClient(client), t.TempDir()).CatalogItems(context.Background())
+	if err != nil {
+		t.Fatalf("CatalogItems failed: %v", err)
+	}
+	if len(items) != 2 || items[1].Name != "Monitor" {
+		t.Errorf("Expected the items of both pages, got %+v", items)
+	}
+}
+
+func TestMetadataCache_StaleWhenOffline(t *testing.T) {
+	var requests int32
+	server := newMetadataServer(t, &requests)
+	client, _ := testutils.NewMockClient(server.URL)
+	dir := t.TempDir()
+	cache := metadata.NewCache(client, schema.NewSchemaClient(client), dir)
+	ctx := context.Background()
+
+	if _, err := cache.Tables(ctx); err != nil {
+		t.Fatalf("Tables failed: %v", err)
+	}
+	server.Close()
+
+	cache.SetTTL(0)
+	tables, err := cache.Tables(ctx)
+	if err != nil {
+		t.Fatalf("Expected the stale entry when the instance is unreachable, got %v", err)
+	}
+	if len(tables) != 5 {
+		t.Errorf("Unexpected stale tables: %+v", tables)
+	}
+	if _, err := cache.CatalogItems(ctx); err == nil {
+		t.Error("Expected an error for metadata that was never cached")
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if _, err := os.Stat(cache.Dir()); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed", cache.Dir())
+	}
+}
+
+func TestMetadataCache_ResolveField(t *testing.T) {
+	var requests int32
+	server := newSchemaServer(t, &requests)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	dir := t.TempDir()
+	cache := metadata.NewCache(client, schema.NewSchemaClient(client), dir)
+	ctx := context.Background()
+
+	field, err := cache.ResolveField(ctx, "incident", "assigned_to.manager.name")
+	if err != nil {
+		t.Fatalf("ResolveField failed: %v", err)
+	}
+	if field.Name != "name" || field.Label != "Name" {
+		t.Errorf("Unexpected field: %+v", field)
+	}
+
+	state, err := cache.ResolveField(ctx, "incident", "state")
+	if err != nil {
+		t.Fatalf("ResolveField failed: %v", err)
+	}
+	if len(state.Choices) != 3 || state.ChoiceLabel("2") != "In Progress" {
+		t.Errorf("Expected cached choices, got %+v", state.Choices)
+	}
+
+	if _, err := cache.ResolveField(ctx, "incident", "number.name"); err == nil {
+		t.Error("Expected an error walking a non-reference field")
+	}
+	if _, err := cache.ResolveField(ctx, "incident", "missing"); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+
+	// Schemas come from disk for a new cache
+	loaded := requests
+	cache = metadata.NewCache(client, schema.NewSchemaClient(client), dir)
+	if _, err := cache.ResolveField(ctx, "incident", "assigned_to.name"); err != nil {
+		t.Fatalf("ResolveField failed: %v", err)
+	}
+	if requests != loaded {
+		t.Errorf("Expected cached schemas, got %d more requests", requests-loaded)
+	}
+}
@@ -0,0 +1,142 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/mirror"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+func TestMirror_SyncAndQuery(t *testing.T) {
+	var mu sync.Mutex
+	incidents := []map[string]interface{}{
+		{"sys_id": "a1", "number": "INC1", "priority": "1", "active": "true", "sys_mod_count": "0", "sys_updated_on": "2024-07-01 10:00:00"},
+		{"sys_id": "a2", "number": "INC2", "priority": "3", "active": "true", "sys_mod_count": "0", "sys_updated_on": "2024-07-01 11:00:00"},
+		{"sys_id": "a3", "number": "INC3", "priority": "2", "active": "true", "sys_mod_count": "4", "sys_updated_on": "2024-07-01 12:00:00"},
+	}
+
+	// The instance evaluates queries with the local evaluator, honoring paging
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		filter, err := query.ParseEncoded(r.URL.Query().Get("sysparm_query"))
+		if err != nil {
+			t.Errorf("Bad query: %v", err)
+		}
+		var matches []map[string]interface{}
+		for _, record := range incidents {
+			if filter.Match(record) {
+				matches = append(matches, record)
+			}
+		}
+		filter.Sort(matches)
+		offset, _ := strconv.Atoi(r.URL.Query().Get("sysparm_offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("sysparm_limit"))
+		page := []map[string]interface{}{}
+		for i := offset; i < len(matches) && (limit == 0 || i < offset+limit); i++ {
+			page = append(page, matches[i])
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(matches)))
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": page})
+	}))
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	m := mirror.NewMirror(client, t.TempDir())
+	config := mirror.TableConfig{Table: "incident", Query: "active=true"}
+
+	result, err := m.Sync(config, mirror.SyncOptions{DetectDeletes: true})
+	if err != nil {
+		t.Fatalf("Initial sync failed: %v", err)
+	}
+	if !result.Full || result.Records != 3 {
+		t.Fatalf("Expected a full sync of 3 records, got %+v", result)
+	}
+
+	// INC2 is updated, INC3 is closed (leaves the query), INC4 is created
+	mu.Lock()
+	incidents[1] = map[string]interface{}{"sys_id": "a2", "number": "INC2", "priority": "1", "active": "true", "sys_mod_count": "1", "sys_updated_on": "2024-07-02 09:00:00"}
+	incidents[2]["active"] = "false"
+	incidents = append(incidents, map[string]interface{}{"sys_id": "a4", "number": "INC4", "priority": "4", "active": "true", "sys_mod_count": "0", "sys_updated_on": "2024-07-02 10:00:00"})
+	mu.Unlock()
+
+	result, err = m.Sync(config, mirror.SyncOptions{DetectDeletes: true})
+	if err != nil {
+		t.Fatalf("Delta sync failed: %v", err)
+	}
+	if result.Full || result.Created != 1 || result.Updated != 1 || result.Deleted != 1 || result.Records != 3 {
+		t.Errorf("Unexpected delta result: %+v", result)
+	}
+
+	if _, err := m.Sync(mirror.TableConfig{Table: "incident"}, mirror.SyncOptions{}); err == nil || !strings.Contains(err.Error(), "full sync") {
+		t.Errorf("Expected a changed query to require a full sync, got %v", err)
+	}
+
+	reader, err := m.Open("incident")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if reader.Count() != 3 || reader.Manifest().HighWater != "2024-07-02 10:00:00" {
+		t.Errorf("Unexpected mirror state: %d records, high water %s", reader.Count(), reader.Manifest().HighWater)
+	}
+	records, err := reader.Query("priority=1^ORDERBYDESCnumber", 0)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(records) != 2 || records[0]["number"] != "INC2" || records[1]["number"] != "INC1" {
+		t.Errorf("Expected INC2 and INC1, got %v", records)
+	}
+	record, err := reader.Get("a2")
+	if err != nil || record["sys_mod_count"] != "1" {
+		t.Errorf("Expected the latest version of INC2, got %v (%v)", record, err)
+	}
+	if record, _ := reader.Get("a3"); record != nil {
+		t.Errorf("Expected INC3 to be dropped, got %v", record)
+	}
+}
+
+func TestMirror_DeltaSyncQuery(t *testing.T) {
+	var queries []string
+	incidents := []map[string]interface{}{
+		{"sys_id": "a1", "priority": "1", "sys_mod_count": "0", "sys_updated_on": "2024-07-01 10:00:00"},
+		{"sys_id": "a2", "priority": "2", "sys_mod_count": "0", "sys_updated_on": "2024-07-01 12:00:00"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("sysparm_query"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(incidents)))
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": incidents})
+	}))
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("No timezone data: %v", err)
+	}
+	client.SetTimeZone(newYork)
+	m := mirror.NewMirror(client, t.TempDir())
+	config := mirror.TableConfig{Table: "incident", Query: "priority=1^NQpriority=2"}
+	if _, err := m.Sync(config, mirror.SyncOptions{}); err != nil {
+		t.Fatalf("Initial sync failed: %v", err)
+	}
+	queries = nil
+	if _, err := m.Sync(config, mirror.SyncOptions{}); err != nil {
+		t.Fatalf("Delta sync failed: %v", err)
+	}
+
+	// The high-water mark bounds every alternative, in the session timezone
+	expected := "priority=1^sys_updated_on>=2024-07-01 08:00:00^NQpriority=2^sys_updated_on>=2024-07-01 08:00:00"
+	if len(queries) == 0 || !strings.HasPrefix(queries[0], expected) {
+		t.Errorf("Expected the delta query to start with %q, got %v", expected, queries)
+	}
+}
@@ -0,0 +1,241 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/internal/output"
+)
+
+var outputRecords = []map[string]interface{}{
+	{"number": "INC0010002", "short_description": "Email down", "priority": "1",
+		"assigned_to": map[string]interface{}{"display_value": "Beth Anglin", "value": "46d44a"}},
+	{"number": "INC0010001", "short_description": "VPN, slow", "priority": "3",
+		"assigned_to": map[string]interface{}{"display_value": "", "value": ""}},
+}
+
+func printOutput(t *testing.T, options output.Options, value interface{}, columns ...output.Column) string {
+	t.Helper()
+	var b bytes.Buffer
+	printer, err := output.NewPrinter(&b, options)
+	if err != nil {
+		t.Fatalf("NewPrinter failed: %v", err)
+	}
+	if err := printer.Print(value, columns...); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	return b.String()
+}
+
+func TestOutputQuery(t *testing.T) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(`{"result":[{"number":"INC1","tags":["a","b"]},{"number":"INC2","tags":[]}],"sys.id":"x"}`), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{".", `{"result":[{"number":"INC1","tags":["a","b"]},{"number":"INC2","tags":[]}],"sys.id":"x"}`},
+		{".result[0].number", `"INC1"`},
+		{".result[-1].number", `"INC2"`},
+		{".result[].number", `["INC1","INC2"]`},
+		{".result[] | .tags | length", `[2,0]`},
+		{".result | length", `2`},
+		{`."sys.id"`, `"x"`},
+		{`.["sys.id"]`, `"x"`},
+		{".missing.field", `null`},
+		{".result[5]", `null`},
+		{"keys", `["result","sys.id"]`},
+	}
+	for _, tt := range tests {
+		query, err := output.CompileQuery(tt.expr)
+		if err != nil {
+			t.Fatalf("CompileQuery(%q) failed: %v", tt.expr, err)
+		}
+		got, err := query.Apply(data)
+		if err != nil {
+			t.Fatalf("Apply(%q) failed: %v", tt.expr, err)
+		}
+		encoded, _ := json.Marshal(got)
+		if string(encoded) != tt.want {
+			t.Errorf("%s = %s, want %s", tt.expr, encoded, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"", "result", ".result[", ".a |", ".[x]"} {
+		if _, err := output.CompileQuery(expr); err == nil {
+			t.Errorf("CompileQuery(%q) should fail", expr)
+		}
+	}
+	query, _ := output.CompileQuery(".result.number")
+	if _, err := query.Apply(data); err == nil || !strings.Contains(err.Error(), "cannot index an array") {
+		t.Errorf("indexing an array by name should fail, got %v", err)
+	}
+}
+
+func TestOutputTableColumns(t *testing.T) {
+	columns := []output.Column{
+		{Path: "number"},
+		{Path: "short_description", Header: "DESCRIPTION"},
+		{Path: "assigned_to.display_value", Header: "ASSIGNED_TO"},
+		{Path: "priority", Wide: true},
+	}
+
+	got := printOutput(t, output.Options{}, outputRecords, columns...)
+	want := "NUMBER      DESCRIPTION  ASSIGNED_TO\n" +
+		"INC0010002  Email down   Beth Anglin\n" +
+		"INC0010001  VPN, slow    \n"
+	if got != want {
+		t.Errorf("table output:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = printOutput(t, output.Options{Format: output.FormatWide}, outputRecords, columns...)
+	if !strings.HasPrefix(got, "NUMBER      DESCRIPTION  ASSIGNED_TO  PRIORITY\n") {
+		t.Errorf("wide output should include wide columns:\n%s", got)
+	}
+
+	// --columns replaces the command's columns
+	got = printOutput(t, output.Options{Columns: []string{"priority", "number"}}, outputRecords, columns...)
+	want = "PRIORITY  NUMBER\n1         INC0010002\n3         INC0010001\n"
+	if got != want {
+		t.Errorf("selected columns:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = printOutput(t, output.Options{}, []map[string]interface{}{})
+	if got != "No results found.\n" {
+		t.Errorf("empty table = %q", got)
+	}
+
+	got = printOutput(t, output.Options{}, map[string]string{"sys_id": "abc", "name": "Beth"})
+	if got != "NAME    Beth\nSYS_ID  abc\n" {
+		t.Errorf("single object = %q", got)
+	}
+}
+
+func TestOutputTableFitsWidth(t *testing.T) {
+	rows := []map[string]string{{"a": strings.Repeat("x", 80), "b": strings.Repeat("y", 30)}}
+
+	got := printOutput(t, output.Options{}, rows)
+	line := strings.Split(got, "\n")[1]
+	if !strings.HasSuffix(strings.Fields(line)[0], "...") || len(strings.Fields(line)[0]) != output.MaxCellWidth {
+		t.Errorf("long cells should be cut to %d characters: %q", output.MaxCellWidth, line)
+	}
+
+	got = printOutput(t, output.Options{Width: 40}, rows)
+	for _, line := range strings.Split(strings.TrimSpace(got), "\n") {
+		if len(line) > 40 {
+			t.Errorf("line wider than the terminal: %q", line)
+		}
+	}
+
+	got = printOutput(t, output.Options{Format: output.FormatWide}, rows)
+	if !strings.Contains(got, strings.Repeat("x", 80)) {
+		t.Errorf("wide output should not cut cells")
+	}
+}
+
+func TestOutputStructuredFormats(t *testing.T) {
+	type record struct {
+		Number string `json:"number"`
+		Active bool   `json:"active"`
+	}
+	records := []record{{"INC1", true}, {"INC2", false}}
+
+	got := printOutput(t, output.Options{Format: output.FormatJSON}, records)
+	if got != "[\n  {\n    \"number\": \"INC1\",\n    \"active\": true\n  },\n  {\n    \"number\": \"INC2\",\n    \"active\": false\n  }\n]\n" {
+		t.Errorf("json output = %q", got)
+	}
+
+	got = printOutput(t, output.Options{Format: output.FormatNDJSON}, records)
+	if got != "{\"number\":\"INC1\",\"active\":true}\n{\"number\":\"INC2\",\"active\":false}\n" {
+		t.Errorf("ndjson output = %q", got)
+	}
+
+	got = printOutput(t, output.Options{Format: output.FormatJSON, Query: ".[].number"}, records)
+	if got != "[\n  \"INC1\",\n  \"INC2\"\n]\n" {
+		t.Errorf("json query output = %q", got)
+	}
+
+	got = printOutput(t, output.Options{Format: output.FormatJSON, Columns: []string{"number"}}, records)
+	if strings.Contains(got, "active") {
+		t.Errorf("--columns should select fields in JSON: %q", got)
+	}
+
+	got = printOutput(t, output.Options{Format: output.FormatCSV}, outputRecords, output.Columns("number", "short_description", "assigned_to.display_value")...)
+	want := "number,short_description,assigned_to.display_value\n" +
+		"INC0010002,Email down,Beth Anglin\n" +
+		"INC0010001,\"VPN, slow\",\n"
+	if got != want {
+		t.Errorf("csv output:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = printOutput(t, output.Options{Format: output.FormatMarkdown}, []map[string]string{{"name": "a|b"}})
+	if got != "| NAME |\n| --- |\n| a\\|b |\n" {
+		t.Errorf("markdown output = %q", got)
+	}
+
+	got = printOutput(t, output.Options{Format: output.FormatTemplate, Template: "{{.number}} {{upper .short_description}}"}, outputRecords)
+	if got != "INC0010002 EMAIL DOWN\nINC0010001 VPN, SLOW\n" {
+		t.Errorf("template output = %q", got)
+	}
+}
+
+func TestOutputYAML(t *testing.T) {
+	value := map[string]interface{}{
+		"number":  "INC1",
+		"active":  "true",
+		"count":   3,
+		"note":    "a: b",
+		"tags":    []string{"x", "z"},
+		"empty":   []string{},
+		"caller":  map[string]interface{}{"name": "Beth", "sys_id": nil},
+		"results": []map[string]interface{}{{"a": 1, "b": "-"}},
+		"hex":     "0x1F",
+		"octal":   "0o17",
+		"inf":     ".inf",
+		"ratio":   1.5,
+	}
+	got := printOutput(t, output.Options{Format: output.FormatYAML}, value)
+	want := `active: "true"
+caller:
+  name: Beth
+  sys_id: null
+count: 3
+empty: []
+hex: "0x1F"
+inf: ".inf"
+note: 'a: b'
+number: INC1
+octal: "0o17"
+ratio: 1.5
+results:
+  - a: 1
+    b: '-'
+tags:
+  - x
+  - z
+`
+	if got != want {
+		t.Errorf("yaml output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestOutputOptionErrors(t *testing.T) {
+	var b bytes.Buffer
+	if _, err := output.NewPrinter(&b, output.Options{Format: "xml"}); err == nil {
+		t.Error("unknown formats should be rejected")
+	}
+	if _, err := output.NewPrinter(&b, output.Options{Format: "xlsx", Extra: []string{"xlsx"}}); err != nil {
+		t.Errorf("extra formats should be accepted: %v", err)
+	}
+	if _, err := output.NewPrinter(&b, output.Options{Format: output.FormatTemplate}); err == nil {
+		t.Error("the template format without a template should be rejected")
+	}
+	if _, err := output.NewPrinter(&b, output.Options{Query: "number"}); err == nil {
+		t.Error("invalid queries should be rejected")
+	}
+}
@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+// newPagedServer serves total numbered records, honoring sysparm_offset/sysparm_limit.
+// Later pages answer faster so out-of-order completion is exercised.
+func newPagedServer(t *testing.T, total int, failOffset int, inFlight, maxInFlight *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(inFlight, 1)
+		defer atomic.AddInt32(inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(maxInFlight, seen, current) {
+				break
+			}
+		}
+
+		offset, _ := strconv.Atoi(r.URL.Query().Get("sysparm_offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("sysparm_limit"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+		if failOffset >= 0 && offset == failOffset && limit > 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"message": "bad page"}})
+			return
+		}
+		if limit > 1 {
+			time.Sleep(time.Duration(total-offset) * 50 * time.Microsecond)
+		}
+
+		var records []map[string]interface{}
+		for i := offset; i < offset+limit && i < total; i++ {
+			records = append(records, map[string]interface{}{"number": fmt.Sprintf("%04d", i)})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": records})
+	}))
+}
+
+func TestTableClient_PaginateParallel_PreservesOrder(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := newPagedServer(t, 250, -1, &inFlight, &maxInFlight)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	tc := table.NewTableClient(client, "incident")
+
+	records, err := tc.PaginateParallel(context.Background(), table.ListOptions{}, table.ParallelOptions{PageSize: 20, Workers: 3})
+	if err != nil {
+		t.Fatalf("PaginateParallel failed: %v", err)
+	}
+	if len(records) != 250 {
+		t.Fatalf("Expected 250 records, got %d", len(records))
+	}
+	for i, record := range records {
+		if record["number"] != fmt.Sprintf("%04d", i) {
+			t.Fatalf("Record %d out of order: %v", i, record["number"])
+		}
+	}
+	if maxInFlight > 3 {
+		t.Errorf("Expected at most 3 concurrent requests, saw %d", maxInFlight)
+	}
+}
+
+func TestTableClient_PaginateParallelFunc_StopsOnError(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := newPagedServer(t, 200, 60, &inFlight, &maxInFlight)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	tc := table.NewTableClient(client, "incident")
+
+	delivered := 0
+	err := tc.PaginateParallelFunc(context.Background(), table.ListOptions{}, table.ParallelOptions{PageSize: 20, Workers: 2},
+		func(page int, records []map[string]interface{}) error {
+			delivered++
+			return nil
+		})
+	if err == nil {
+		t.Fatal("Expected error from failing page")
+	}
+	if delivered > 3 {
+		t.Errorf("Pages after the failing page must not be delivered, got %d", delivered)
+	}
+}
+
+func TestTableClient_Count_UsesTotalCountHeader(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := newPagedServer(t, 42, -1, &inFlight, &maxInFlight)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	count, err := table.NewTableClient(client, "incident").Count("active=true")
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if count != 42 {
+		t.Errorf("Expected 42, got %d", count)
+	}
+}
@@ -0,0 +1,150 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+)
+
+func TestQueryBuilderDateOperators(t *testing.T) {
+	berlin := time.FixedZone("CEST", 2*60*60)
+	instant := time.Date(2024, 6, 30, 23, 30, 0, 0, time.UTC) // July 1st, 01:30 in CEST
+
+	tests := []struct {
+		name    string
+		builder func() *query.QueryBuilder
+		want    string
+	}{
+		{
+			name:    "named range",
+			builder: func() *query.QueryBuilder { return query.New().On("opened_at", query.LastWeek) },
+			want:    "opened_atONLast week@javascript:gs.beginningOfLastWeek()@javascript:gs.endOfLastWeek()",
+		},
+		{
+			name:    "not in quarter",
+			builder: func() *query.QueryBuilder { return query.New().NotOn("opened_at", query.ThisQuarter) },
+			want:    "opened_atNOTONThis quarter@javascript:gs.beginningOfThisQuarter()@javascript:gs.endOfThisQuarter()",
+		},
+		{
+			name:    "last days",
+			builder: func() *query.QueryBuilder { return query.New().On("sys_created_on", query.LastDays(7)) },
+			want:    "sys_created_onONLast 7 days@javascript:gs.daysAgoStart(7)@javascript:gs.daysAgoEnd(0)",
+		},
+		{
+			name:    "relative ago",
+			builder: func() *query.QueryBuilder { return query.New().NewerThan("sys_updated_on", 15, query.UnitMinute) },
+			want:    "sys_updated_onRELATIVEGT@minute@ago@15",
+		},
+		{
+			name: "relative ahead",
+			builder: func() *query.QueryBuilder {
+				return query.New().Relative("due_date", query.OpRelativeBefore, -3, query.UnitDay)
+			},
+			want: "due_dateRELATIVELT@dayofweek@ahead@3",
+		},
+		{
+			name:    "date part",
+			builder: func() *query.QueryBuilder { return query.New().DatePartOn("opened_at", query.Weekday(time.Monday)) },
+			want:    "opened_atDATEPARTMonday@javascript:gs.datePart('dayofweek','monday','EE')",
+		},
+		{
+			name:    "date part after",
+			builder: func() *query.QueryBuilder { return query.New().DatePartAfter("opened_at", query.HourPart(17)) },
+			want:    "opened_atDATEPARTHour 17@javascript:gs.datePart('hour','17','GT')",
+		},
+		{
+			name:    "time in UTC by default",
+			builder: func() *query.QueryBuilder { return query.New().After("sys_updated_on", instant) },
+			want:    "sys_updated_on>2024-06-30 23:30:00",
+		},
+		{
+			name:    "time in the instance timezone",
+			builder: func() *query.QueryBuilder { return query.New().TimeZone(berlin).OnOrBefore("sys_updated_on", instant) },
+			want:    "sys_updated_on<=2024-07-01 01:30:00",
+		},
+		{
+			name:    "day in the instance timezone",
+			builder: func() *query.QueryBuilder { return query.New().TimeZone(berlin).OnDate("opened_at", instant) },
+			want:    "opened_atON2024-07-01@javascript:gs.dateGenerate('2024-07-01','start')@javascript:gs.dateGenerate('2024-07-01','end')",
+		},
+		{
+			name: "between",
+			builder: func() *query.QueryBuilder {
+				return query.New().Between("opened_at", instant, instant.Add(48*time.Hour))
+			},
+			want: "opened_atBETWEENjavascript:gs.dateGenerate('2024-06-30','23:30:00')@javascript:gs.dateGenerate('2024-07-02','23:30:00')",
+		},
+		{
+			name: "groups keep the timezone",
+			builder: func() *query.QueryBuilder {
+				return query.New().TimeZone(berlin).Equals("active", true).AndGroup(func(g *query.QueryBuilder) {
+					g.Before("opened_at", instant).Or().IsEmpty("opened_at")
+				})
+			},
+			want: "active=true^opened_at<2024-07-01 01:30:00^ORopened_atISEMPTY",
+		},
+		{
+			name:    "recent records",
+			builder: func() *query.QueryBuilder { return query.RecentRecords(7) },
+			want:    "sys_created_onONLast 7 days@javascript:gs.daysAgoStart(7)@javascript:gs.daysAgoEnd(0)",
+		},
+		{
+			name:    "updated since in the instance timezone",
+			builder: func() *query.QueryBuilder { return query.UpdatedSince(instant, berlin) },
+			want:    "sys_updated_on>2024-07-01 01:30:00",
+		},
+		{
+			name:    "after",
+			builder: func() *query.QueryBuilder { return query.New().After("sys_updated_on", time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)) },
+			want:    "sys_updated_on>2024-07-01 09:00:00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.builder().BuildQuery(); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQueryBuilderDatesParse(t *testing.T) {
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	encoded := query.New().
+		BetweenDates("opened_at", start, start.Add(24*time.Hour-time.Second)).
+		And().
+		NotOnDate("closed_at", start).
+		BuildQuery()
+
+	filter, err := query.ParseEncoded(encoded)
+	if err != nil {
+		t.Fatalf("ParseEncoded(%q) failed: %v", encoded, err)
+	}
+	if err := filter.Validate(); err != nil {
+		t.Fatalf("Expected absolute dates to be evaluated locally: %v", err)
+	}
+	if !filter.Match(map[string]interface{}{"opened_at": "2024-07-01 12:00:00", "closed_at": "2024-07-02 08:00:00"}) {
+		t.Error("Expected a record opened on July 1st and closed later to match")
+	}
+	if filter.Match(map[string]interface{}{"opened_at": "2024-07-01 12:00:00", "closed_at": "2024-07-01 18:00:00"}) {
+		t.Error("Expected a record closed on July 1st not to match")
+	}
+
+	for _, q := range []*query.QueryBuilder{
+		query.New().NewerThan("sys_updated_on", 2, query.UnitHour),
+		query.New().DatePartOn("opened_at", query.MonthPart(time.March)),
+		query.New().On("opened_at", query.Today),
+	} {
+		filter, err := query.ParseEncoded(q.BuildQuery())
+		if err != nil {
+			t.Fatalf("ParseEncoded(%q) failed: %v", q.BuildQuery(), err)
+		}
+		if conditions := filter.Conditions(); len(conditions) != 1 || conditions[0].Field == "" {
+			t.Errorf("Unexpected conditions for %q: %+v", q.BuildQuery(), conditions)
+		}
+		if err := filter.Validate(); err == nil {
+			t.Errorf("Expected %q to be rejected for local evaluation", q.BuildQuery())
+		}
+	}
+}
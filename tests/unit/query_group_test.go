@@ -0,0 +1,251 @@
+package unit
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+)
+
+func TestQueryBuilderGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder func() *query.QueryBuilder
+		want    string
+	}{
+		{
+			name: "and of or groups",
+			builder: func() *query.QueryBuilder {
+				return query.New().
+					AndGroup(func(g *query.QueryBuilder) { g.Equals("priority", "1").Or().Equals("priority", "2") }).
+					AndGroup(func(g *query.QueryBuilder) { g.Equals("state", "1").Or().IsEmpty("assigned_to") })
+			},
+			want: "priority=1^ORpriority=2^state=1^ORassigned_toISEMPTY",
+		},
+		{
+			name: "or of and groups",
+			builder: func() *query.QueryBuilder {
+				return query.New().
+					AndGroup(func(g *query.QueryBuilder) { g.Equals("priority", "1").And().Equals("state", "1") }).
+					OrGroup(func(g *query.QueryBuilder) { g.Equals("priority", "2").And().Equals("state", "2") })
+			},
+			want: "priority=1^state=1^NQpriority=2^state=2",
+		},
+		{
+			name: "or distributes over a single alternative",
+			builder: func() *query.QueryBuilder {
+				return query.New().
+					Equals("active", true).
+					And().
+					Equals("urgency", "1").
+					OrGroup(func(g *query.QueryBuilder) { g.Equals("priority", "1").And().Equals("state", "2") })
+			},
+			want: "active=true^urgency=1^ORpriority=1^urgency=1^ORstate=2",
+		},
+		{
+			name: "conditions after a group apply to every alternative",
+			builder: func() *query.QueryBuilder {
+				return query.New().
+					AndGroup(func(g *query.QueryBuilder) {
+						g.AndGroup(func(h *query.QueryBuilder) { h.Equals("a", "1").And().Equals("b", "1") }).
+							OrGroup(func(h *query.QueryBuilder) { h.Equals("c", "1").And().Equals("d", "1") })
+					}).
+					And().
+					Equals("active", true)
+			},
+			want: "a=1^b=1^active=true^NQc=1^d=1^active=true",
+		},
+		{
+			name: "empty groups are ignored",
+			builder: func() *query.QueryBuilder {
+				return query.New().Equals("active", true).AndGroup(func(g *query.QueryBuilder) {})
+			},
+			want: "active=true",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.builder().BuildQuery(); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	q := query.New().Equals("active", true).AndGroup(func(g *query.QueryBuilder) {
+		g.Equals("priority", "1").Or().Equals("priority", "2")
+	})
+	clone := q.Clone()
+	clone.And().Equals("state", "1")
+	if q.BuildQuery() != "active=true^priority=1^ORpriority=2" || clone.BuildQuery() != "active=true^priority=1^ORpriority=2^state=1" {
+		t.Errorf("Expected the clone to keep its groups separately, got %q and %q", q.BuildQuery(), clone.BuildQuery())
+	}
+	if params := q.Build(); params["sysparm_query"] != q.BuildQuery() {
+		t.Errorf("Expected Build to use the compiled query, got %q", params["sysparm_query"])
+	}
+}
+
+// expr is a boolean expression evaluated directly, to check compiled queries against
+type expr struct {
+	op       string // "and", "or", or a leaf operator: "=", "!=", "ISEMPTY"
+	field    string
+	value    string
+	children []expr
+}
+
+var groupFields = []string{"a", "b", "c", "d"}
+var groupValues = []string{"1", "2", ""}
+
+func randomExpr(r *rand.Rand, depth int) expr {
+	if depth == 0 || r.Intn(3) == 0 {
+		field := groupFields[r.Intn(len(groupFields))]
+		switch r.Intn(3) {
+		case 0:
+			return expr{op: "=", field: field, value: "1"}
+		case 1:
+			return expr{op: "!=", field: field, value: "2"}
+		}
+		return expr{op: "ISEMPTY", field: field}
+	}
+	e := expr{op: []string{"and", "or"}[r.Intn(2)]}
+	for i := 0; i < 2+r.Intn(2); i++ {
+		e.children = append(e.children, randomExpr(r, depth-1))
+	}
+	return e
+}
+
+func (e expr) eval(record map[string]interface{}) bool {
+	value, _ := record[e.field].(string)
+	switch e.op {
+	case "=":
+		return value == e.value
+	case "!=":
+		return value != e.value
+	case "ISEMPTY":
+		return value == ""
+	case "and":
+		for _, child := range e.children {
+			if !child.eval(record) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, child := range e.children {
+		if child.eval(record) {
+			return true
+		}
+	}
+	return false
+}
+
+// build adds the expression's children to q, using groups for nested expressions
+func (e expr) build(q *query.QueryBuilder) {
+	if e.op != "and" && e.op != "or" {
+		e.addLeaf(q)
+		return
+	}
+	for i, child := range e.children {
+		if child.op == "and" || child.op == "or" {
+			if e.op == "and" {
+				q.AndGroup(child.build)
+			} else {
+				q.OrGroup(child.build)
+			}
+			continue
+		}
+		if i > 0 {
+			if e.op == "and" {
+				q.And()
+			} else {
+				q.Or()
+			}
+		}
+		child.addLeaf(q)
+	}
+}
+
+func (e expr) addLeaf(q *query.QueryBuilder) {
+	switch e.op {
+	case "=":
+		q.Equals(e.field, e.value)
+	case "!=":
+		q.NotEquals(e.field, e.value)
+	default:
+		q.IsEmpty(e.field)
+	}
+}
+
+// allRecords returns every combination of groupValues for groupFields
+func allRecords() []map[string]interface{} {
+	records := []map[string]interface{}{{}}
+	for _, field := range groupFields {
+		var next []map[string]interface{}
+		for _, record := range records {
+			for _, value := range groupValues {
+				extended := map[string]interface{}{field: value}
+				for k, v := range record {
+					extended[k] = v
+				}
+				next = append(next, extended)
+			}
+		}
+		records = next
+	}
+	return records
+}
+
+func TestQueryBuilderGroupsMatchEvaluator(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	records := allRecords()
+	withNQ := 0
+	for i := 0; i < 500; i++ {
+		e := randomExpr(r, 4)
+		q := query.New()
+		e.build(q)
+		encoded := q.BuildQuery()
+		if strings.Contains(encoded, "^NQ") {
+			withNQ++
+		}
+
+		filter, err := query.ParseEncoded(encoded)
+		if err != nil {
+			t.Fatalf("Expression %d compiled to %q, which doesn't parse: %v", i, encoded, err)
+		}
+		for _, record := range records {
+			if got, want := filter.Match(record), e.eval(record); got != want {
+				t.Fatalf("Expression %d compiled to %q: record %v matched %v, expected %v", i, encoded, record, got, want)
+			}
+		}
+	}
+	if withNQ == 0 {
+		t.Error("Expected some expressions to need ^NQ")
+	}
+}
+
+func TestQueryBuilderGroupsWithNot(t *testing.T) {
+	// Not before a condition is kept in a query with groups
+	q := query.New().Equals("active", true).Not().Equals("state", 6).AndGroup(func(g *query.QueryBuilder) {
+		g.Equals("priority", 1).Or().Equals("priority", 2)
+	})
+	if got, want := q.BuildQuery(), "active=true^NOTstate=6^priority=1^ORpriority=2"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if err := q.Err(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	// A group can't be negated
+	q = query.New().Equals("active", true).Not().AndGroup(func(g *query.QueryBuilder) {
+		g.Equals("priority", 1).Or().Equals("priority", 2)
+	})
+	if q.Err() == nil || q.Clone().Err() == nil {
+		t.Error("Expected Not before a group to be an error")
+	}
+	outer := query.New().Equals("active", true).AndGroup(func(g *query.QueryBuilder) {
+		g.Not().OrGroup(func(inner *query.QueryBuilder) { inner.Equals("state", 1) })
+	})
+	if outer.Err() == nil {
+		t.Error("Expected the error of a nested group to be reported")
+	}
+}
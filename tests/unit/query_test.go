@@ -228,6 +228,49 @@ func TestQuerySet(t *testing.T) {
 	}
 }
 
+func TestParseEncoded(t *testing.T) {
+	filter, err := query.ParseEncoded("active=true^priorityIN1,2^ORurgency=1^short_descriptionLIKEmail^NQnumber=INC9^ORDERBYDESCnumber")
+	if err != nil {
+		t.Fatalf("ParseEncoded failed: %v", err)
+	}
+	if len(filter.Alternatives) != 2 || len(filter.Alternatives[0]) != 3 || len(filter.Alternatives[0][1]) != 2 {
+		t.Fatalf("Unexpected structure: %+v", filter.Alternatives)
+	}
+	if len(filter.OrderBy) != 1 || filter.OrderBy[0].Field != "number" || !filter.OrderBy[0].Desc {
+		t.Errorf("Unexpected order: %+v", filter.OrderBy)
+	}
+
+	tests := []struct {
+		record map[string]interface{}
+		want   bool
+	}{
+		{map[string]interface{}{"active": "true", "priority": "2", "urgency": "3", "short_description": "Mail is down"}, true},
+		{map[string]interface{}{"active": "true", "priority": "3", "urgency": "1", "short_description": "MAIL"}, true},
+		{map[string]interface{}{"active": "true", "priority": "3", "urgency": "3", "short_description": "Mail"}, false},
+		{map[string]interface{}{"active": "false", "priority": "1", "short_description": "Mail"}, false},
+		{map[string]interface{}{"number": "INC9"}, true},
+	}
+	for i, tt := range tests {
+		if got := filter.Match(tt.record); got != tt.want {
+			t.Errorf("Record %d: expected %v, got %v", i, tt.want, got)
+		}
+	}
+
+	dates, _ := query.ParseEncoded("opened_atON2024-07-01@javascript:gs.dateGenerate('2024-07-01','start')@javascript:gs.dateGenerate('2024-07-01','end')^caller_id=u1^reopen_count>2")
+	record := map[string]interface{}{"opened_at": "2024-07-01 12:00:00", "caller_id": map[string]interface{}{"value": "u1"}, "reopen_count": "10"}
+	if err := dates.Validate(); err != nil || !dates.Match(record) {
+		t.Errorf("Expected dates, references and numbers to match (err %v)", err)
+	}
+
+	relative, _ := query.ParseEncoded("sys_created_on>javascript:gs.daysAgoStart(7)")
+	if err := relative.Validate(); err == nil {
+		t.Error("Expected relative dates to be rejected for local evaluation")
+	}
+	if _, err := query.ParseEncoded("^ORactive=true"); err == nil {
+		t.Error("Expected a leading ^OR to be rejected")
+	}
+}
+
 // Helper function to check if string contains substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || 
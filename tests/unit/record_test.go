@@ -0,0 +1,116 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+func TestRecord_ValueShapes(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	raw := table.NewRecord(map[string]interface{}{
+		"number":             "INC0010001",
+		"reassignment_count": "3",
+		"active":             "true",
+		"opened_at":          "2024-07-01 14:30:00",
+		"due_date":           "2024-07-02",
+		"caller_id":          map[string]interface{}{"link": "https://dev.service-now.com/api/now/table/sys_user/u1", "value": "u1"},
+		"empty":              "",
+		"close_code":         nil,
+	}, core.DisplayFalse, newYork)
+
+	if raw.String("number") != "INC0010001" || raw.DisplayValue("number") != "INC0010001" {
+		t.Errorf("Unexpected plain string handling")
+	}
+	if count, err := raw.Int("reassignment_count"); err != nil || count != 3 {
+		t.Errorf("Int = %d, %v", count, err)
+	}
+	if !raw.Bool("active") || raw.Bool("empty") {
+		t.Errorf("Unexpected Bool results")
+	}
+	openedAt, err := raw.Time("opened_at")
+	if err != nil {
+		t.Fatalf("Time failed: %v", err)
+	}
+	if !openedAt.Equal(time.Date(2024, 7, 1, 14, 30, 0, 0, time.UTC)) || openedAt.Location() != newYork {
+		t.Errorf("Expected raw datetime parsed as UTC and returned in the instance timezone, got %v", openedAt)
+	}
+	if dueDate, err := raw.Time("due_date"); err != nil || dueDate.Hour() != 0 || dueDate.Location() != newYork {
+		t.Errorf("Expected date at local midnight, got %v (%v)", dueDate, err)
+	}
+	if ref := raw.Reference("caller_id"); ref.SysID != "u1" || ref.Table != "sys_user" {
+		t.Errorf("Unexpected reference: %+v", ref)
+	}
+	if _, err := raw.Int("empty"); err == nil {
+		t.Error("Expected an error for an empty integer")
+	}
+	if raw.Has("close_code") || raw.Has("missing") {
+		t.Error("Expected null and missing fields to be absent")
+	}
+
+	display := table.NewRecord(map[string]interface{}{
+		"opened_at":   "2024-07-01 10:30:00",
+		"amount":      "1,234.50",
+		"assigned_to": "Beth Anglin",
+	}, core.DisplayTrue, newYork)
+	displayOpened, err := display.Time("opened_at")
+	if err != nil || !displayOpened.Equal(openedAt) {
+		t.Errorf("Expected display datetime parsed in the instance timezone, got %v (%v)", displayOpened, err)
+	}
+	if amount, err := display.Float("amount"); err != nil || amount != 1234.5 {
+		t.Errorf("Float = %v, %v", amount, err)
+	}
+	if ref := display.Reference("assigned_to"); ref.DisplayValue != "Beth Anglin" || ref.SysID != "" {
+		t.Errorf("Unexpected display-only reference: %+v", ref)
+	}
+}
+
+func TestTableClient_GetRecord(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sysparm_display_value") != "all" {
+			t.Errorf("Expected display_value=all, got %q", r.URL.Query().Get("sysparm_display_value"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]interface{}{
+				"sys_id":      map[string]interface{}{"display_value": "abc", "value": "abc"},
+				"state":       map[string]interface{}{"display_value": "In Progress", "value": "2"},
+				"opened_at":   map[string]interface{}{"display_value": "2024-07-01 16:30:00", "value": "2024-07-01 14:30:00"},
+				"assigned_to": map[string]interface{}{"display_value": "Beth Anglin", "value": "u2", "link": "https://x/api/now/table/sys_user/u2"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	client.SetTimeZone(berlin)
+
+	record, err := table.NewTableClient(client, "incident").GetRecord("abc")
+	if err != nil {
+		t.Fatalf("GetRecord failed: %v", err)
+	}
+	if record.SysID() != "abc" || record.String("state") != "2" || record.DisplayValue("state") != "In Progress" {
+		t.Errorf("Unexpected values: sys_id=%q state=%q/%q", record.SysID(), record.String("state"), record.DisplayValue("state"))
+	}
+	openedAt, err := record.Time("opened_at")
+	if err != nil || openedAt.Format(table.GlideDateTimeLayout) != "2024-07-01 16:30:00" {
+		t.Errorf("Expected raw UTC value converted to Berlin time, got %v (%v)", openedAt, err)
+	}
+	if ref := record.Reference("assigned_to"); ref.SysID != "u2" || ref.DisplayValue != "Beth Anglin" || ref.Table != "sys_user" {
+		t.Errorf("Unexpected reference: %+v", ref)
+	}
+}
@@ -0,0 +1,121 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/related"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+func newRelatedServer(t *testing.T) *httptest.Server {
+	responses := map[string][]map[string]interface{}{
+		"sys_db_object|name=sys_user_group":                         {{"name": "sys_user_group", "label": "Group", "super_class.name": ""}},
+		"sys_db_object|super_class.name=sys_user_group^ORDERBYname": nil,
+		"sys_dictionary|nameINsys_user_group^elementISNOTEMPTY": {
+			{"name": "sys_user_group", "element": "name", "column_label": "Name", "internal_type": "string"},
+		},
+		"sys_dictionary_override|nameINsys_user_group":                               nil,
+		"sys_choice|nameINsys_user_group^inactive=false^language=en^ORDERBYsequence": nil,
+		"sys_dictionary|reference=sys_user_group^elementISNOTEMPTY^ORDERBYname^ORDERBYelement": {
+			{"name": "task", "element": "assignment_group", "column_label": "Assignment group", "internal_type": "reference", "reference": "sys_user_group"},
+			{"name": "sys_user_grmember", "element": "group", "column_label": "Group", "internal_type": "reference", "reference": "sys_user_group"},
+			{"name": "sys_user_group", "element": "parent", "column_label": "Parent", "internal_type": "reference", "reference": "sys_user_group", "active": "false"},
+		},
+		"sys_db_object|name=sys_user_grmember":                         {{"name": "sys_user_grmember", "label": "Group Member", "super_class.name": ""}},
+		"sys_db_object|super_class.name=sys_user_grmember^ORDERBYname": nil,
+		"sys_dictionary|nameINsys_user_grmember^elementISNOTEMPTY": {
+			{"name": "sys_user_grmember", "element": "group", "column_label": "Group", "internal_type": "reference", "reference": "sys_user_group"},
+			{"name": "sys_user_grmember", "element": "user", "column_label": "User", "internal_type": "reference", "reference": "sys_user"},
+		},
+		"sys_dictionary_override|nameINsys_user_grmember":                               nil,
+		"sys_choice|nameINsys_user_grmember^inactive=false^language=en^ORDERBYsequence": nil,
+		"sys_user_grmember|groupINg1,g2^ORDERBYsys_id": {
+			{"sys_id": "m1", "group": "g1", "user": "u1"},
+			{"sys_id": "m2", "group": "g1", "user": "u2"},
+			{"sys_id": "m3", "group": "g2", "user": "u1"},
+		},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		tableName := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		query := r.URL.Query().Get("sysparm_query")
+
+		if strings.Contains(r.URL.Path, "/stats/") {
+			switch tableName + "|" + query {
+			case "sys_user_grmember|group=g1":
+				json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]interface{}{"stats": map[string]interface{}{"count": "2"}}})
+			case "task|assignment_group=g1":
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"message": "ACL"}})
+			default:
+				t.Errorf("Unexpected stats request %s|%s", tableName, query)
+			}
+			return
+		}
+
+		result, ok := responses[tableName+"|"+query]
+		if !ok {
+			t.Errorf("Unexpected request %s|%s", tableName, query)
+		}
+		if result == nil {
+			result = []map[string]interface{}{}
+		}
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(result)))
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+}
+
+func TestRelatedClient_Lists(t *testing.T) {
+	server := newRelatedServer(t)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	lists, err := related.NewRelatedClient(client, nil).Lists("sys_user_group")
+	if err != nil {
+		t.Fatalf("Lists failed: %v", err)
+	}
+
+	if len(lists) != 2 {
+		t.Fatalf("Expected 2 active related lists, got %+v", lists)
+	}
+	if lists[0].Name() != "sys_user_grmember.group" || !lists[0].M2M || lists[0].OtherField != "user" || lists[0].OtherTable != "sys_user" {
+		t.Errorf("Expected the group member m2m list first, got %+v", lists[0])
+	}
+	if lists[1].Name() != "task.assignment_group" || lists[1].M2M {
+		t.Errorf("Unexpected second list: %+v", lists[1])
+	}
+}
+
+func TestRelatedClient_CountsAndFetch(t *testing.T) {
+	server := newRelatedServer(t)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	relatedClient := related.NewRelatedClient(client, nil)
+
+	counts, err := relatedClient.Counts("sys_user_group", "g1")
+	if err != nil {
+		t.Fatalf("Counts failed: %v", err)
+	}
+	if len(counts) != 2 || counts[0].Count != 2 || counts[0].Error != "" {
+		t.Fatalf("Unexpected counts: %+v", counts)
+	}
+	if counts[1].Error == "" {
+		t.Errorf("Expected the unreadable task list to carry an error, got %+v", counts[1])
+	}
+
+	members, err := relatedClient.Fetch(counts[0].List, []string{"g1", "g2"}, table.ListOptions{})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(members["g1"]) != 2 || len(members["g2"]) != 1 {
+		t.Errorf("Expected members grouped by group, got %v", members)
+	}
+}
@@ -0,0 +1,146 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/relay"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/watch"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+func TestRelay_RunOnce(t *testing.T) {
+	instance := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": []map[string]interface{}{
+			{"sys_id": "i1", "number": "INC1", "short_description": "Mail down", "sys_mod_count": "2", "sys_created_on": "2024-07-01 09:00:00", "sys_updated_on": "2024-07-01 10:00:00", "sys_updated_by": "abel"},
+		}})
+	}))
+	defer instance.Close()
+
+	var mu sync.Mutex
+	var signed, templated []string
+	pagerCalls := 0
+	hooks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/pager":
+			pagerCalls++
+			if pagerCalls == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			if r.Header.Get(relay.SignatureHeader) != relay.Sign("s3cret", body) {
+				t.Errorf("Bad signature %q", r.Header.Get(relay.SignatureHeader))
+			}
+			if r.Header.Get(relay.DeliveryHeader) != "incident:i1:updated:2" {
+				t.Errorf("Unexpected delivery ID %q", r.Header.Get(relay.DeliveryHeader))
+			}
+			signed = append(signed, string(body))
+		case "/chat":
+			templated = append(templated, string(body))
+		case "/broken":
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer hooks.Close()
+
+	dir := t.TempDir()
+	client, _ := testutils.NewMockClient(instance.URL)
+	r, err := relay.NewRelay(client, relay.Config{
+		Sources: []relay.Source{{Table: "incident", Query: "priority=1"}},
+		Targets: []relay.Target{
+			{Name: "pager", URL: hooks.URL + "/pager", Secret: "s3cret", Fields: []string{"number"}},
+			{Name: "chat", URL: hooks.URL + "/chat", Template: `{"text": "{{index .Record "number"}} {{.Type}} by {{.User}}"}`},
+			{Name: "broken", URL: hooks.URL + "/broken"},
+		},
+		BaseDelay:      relay.Duration(time.Millisecond),
+		Since:          time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		CheckpointDir:  dir,
+		DeadLetterFile: filepath.Join(dir, "dead.ndjson"),
+	})
+	if err != nil {
+		t.Fatalf("NewRelay failed: %v", err)
+	}
+	if err := r.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+
+	if pagerCalls != 2 || len(signed) != 1 {
+		t.Fatalf("Expected the pager delivery to be retried once, got %d calls", pagerCalls)
+	}
+	var event watch.Event
+	json.Unmarshal([]byte(signed[0]), &event)
+	if event.SysID != "i1" || len(event.Record) != 1 || event.Record["number"] != "INC1" {
+		t.Errorf("Expected the record to be filtered to number, got %s", signed[0])
+	}
+	if len(templated) != 1 || templated[0] != `{"text": "INC1 updated by abel"}` {
+		t.Errorf("Unexpected templated body %v", templated)
+	}
+
+	file, err := os.Open(filepath.Join(dir, "dead.ndjson"))
+	if err != nil {
+		t.Fatalf("Expected a dead-letter file: %v", err)
+	}
+	defer file.Close()
+	var letters []relay.DeadLetter
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var letter relay.DeadLetter
+		json.Unmarshal(scanner.Bytes(), &letter)
+		letters = append(letters, letter)
+	}
+	if len(letters) != 1 || letters[0].Target != "broken" || letters[0].Attempts != 1 || letters[0].Event.SysID != "i1" {
+		t.Errorf("Expected one dead letter for the broken target without retries, got %+v", letters)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "incident_priority-1.json")); err != nil {
+		t.Errorf("Expected a checkpoint for the source: %v", err)
+	}
+}
+
+func TestRelay_SourcesLoadTimeZoneConcurrently(t *testing.T) {
+	instance := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		result := []map[string]interface{}{}
+		if r.URL.Path == "/api/now/table/sys_user" {
+			result = append(result, map[string]interface{}{"time_zone": "Europe/Paris"})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+	defer instance.Close()
+
+	// Without a preset timezone every source's watcher loads it at once (run with -race)
+	client, err := core.NewClientBasicAuth(instance.URL, "user", "pass")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	dir := t.TempDir()
+	r, err := relay.NewRelay(client, relay.Config{
+		Sources:       []relay.Source{{Table: "incident"}, {Table: "problem"}, {Table: "change_request"}},
+		Targets:       []relay.Target{{Name: "hook", URL: instance.URL + "/hook"}},
+		Since:         time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		CheckpointDir: dir,
+	})
+	if err != nil {
+		t.Fatalf("NewRelay failed: %v", err)
+	}
+	if err := r.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+	if client.GetTimeZone().String() != "Europe/Paris" {
+		t.Errorf("Expected the session timezone to be loaded, got %s", client.GetTimeZone())
+	}
+}
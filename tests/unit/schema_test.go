@@ -0,0 +1,219 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/schema"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+func newSchemaServer(t *testing.T, requests *int32) *httptest.Server {
+	responses := map[string][]map[string]interface{}{
+		"sys_db_object|name=incident":                         {{"name": "incident", "label": "Incident", "super_class.name": "task"}},
+		"sys_db_object|name=task":                             {{"name": "task", "label": "Task", "super_class.name": ""}},
+		"sys_db_object|super_class.name=incident^ORDERBYname": {{"name": "incident_task"}},
+		"sys_dictionary|nameINincident,task^elementISNOTEMPTY": {
+			{"name": "task", "element": "number", "column_label": "Number", "internal_type": "string", "max_length": "40", "active": "true"},
+			{"name": "task", "element": "state", "column_label": "State", "internal_type": "integer", "choice": "3", "active": "true"},
+			{"name": "task", "element": "assigned_to", "column_label": "Assigned to", "internal_type": "reference", "reference": "sys_user", "active": "true"},
+			{"name": "task", "element": "short_description", "column_label": "Short description", "internal_type": "string", "active": "true"},
+			{"name": "incident", "element": "category", "column_label": "Category", "internal_type": "string", "choice": "1", "active": "true"},
+			{"name": "incident", "element": "subcategory", "column_label": "Subcategory", "internal_type": "string", "dependent": "category", "active": "true"},
+			{"name": "incident", "element": "number", "column_label": "Incident number", "internal_type": "string", "max_length": "40", "active": "true"},
+		},
+		"sys_dictionary_override|nameINincident,task": {
+			{"name": "incident", "element": "short_description", "mandatory_override": "true", "mandatory": "true"},
+			{"name": "incident", "element": "state", "default_value_override": "true", "default_value": "1"},
+		},
+		"sys_choice|nameINincident,task^inactive=false^language=en^ORDERBYsequence": {
+			{"name": "task", "element": "state", "value": "1", "label": "Open", "sequence": "1"},
+			{"name": "incident", "element": "state", "value": "1", "label": "New", "sequence": "1"},
+			{"name": "incident", "element": "state", "value": "6", "label": "Resolved", "sequence": "6"},
+			{"name": "incident", "element": "state", "value": "2", "label": "In Progress", "sequence": "2"},
+			{"name": "incident", "element": "category", "value": "network", "label": "Network", "sequence": "1"},
+			{"name": "incident", "element": "subcategory", "value": "dns", "label": "DNS", "dependent_value": "network", "sequence": "1"},
+			{"name": "incident", "element": "subcategory", "value": "email", "label": "Email", "dependent_value": "software", "sequence": "2"},
+		},
+		"sys_db_object|name=sys_user":                         {{"name": "sys_user", "label": "User", "super_class.name": ""}},
+		"sys_db_object|super_class.name=sys_user^ORDERBYname": nil,
+		"sys_dictionary|nameINsys_user^elementISNOTEMPTY": {
+			{"name": "sys_user", "element": "name", "column_label": "Name", "internal_type": "string", "active": "true"},
+			{"name": "sys_user", "element": "manager", "column_label": "Manager", "internal_type": "reference", "reference": "sys_user", "active": "true"},
+		},
+		"sys_dictionary_override|nameINsys_user":                               nil,
+		"sys_choice|nameINsys_user^inactive=false^language=en^ORDERBYsequence": nil,
+		"sys_dictionary|reference=incident^elementISNOTEMPTY^ORDERBYname^ORDERBYelement": {
+			{"name": "incident_task", "element": "incident", "column_label": "Incident", "internal_type": "reference", "reference": "incident"},
+		},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		tableName := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		key := tableName + "|" + r.URL.Query().Get("sysparm_query")
+		result, ok := responses[key]
+		if !ok {
+			t.Errorf("Unexpected request %s", key)
+		}
+		if result == nil {
+			result = []map[string]interface{}{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+}
+
+func TestSchemaClient_GetTable(t *testing.T) {
+	var requests int32
+	server := newSchemaServer(t, &requests)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	schemaClient := schema.NewSchemaClient(client)
+
+	incident, err := schemaClient.GetTable("incident")
+	if err != nil {
+		t.Fatalf("GetTable failed: %v", err)
+	}
+
+	if strings.Join(incident.Hierarchy, ",") != "incident,task" || incident.SuperClass != "task" {
+		t.Errorf("Unexpected hierarchy: %v (super class %q)", incident.Hierarchy, incident.SuperClass)
+	}
+	if len(incident.Extensions) != 1 || incident.Extensions[0] != "incident_task" {
+		t.Errorf("Unexpected extensions: %v", incident.Extensions)
+	}
+	if strings.Join(incident.FieldNames(), ",") != "assigned_to,category,number,short_description,state,subcategory" {
+		t.Errorf("Expected inherited task fields, got %v", incident.FieldNames())
+	}
+	if !incident.Extends("task") {
+		t.Error("Expected incident to extend task")
+	}
+
+	number := incident.Field("number")
+	if number.DefinedOn != "incident" || number.Label != "Incident number" {
+		t.Errorf("Expected the incident dictionary entry to win over task, got %+v", number)
+	}
+	assignedTo := incident.Field("assigned_to")
+	if assignedTo.DefinedOn != "task" || assignedTo.Reference != "sys_user" {
+		t.Errorf("Unexpected inherited reference field: %+v", assignedTo)
+	}
+	if shortDescription := incident.Field("short_description"); !shortDescription.Mandatory || len(shortDescription.OverriddenOn) != 1 {
+		t.Errorf("Expected dictionary override to make short_description mandatory, got %+v", shortDescription)
+	}
+
+	state := incident.Field("state")
+	if state.DefaultValue != "1" {
+		t.Errorf("Expected overridden default value, got %q", state.DefaultValue)
+	}
+	if state.ChoicesFrom != "incident" || len(state.Choices) != 3 {
+		t.Fatalf("Expected incident choices to replace task choices, got %+v", state.Choices)
+	}
+	if state.Choices[1].Value != "2" || state.ChoiceLabel("6") != "Resolved" {
+		t.Errorf("Expected choices ordered by sequence with labels, got %+v", state.Choices)
+	}
+
+	subcategory := incident.Field("subcategory")
+	if choices := subcategory.ChoicesFor("network"); len(choices) != 1 || choices[0].Value != "dns" {
+		t.Errorf("Expected dependent choices for network, got %+v", choices)
+	}
+
+	// A second lookup is served from the cache
+	before := atomic.LoadInt32(&requests)
+	if _, err := schemaClient.GetTable("incident"); err != nil {
+		t.Fatalf("Cached GetTable failed: %v", err)
+	}
+	if atomic.LoadInt32(&requests) != before {
+		t.Error("Expected cached schema to be reused")
+	}
+}
+
+func TestTableClient_GetSchemaReusesCache(t *testing.T) {
+	var requests int32
+	server := newSchemaServer(t, &requests)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	schemaClient := schema.NewSchemaClient(client)
+	if _, err := schemaClient.GetTable("incident"); err != nil {
+		t.Fatalf("GetTable failed: %v", err)
+	}
+
+	// A table client sharing the schema client is served from its cache
+	before := atomic.LoadInt32(&requests)
+	columns, err := table.NewTableClient(client, "incident").WithSchema(schemaClient).GetSchema()
+	if err != nil || len(columns) != 6 {
+		t.Fatalf("Expected 6 columns, got %d (%v)", len(columns), err)
+	}
+	if atomic.LoadInt32(&requests) != before {
+		t.Error("Expected the shared schema cache to be reused")
+	}
+
+	// Without one, the table client keeps the schema client it creates
+	tc := table.NewTableClient(client, "incident")
+	if _, err := tc.GetSchema(); err != nil {
+		t.Fatalf("GetSchema failed: %v", err)
+	}
+	before = atomic.LoadInt32(&requests)
+	if _, err := tc.GetSchema(); err != nil {
+		t.Fatalf("Cached GetSchema failed: %v", err)
+	}
+	if atomic.LoadInt32(&requests) != before {
+		t.Error("Expected the second GetSchema to be served from the cache")
+	}
+}
+
+func TestSchemaClient_ReferencedBy(t *testing.T) {
+	var requests int32
+	server := newSchemaServer(t, &requests)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	fields, err := schema.NewSchemaClient(client).ReferencedBy(context.Background(), "incident")
+	if err != nil {
+		t.Fatalf("ReferencedBy failed: %v", err)
+	}
+	if len(fields) != 1 || fields[0].DefinedOn != "incident_task" || fields[0].Name != "incident" {
+		t.Errorf("Unexpected referencing fields: %+v", fields)
+	}
+}
+
+func TestSchemaClient_ResolvePath(t *testing.T) {
+	var requests int32
+	server := newSchemaServer(t, &requests)
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	schemaClient := schema.NewSchemaClient(client)
+	ctx := context.Background()
+
+	path, err := schemaClient.ResolvePath(ctx, "incident", "assigned_to.manager.name")
+	if err != nil {
+		t.Fatalf("ResolvePath failed: %v", err)
+	}
+	if !path.IsDotWalk() || path.Table() != "sys_user" || path.Field().Label != "Name" {
+		t.Errorf("Unexpected path: tables=%v field=%+v", path.Tables, path.Field())
+	}
+	if strings.Join(path.Tables, ",") != "incident,sys_user,sys_user" {
+		t.Errorf("Unexpected tables: %v", path.Tables)
+	}
+
+	err = schemaClient.ValidateFields(ctx, "incident", []string{"number", "assigned_to.nmae", "short_description.name"})
+	if err == nil {
+		t.Fatal("Expected invalid paths to be reported")
+	}
+	if !strings.Contains(err.Error(), "assigned_to.nmae") || !strings.Contains(err.Error(), "not a reference field") {
+		t.Errorf("Expected both invalid paths in the error, got: %v", err)
+	}
+	var pathErr *schema.PathError
+	if !errors.As(err, &pathErr) {
+		t.Errorf("Expected a PathError, got %T", err)
+	}
+}
@@ -0,0 +1,182 @@
+package unit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/query"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/sql"
+)
+
+func TestSQLParse(t *testing.T) {
+	statement, err := sql.Parse("SELECT number, short_description FROM incident WHERE priority <= 2 AND (state IN (1,2) OR assigned_to IS EMPTY) ORDER BY sys_created_on DESC LIMIT 50")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	params := statement.Params()
+	expected := map[string]string{
+		"sysparm_query":  "priority<=2^stateIN1,2^ORassigned_toISEMPTY^ORDERBYDESCsys_created_on",
+		"sysparm_fields": "number,short_description",
+		"sysparm_limit":  "50",
+	}
+	if statement.Table != "incident" || len(params) != len(expected) {
+		t.Fatalf("Unexpected table %q or params %v", statement.Table, params)
+	}
+	for name, value := range expected {
+		if params[name] != value {
+			t.Errorf("Expected %s=%s, got %s", name, value, params[name])
+		}
+	}
+	if options := statement.ListOptions(); options.Query != expected["sysparm_query"] || options.Limit != 50 || len(options.Fields) != 2 {
+		t.Errorf("Unexpected list options %+v", options)
+	}
+}
+
+func TestSQLConditions(t *testing.T) {
+	tests := []struct {
+		where    string
+		expected string
+	}{
+		{"short_description LIKE '%vpn%'", "short_descriptionLIKEvpn"},
+		{"number LIKE 'INC%'", "numberSTARTSWITHINC"},
+		{"email LIKE \"%@example.com\"", "emailENDSWITH@example.com"},
+		{"category LIKE 'network'", "category=network"},
+		{"short_description NOT LIKE '%test%'", "short_descriptionNOTLIKEtest"},
+		{"state NOT IN (6, 7)", "stateNOT IN6,7"},
+		{"state <> 6 AND active = TRUE", "state!=6^active=true"},
+		{"opened_by = `caller_id` OR opened_by != `assigned_to`", "opened_bySAMEAScaller_id^ORopened_byNSAMEASassigned_to"},
+		{"assigned_to = caller_id.manager", "assigned_toSAMEAScaller_id.manager"},
+		{"opened_at BETWEEN '2024-01-01' AND '2024-01-31'", "opened_atBETWEENjavascript:gs.dateGenerate('2024-01-01','00:00:00')@javascript:gs.dateGenerate('2024-01-31','23:59:59')"},
+		{"opened_at BETWEEN '2024-01-01 08:00:00' AND '2024-01-01 17:30:00'", "opened_atBETWEENjavascript:gs.dateGenerate('2024-01-01','08:00:00')@javascript:gs.dateGenerate('2024-01-01','17:30:00')"},
+		{"priority BETWEEN 1 AND 3", "priorityBETWEEN1@3"},
+		{"caller_id.name IS NOT NULL", "caller_id.nameISNOTEMPTY"},
+		{"short_description = 'it''s down'", "short_description=it's down"},
+		{"(a = 1)", "a=1"},
+	}
+	for _, tt := range tests {
+		statement, err := sql.Parse("SELECT * FROM incident WHERE " + tt.where)
+		if err != nil {
+			t.Errorf("Parse %q failed: %v", tt.where, err)
+			continue
+		}
+		if got := statement.Query(); got != tt.expected {
+			t.Errorf("Expected %q to compile to %q, got %q", tt.where, tt.expected, got)
+		}
+	}
+}
+
+func TestSQLQuotedNames(t *testing.T) {
+	statement, err := sql.Parse("SELECT `order`, `count` FROM sys_choice WHERE `order` > 1 AND sequence = `order` ORDER BY `order` DESC")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got, want := statement.Query(), "order>1^sequenceSAMEASorder^ORDERBYDESCorder"; got != want {
+		t.Errorf("Expected query %q, got %q", want, got)
+	}
+	if len(statement.Fields) != 2 || statement.Fields[0] != "order" || statement.Fields[1] != "count" || statement.IsAggregate() {
+		t.Errorf("Unexpected fields %v", statement.Fields)
+	}
+}
+
+func TestSQLParseWithFields(t *testing.T) {
+	isField := func(table, name string) (bool, error) {
+		return table == "incident" && (name == "caller_id" || name == "opened_by"), nil
+	}
+	statement, err := sql.ParseWithFields("SELECT * FROM incident WHERE opened_by = caller_id", isField)
+	if err != nil {
+		t.Fatalf("ParseWithFields failed: %v", err)
+	}
+	if got, want := statement.Query(), "opened_bySAMEAScaller_id"; got != want {
+		t.Errorf("Expected query %q, got %q", want, got)
+	}
+	_, err = sql.ParseWithFields("SELECT * FROM incident WHERE state = new", isField)
+	if err == nil || !strings.Contains(err.Error(), "new is not a field of incident") {
+		t.Errorf("Expected an unknown field error, got %v", err)
+	}
+	failing := func(table, name string) (bool, error) { return false, errors.New("schema unavailable") }
+	if _, err := sql.ParseWithFields("SELECT * FROM incident WHERE state = new", failing); err == nil || err.Error() != "schema unavailable" {
+		t.Errorf("Expected the checker error, got %v", err)
+	}
+}
+
+func TestSQLNestedConditions(t *testing.T) {
+	// The compiled query must select the same records as the statement
+	statement, err := sql.Parse("SELECT * FROM incident WHERE (a = 1 OR b = 1) AND (c = 1 OR (d = 1 AND e = 1))")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	filter, err := query.ParseEncoded(statement.Query())
+	if err != nil {
+		t.Fatalf("Compiled query %q doesn't parse: %v", statement.Query(), err)
+	}
+	for bits := 0; bits < 32; bits++ {
+		record := map[string]interface{}{}
+		value := map[string]bool{}
+		for i, field := range []string{"a", "b", "c", "d", "e"} {
+			value[field] = bits&(1<<i) != 0
+			record[field] = "0"
+			if value[field] {
+				record[field] = "1"
+			}
+		}
+		want := (value["a"] || value["b"]) && (value["c"] || (value["d"] && value["e"]))
+		if got := filter.Match(record); got != want {
+			t.Errorf("Query %q matched %v as %v, expected %v", statement.Query(), record, got, want)
+		}
+	}
+}
+
+func TestSQLAggregate(t *testing.T) {
+	statement, err := sql.Parse("select category, count(*), avg(reassignment_count) from incident where active = true group by category order by category desc limit 5")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !statement.IsAggregate() {
+		t.Fatal("Expected an aggregate statement")
+	}
+	params := statement.Aggregate(nil).BuildParams()
+	expected := map[string]string{
+		"sysparm_query":      "active=true",
+		"sysparm_count":      "true",
+		"sysparm_avg_fields": "reassignment_count",
+		"sysparm_group_by":   "category",
+		"sysparm_orderby":    "category DESC",
+		"sysparm_limit":      "5",
+	}
+	for name, value := range expected {
+		if params[name] != value {
+			t.Errorf("Expected %s=%s, got %q", name, value, params[name])
+		}
+	}
+}
+
+func TestSQLErrors(t *testing.T) {
+	tests := []struct {
+		statement string
+		message   string
+	}{
+		{"SELECT FROM incident", "position 8: expected a field"},
+		{"SELECT * FROM incident WHERE state = 'New", "unterminated string"},
+		{"SELECT * FROM incident WHERE NOT active = true", "NOT is only supported"},
+		{"SELECT * FROM incident WHERE priority < urgency", "only = and != compare two fields"},
+		{"SELECT * FROM incident WHERE state = new", "quote new as 'new' to compare with text, or as `new` to compare with the field"},
+		{"SELECT * FROM incident WHERE number NOT LIKE 'INC%'", "NOT LIKE only supports"},
+		{"SELECT * FROM incident WHERE short_description = 'a^b'", "can't contain ^"},
+		{"SELECT * FROM incident WHERE (state = 1", "expected \")\""},
+		{"SELECT number FROM incident GROUP BY number", "GROUP BY needs an aggregate"},
+		{"SELECT number, COUNT(*) FROM incident GROUP BY category", "number must be in GROUP BY"},
+		{"SELECT * FROM incident LIMIT -1", "non-negative"},
+		{"SELECT * FROM incident extra", "unexpected \"extra\""},
+		{"SELECT * FROM incident WHERE opened_at BETWEEN '2024-01-01' AND 5", "BETWEEN bounds must both be dates"},
+		{"SELECT order FROM sys_choice", "quote it as `order`"},
+		{"SELECT * FROM sys_choice ORDER BY order", "quote it as `order`"},
+		{"SELECT `a b` FROM incident", "backticks must enclose a field or table name"},
+	}
+	for _, tt := range tests {
+		_, err := sql.Parse(tt.statement)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("Expected %q to fail with %q, got %v", tt.statement, tt.message, err)
+		}
+	}
+}
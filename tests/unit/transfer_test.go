@@ -0,0 +1,150 @@
+package unit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/batch"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/transfer"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+// newRecordServer answers table GETs from a "table|query" → records map (ORDERBY clauses stripped)
+// and records batch inserts, returning "t_<name>" as the new sys_id
+func newRecordServer(t *testing.T, records map[string][]map[string]interface{}, inserted *[]map[string]interface{}, mu *sync.Mutex) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/batch") {
+			var request batch.BatchRequest
+			json.NewDecoder(r.Body).Decode(&request)
+			var serviced []map[string]interface{}
+			for _, req := range request.RestRequests {
+				decoded, _ := base64.StdEncoding.DecodeString(req.Body)
+				var body map[string]interface{}
+				json.Unmarshal(decoded, &body)
+				mu.Lock()
+				*inserted = append(*inserted, body)
+				mu.Unlock()
+				encoded, _ := json.Marshal(map[string]interface{}{"result": map[string]interface{}{"sys_id": "t_" + body["name"].(string)}})
+				serviced = append(serviced, map[string]interface{}{
+					"id": req.ID, "status_code": 201, "status_text": "Created",
+					"body": base64.StdEncoding.EncodeToString(encoded),
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"batch_request_id": request.BatchRequestID, "serviced_requests": serviced, "unserviced_requests": []interface{}{},
+			})
+			return
+		}
+
+		tableName := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		query := strings.TrimSuffix(r.URL.Query().Get("sysparm_query"), "^ORDERBYsys_id")
+		result := records[tableName+"|"+query]
+		if result == nil {
+			result = []map[string]interface{}{}
+		}
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(result)))
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+}
+
+func reference(table, sysID string) map[string]interface{} {
+	return map[string]interface{}{"link": "https://dev.service-now.com/api/now/table/" + table + "/" + sysID, "value": sysID}
+}
+
+func newTransferFixture(t *testing.T) (*httptest.Server, *httptest.Server, *[]map[string]interface{}) {
+	var mu sync.Mutex
+	inserted := &[]map[string]interface{}{}
+	source := newRecordServer(t, map[string][]map[string]interface{}{
+		"sys_user_group|nameSTARTSWITHCAB": {{
+			"sys_id":         "g1",
+			"name":           "CAB",
+			"manager":        reference("sys_user", "u1"),
+			"parent":         reference("sys_user_group", "g0"),
+			"sys_updated_on": "2024-01-01 00:00:00",
+		}},
+		"sys_user|sys_id=u1":       {{"sys_id": "u1", "user_name": "jdoe"}},
+		"sys_user_group|sys_id=g0": {{"sys_id": "g0", "name": "Parent", "manager": map[string]interface{}{"link": "", "value": ""}}},
+	}, inserted, &mu)
+	target := newRecordServer(t, map[string][]map[string]interface{}{
+		"sys_user|user_name=jdoe": {{"sys_id": "t_u1"}},
+	}, inserted, &mu)
+	return source, target, inserted
+}
+
+func TestTransfer_CopyRemapsReferences(t *testing.T) {
+	source, target, inserted := newTransferFixture(t)
+	defer source.Close()
+	defer target.Close()
+
+	sourceClient, _ := testutils.NewMockClient(source.URL)
+	targetClient, _ := testutils.NewMockClient(target.URL)
+
+	copier := transfer.NewCopier(sourceClient, targetClient, transfer.Options{KeyFields: []string{"name"}})
+	report, err := copier.Copy(context.Background(), "sys_user_group", "nameSTARTSWITHCAB")
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if len(report.Records) != 1 || report.Records[0].Status != transfer.StatusCreated || report.Records[0].TargetSysID != "t_CAB" {
+		t.Fatalf("Unexpected record mapping: %+v", report.Records)
+	}
+	if len(*inserted) != 1 {
+		t.Fatalf("Expected 1 insert, got %d", len(*inserted))
+	}
+	body := (*inserted)[0]
+	if body["manager"] != "t_u1" {
+		t.Errorf("Expected manager remapped by user_name to t_u1, got %v", body["manager"])
+	}
+	if parent, ok := body["parent"]; !ok || parent != "" {
+		t.Errorf("Expected unresolved parent to be cleared, got %v", body["parent"])
+	}
+	for _, field := range []string{"sys_id", "sys_updated_on"} {
+		if _, ok := body[field]; ok {
+			t.Errorf("Expected %s not to be copied", field)
+		}
+	}
+
+	unresolved := report.Unresolved()
+	if len(unresolved) != 1 || unresolved[0].Table != "sys_user_group" || unresolved[0].Key != "name=Parent" {
+		t.Errorf("Unexpected unresolved references: %+v", unresolved)
+	}
+}
+
+func TestTransfer_CopyFollowsReferences(t *testing.T) {
+	source, target, inserted := newTransferFixture(t)
+	defer source.Close()
+	defer target.Close()
+
+	sourceClient, _ := testutils.NewMockClient(source.URL)
+	targetClient, _ := testutils.NewMockClient(target.URL)
+
+	copier := transfer.NewCopier(sourceClient, targetClient, transfer.Options{
+		KeyFields:        []string{"name"},
+		FollowReferences: true,
+	})
+	report, err := copier.Copy(context.Background(), "sys_user_group", "nameSTARTSWITHCAB")
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if len(*inserted) != 2 || (*inserted)[0]["name"] != "Parent" {
+		t.Fatalf("Expected the parent group to be copied first, got %+v", *inserted)
+	}
+	if (*inserted)[1]["parent"] != "t_Parent" {
+		t.Errorf("Expected parent remapped to the copied record, got %v", (*inserted)[1]["parent"])
+	}
+	if len(report.Records) != 2 || report.Records[0].Depth != 1 || report.Records[1].Depth != 0 {
+		t.Errorf("Unexpected record mappings: %+v", report.Records)
+	}
+	if len(report.Unresolved()) != 0 {
+		t.Errorf("Expected all references resolved, got %+v", report.Unresolved())
+	}
+}
@@ -0,0 +1,183 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/undo"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+func TestUndoStore_SaveLoadList(t *testing.T) {
+	store := undo.NewStore(t.TempDir())
+
+	op := &undo.Operation{
+		ID:          "op_1",
+		Description: "batch update incident",
+		Entries: []*undo.Entry{
+			{Table: "incident", SysID: "abc", Action: undo.ActionUpdate, Applied: true},
+			{Table: "incident", SysID: "def", Action: undo.ActionUpdate},
+		},
+	}
+	if err := store.Save(op); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := store.Load("op_1")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded.AppliedEntries()) != 1 {
+		t.Errorf("Expected 1 applied entry, got %d", len(loaded.AppliedEntries()))
+	}
+
+	ops, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(ops) != 1 || ops[0].ID != "op_1" {
+		t.Errorf("Expected op_1 in listing, got %+v", ops)
+	}
+
+	if _, err := store.Load("missing"); err == nil {
+		t.Error("Expected error loading missing operation")
+	}
+}
+
+func TestUndo_RestoresUpdatedFields(t *testing.T) {
+	modCount := "3"
+	var patched map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case "GET":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"result": map[string]interface{}{
+					"sys_id":        "abc",
+					"state":         "2",
+					"priority":      "1",
+					"sys_mod_count": modCount,
+				},
+			})
+		case "PATCH":
+			json.NewDecoder(r.Body).Decode(&patched)
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": patched})
+		default:
+			t.Errorf("Unexpected %s request", r.Method)
+		}
+	}))
+	defer server.Close()
+
+	client, err := testutils.NewMockClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	recorder := undo.NewRecorder(client, undo.NewStore(t.TempDir()), "test")
+	if _, err := recorder.Capture(context.Background(), "incident", "abc", undo.ActionUpdate, []string{"state"}); err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	modCount = "4"
+	recorder.MarkApplied("incident", "abc", map[string]interface{}{"sys_mod_count": "4"})
+
+	result, err := undo.Undo(client, recorder.Operation(), undo.Options{})
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if result.Restored != 1 {
+		t.Fatalf("Expected 1 restored record, got %+v", result.Outcomes[0])
+	}
+	if len(patched) != 1 || patched["state"] != "2" {
+		t.Errorf("Expected only state=2 to be restored, got %v", patched)
+	}
+}
+
+func TestUndo_ReportsConflicts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("Conflicting record should not be written, got %s", r.Method)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]interface{}{"sys_id": "abc", "sys_mod_count": "7"},
+		})
+	}))
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	op := &undo.Operation{
+		ID: "op_conflict",
+		Entries: []*undo.Entry{{
+			Table:         "incident",
+			SysID:         "abc",
+			Action:        undo.ActionUpdate,
+			Fields:        []string{"state"},
+			Before:        map[string]interface{}{"state": "1"},
+			AfterModCount: "5",
+			Applied:       true,
+		}},
+	}
+
+	result, err := undo.Undo(client, op, undo.Options{})
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if result.Conflicts != 1 || result.Outcomes[0].Status != undo.StatusConflict {
+		t.Errorf("Expected a conflict, got %+v", result.Outcomes[0])
+	}
+}
+
+func TestUndo_RecreatesDeletedRecord(t *testing.T) {
+	var created map[string]interface{}
+	var inputDisplayValue string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case "GET":
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error": map[string]interface{}{"message": "No Record found"},
+			})
+		case "POST":
+			inputDisplayValue = r.URL.Query().Get("sysparm_input_display_value")
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": created})
+		}
+	}))
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	op := &undo.Operation{
+		ID: "op_delete",
+		Entries: []*undo.Entry{{
+			Table:   "incident",
+			SysID:   "abc",
+			Action:  undo.ActionDelete,
+			Before:  map[string]interface{}{"sys_id": "abc", "short_description": "gone", "sys_mod_count": "2"},
+			Applied: true,
+		}},
+	}
+
+	result, err := undo.Undo(client, op, undo.Options{})
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if result.Outcomes[0].Status != undo.StatusRecreated {
+		t.Fatalf("Expected record to be re-created, got %+v", result.Outcomes[0])
+	}
+	if created["sys_id"] != "abc" || created["short_description"] != "gone" {
+		t.Errorf("Unexpected re-create payload: %v", created)
+	}
+	if _, ok := created["sys_mod_count"]; ok {
+		t.Error("System fields should not be posted back")
+	}
+	if inputDisplayValue != "false" {
+		t.Errorf("Expected sysparm_input_display_value=false, got %q", inputDisplayValue)
+	}
+}
@@ -0,0 +1,154 @@
+package unit
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/batch"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/table"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+func TestTableClient_UpsertMany(t *testing.T) {
+	var lookupQuery string
+	var batchRequest batch.BatchRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/table/incident"):
+			lookupQuery = r.URL.Query().Get("sysparm_query")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"result": []map[string]interface{}{
+					{"sys_id": "s1", "correlation_id": "EXT-1", "short_description": "same"},
+					{"sys_id": "s2", "correlation_id": "EXT-2", "short_description": "old"},
+					{"sys_id": "s3", "correlation_id": "EXT-3", "short_description": "a"},
+					{"sys_id": "s4", "correlation_id": "EXT-3", "short_description": "b"},
+				},
+			})
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/batch"):
+			json.NewDecoder(r.Body).Decode(&batchRequest)
+			var serviced []map[string]interface{}
+			for _, req := range batchRequest.RestRequests {
+				body := map[string]interface{}{"result": map[string]interface{}{"sys_id": "new_" + req.ID}}
+				if req.Method == batch.MethodPATCH {
+					body = map[string]interface{}{"result": map[string]interface{}{"sys_id": "s2"}}
+				}
+				encoded, _ := json.Marshal(body)
+				serviced = append(serviced, map[string]interface{}{
+					"id":          req.ID,
+					"status_code": 200,
+					"status_text": "OK",
+					"body":        base64.StdEncoding.EncodeToString(encoded),
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"batch_request_id":    batchRequest.BatchRequestID,
+				"serviced_requests":   serviced,
+				"unserviced_requests": []interface{}{},
+			})
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client, err := testutils.NewMockClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	records := []map[string]interface{}{
+		{"correlation_id": "EXT-1", "short_description": "same"},
+		{"correlation_id": "EXT-2", "short_description": "new"},
+		{"correlation_id": "EXT-3", "short_description": "x"},
+		{"correlation_id": "EXT-4", "short_description": "brand new"},
+		{"short_description": "no key"},
+		{"correlation_id": "EXT-4", "short_description": "duplicate"},
+	}
+
+	results, err := table.NewTableClient(client, "incident").UpsertMany(records, table.UpsertOptions{
+		MatchFields: []string{"correlation_id"},
+	})
+	if err != nil {
+		t.Fatalf("UpsertMany failed: %v", err)
+	}
+
+	if lookupQuery != "correlation_idINEXT-1,EXT-2,EXT-3,EXT-4" {
+		t.Errorf("Unexpected lookup query: %s", lookupQuery)
+	}
+	if len(batchRequest.RestRequests) != 2 {
+		t.Errorf("Expected 2 batched writes, got %d", len(batchRequest.RestRequests))
+	}
+
+	expected := []table.UpsertStatus{
+		table.UpsertUnchanged,
+		table.UpsertUpdated,
+		table.UpsertConflict,
+		table.UpsertCreated,
+		table.UpsertFailed,
+		table.UpsertConflict,
+	}
+	for i, status := range expected {
+		if results[i].Status != status {
+			t.Errorf("Record %d: expected %s, got %s (%s)", i, status, results[i].Status, results[i].Error)
+		}
+	}
+	if results[1].SysID != "s2" {
+		t.Errorf("Expected updated sys_id s2, got %s", results[1].SysID)
+	}
+	if results[3].SysID != "new_upsert_4" {
+		t.Errorf("Expected created sys_id from batch response, got %s", results[3].SysID)
+	}
+	if len(results[2].Matches) != 2 {
+		t.Errorf("Expected conflict to list 2 matches, got %v", results[2].Matches)
+	}
+}
+
+func TestTableClient_Upsert_RequiresMatchFields(t *testing.T) {
+	client, _ := testutils.NewMockClient("http://localhost")
+	_, err := table.NewTableClient(client, "incident").Upsert(nil, map[string]interface{}{"number": "INC1"})
+	if err == nil {
+		t.Error("Expected error when no match fields are given")
+	}
+}
+
+func TestTableClient_UpsertMany_KeysWithCommas(t *testing.T) {
+	var lookupQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != "GET" {
+			t.Errorf("Unexpected write %s %s: the record exists", r.Method, r.URL.Path)
+			return
+		}
+		lookupQuery = r.URL.Query().Get("sysparm_query")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": []map[string]interface{}{
+				{"sys_id": "s1", "name": "Doe, Jane", "title": "Engineer"},
+				{"sys_id": "s2", "name": "Smith", "title": "Manager"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	results, err := table.NewTableClient(client, "sys_user").UpsertMany([]map[string]interface{}{
+		{"name": "Doe, Jane", "title": "Engineer"},
+		{"name": "Smith", "title": "Manager"},
+	}, table.UpsertOptions{MatchFields: []string{"name"}})
+	if err != nil {
+		t.Fatalf("UpsertMany failed: %v", err)
+	}
+	if lookupQuery != "nameINSmith^ORname=Doe, Jane" {
+		t.Errorf("Unexpected lookup query: %s", lookupQuery)
+	}
+	for i, result := range results {
+		if result.Status != table.UpsertUnchanged {
+			t.Errorf("Record %d: expected %s, got %s (%s)", i, table.UpsertUnchanged, result.Status, result.Error)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/watch"
+	"github.com/Krive/ServiceNow-Toolkit/tests/testutils"
+)
+
+func TestWatcher_PollAndResume(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	incidents := []map[string]interface{}{
+		{"sys_id": "i1", "number": "INC1", "sys_mod_count": "0", "sys_created_on": "2024-07-01 10:00:00", "sys_updated_on": "2024-07-01 10:00:00", "sys_updated_by": "abel"},
+		{"sys_id": "i2", "number": "INC2", "sys_mod_count": "3", "sys_created_on": "2024-06-01 10:00:00", "sys_updated_on": "2024-07-01 10:05:00", "sys_updated_by": "beth"},
+	}
+	deletes := []map[string]interface{}{
+		{"sys_id": "d1", "documentkey": "i9", "sys_created_on": "2024-07-01 10:03:00", "sys_created_by": "admin"},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tableName := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		query := r.URL.Query().Get("sysparm_query")
+		mu.Lock()
+		queries = append(queries, tableName+"|"+query)
+		current := incidents
+		mu.Unlock()
+
+		var result []map[string]interface{}
+		switch tableName {
+		case "incident":
+			since := strings.TrimPrefix(strings.Split(query, "^")[1], "sys_updated_on>=")
+			for _, record := range current {
+				if record["sys_updated_on"].(string) >= since {
+					result = append(result, record)
+				}
+			}
+		case "sys_audit_delete":
+			since := strings.TrimPrefix(strings.Split(query, "^")[1], "sys_created_on>=")
+			for _, record := range deletes {
+				if record["sys_created_on"].(string) >= since {
+					result = append(result, record)
+				}
+			}
+			w.Header().Set("X-Total-Count", "1")
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+	defer server.Close()
+
+	client, _ := testutils.NewMockClient(server.URL)
+	checkpointFile := filepath.Join(t.TempDir(), "incident.json")
+	options := watch.Options{
+		Query:          "active=true",
+		Since:          time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC),
+		IncludeDeletes: true,
+		CheckpointFile: checkpointFile,
+	}
+
+	watcher, err := watch.NewWatcher(client, "incident", options)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	events, err := watcher.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %+v", events)
+	}
+	if events[0].Type != watch.EventCreated || events[0].SysID != "i1" {
+		t.Errorf("Expected INC1 to be created, got %+v", events[0])
+	}
+	if events[1].Type != watch.EventUpdated || events[1].ModCount != 3 || events[1].User != "beth" {
+		t.Errorf("Expected INC2 to be updated, got %+v", events[1])
+	}
+	if events[2].Type != watch.EventDeleted || events[2].SysID != "i9" {
+		t.Errorf("Expected i9 to be deleted, got %+v", events[2])
+	}
+	if queries[0] != "incident|active=true^sys_updated_on>=2024-07-01 09:00:00^ORDERBYsys_updated_on" {
+		t.Errorf("Unexpected poll query %s", queries[0])
+	}
+
+	// A restarted watcher resumes from the checkpoint: the change at the high-water mark
+	// isn't repeated, but a newer modification in the same second is reported
+	mu.Lock()
+	incidents = append(incidents[:1:1],
+		map[string]interface{}{"sys_id": "i2", "number": "INC2", "sys_mod_count": "4", "sys_created_on": "2024-06-01 10:00:00", "sys_updated_on": "2024-07-01 10:05:00"},
+		map[string]interface{}{"sys_id": "i3", "number": "INC3", "sys_mod_count": "1", "sys_created_on": "2024-06-01 10:00:00", "sys_updated_on": "2024-07-01 10:05:00"},
+	)
+	mu.Unlock()
+
+	resumed, err := watch.NewWatcher(client, "incident", options)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	if !resumed.Checkpoint().HighWater.Equal(time.Date(2024, 7, 1, 10, 5, 0, 0, time.UTC)) {
+		t.Errorf("Expected the checkpoint to be restored, got %v", resumed.Checkpoint().HighWater)
+	}
+	events, err = resumed.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if len(events) != 2 || events[0].SysID != "i2" || events[0].ModCount != 4 || events[1].SysID != "i3" {
+		t.Errorf("Expected only the new changes, got %+v", events)
+	}
+
+	events, err = resumed.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Expected no events without changes, got %+v", events)
+	}
+
+	if _, err := watch.NewWatcher(client, "incident", watch.Options{CheckpointFile: checkpointFile}); err == nil {
+		t.Error("Expected a checkpoint of another query to be rejected")
+	}
+}
+
+func TestWatcher_SessionTimeZone(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := []map[string]interface{}{}
+		switch r.URL.Path {
+		case "/api/now/table/sys_user":
+			result = []map[string]interface{}{{"time_zone": "America/New_York"}}
+		case "/api/now/table/incident":
+			queries = append(queries, r.URL.Query().Get("sysparm_query"))
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+	defer server.Close()
+
+	// A client without a timezone loads the session user's
+	client, _ := core.NewClientBasicAuth(server.URL, "test", "test")
+	watcher, err := watch.NewWatcher(client, "incident", watch.Options{
+		Query: "priority=1^NQpriority=2",
+		Since: time.Date(2024, 7, 1, 14, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	if _, err := watcher.Poll(context.Background()); err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	// The high-water mark bounds every alternative of the query
+	expected := "priority=1^sys_updated_on>=2024-07-01 10:00:00^NQpriority=2^sys_updated_on>=2024-07-01 10:00:00^ORDERBYsys_updated_on"
+	if len(queries) != 1 || queries[0] != expected {
+		t.Errorf("Expected %q, got %v", expected, queries)
+	}
+	if client.GetTimeZone().String() != "America/New_York" {
+		t.Errorf("Expected the session timezone to be kept on the client, got %s", client.GetTimeZone())
+	}
+}
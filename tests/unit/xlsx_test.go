@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/core"
+	"github.com/Krive/ServiceNow-Toolkit/pkg/servicenow/export"
+)
+
+func TestWriteXLSX(t *testing.T) {
+	records := []map[string]interface{}{
+		{
+			"number":    map[string]interface{}{"value": "INC0010001", "display_value": "INC0010001"},
+			"priority":  map[string]interface{}{"value": "1", "display_value": "1"},
+			"opened_at": map[string]interface{}{"value": "2024-07-01 08:30:00", "display_value": "2024-07-01 10:30:00"},
+			"caller_id": map[string]interface{}{"value": "u1", "display_value": "Beth Anglin", "link": "https://example/u1"},
+			"category":  map[string]interface{}{"value": "network", "display_value": "Network"},
+		},
+		{
+			"number":    map[string]interface{}{"value": "INC0010002", "display_value": "INC0010002"},
+			"priority":  map[string]interface{}{"value": "3", "display_value": "3"},
+			"opened_at": map[string]interface{}{"value": "2024-07-02 09:00:00", "display_value": "2024-07-02 11:00:00"},
+			"caller_id": map[string]interface{}{"value": "u2", "display_value": "Fred Luddy", "link": "https://example/u2"},
+			"category":  map[string]interface{}{"value": "software", "display_value": "Software"},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := export.WriteXLSX(&buf, records, export.XLSXOptions{
+		Fields:       []string{"number", "priority", "opened_at", "caller_id"},
+		References:   export.ReferenceBoth,
+		DisplayValue: core.DisplayAll,
+		GroupBy:      "category",
+		Location:     time.UTC,
+	})
+	if err != nil {
+		t.Fatalf("WriteXLSX failed: %v", err)
+	}
+
+	files := unzipXLSX(t, buf.Bytes())
+	if workbook := files["xl/workbook.xml"]; !strings.Contains(workbook, `name="Network"`) || !strings.Contains(workbook, `name="Software"`) {
+		t.Errorf("Expected one sheet per category, got %s", workbook)
+	}
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	checks := map[string]string{
+		"frozen header":    `state="frozen"`,
+		"sys_id column":    "caller_id (sys_id)",
+		"styled header":    `<c r="A1" t="inlineStr" s="1">`,
+		"numeric cell":     `<c r="B2"><v>1</v></c>`,
+		"date/time cell":   `<c r="C2" s="2"><v>45474.354166`,
+		"reference value":  "Beth Anglin",
+		"reference sys_id": `<c r="E2" t="inlineStr"><is><t xml:space="preserve">u1</t>`,
+	}
+	for name, want := range checks {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("Expected %s (%s) in sheet, got %s", name, want, sheet)
+		}
+	}
+	if strings.Contains(sheet, "Fred Luddy") {
+		t.Errorf("Expected the software incident on its own sheet")
+	}
+}
+
+func unzipXLSX(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Invalid zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("Open %s: %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[file.Name] = string(content)
+	}
+	return files
+}